@@ -0,0 +1,349 @@
+// Copyright (c) 2024 Michael D Henderson. All rights reserved.
+
+package fargo
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"golang.org/x/crypto/bcrypt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// functions to manage player accounts and their credentials.
+//
+// accounts are kept in accounts.json in the game directory, or in the
+// site's directory when a site runs several games. a player account
+// controls one race in each game it has joined. a GM can see every race
+// in every game, and an admin can also manage accounts.
+//
+// nothing secret is stored as given. passwords are hashed with bcrypt.
+// tokens are long random strings, so a SHA-256 hash is enough to store
+// them. a token is shown to its owner once, when it is created. a player
+// who sends orders by mail puts a secret on the race line of the orders;
+// it is hashed like a password.
+//
+//	session  issued when a player logs in, expires after SessionTTL
+//	api      created by a player for scripts, good until revoked
+//	magic    a one-time login link, expires after MagicLinkTTL
+
+const (
+	AccountsFile = "accounts.json"
+
+	// SessionTTL is how long a login lasts.
+	SessionTTL = 7 * 24 * time.Hour
+	// MagicLinkTTL is how long a login link can be used.
+	MagicLinkTTL = 15 * time.Minute
+	// MinimumPasswordLength is the shortest password an account may have.
+	MinimumPasswordLength = 8
+)
+
+// Role_e is what an account is allowed to do.
+type Role_e int
+
+const (
+	RolePlayer Role_e = iota // sees only its own races
+	RoleGM                   // sees every race in every game
+	RoleAdmin                // a GM who also manages accounts
+)
+
+func (r Role_e) String() string {
+	switch r {
+	case RolePlayer:
+		return "player"
+	case RoleGM:
+		return "gm"
+	case RoleAdmin:
+		return "admin"
+	}
+	return "unknown"
+}
+
+func (r Role_e) MarshalText() ([]byte, error) {
+	return []byte(r.String()), nil
+}
+
+func (r *Role_e) UnmarshalText(text []byte) (err error) {
+	*r, err = ParseRole(string(text))
+	return err
+}
+
+// ParseRole returns the role with the name.
+func ParseRole(name string) (Role_e, error) {
+	for r := RolePlayer; r <= RoleAdmin; r++ {
+		if strings.EqualFold(name, r.String()) {
+			return r, nil
+		}
+	}
+	return RolePlayer, fmt.Errorf("%q: %w", name, ErrUnknownRole)
+}
+
+// Account_t is a person who can use the server.
+type Account_t struct {
+	Handle   string            `json:"handle"`
+	Email    string            `json:"email,omitempty"`
+	Role     Role_e            `json:"role"`
+	Races    map[string]string `json:"races,omitempty"`    // id of the race a player controls, by game id
+	Password string            `json:"password,omitempty"` // bcrypt hash, empty if the account only uses login links
+	Secret   string            `json:"secret,omitempty"`   // bcrypt hash of the secret for orders sent by mail
+}
+
+// RaceIn returns the id of the race the account controls in the game,
+// or an empty string if it hasn't joined the game.
+func (a *Account_t) RaceIn(game string) string {
+	return a.Races[game]
+}
+
+// Join makes the account the player of the race in the game.
+// An account controls one race in each game.
+func (a *Account_t) Join(game, race string) {
+	if a.Races == nil {
+		a.Races = make(map[string]string)
+	}
+	a.Races[game] = race
+}
+
+// CanSee returns true if the account may see the race's reports and orders.
+func (a *Account_t) CanSee(game, race string) bool {
+	return a.Role != RolePlayer || (race != "" && strings.EqualFold(a.RaceIn(game), race))
+}
+
+// Token_t is a credential issued to an account.
+type Token_t struct {
+	Id      string     `json:"id"`
+	Kind    string     `json:"kind"` // "session", "api" or "magic"
+	Name    string     `json:"name,omitempty"`
+	Handle  string     `json:"handle"`
+	Hash    string     `json:"hash"` // SHA-256 of the token
+	Created time.Time  `json:"created"`
+	Expires *time.Time `json:"expires,omitempty"`
+}
+
+// Accounts_t is the set of accounts for a game or a site.
+type Accounts_t struct {
+	Accounts []*Account_t `json:"accounts"`
+	Tokens   []*Token_t   `json:"tokens,omitempty"`
+}
+
+// LoadAccounts reads the accounts in the directory.
+// A directory without an accounts file has no accounts.
+func LoadAccounts(path string) (*Accounts_t, error) {
+	data, err := os.ReadFile(filepath.Join(path, AccountsFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return &Accounts_t{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var a Accounts_t
+	if err := json.Unmarshal(data, &a); err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+// Save writes the accounts to the directory. The old file is only replaced
+// once the new one is on disk. The file may only be read by its owner.
+func (a *Accounts_t) Save(path string) error {
+	data, err := json.MarshalIndent(a, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFile(filepath.Join(path, AccountsFile), data, 0600)
+}
+
+// Account returns the account with the handle, or nil if there is none.
+// Handles are not case-sensitive.
+func (a *Accounts_t) Account(handle string) *Account_t {
+	for _, acct := range a.Accounts {
+		if strings.EqualFold(acct.Handle, handle) {
+			return acct
+		}
+	}
+	return nil
+}
+
+// AccountByEmail returns the account with the email address, or nil if there is none.
+func (a *Accounts_t) AccountByEmail(email string) *Account_t {
+	for _, acct := range a.Accounts {
+		if acct.Email != "" && strings.EqualFold(acct.Email, email) {
+			return acct
+		}
+	}
+	return nil
+}
+
+// ValidHandle returns true if the handle can name an account.
+// Handles are letters, digits, dashes, dots and underscores, so that they
+// are safe in file names, mail headers and URLs.
+func ValidHandle(handle string) bool {
+	if handle == "" || len(handle) > 32 || handle[0] == '-' || handle[0] == '.' {
+		return false
+	}
+	for _, ch := range handle {
+		if !(('a' <= ch && ch <= 'z') || ('A' <= ch && ch <= 'Z') || ('0' <= ch && ch <= '9') || ch == '-' || ch == '.' || ch == '_') {
+			return false
+		}
+	}
+	return true
+}
+
+// Add creates an account. A player must be given a race in at least one game.
+func (a *Accounts_t) Add(acct *Account_t) error {
+	if !ValidHandle(acct.Handle) {
+		return fmt.Errorf("%q: %w", acct.Handle, ErrInvalidName)
+	} else if a.Account(acct.Handle) != nil {
+		return fmt.Errorf("%q: %w", acct.Handle, ErrDuplicateAccount)
+	} else if acct.Email != "" && a.AccountByEmail(acct.Email) != nil {
+		return fmt.Errorf("%q: %w", acct.Email, ErrDuplicateAccount)
+	} else if acct.Role == RolePlayer && len(acct.Races) == 0 {
+		return fmt.Errorf("%s: %w", acct.Handle, ErrMissingRace)
+	}
+	a.Accounts = append(a.Accounts, acct)
+	return nil
+}
+
+// SetPassword hashes the password and saves it in the account.
+func (a *Accounts_t) SetPassword(acct *Account_t, password string) error {
+	if len(password) < MinimumPasswordLength {
+		return fmt.Errorf("password must be at least %d characters: %w", MinimumPasswordLength, ErrInvalidCredentials)
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	acct.Password = string(hash)
+	return nil
+}
+
+// CheckPassword returns the account if the password is right.
+func (a *Accounts_t) CheckPassword(handle, password string) (*Account_t, error) {
+	acct := a.Account(handle)
+	if acct == nil || acct.Password == "" {
+		return nil, ErrInvalidCredentials
+	} else if bcrypt.CompareHashAndPassword([]byte(acct.Password), []byte(password)) != nil {
+		return nil, ErrInvalidCredentials
+	}
+	return acct, nil
+}
+
+// SetSecret hashes the secret for mailed orders and saves it in the account.
+func (a *Accounts_t) SetSecret(acct *Account_t, secret string) error {
+	if acct.Role != RolePlayer {
+		return fmt.Errorf("%s: %w", acct.Handle, ErrNotAPlayer)
+	} else if secret == "" || strings.ContainsAny(secret, " \t\r\n\"#") {
+		return fmt.Errorf("secret: %w", ErrInvalidCredentials)
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(secret), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	acct.Secret = string(hash)
+	return nil
+}
+
+// PlayersOf returns the player accounts for the race in the game.
+func (a *Accounts_t) PlayersOf(game, race string) []*Account_t {
+	var list []*Account_t
+	for _, acct := range a.Accounts {
+		if acct.Role == RolePlayer && acct.CanSee(game, race) {
+			list = append(list, acct)
+		}
+	}
+	return list
+}
+
+// CheckSecret returns the player account for the race in the game if the secret is right.
+func (a *Accounts_t) CheckSecret(game, race, secret string) (*Account_t, error) {
+	for _, acct := range a.PlayersOf(game, race) {
+		if acct.Secret == "" {
+			continue
+		} else if bcrypt.CompareHashAndPassword([]byte(acct.Secret), []byte(secret)) == nil {
+			return acct, nil
+		}
+	}
+	return nil, ErrInvalidCredentials
+}
+
+// NewToken issues a token to the account. A ttl of zero means the token doesn't expire.
+// The token itself is returned; only its hash is kept.
+func (a *Accounts_t) NewToken(acct *Account_t, kind, name string, ttl time.Duration, now time.Time) (string, *Token_t, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", nil, err
+	}
+	secret := base64.RawURLEncoding.EncodeToString(buf)
+	// the id is shown in lists and logs, so it must not give away any of the secret
+	id := make([]byte, 6)
+	if _, err := rand.Read(id); err != nil {
+		return "", nil, err
+	}
+	t := &Token_t{Id: base64.RawURLEncoding.EncodeToString(id), Kind: kind, Name: name, Handle: acct.Handle, Hash: hashToken(secret), Created: now.UTC()}
+	if ttl > 0 {
+		expires := now.Add(ttl).UTC()
+		t.Expires = &expires
+	}
+	a.expire(now)
+	a.Tokens = append(a.Tokens, t)
+	return secret, t, nil
+}
+
+// Authenticate returns the account that owns the token, if the token is
+// of one of the kinds and hasn't expired.
+func (a *Accounts_t) Authenticate(secret string, now time.Time, kinds ...string) (*Account_t, *Token_t) {
+	hash := hashToken(secret)
+	for _, t := range a.Tokens {
+		if t.Hash != hash || (t.Expires != nil && now.After(*t.Expires)) {
+			continue
+		}
+		for _, kind := range kinds {
+			if t.Kind == kind {
+				return a.Account(t.Handle), t
+			}
+		}
+	}
+	return nil, nil
+}
+
+// TokensOf returns the tokens issued to the account.
+func (a *Accounts_t) TokensOf(acct *Account_t) []*Token_t {
+	var list []*Token_t
+	for _, t := range a.Tokens {
+		if t.Handle == acct.Handle {
+			list = append(list, t)
+		}
+	}
+	return list
+}
+
+// Revoke removes a token.
+func (a *Accounts_t) Revoke(t *Token_t) {
+	for i, o := range a.Tokens {
+		if o == t {
+			a.Tokens = append(a.Tokens[:i:i], a.Tokens[i+1:]...)
+			return
+		}
+	}
+}
+
+// expire removes the tokens that have expired.
+func (a *Accounts_t) expire(now time.Time) {
+	var list []*Token_t
+	for _, t := range a.Tokens {
+		if t.Expires == nil || !now.After(*t.Expires) {
+			list = append(list, t)
+		}
+	}
+	a.Tokens = list
+}
+
+func hashToken(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,142 @@
+// Copyright (c) 2024 Michael D Henderson. All rights reserved.
+
+package fargo
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTokenHash(t *testing.T) {
+	a := &Accounts_t{}
+	acct := &Account_t{Handle: "gm", Role: RoleGM}
+	if err := a.Add(acct); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	secret, tok, err := a.NewToken(acct, "api", "laptop", 0, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tok.Hash != hashToken(secret) {
+		t.Errorf("hash: want the hash of the secret, got %q", tok.Hash)
+	}
+	// only the hash may be saved or shown
+	data, err := json.Marshal(a)
+	if err != nil {
+		t.Fatal(err)
+	} else if strings.Contains(string(data), secret) {
+		t.Errorf("accounts: saved the secret")
+	} else if strings.Contains(secret, tok.Id) {
+		t.Errorf("id: %q is part of the secret", tok.Id)
+	}
+
+	if got, _ := a.Authenticate(secret, now, "api"); got != acct {
+		t.Errorf("authenticate: want %s, got %v", acct.Handle, got)
+	}
+	if got, _ := a.Authenticate(secret, now, "session", "magic"); got != nil {
+		t.Errorf("authenticate: wrong kind: want nil, got %s", got.Handle)
+	}
+	if got, _ := a.Authenticate(secret+"x", now, "api"); got != nil {
+		t.Errorf("authenticate: wrong secret: want nil, got %s", got.Handle)
+	}
+	if got, _ := a.Authenticate(tok.Hash, now, "api"); got != nil {
+		t.Errorf("authenticate: hash as secret: want nil, got %s", got.Handle)
+	}
+
+	a.Revoke(tok)
+	if got, _ := a.Authenticate(secret, now, "api"); got != nil {
+		t.Errorf("authenticate: revoked: want nil, got %s", got.Handle)
+	}
+}
+
+func TestTokenExpiry(t *testing.T) {
+	a := &Accounts_t{}
+	acct := &Account_t{Handle: "gm", Role: RoleGM}
+	if err := a.Add(acct); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	secret, tok, err := a.NewToken(acct, "magic", "", 15*time.Minute, now)
+	if err != nil {
+		t.Fatal(err)
+	} else if tok.Expires == nil || !tok.Expires.Equal(now.Add(15*time.Minute)) {
+		t.Fatalf("expires: want %v, got %v", now.Add(15*time.Minute), tok.Expires)
+	}
+
+	if got, _ := a.Authenticate(secret, now.Add(15*time.Minute), "magic"); got != acct {
+		t.Errorf("authenticate: at expiry: want %s, got %v", acct.Handle, got)
+	}
+	if got, _ := a.Authenticate(secret, now.Add(15*time.Minute+time.Second), "magic"); got != nil {
+		t.Errorf("authenticate: after expiry: want nil, got %s", got.Handle)
+	}
+
+	// issuing a token drops the ones that have expired
+	later := now.Add(time.Hour)
+	if _, _, err := a.NewToken(acct, "session", "", 0, later); err != nil {
+		t.Fatal(err)
+	}
+	for _, o := range a.TokensOf(acct) {
+		if o == tok {
+			t.Errorf("tokens: expired token was kept")
+		}
+	}
+	if n := len(a.TokensOf(acct)); n != 1 {
+		t.Errorf("tokens: want 1, got %d", n)
+	}
+}
+
+func TestAddHandle(t *testing.T) {
+	for _, tc := range []struct {
+		handle string
+		ok     bool
+	}{
+		{"gm", true},
+		{"Jane.Doe_2", true},
+		{"", false},
+		{"-gm", false},
+		{".gm", false},
+		{"a/b", false},
+		{"../x", false},
+		{"a>b", false},
+		{`a"b`, false},
+		{"a b", false},
+		{strings.Repeat("x", 33), false},
+	} {
+		err := (&Accounts_t{}).Add(&Account_t{Handle: tc.handle, Role: RoleGM})
+		if tc.ok && err != nil {
+			t.Errorf("%q: want ok, got %v", tc.handle, err)
+		} else if !tc.ok && !errors.Is(err, ErrInvalidName) {
+			t.Errorf("%q: want %v, got %v", tc.handle, ErrInvalidName, err)
+		}
+	}
+}
+
+func TestAccountsSave(t *testing.T) {
+	path := t.TempDir()
+	a := &Accounts_t{}
+	if err := a.Add(&Account_t{Handle: "gm", Email: "gm@example.com", Role: RoleGM}); err != nil {
+		t.Fatal(err)
+	} else if err := a.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	sb, err := os.Stat(filepath.Join(path, AccountsFile))
+	if err != nil {
+		t.Fatal(err)
+	} else if perm := sb.Mode().Perm(); perm != 0600 {
+		t.Errorf("mode: want 0600, got %o", perm)
+	} else if _, err := os.Stat(filepath.Join(path, AccountsFile+".tmp")); err == nil {
+		t.Errorf("save: left the temporary file behind")
+	}
+	got, err := LoadAccounts(path)
+	if err != nil {
+		t.Fatal(err)
+	} else if acct := got.Account("GM"); acct == nil || acct.Email != "gm@example.com" {
+		t.Errorf("load: want gm, got %+v", got.Accounts)
+	}
+}
@@ -0,0 +1,522 @@
+// Copyright (c) 2024 Michael D Henderson. All rights reserved.
+
+package fargo
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/playbymail/fargo/internal/aow"
+	"math/rand/v2"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// functions to keep an audit log of the changes a turn makes.
+//
+// while a turn is processed, the state of the game is compared before and
+// after each order and after the work each phase does on its own, like
+// moving fleets or growing colonies. every value that changed is an event
+// in the log, with the order that caused it, if there was one, and the
+// value before and after.
+//
+// values are named by their path in the saved game:
+//
+//	races/R001/credits
+//	colonies/C004/population
+//	cluster/star-systems/S017/name
+//	prng/combat
+//
+// elements of a list are named by their id if they have one and by their
+// index if they don't. the log entries for the race reports are not
+// events. the PRNG streams are counted as they are used; the number of
+// values drawn from a stream is logged as a change to prng/<stream>, so
+// that a battle can be replayed from the same place in the stream.
+//
+// the log is append-only. when a turn is rolled back, the rollback is
+// an event too, and the events from the earlier run are kept. the run
+// number tells the runs of a turn apart.
+
+// Change_t is a value that differs between two states of the game.
+type Change_t struct {
+	Path   string          `json:"path"`
+	Before json.RawMessage `json:"before,omitempty"` // missing if the value was added
+	After  json.RawMessage `json:"after,omitempty"`  // missing if the value was removed
+}
+
+// Event_t is a change to the state of the game.
+type Event_t struct {
+	Turn  int    `json:"turn"`            // the turn that was processed or rolled back to
+	Run   int    `json:"run"`             // how many times the turn has been processed, 0 for a rollback
+	Seq   int    `json:"seq"`             // the order of the event within the run
+	Phase string `json:"phase"`           // the phase of the turn, or "rollback"
+	Race  string `json:"race,omitempty"`  // the race whose order caused the change
+	Line  int    `json:"line,omitempty"`  // the line of the order in the race's orders
+	Order string `json:"order,omitempty"` // the order as the player wrote it
+	Change_t
+}
+
+// AuditTurn carries out the orders like ProcessTurn and returns the events
+// for every change the turn made, in the order they were made.
+func (g *Game_t) AuditTurn(orders []*Orders_t) (map[string][]*OrderError_t, []*Event_t, error) {
+	a, err := newAudit(g)
+	if err != nil {
+		return nil, nil, err
+	}
+	results, err := g.processTurn(orders, a)
+	if err != nil {
+		return nil, nil, err
+	}
+	return results, a.events, a.err
+}
+
+// audit_t records the changes while a turn is processed.
+//
+// the values are kept by object: an element of a list with an id, like
+// races/R001 or cluster/star-systems/S017, or any other value at the top
+// of the game, like next-id. the whole game is only compared at the end
+// of the work a phase does on its own. an order is checked by comparing
+// just the objects it may change: its race, the objects its text names,
+// and the lists that every order may add to. objects that an order adds
+// or removes are found by their ids. a change an order makes outside of
+// those objects is found later and blamed on the phase.
+type audit_t struct {
+	turn    int
+	events  []*Event_t
+	err     error // the first error taking a snapshot
+	phase   string
+	race    string
+	src     OrderSource_t
+	objects map[string]map[string]string // the values when they were last recorded, by object and path
+	scope   map[string]map[string]string // the objects the order may change, when it began; nil for a phase
+	ids     map[string]bool              // the objects in the lists when the order began
+	streams map[string]*counter_t
+	drawn   map[string]uint64 // values drawn from each stream at the last snapshot
+}
+
+func newAudit(g *Game_t) (*audit_t, error) {
+	objects, err := snapshot(g)
+	if err != nil {
+		return nil, err
+	}
+	return &audit_t{turn: g.Turn, objects: objects, streams: make(map[string]*counter_t), drawn: make(map[string]uint64)}, nil
+}
+
+// snapshot returns the values in the game and cluster as they are saved,
+// without the log, by object.
+func snapshot(g *Game_t) (map[string]map[string]string, error) {
+	entries := g.Log
+	g.Log = nil
+	game, err := json.Marshal(g)
+	g.Log = entries
+	if err != nil {
+		return nil, err
+	}
+	cluster, err := json.Marshal(g.Cluster)
+	if err != nil {
+		return nil, err
+	}
+	return flattenGame(game, cluster)
+}
+
+// begin records the changes made so far and blames the changes that
+// follow on the order. A nil race blames them on the phase.
+func (a *audit_t) begin(g *Game_t, phase string, race *Race_t, order Order) {
+	a.record(g)
+	a.phase, a.race, a.src, a.scope, a.ids = phase, "", OrderSource_t{}, nil, nil
+	if race == nil || a.err != nil {
+		return
+	}
+	scope := make(map[string]map[string]string)
+	var missed []*Change_t
+	for _, key := range orderScope(g, race, order) {
+		values, err := objectValues(g, key)
+		if err != nil {
+			a.err = err
+			return
+		}
+		scope[key] = values
+		// a change made outside of an earlier order's objects shows up
+		// here, and is blamed on the phase
+		missed = append(missed, diffValues(a.objects[key], values)...)
+		a.objects[key] = values
+	}
+	a.emit(sortChanges(missed))
+	a.race, a.src, a.scope, a.ids = race.Id, order.Source(), scope, listIds(g)
+}
+
+// record adds an event for every value that changed since the last snapshot.
+func (a *audit_t) record(g *Game_t) {
+	if a.err != nil {
+		return
+	}
+	var changes []*Change_t
+	if a.scope == nil {
+		objects, err := snapshot(g)
+		if err != nil {
+			a.err = err
+			return
+		}
+		changes = diffObjects(a.objects, objects)
+		a.objects = objects
+	} else {
+		ids := listIds(g)
+		for key := range ids {
+			if _, ok := a.scope[key]; !ok && !a.ids[key] {
+				a.scope[key] = nil
+			}
+		}
+		for key := range a.ids {
+			if _, ok := a.scope[key]; !ok && !ids[key] {
+				a.scope[key] = a.objects[key]
+			}
+		}
+		after := make(map[string]map[string]string)
+		for key := range a.scope {
+			values, err := objectValues(g, key)
+			if err != nil {
+				a.err = err
+				return
+			}
+			after[key] = values
+			if values == nil {
+				delete(a.objects, key)
+			} else {
+				a.objects[key] = values
+			}
+		}
+		changes = diffObjects(a.scope, after)
+	}
+	var names []string
+	for name := range a.streams {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if n := a.streams[name].n; n != a.drawn[name] {
+			before, _ := json.Marshal(a.drawn[name])
+			after, _ := json.Marshal(n)
+			changes = append(changes, &Change_t{Path: "prng/" + name, Before: before, After: after})
+			a.drawn[name] = n
+		}
+	}
+	a.emit(changes)
+}
+
+// emit adds an event for each change, blamed on the current order or phase.
+func (a *audit_t) emit(changes []*Change_t) {
+	for _, c := range changes {
+		a.events = append(a.events, &Event_t{
+			Turn:     a.turn,
+			Seq:      len(a.events) + 1,
+			Phase:    a.phase,
+			Race:     a.race,
+			Line:     a.src.Line,
+			Order:    a.src.Text,
+			Change_t: *c,
+		})
+	}
+}
+
+// orderScope returns the objects the order may change: the race, the
+// races, colonies, fleets, ships and systems named in the order, the
+// ships in the fleets and the systems of the colonies and fleets, and
+// the lists that aren't kept by id.
+func orderScope(g *Game_t, race *Race_t, order Order) []string {
+	keys := map[string]bool{"races/" + race.Id: true, "messages": true, "history": true, "next-id": true}
+	words := strings.FieldsFunc(order.Source().Text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for _, word := range words {
+		if other := g.Race(word); other != nil {
+			keys["races/"+other.Id] = true
+		}
+		if colony := g.Colony(word); colony != nil {
+			keys["colonies/"+colony.Id] = true
+			keys["cluster/star-systems/"+colony.System] = true
+		}
+		if fleet := g.Fleet(word); fleet != nil {
+			keys["fleets/"+fleet.Id] = true
+			for _, id := range fleet.Ships {
+				keys["ships/"+id] = true
+			}
+			if fleet.System != "" {
+				keys["cluster/star-systems/"+fleet.System] = true
+			}
+		}
+		if ship := g.Ship(word); ship != nil {
+			keys["ships/"+ship.Id] = true
+		}
+		if ss := starSystem(g, word); ss != nil {
+			keys["cluster/star-systems/"+ss.Id] = true
+		}
+	}
+	var list []string
+	for key := range keys {
+		list = append(list, key)
+	}
+	sort.Strings(list)
+	return list
+}
+
+// listIds returns the objects in the lists that orders add to and remove from.
+func listIds(g *Game_t) map[string]bool {
+	ids := make(map[string]bool)
+	for _, race := range g.Races {
+		ids["races/"+race.Id] = true
+	}
+	for _, colony := range g.Colonies {
+		ids["colonies/"+colony.Id] = true
+	}
+	for _, fleet := range g.Fleets {
+		ids["fleets/"+fleet.Id] = true
+	}
+	for _, ship := range g.Ships {
+		ids["ships/"+ship.Id] = true
+	}
+	return ids
+}
+
+// objectValues returns the values of the object, by path, or nil if the
+// game doesn't have it. The keys are the JSON names the game is saved with.
+func objectValues(g *Game_t, key string) (map[string]string, error) {
+	var v any
+	kind, id, _ := strings.Cut(key, "/")
+	switch kind {
+	case "races":
+		if race := g.Race(id); race != nil {
+			v = race
+		}
+	case "colonies":
+		if colony := g.Colony(id); colony != nil {
+			v = colony
+		}
+	case "fleets":
+		if fleet := g.Fleet(id); fleet != nil {
+			v = fleet
+		}
+	case "ships":
+		if ship := g.Ship(id); ship != nil {
+			v = ship
+		}
+	case "cluster":
+		if ss := starSystem(g, strings.TrimPrefix(id, "star-systems/")); ss != nil {
+			v = ss
+		}
+	case "messages":
+		if len(g.Messages) != 0 {
+			v = g.Messages
+		}
+	case "history":
+		if len(g.History) != 0 {
+			v = g.History
+		}
+	case "next-id":
+		v = g.NextId
+	}
+	if v == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber()
+	var doc any
+	if err := d.Decode(&doc); err != nil {
+		return nil, err
+	}
+	values := make(map[string]string)
+	flatten(values, key, doc)
+	return values, nil
+}
+
+// starSystem returns the system with the id, or nil.
+func starSystem(g *Game_t, id string) *aow.StarSystem_t {
+	for _, ss := range g.Cluster.StarSystems {
+		if strings.EqualFold(ss.Id, id) {
+			return ss
+		}
+	}
+	return nil
+}
+
+// stream returns a PRNG that counts the values drawn from the source.
+func (a *audit_t) stream(name string, src rand.Source) *rand.Rand {
+	c := &counter_t{src: src}
+	a.streams[name] = c
+	return rand.New(c)
+}
+
+// counter_t is a source that counts the values drawn from it.
+type counter_t struct {
+	src rand.Source
+	n   uint64
+}
+
+func (c *counter_t) Uint64() uint64 {
+	c.n++
+	return c.src.Uint64()
+}
+
+// DiffGames returns the values that differ between the two states of the
+// game, sorted by path. The tech tree and the log are not compared.
+func DiffGames(from, to *Game_t) ([]*Change_t, error) {
+	before, err := snapshot(from)
+	if err != nil {
+		return nil, err
+	}
+	after, err := snapshot(to)
+	if err != nil {
+		return nil, err
+	}
+	return diffObjects(before, after), nil
+}
+
+// Summary_t is the changes to one thing in the game, like a race or a fleet.
+type Summary_t struct {
+	Path    string      // the thing, like races/R001 or cluster/star-systems/S017
+	Added   bool        // every value is new
+	Removed bool        // every value is gone
+	Changes []*Change_t // with paths relative to the thing
+}
+
+// SummarizeChanges groups the changes by the thing they were made to.
+// The changes must be sorted by path.
+func SummarizeChanges(changes []*Change_t) []*Summary_t {
+	var list []*Summary_t
+	for _, c := range changes {
+		parts := strings.Split(c.Path, "/")
+		depth := 2
+		if parts[0] == "cluster" {
+			depth = 3
+		}
+		depth = max(1, min(depth, len(parts)-1))
+		path := strings.Join(parts[:depth], "/")
+		if len(list) == 0 || list[len(list)-1].Path != path {
+			list = append(list, &Summary_t{Path: path, Added: true, Removed: true})
+		}
+		sum := list[len(list)-1]
+		sum.Added = sum.Added && c.Before == nil
+		sum.Removed = sum.Removed && c.After == nil
+		sum.Changes = append(sum.Changes, &Change_t{Path: strings.Join(parts[depth:], "/"), Before: c.Before, After: c.After})
+	}
+	return list
+}
+
+// diffObjects returns the changes from one snapshot to the other, sorted by path.
+func diffObjects(before, after map[string]map[string]string) []*Change_t {
+	var changes []*Change_t
+	for key, values := range before {
+		changes = append(changes, diffValues(values, after[key])...)
+	}
+	for key, values := range after {
+		if _, ok := before[key]; !ok {
+			changes = append(changes, diffValues(nil, values)...)
+		}
+	}
+	return sortChanges(changes)
+}
+
+// diffValues returns the changes from one set of values to the other.
+func diffValues(before, after map[string]string) []*Change_t {
+	var changes []*Change_t
+	for path, old := range before {
+		if value, ok := after[path]; !ok {
+			changes = append(changes, &Change_t{Path: path, Before: json.RawMessage(old)})
+		} else if value != old {
+			changes = append(changes, &Change_t{Path: path, Before: json.RawMessage(old), After: json.RawMessage(value)})
+		}
+	}
+	for path, value := range after {
+		if _, ok := before[path]; !ok {
+			changes = append(changes, &Change_t{Path: path, After: json.RawMessage(value)})
+		}
+	}
+	return changes
+}
+
+// sortChanges sorts the changes by path.
+func sortChanges(changes []*Change_t) []*Change_t {
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Path < changes[j].Path
+	})
+	return changes
+}
+
+// flattenGame returns the values in the saved game and cluster, by object
+// and path. An element of a list with an id is an object, like races/R001;
+// so is anything else at the top of the document, like next-id.
+func flattenGame(game, cluster []byte) (map[string]map[string]string, error) {
+	objects := make(map[string]map[string]string)
+	add := func(key string, v any) {
+		values := make(map[string]string)
+		flatten(values, key, v)
+		objects[key] = values
+	}
+	for _, doc := range []struct {
+		prefix string
+		data   []byte
+	}{{"", game}, {"cluster/", cluster}} {
+		d := json.NewDecoder(bytes.NewReader(doc.data))
+		d.UseNumber()
+		var top map[string]any
+		if err := d.Decode(&top); err != nil {
+			return nil, err
+		}
+		for name, v := range top {
+			list, ok := v.([]any)
+			if !ok || !hasIds(list) {
+				add(doc.prefix+name, v)
+				continue
+			}
+			for _, value := range list {
+				add(doc.prefix+name+"/"+value.(map[string]any)["id"].(string), value)
+			}
+		}
+	}
+	return objects, nil
+}
+
+// hasIds returns true if every element of the list is an object with an id.
+func hasIds(list []any) bool {
+	for _, value := range list {
+		if m, ok := value.(map[string]any); !ok {
+			return false
+		} else if id, ok := m["id"].(string); !ok || id == "" {
+			return false
+		}
+	}
+	return len(list) != 0
+}
+
+// flatten adds the values under the path to the map.
+func flatten(values map[string]string, path string, v any) {
+	join := func(key string) string {
+		if path == "" {
+			return key
+		}
+		return path + "/" + key
+	}
+	switch v := v.(type) {
+	case map[string]any:
+		for key, value := range v {
+			flatten(values, join(key), value)
+		}
+	case []any:
+		for i, value := range v {
+			key := strconv.Itoa(i)
+			if m, ok := value.(map[string]any); ok {
+				if id, ok := m["id"].(string); ok && id != "" {
+					key = id
+				}
+			}
+			flatten(values, join(key), value)
+		}
+	default:
+		data, _ := json.Marshal(v)
+		values[path] = string(data)
+	}
+}
@@ -4,6 +4,7 @@ package fargo
 
 import (
 	"github.com/playbymail/fargo/internal/aow"
+	"github.com/playbymail/fargo/internal/names"
 	"math/rand/v2"
 )
 
@@ -17,7 +18,10 @@ type Catalog_t struct {
 	prng *rand.Rand
 }
 
-func NewCluster(numberOfSystems int, seed string) (*aow.Catalog_t, error) {
+// NewCluster returns a new cluster catalog. Systems are named using the
+// culture's name list and style. If culture is empty, systems are named
+// with their catalog designations.
+func NewCluster(numberOfSystems int, seed string, culture, style string) (*aow.Catalog_t, error) {
 	const (
 		// the minimum distance between systems in parsecs.
 		// this is weird because it's a percentage of the radius.
@@ -27,7 +31,21 @@ func NewCluster(numberOfSystems int, seed string) (*aow.Catalog_t, error) {
 		epsilon = 0.010278057190847669
 	)
 
-	return aow.NewSolClusterCatalog(numberOfSystems, 1.0, NewPRNG(seed))
+	catalog, err := aow.NewSolClusterCatalog(numberOfSystems, 1.0, NewPRNG(seed))
+	if err != nil {
+		return nil, err
+	}
+
+	// names use their own stream so that they don't change the layout of the cluster
+	var g names.Generator
+	if culture != "" {
+		if g, err = names.NewGenerator(culture, style); err != nil {
+			return nil, err
+		}
+	}
+	catalog.AssignNames(g, NewPRNG(seed+"/names"))
+
+	return catalog, nil
 }
 
 func (c *Catalog_t) Scale(scale float64) {
@@ -0,0 +1,13 @@
+// Copyright (c) 2024 Michael D Henderson. All rights reserved.
+
+package main
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var cmdAccount = &cobra.Command{
+	Use:   "account",
+	Short: "Manage player accounts",
+	Long:  `Manage the accounts that can use the web server for the game.`,
+}
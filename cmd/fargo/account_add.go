@@ -0,0 +1,73 @@
+// Copyright (c) 2024 Michael D Henderson. All rights reserved.
+
+package main
+
+import (
+	"fmt"
+	"github.com/playbymail/fargo"
+	"github.com/spf13/cobra"
+	"log"
+)
+
+var argsAccountAdd = struct {
+	email    string
+	role     string
+	race     string
+	password string
+	secret   string
+}{}
+
+var cmdAccountAdd = &cobra.Command{
+	Use:   "add <handle>",
+	Short: "Add an account",
+	Long: `Add an account to the game.
+
+Handles are letters, digits, dashes, dots and underscores. A player
+must be given a race. If the game is one of several run from a site,
+the account is added to the site's accounts; use "fargod game join" to
+add a race in another game to an account that already exists. An
+account without a password can only log in with a link sent to its
+email address. A player who sends orders
+by mail needs a secret to put on the race line of the orders.
+`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		role, err := fargo.ParseRole(argsAccountAdd.role)
+		if err != nil {
+			log.Fatal(err)
+		}
+		acct := &fargo.Account_t{Handle: args[0], Email: argsAccountAdd.email, Role: role}
+		if argsAccountAdd.race != "" {
+			g, err := fargo.LoadGame(argsRoot.game)
+			if err != nil {
+				log.Fatal(err)
+			}
+			race := g.Race(argsAccountAdd.race)
+			if race == nil {
+				log.Fatal(fmt.Errorf("%q: %w", argsAccountAdd.race, fargo.ErrUnknownRace))
+			}
+			acct.Join(g.Id, race.Id)
+		}
+		path := fargo.AccountsPath(argsRoot.game)
+		accounts, err := fargo.LoadAccounts(path)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if argsAccountAdd.password != "" {
+			if err := accounts.SetPassword(acct, argsAccountAdd.password); err != nil {
+				log.Fatal(err)
+			}
+		}
+		if argsAccountAdd.secret != "" {
+			if err := accounts.SetSecret(acct, argsAccountAdd.secret); err != nil {
+				log.Fatal(err)
+			}
+		}
+		if err := accounts.Add(acct); err != nil {
+			log.Fatal(err)
+		} else if err := accounts.Save(path); err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("account: add: added %s (%s)\n", acct.Handle, acct.Role)
+	},
+}
@@ -0,0 +1,43 @@
+// Copyright (c) 2024 Michael D Henderson. All rights reserved.
+
+package main
+
+import (
+	"fmt"
+	"github.com/playbymail/fargo"
+	"github.com/spf13/cobra"
+	"log"
+	"sort"
+	"strings"
+)
+
+var cmdAccountList = &cobra.Command{
+	Use:   "list",
+	Short: "List the accounts",
+	Run: func(cmd *cobra.Command, args []string) {
+		accounts, err := fargo.LoadAccounts(fargo.AccountsPath(argsRoot.game))
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, acct := range accounts.Accounts {
+			password := "no password"
+			if acct.Password != "" {
+				password = "password"
+			}
+			fmt.Printf("%-16s %-6s %-32s %s, %d tokens, %s\n", acct.Handle, acct.Role, acct.Email, password, len(accounts.TokensOf(acct)), races(acct))
+		}
+	},
+}
+
+// races returns the races the account plays, like "alpha:R001, beta:R004".
+func races(acct *fargo.Account_t) string {
+	var list []string
+	for game, race := range acct.Races {
+		list = append(list, game+":"+race)
+	}
+	if len(list) == 0 {
+		return "no races"
+	}
+	sort.Strings(list)
+	return strings.Join(list, ", ")
+}
@@ -0,0 +1,13 @@
+// Copyright (c) 2024 Michael D Henderson. All rights reserved.
+
+package main
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var cmdCombat = &cobra.Command{
+	Use:   "combat",
+	Short: "Work with combat",
+	Long:  `Try out ship designs in battles outside of a game.`,
+}
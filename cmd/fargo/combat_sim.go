@@ -0,0 +1,103 @@
+// Copyright (c) 2024 Michael D Henderson. All rights reserved.
+
+package main
+
+import (
+	"fmt"
+	"github.com/playbymail/fargo"
+	"github.com/spf13/cobra"
+	"log"
+	"os"
+)
+
+var argsCombatSim = struct {
+	runs   int
+	rounds int
+	tree   string
+	log    bool
+}{}
+
+var cmdCombatSim = &cobra.Command{
+	Use:   "sim <scenario>",
+	Short: "Simulate a battle",
+	Long: `Fight the battle in a scenario file many times and print how often each side wins.
+
+A scenario lists the sides, their tech levels and their ships:
+
+  side Red weapons 2 shields 1 retreat 50
+  ships 3 Raider hull 20 drive 1 weapons 2 shields 1
+  side Blue weapons 2 shields 2
+  ships 2 Guard hull 20 weapons 3 shields 2
+  defenses 40
+
+Every run uses its own PRNG, derived from the seed, so the results can
+be repeated. Use --log to print the blow-by-blow account of the first run.
+`,
+	Args: cobra.ExactArgs(1),
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if argsCombatSim.runs < 1 {
+			return fmt.Errorf("runs must be at least 1")
+		} else if argsCombatSim.rounds < 1 {
+			return fmt.Errorf("rounds must be at least 1")
+		}
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		fp, err := os.Open(args[0])
+		if err != nil {
+			log.Fatal(err)
+		}
+		scenario, err := fargo.ParseScenario(fp)
+		_ = fp.Close()
+		if err != nil {
+			log.Fatalf("%s: %v\n", args[0], err)
+		}
+		tree := fargo.DefaultTechTree()
+		if argsCombatSim.tree != "" {
+			if tree, err = fargo.LoadTechTree(argsCombatSim.tree); err != nil {
+				log.Fatal(err)
+			}
+		}
+		seed := argsRoot.seed
+		if seed == "" {
+			seed = "combat"
+		}
+
+		wins, left := make(map[string]int), make(map[string]int)
+		var rounds int
+		for run := 1; run <= argsCombatSim.runs; run++ {
+			b, err := scenario.Battle(tree, fargo.NewPRNG(fmt.Sprintf("%s/%d", seed, run)))
+			if err != nil {
+				log.Fatalf("%s: %v\n", args[0], err)
+			}
+			b.Fight(argsCombatSim.rounds)
+			if run == 1 && argsCombatSim.log {
+				for _, line := range b.Log {
+					fmt.Println(line)
+				}
+				fmt.Println()
+			}
+			wins[b.Winner()]++
+			rounds += b.Rounds
+			for _, c := range b.Combatants {
+				if !c.Destroyed {
+					left[c.Side]++
+				}
+			}
+		}
+
+		runs := float64(argsCombatSim.runs)
+		fmt.Printf("%d runs, %.1f rounds on average\n", argsCombatSim.runs, float64(rounds)/runs)
+		for _, side := range scenario.Sides {
+			var ships int
+			for _, s := range side.Ships {
+				ships += s.Count
+			}
+			if side.Defenses > 0 {
+				ships++
+			}
+			fmt.Printf("  %-16s wins %6d (%5.1f%%), %.1f of %d left on average\n", side.Name, wins[side.Name], 100*float64(wins[side.Name])/runs, float64(left[side.Name])/runs, ships)
+		}
+		fmt.Printf("  %-16s      %6d (%5.1f%%)\n", "draws", wins[""], 100*float64(wins[""])/runs)
+	},
+}
@@ -6,15 +6,20 @@ import (
 	"fmt"
 	"github.com/playbymail/fargo"
 	"github.com/playbymail/fargo/internal/mars"
+	"github.com/playbymail/fargo/internal/names"
+	"github.com/playbymail/fargo/internal/render"
 	"github.com/spf13/cobra"
 	"log"
 	"math"
+	"strings"
 )
 
 var argsCreateCluster = struct {
 	numberOfRaces  int
 	systemsPerRace float64
 	scale          float64
+	culture        string
+	nameStyle      string
 }{}
 
 var cmdCreateCluster = &cobra.Command{
@@ -24,6 +29,10 @@ var cmdCreateCluster = &cobra.Command{
 
 The radius of the cluster is derived from the number of systems and the scale factor.
 The scale factor is a multiplier that expands or shrinks the radius of the cluster.
+
+Systems are named from the culture's name list, using either the syllable
+or the markov style. Use an empty culture to name systems with their
+catalog designations.
 `,
 	PreRunE: func(cmd *cobra.Command, args []string) error {
 		if argsCreateCluster.numberOfRaces < fargo.MinimumNumberOfRaces {
@@ -33,14 +42,19 @@ The scale factor is a multiplier that expands or shrinks the radius of the clust
 		} else if argsCreateCluster.scale < 0.1 {
 			return fmt.Errorf("scale factor must be greater than 0.1")
 		} else if argsCreateCluster.systemsPerRace < fargo.MinimumSystemsPerRace {
-			return fmt.Errorf("number of systems per race must be at least %d", fargo.MinimumSystemsPerRace)
+			return fmt.Errorf("number of systems per race must be at least %g", fargo.MinimumSystemsPerRace)
 		} else if argsCreateCluster.systemsPerRace > fargo.MaximumSystemsPerRace {
-			return fmt.Errorf("number of systems per race must be at most %d", fargo.MaximumSystemsPerRace)
+			return fmt.Errorf("number of systems per race must be at most %g", fargo.MaximumSystemsPerRace)
 		} else if argsCreateCluster.scale < fargo.MinimumRadiusScaleFactor {
 			return fmt.Errorf("scale factor must be greater than %g", fargo.MinimumRadiusScaleFactor)
 		} else if argsCreateCluster.scale > fargo.MaximumRadiusScaleFactor {
 			return fmt.Errorf("scale factor must be less than %g", fargo.MaximumRadiusScaleFactor)
 		}
+		if argsCreateCluster.culture != "" {
+			if _, err := names.NewGenerator(argsCreateCluster.culture, argsCreateCluster.nameStyle); err != nil {
+				return fmt.Errorf("names: %w: cultures are %s", err, strings.Join(names.Cultures(), ", "))
+			}
+		}
 		return nil
 	},
 	Run: func(cmd *cobra.Command, args []string) {
@@ -48,11 +62,15 @@ The scale factor is a multiplier that expands or shrinks the radius of the clust
 		log.Printf("create: cluster: systems %8d\n", int(argsCreateCluster.systemsPerRace))
 		log.Printf("create: cluster: scale   %8.2f\n", argsCreateCluster.scale)
 
-		cluster, err := fargo.NewCluster(int(math.Ceil(float64(argsCreateCluster.numberOfRaces)*argsCreateCluster.systemsPerRace)), argsRoot.seed)
+		cluster, err := fargo.NewCluster(int(math.Ceil(float64(argsCreateCluster.numberOfRaces)*argsCreateCluster.systemsPerRace)), argsRoot.seed, argsCreateCluster.culture, argsCreateCluster.nameStyle)
+		if err != nil {
+			log.Fatal(err)
+		}
+		err = cluster.SaveAsJSON("cluster.json")
 		if err != nil {
 			log.Fatal(err)
 		}
-		err = cluster.SaveAsPNG("cluster.png")
+		err = render.SavePNG("cluster.png", cluster)
 		if err != nil {
 			log.Fatal(err)
 		}
@@ -0,0 +1,59 @@
+// Copyright (c) 2024 Michael D Henderson. All rights reserved.
+
+package main
+
+import (
+	"fmt"
+	"github.com/playbymail/fargo"
+	"github.com/spf13/cobra"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+var argsCreateGame = struct {
+	name           string
+	numberOfRaces  int
+	systemsPerRace float64
+	culture        string
+	nameStyle      string
+}{}
+
+var cmdCreateGame = &cobra.Command{
+	Use:   "game",
+	Short: "Create a new game",
+	Long: `Create a new game in the game directory.
+
+A new cluster is created and each race is given a home world.
+The state of the game and the reports for the first turn are
+written to the game directory.
+`,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		for _, name := range []string{fargo.GameFile, fargo.BoltFile} {
+			if _, err := os.Stat(filepath.Join(argsRoot.game, name)); err == nil {
+				return fmt.Errorf("%s: game already exists", argsRoot.game)
+			}
+		}
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		g, err := fargo.CreateGame(fargo.GameOptions_t{
+			Name:           argsCreateGame.name,
+			Seed:           argsRoot.seed,
+			NumberOfRaces:  argsCreateGame.numberOfRaces,
+			SystemsPerRace: argsCreateGame.systemsPerRace,
+			Culture:        argsCreateGame.culture,
+			NameStyle:      argsCreateGame.nameStyle,
+		})
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err := os.MkdirAll(argsRoot.game, 0755); err != nil {
+			log.Fatal(err)
+		}
+		if err := g.SaveTurn(argsRoot.game); err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("create: game: created %q with %d races in %s\n", g.Name, len(g.Races), argsRoot.game)
+	},
+}
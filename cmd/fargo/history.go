@@ -0,0 +1,20 @@
+// Copyright (c) 2024 Michael D Henderson. All rights reserved.
+
+package main
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var cmdHistory = &cobra.Command{
+	Use:   "history",
+	Short: "Query the history of the game",
+	Long: `Show how fleets and colonies changed from turn to turn.
+
+The state at the start of every turn is kept in the game's store.
+Without --turn, every turn is shown.`,
+}
+
+var argsHistory struct {
+	turn int
+}
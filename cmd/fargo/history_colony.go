@@ -0,0 +1,49 @@
+// Copyright (c) 2024 Michael D Henderson. All rights reserved.
+
+package main
+
+import (
+	"fmt"
+	"github.com/playbymail/fargo"
+	"github.com/spf13/cobra"
+	"log"
+)
+
+var cmdHistoryColony = &cobra.Command{
+	Use:   "colony <id>",
+	Short: "Show how a colony grew on each turn",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		s, err := fargo.OpenStore(argsRoot.game, true)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer s.Close()
+		if argsHistory.turn != 0 {
+			colony, err := fargo.ColonyAt(s, args[0], argsHistory.turn)
+			if err != nil {
+				log.Fatal(err)
+			}
+			printColony(argsHistory.turn, colony)
+			return
+		}
+		found := false
+		err = fargo.History(s, func(g *fargo.Game_t) error {
+			if colony := g.Colony(args[0]); colony != nil {
+				printColony(g.Turn, colony)
+				found = true
+			}
+			return nil
+		})
+		if err != nil {
+			log.Fatal(err)
+		} else if !found {
+			log.Fatal(fmt.Errorf("%q: %w", args[0], fargo.ErrUnknownColony))
+		}
+	},
+}
+
+func printColony(turn int, colony *fargo.Colony_t) {
+	fmt.Printf("turn %4d  %-6s %-4s %s/%d  population %8.2f  infrastructure %8.2f  defenses %6.1f\n",
+		turn, colony.Id, colony.Race, colony.System, colony.Orbit, colony.Population, colony.Infrastructure, colony.Defenses)
+}
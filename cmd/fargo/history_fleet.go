@@ -0,0 +1,55 @@
+// Copyright (c) 2024 Michael D Henderson. All rights reserved.
+
+package main
+
+import (
+	"fmt"
+	"github.com/playbymail/fargo"
+	"github.com/spf13/cobra"
+	"log"
+)
+
+var cmdHistoryFleet = &cobra.Command{
+	Use:   "fleet <id>",
+	Short: "Show where a fleet was on each turn",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		s, err := fargo.OpenStore(argsRoot.game, true)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer s.Close()
+		if argsHistory.turn != 0 {
+			fleet, err := fargo.FleetAt(s, args[0], argsHistory.turn)
+			if err != nil {
+				log.Fatal(err)
+			}
+			printFleet(argsHistory.turn, fleet)
+			return
+		}
+		found := false
+		err = fargo.History(s, func(g *fargo.Game_t) error {
+			if fleet := g.Fleet(args[0]); fleet != nil {
+				printFleet(g.Turn, fleet)
+				found = true
+			}
+			return nil
+		})
+		if err != nil {
+			log.Fatal(err)
+		} else if !found {
+			log.Fatal(fmt.Errorf("%q: %w", args[0], fargo.ErrUnknownFleet))
+		}
+	},
+}
+
+func printFleet(turn int, fleet *fargo.Fleet_t) {
+	where := fleet.System
+	if fleet.InTransit() {
+		where = "in transit at " + fleet.Position.String()
+	}
+	if fleet.Destination != "" {
+		where += ", bound for " + fleet.Destination
+	}
+	fmt.Printf("turn %4d  %-6s %-4s %2d ships  %s\n", turn, fleet.Id, fleet.Race, len(fleet.Ships), where)
+}
@@ -0,0 +1,17 @@
+// Copyright (c) 2024 Michael D Henderson. All rights reserved.
+
+package main
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var cmdMail = &cobra.Command{
+	Use:   "mail",
+	Short: "Exchange orders and reports by mail",
+	Long: `Read orders sent by mail and write the replies.
+
+Mail for the game is kept in the "mail" directory of the game. Replies
+are written to "mail/outbox" to be sent.
+`,
+}
@@ -0,0 +1,176 @@
+// Copyright (c) 2024 Michael D Henderson. All rights reserved.
+
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"github.com/playbymail/fargo"
+	"github.com/playbymail/fargo/internal/mail"
+	"github.com/spf13/cobra"
+	"log"
+	netmail "net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var argsMailIngest = struct {
+	from string
+}{}
+
+var cmdMailIngest = &cobra.Command{
+	Use:   "ingest <maildir|mbox>...",
+	Short: "Read orders from a mailbox",
+	Long: `Read orders from a maildir or an mbox file.
+
+The orders must start with the race and the player's secret:
+
+	race R001 secret
+
+If the player's account has an email address, the orders must be sent
+from it.
+
+Orders with the right secret are checked and saved for the current turn,
+just like orders submitted to the server. Every message gets a reply in
+the outbox that lists the orders that failed the check.
+
+Messages are only read once. Messages in a maildir are moved to "cur";
+the ids of the messages read are kept in "mail/ingested.txt". A message
+that can't be parsed is logged and treated as read, without a reply.
+`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		from, err := netmail.ParseAddress(argsMailIngest.from)
+		if err != nil {
+			log.Fatalf("from: %v\n", err)
+		}
+		path, err := fargo.AbsPath(argsRoot.game)
+		if err != nil {
+			log.Fatal(err)
+		}
+		g, err := fargo.LoadGame(path)
+		if err != nil {
+			log.Fatal(err)
+		}
+		accounts, err := fargo.LoadAccounts(fargo.AccountsPath(path))
+		if err != nil {
+			log.Fatal(err)
+		}
+		ledger := filepath.Join(fargo.MailPath(path), "ingested.txt")
+		seen, err := readIngested(ledger)
+		if err != nil {
+			log.Fatal(err)
+		}
+		outbox := mail.NewOutbox(fargo.MailPath(path))
+		for _, mailbox := range args {
+			messages, err := mail.ReadMailbox(mailbox)
+			if err != nil {
+				log.Fatal(err)
+			}
+			for _, msg := range messages {
+				if seen[msg.Id] {
+					if err := msg.Done(); err != nil {
+						log.Fatal(err)
+					}
+					continue
+				} else if msg.Err != nil {
+					log.Printf("mail: ingest: %s: %v\n", msg.Id, msg.Err)
+					if err := markIngested(ledger, msg.Id); err != nil {
+						log.Fatal(err)
+					} else if err := msg.Done(); err != nil {
+						log.Fatal(err)
+					}
+					seen[msg.Id] = true
+					continue
+				}
+				reply := ingest(g, path, accounts, msg)
+				name, err := mail.Reply(msg, from, reply).Spool(outbox.Dir)
+				if err != nil {
+					log.Fatal(err)
+				} else if err := markIngested(ledger, msg.Id); err != nil {
+					log.Fatal(err)
+				} else if err := msg.Done(); err != nil {
+					log.Fatal(err)
+				}
+				seen[msg.Id] = true
+				log.Printf("mail: ingest: %s: %s\n", msg.From.Address, filepath.Base(name))
+			}
+		}
+	},
+}
+
+// ingest files the orders in a message and returns the text of the reply.
+func ingest(g *fargo.Game_t, path string, accounts *fargo.Accounts_t, msg *mail.Message_t) string {
+	o, err := fargo.ParseOrders(strings.NewReader(msg.Body))
+	if err != nil {
+		return fmt.Sprintf("Your orders could not be read: %v\n", err)
+	} else if o.Race == "" {
+		return "Your orders were not accepted.\nThe first line of the orders must be \"race <id> <secret>\".\n"
+	}
+	race := g.Race(o.Race)
+	if race == nil {
+		return fmt.Sprintf("Your orders were not accepted.\n%q is not a race in %s.\n", o.Race, g.Name)
+	}
+	acct, err := accounts.CheckSecret(g.Id, race.Id, o.Secret)
+	if err != nil {
+		log.Printf("mail: ingest: %s: %s: %v\n", msg.From.Address, race.Id, err)
+		return fmt.Sprintf("Your orders for %s were not accepted.\nThe secret on the race line is not right.\n", race.Id)
+	} else if acct.Email != "" && !strings.EqualFold(msg.From.Address, acct.Email) {
+		// the secret is right, so the player may have leaked it
+		log.Printf("mail: ingest: %s: %s: secret for %s sent from another address\n", msg.From.Address, race.Id, acct.Handle)
+		return fmt.Sprintf("Your orders for %s were not accepted.\nOrders must be sent from the address on the player's account.\n", race.Id)
+	}
+	errs, err := g.SubmitOrders(path, race, []byte(msg.Body))
+	if err != nil {
+		return fmt.Sprintf("Your orders for %s were not accepted: %v\n", race.Id, err)
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Your orders for %s (%s) have been filed for turn %d of %s.\n", race.Id, race.Name, g.Turn, g.Name)
+	fmt.Fprintf(&sb, "You may send new orders until the turn is processed; the last orders sent are used.\n\n")
+	if len(errs) == 0 {
+		fmt.Fprintf(&sb, "All %d orders passed the check.\n", len(o.Orders))
+		return sb.String()
+	}
+	fmt.Fprintf(&sb, "%d orders failed the check:\n\n", len(errs))
+	for _, e := range errs {
+		fmt.Fprintf(&sb, "  line %d: %s\n    %v\n", e.Line, e.Text, e.Err)
+	}
+	return sb.String()
+}
+
+// readIngested returns the ids of the messages that have been read.
+func readIngested(name string) (map[string]bool, error) {
+	seen := map[string]bool{}
+	fp, err := os.Open(name)
+	if errors.Is(err, os.ErrNotExist) {
+		return seen, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer fp.Close()
+	scanner := bufio.NewScanner(fp)
+	for scanner.Scan() {
+		if id := strings.TrimSpace(scanner.Text()); id != "" {
+			seen[id] = true
+		}
+	}
+	return seen, scanner.Err()
+}
+
+// markIngested adds the message's id to the list of messages that have been read.
+func markIngested(name, id string) error {
+	if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+		return err
+	}
+	fp, err := os.OpenFile(name, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintln(fp, id); err != nil {
+		_ = fp.Close()
+		return err
+	}
+	return fp.Close()
+}
@@ -0,0 +1,184 @@
+// Copyright (c) 2024 Michael D Henderson. All rights reserved.
+
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"github.com/playbymail/fargo"
+	"github.com/playbymail/fargo/internal/mail"
+	"github.com/playbymail/fargo/internal/render"
+	"github.com/spf13/cobra"
+	"io"
+	"log"
+	netmail "net/mail"
+	"os"
+	"strings"
+	"time"
+)
+
+var argsMailSend = struct {
+	from       string
+	smtp       string
+	username   string
+	attach     string
+	mapSize    int
+	dryRun     string
+	retries    int
+	retryDelay time.Duration
+}{}
+
+var cmdMailSend = &cobra.Command{
+	Use:   "send",
+	Short: "Send the reports and replies",
+	Long: `Send every player the report for the current turn, along with a map
+of the cluster, then send everything waiting in the outbox.
+
+Reports go to the email address of every player account with a race.
+A report is only queued once, so the command can be run again to send
+the messages that failed. Maps are attached as png, svg or pdf files.
+
+The password for the SMTP server is read from FARGO_SMTP_PASSWORD.
+With --dry-run, messages are copied to a directory instead of being
+sent, and stay in the outbox.
+
+Every attempt is recorded in "mail/delivery.log". Messages that were
+sent are moved to "mail/sent".
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		from, err := netmail.ParseAddress(argsMailSend.from)
+		if err != nil {
+			log.Fatalf("from: %v\n", err)
+		}
+		formats := strings.Split(argsMailSend.attach, ",")
+		for _, format := range formats {
+			if format != "" && format != "png" && format != "svg" && format != "pdf" {
+				log.Fatalf("attach: %q: %v\n", format, fargo.ErrInvalidArguments)
+			}
+		}
+		path, err := fargo.AbsPath(argsRoot.game)
+		if err != nil {
+			log.Fatal(err)
+		}
+		g, err := fargo.LoadGame(path)
+		if err != nil {
+			log.Fatal(err)
+		}
+		accounts, err := fargo.LoadAccounts(fargo.AccountsPath(path))
+		if err != nil {
+			log.Fatal(err)
+		}
+		outbox := mail.NewOutbox(fargo.MailPath(path))
+		outbox.Retries, outbox.RetryDelay = argsMailSend.retries, argsMailSend.retryDelay
+
+		// queue the reports that haven't been sent or queued yet
+		queued, err := outbox.Queued()
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, acct := range accounts.Accounts {
+			race := g.Race(acct.RaceIn(g.Id))
+			if acct.Role != fargo.RolePlayer || race == nil {
+				continue
+			} else if acct.Email == "" {
+				log.Printf("mail: send: %s: %s: no email address\n", race.Id, acct.Handle)
+				continue
+			}
+			msg, err := reportMessage(g, path, race, from, acct, formats)
+			if err != nil {
+				log.Fatal(err)
+			} else if queued[msg.Id] {
+				continue
+			} else if _, err := msg.Spool(outbox.Dir); err != nil {
+				log.Fatal(err)
+			}
+		}
+
+		// then send everything in the outbox
+		var t mail.Transport = &mail.SMTP_t{Addr: argsMailSend.smtp, Username: argsMailSend.username, Password: os.Getenv("FARGO_SMTP_PASSWORD")}
+		if argsMailSend.dryRun != "" {
+			t = mail.Dir_t(argsMailSend.dryRun)
+		}
+		failed, err := outbox.Flush(t)
+		if err != nil {
+			log.Fatal(err)
+		} else if failed != 0 {
+			log.Fatalf("mail: send: %d messages failed\n", failed)
+		}
+	},
+}
+
+// reportMessage returns the message with the race's report for the current turn.
+// The message id is made from the game, turn, race and a hash of the
+// report and the address, so that a report is only sent once to each
+// player, but a turn that is rolled back and processed again sends the
+// new reports. Nothing the player chose goes into the id.
+func reportMessage(g *fargo.Game_t, path string, race *fargo.Race_t, from *netmail.Address, acct *fargo.Account_t, formats []string) (*mail.Outgoing_t, error) {
+	report, err := fargo.ReadReport(path, g.Turn, race.Id)
+	if errors.Is(err, os.ErrNotExist) {
+		// a game that was just created doesn't have reports in its store yet
+		var buf bytes.Buffer
+		if err := g.WriteReport(&buf, race); err != nil {
+			return nil, err
+		}
+		report = buf.Bytes()
+	} else if err != nil {
+		return nil, err
+	}
+	domain := "localhost"
+	if n := strings.LastIndexByte(from.Address, '@'); n != -1 {
+		domain = from.Address[n+1:]
+	}
+	h := sha256.New()
+	h.Write(report)
+	h.Write([]byte{0})
+	h.Write([]byte(strings.ToLower(acct.Email)))
+	sum := h.Sum(nil)
+	msg := &mail.Outgoing_t{
+		Id:      strings.ToLower(fmt.Sprintf("report-%s-%04d-%s-%x@%s", g.Id, g.Turn, race.Id, sum[:8], domain)),
+		From:    from,
+		To:      &netmail.Address{Name: acct.Handle, Address: acct.Email},
+		Subject: fmt.Sprintf("%s: turn %d report for %s", g.Name, g.Turn, race.Name),
+		Body:    string(report),
+	}
+	var colonies []string
+	for _, colony := range g.ColoniesOf(race) {
+		colonies = append(colonies, colony.System)
+	}
+	highlight, _ := render.ParseColor("cyan")
+	r, err := render.NewRenderer(
+		render.WithSize(argsMailSend.mapSize, argsMailSend.mapSize),
+		render.WithLabels(render.LabelId),
+		render.WithHighlights(highlight, colonies...),
+		render.WithScaleBar(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	for _, format := range formats {
+		var write func(io.Writer) error
+		var contentType string
+		switch format {
+		case "png":
+			write, contentType = func(w io.Writer) error { return r.WritePNG(w, g.Cluster) }, "image/png"
+		case "svg":
+			write, contentType = func(w io.Writer) error { return r.WriteSVG(w, g.Cluster) }, "image/svg+xml"
+		case "pdf":
+			write, contentType = func(w io.Writer) error { return r.WritePDF(w, g.Cluster) }, "application/pdf"
+		default:
+			continue
+		}
+		var buf bytes.Buffer
+		if err := write(&buf); err != nil {
+			return nil, err
+		}
+		msg.Files = append(msg.Files, &mail.Attachment_t{
+			Name:        fmt.Sprintf("%s-turn-%04d.%s", strings.ToLower(race.Id), g.Turn, format),
+			ContentType: contentType,
+			Data:        buf.Bytes(),
+		})
+	}
+	return msg, nil
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/playbymail/fargo"
 	"github.com/spf13/cobra"
 	"log"
+	"time"
 )
 
 func main() {
@@ -18,14 +19,89 @@ func main() {
 }
 
 func Execute() error {
-	cmdRoot.AddCommand(cmdCreate, cmdVersion)
-	cmdCreate.AddCommand(cmdCreateCluster)
+	cmdRoot.AddCommand(cmdAccount, cmdCombat, cmdCreate, cmdHistory, cmdMail, cmdMap, cmdOrders, cmdReport, cmdRoute, cmdStore, cmdSystem, cmdTurn, cmdVersion)
+	cmdAccount.AddCommand(cmdAccountAdd, cmdAccountList)
+	cmdCombat.AddCommand(cmdCombatSim)
+	cmdCreate.AddCommand(cmdCreateCluster, cmdCreateGame)
+	cmdHistory.AddCommand(cmdHistoryColony, cmdHistoryFleet)
+	cmdMail.AddCommand(cmdMailIngest, cmdMailSend)
+	cmdMap.AddCommand(cmdMapAnimate, cmdMapPNG)
+	cmdOrders.AddCommand(cmdOrdersCheck)
+	cmdStore.AddCommand(cmdStoreMigrate)
+	cmdTurn.AddCommand(cmdTurnDiff, cmdTurnEvents, cmdTurnProcess, cmdTurnRollback)
 
 	cmdRoot.PersistentFlags().StringVar(&argsRoot.seed, "seed", "", "optional seed for the PRNG")
+	cmdRoot.PersistentFlags().StringVar(&argsRoot.game, "game", ".", "game directory")
+
+	cmdAccountAdd.Flags().StringVar(&argsAccountAdd.email, "email", "", "email address for login links")
+	cmdAccountAdd.Flags().StringVar(&argsAccountAdd.role, "role", "player", "role (player, gm or admin)")
+	cmdAccountAdd.Flags().StringVar(&argsAccountAdd.race, "race", "", "race a player controls")
+	cmdAccountAdd.Flags().StringVar(&argsAccountAdd.password, "password", "", "password, if the account may log in with one")
+	cmdAccountAdd.Flags().StringVar(&argsAccountAdd.secret, "secret", "", "secret for a player's orders sent by mail")
+
+	cmdCombatSim.Flags().IntVar(&argsCombatSim.runs, "runs", 100, "number of battles to fight")
+	cmdCombatSim.Flags().IntVar(&argsCombatSim.rounds, "rounds", fargo.CombatRounds, "most rounds in a battle")
+	cmdCombatSim.Flags().StringVar(&argsCombatSim.tree, "tree", "", "tech tree to use instead of the default")
+	cmdCombatSim.Flags().BoolVar(&argsCombatSim.log, "log", false, "print the log of the first battle")
 
 	cmdCreateCluster.Flags().IntVar(&argsCreateCluster.numberOfRaces, "races", fargo.DefaultNumberOfRaces, "number of races")
 	cmdCreateCluster.Flags().Float64Var(&argsCreateCluster.systemsPerRace, "systems-per-race", 6, "number of systems per race")
 	cmdCreateCluster.Flags().Float64Var(&argsCreateCluster.scale, "scale", fargo.DefaultRadiusScaleFactor, "cluster scale factor")
+	cmdCreateCluster.Flags().StringVar(&argsCreateCluster.culture, "names", "classical", "culture for system names")
+	cmdCreateCluster.Flags().StringVar(&argsCreateCluster.nameStyle, "name-style", "markov", "style of system names (syllable or markov)")
+
+	cmdCreateGame.Flags().StringVar(&argsCreateGame.name, "name", "Fargo", "name of the game")
+	cmdCreateGame.Flags().IntVar(&argsCreateGame.numberOfRaces, "races", fargo.DefaultNumberOfRaces, "number of races")
+	cmdCreateGame.Flags().Float64Var(&argsCreateGame.systemsPerRace, "systems-per-race", fargo.DefaultSystemsPerRace, "number of systems per race")
+	cmdCreateGame.Flags().StringVar(&argsCreateGame.culture, "names", "classical", "culture for system names")
+	cmdCreateGame.Flags().StringVar(&argsCreateGame.nameStyle, "name-style", "markov", "style of system names (syllable or markov)")
+
+	cmdHistory.PersistentFlags().IntVar(&argsHistory.turn, "turn", 0, "only show the start of this turn")
+
+	cmdMailIngest.Flags().StringVar(&argsMailIngest.from, "from", "GM <gm@localhost>", "address the replies are sent from")
+	cmdMailSend.Flags().StringVar(&argsMailSend.from, "from", "GM <gm@localhost>", "address the mail is sent from")
+	cmdMailSend.Flags().StringVar(&argsMailSend.smtp, "smtp", "localhost:25", "host and port of the SMTP server")
+	cmdMailSend.Flags().StringVar(&argsMailSend.username, "username", "", "login for the SMTP server, if it needs one")
+	cmdMailSend.Flags().StringVar(&argsMailSend.attach, "attach", "png", "map formats to attach (png, svg or pdf)")
+	cmdMailSend.Flags().IntVar(&argsMailSend.mapSize, "map-size", 1024, "width and height of the map in pixels")
+	cmdMailSend.Flags().StringVar(&argsMailSend.dryRun, "dry-run", "", "copy messages to this directory instead of sending them")
+	cmdMailSend.Flags().IntVar(&argsMailSend.retries, "retries", 3, "times to try again when sending fails")
+	cmdMailSend.Flags().DurationVar(&argsMailSend.retryDelay, "retry-delay", 5*time.Second, "time to wait before the first retry")
+
+	cmdMap.PersistentFlags().StringVar(&argsMap.cluster, "cluster", "cluster.json", "cluster catalog to load")
+	cmdMap.PersistentFlags().StringVar(&argsMap.race, "race", "", "only draw the systems this race knows about")
+	cmdMapAnimate.Flags().StringVar(&argsMapAnimate.output, "output", "cluster.gif", "name of the file to create")
+	cmdMapAnimate.Flags().StringVar(&argsMapAnimate.format, "format", "", "animation format (gif or apng)")
+	cmdMapAnimate.Flags().IntVar(&argsMapAnimate.size, "size", 512, "width and height of the animation in pixels")
+	cmdMapAnimate.Flags().StringVar(&argsMapAnimate.plane, "plane", "xz", "projection plane (xy, xz or yz)")
+	cmdMapAnimate.Flags().StringVar(&argsMapAnimate.axis, "axis", "z", "rotation axis (x, y or z)")
+	cmdMapAnimate.Flags().IntVar(&argsMapAnimate.frames, "frames", 36, "number of frames")
+	cmdMapAnimate.Flags().Float64Var(&argsMapAnimate.speed, "speed", 10, "degrees of rotation per frame")
+	cmdMapAnimate.Flags().IntVar(&argsMapAnimate.delay, "delay", 100, "milliseconds between frames")
+	cmdMapAnimate.Flags().StringVar(&argsMapAnimate.labels, "labels", "none", "label fields (id, name, z or none)")
+	cmdMapAnimate.Flags().StringVar(&argsMapAnimate.background, "background", "black", "background color")
+	cmdMapAnimate.Flags().StringVar(&argsMapAnimate.center, "center", "", "only draw systems near this system")
+	cmdMapAnimate.Flags().Float64Var(&argsMapAnimate.radius, "radius", 0, "light years from the center to draw")
+	cmdMapPNG.Flags().StringVar(&argsMapPNG.output, "output", "cluster.png", "name of the file to create")
+	cmdMapPNG.Flags().IntVar(&argsMapPNG.size, "size", 4096, "width and height of the map in pixels")
+	cmdMapPNG.Flags().StringVar(&argsMapPNG.plane, "plane", "xy", "projection plane (xy, xz or yz)")
+	cmdMapPNG.Flags().Float64Var(&argsMapPNG.grid, "grid", 0, "light years between grid lines, 0 for no grid")
+	cmdMapPNG.Flags().BoolVar(&argsMapPNG.scaleBar, "scale-bar", false, "draw a scale bar")
+	cmdMapPNG.Flags().StringVar(&argsMapPNG.labels, "labels", "z", "label fields (id, name, z or none)")
+	cmdMapPNG.Flags().StringVar(&argsMapPNG.highlight, "highlight", "", "systems to highlight")
+	cmdMapPNG.Flags().StringVar(&argsMapPNG.background, "background", "black", "background color")
+
+	cmdRoute.Flags().StringVar(&argsRoute.cluster, "cluster", "cluster.json", "cluster catalog to load")
+	cmdRoute.Flags().StringVar(&argsRoute.by, "by", "distance", "optimize route for distance, hops or turns")
+	cmdRoute.Flags().IntVar(&argsRoute.driveTech, "drive", 1, "drive tech level")
+	cmdRoute.Flags().Float64Var(&argsRoute.maxJump, "max-jump", fargo.DefaultMaximumJump, "longest single jump in light years")
+
+	cmdSystem.Flags().StringVar(&argsSystem.cluster, "cluster", "cluster.json", "cluster catalog to load")
+
+	cmdTurnEvents.Flags().StringVar(&argsTurnEvents.race, "race", "", "only show the changes made by the race's orders")
+	cmdTurnEvents.Flags().IntVar(&argsTurnEvents.run, "run", 0, "only show this run of the turn")
+	cmdTurnEvents.Flags().StringVar(&argsTurnEvents.path, "path", "", "only show the values under this path, like races/R001")
+	cmdTurnRollback.Flags().IntVar(&argsTurnRollback.to, "to", 0, "turn to restore")
 
 	if argsRoot.seed != "" {
 		fargo.WithSeed(argsRoot.seed, true)
@@ -45,6 +121,7 @@ var cmdRoot = &cobra.Command{
 
 var argsRoot = struct {
 	e    *fargo.Engine
+	game string
 	seed string
 }{
 	seed: "0xdeadbeef^0xcafebabe",
@@ -0,0 +1,52 @@
+// Copyright (c) 2024 Michael D Henderson. All rights reserved.
+
+package main
+
+import (
+	"fmt"
+	"github.com/playbymail/fargo"
+	"github.com/playbymail/fargo/internal/aow"
+	"github.com/spf13/cobra"
+	"sort"
+)
+
+var argsMap = struct {
+	cluster string
+	race    string
+}{}
+
+var cmdMap = &cobra.Command{
+	Use:   "map",
+	Short: "Draw maps of the cluster",
+	Long: `Draw maps of the cluster catalog.
+
+With a race, the map is drawn from the game's catalog and shows only
+the systems that the race knows about.`,
+}
+
+// loadMap returns the catalog to draw. When a race is given, it also returns
+// the ids of the systems in the race's knowledge. Otherwise, the ids are nil
+// and every system is drawn.
+func loadMap() (*aow.Catalog_t, []string, error) {
+	if argsMap.race == "" {
+		cluster, err := aow.LoadCatalog(argsMap.cluster)
+		return cluster, nil, err
+	}
+	g, err := fargo.LoadGame(argsRoot.game)
+	if err != nil {
+		return nil, nil, err
+	}
+	race := g.Race(argsMap.race)
+	if race == nil {
+		return nil, nil, fmt.Errorf("%q: %w", argsMap.race, fargo.ErrUnknownRace)
+	}
+	ids := []string{}
+	for id := range race.Knowledge {
+		ids = append(ids, id)
+	}
+	if len(ids) == 0 {
+		return nil, nil, fmt.Errorf("%s: knows no systems", race.Id)
+	}
+	sort.Strings(ids)
+	return g.Cluster, ids, nil
+}
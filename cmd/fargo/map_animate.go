@@ -0,0 +1,119 @@
+// Copyright (c) 2024 Michael D Henderson. All rights reserved.
+
+package main
+
+import (
+	"fmt"
+	"github.com/playbymail/fargo/internal/render"
+	"github.com/spf13/cobra"
+	"log"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+var argsMapAnimate = struct {
+	output     string
+	format     string
+	size       int
+	plane      string
+	axis       string
+	frames     int
+	speed      float64
+	delay      int
+	labels     string
+	background string
+	center     string
+	radius     float64
+}{}
+
+var cmdMapAnimate = &cobra.Command{
+	Use:   "animate",
+	Short: "Draw a rotating 3D map of the cluster",
+	Long: `Draw a rotating map of the cluster as an animated GIF or APNG.
+
+The cluster is rotated around the axis, then drawn in perspective onto the plane.
+The speed is the number of degrees the cluster turns between frames.
+Use center and radius to limit the map to the systems near one system.
+The format defaults to the extension of the output file.
+`,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if argsMapAnimate.format == "" {
+			argsMapAnimate.format = strings.TrimPrefix(strings.ToLower(filepath.Ext(argsMapAnimate.output)), ".")
+		}
+		switch argsMapAnimate.format {
+		case "apng", "gif":
+		case "png":
+			argsMapAnimate.format = "apng"
+		default:
+			return fmt.Errorf("format must be gif or apng")
+		}
+		if argsMapAnimate.center == "" && argsMapAnimate.radius != 0 {
+			return fmt.Errorf("radius requires a center")
+		}
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		cluster, known, err := loadMap()
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		plane, err := render.ParsePlane(argsMapAnimate.plane)
+		if err != nil {
+			log.Fatal(err)
+		}
+		axis, err := render.ParseAxis(argsMapAnimate.axis)
+		if err != nil {
+			log.Fatal(err)
+		}
+		labels, err := render.ParseLabels(argsMapAnimate.labels)
+		if err != nil {
+			log.Fatal(err)
+		}
+		background, err := render.ParseColor(argsMapAnimate.background)
+		if err != nil {
+			log.Fatal(err)
+		}
+		options := []render.Option{
+			render.WithSize(argsMapAnimate.size, argsMapAnimate.size),
+			render.WithDotRadius(max(2, float64(argsMapAnimate.size)/256)),
+			render.WithPlane(plane),
+			render.WithRotationAxis(axis),
+			render.WithFrames(argsMapAnimate.frames),
+			render.WithRotationSpeed(argsMapAnimate.speed),
+			render.WithFrameDelay(argsMapAnimate.delay),
+			render.WithLabels(labels...),
+			render.WithBackground(background),
+		}
+		if argsMapAnimate.center != "" {
+			center, err := cluster.Lookup(argsMapAnimate.center)
+			if err != nil {
+				log.Fatal(err)
+			}
+			var ids []string
+			for _, ss := range cluster.StarSystems {
+				if known != nil && !slices.Contains(known, ss.Id) {
+					continue
+				} else if argsMapAnimate.radius <= 0 || center.DistanceTo(ss) <= argsMapAnimate.radius {
+					ids = append(ids, ss.Id)
+				}
+			}
+			highlight, _ := render.ParseColor("cyan")
+			options = append(options, render.WithCenter(center.Coordinates), render.WithSystems(ids...), render.WithHighlights(highlight, center.Id))
+		} else if known != nil {
+			options = append(options, render.WithSystems(known...))
+		}
+
+		switch argsMapAnimate.format {
+		case "apng":
+			err = render.SaveAPNG(argsMapAnimate.output, cluster, options...)
+		case "gif":
+			err = render.SaveGIF(argsMapAnimate.output, cluster, options...)
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("map: animate: wrote %s\n", argsMapAnimate.output)
+	},
+}
@@ -0,0 +1,80 @@
+// Copyright (c) 2024 Michael D Henderson. All rights reserved.
+
+package main
+
+import (
+	"github.com/playbymail/fargo/internal/render"
+	"github.com/spf13/cobra"
+	"log"
+	"strings"
+)
+
+var argsMapPNG = struct {
+	output     string
+	size       int
+	plane      string
+	grid       float64
+	scaleBar   bool
+	labels     string
+	highlight  string
+	background string
+}{}
+
+var cmdMapPNG = &cobra.Command{
+	Use:   "png",
+	Short: "Draw a flat map of the cluster as a PNG",
+	Long: `Draw a flat map of the cluster, projected onto the XY, XZ or YZ plane.
+
+Labels are a comma separated list of id, name and z (the collapsed coordinate).
+Highlighted systems are a comma separated list of system references.
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cluster, known, err := loadMap()
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		plane, err := render.ParsePlane(argsMapPNG.plane)
+		if err != nil {
+			log.Fatal(err)
+		}
+		labels, err := render.ParseLabels(argsMapPNG.labels)
+		if err != nil {
+			log.Fatal(err)
+		}
+		background, err := render.ParseColor(argsMapPNG.background)
+		if err != nil {
+			log.Fatal(err)
+		}
+		options := []render.Option{
+			render.WithSize(argsMapPNG.size, argsMapPNG.size),
+			render.WithPlane(plane),
+			render.WithGrid(argsMapPNG.grid),
+			render.WithLabels(labels...),
+			render.WithBackground(background),
+		}
+		if known != nil {
+			options = append(options, render.WithSystems(known...))
+		}
+		if argsMapPNG.scaleBar {
+			options = append(options, render.WithScaleBar())
+		}
+		if argsMapPNG.highlight != "" {
+			var ids []string
+			for _, ref := range strings.Split(argsMapPNG.highlight, ",") {
+				ss, err := cluster.Lookup(ref)
+				if err != nil {
+					log.Fatal(err)
+				}
+				ids = append(ids, ss.Id)
+			}
+			highlight, _ := render.ParseColor("cyan")
+			options = append(options, render.WithHighlights(highlight, ids...))
+		}
+
+		if err := render.SavePNG(argsMapPNG.output, cluster, options...); err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("map: png: wrote %s\n", argsMapPNG.output)
+	},
+}
@@ -0,0 +1,13 @@
+// Copyright (c) 2024 Michael D Henderson. All rights reserved.
+
+package main
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var cmdOrders = &cobra.Command{
+	Use:   "orders",
+	Short: "Work with orders",
+	Long:  `Work with the orders for the current turn.`,
+}
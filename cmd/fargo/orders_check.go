@@ -0,0 +1,55 @@
+// Copyright (c) 2024 Michael D Henderson. All rights reserved.
+
+package main
+
+import (
+	"fmt"
+	"github.com/playbymail/fargo"
+	"github.com/spf13/cobra"
+	"log"
+	"os"
+)
+
+var cmdOrdersCheck = &cobra.Command{
+	Use:   "check <file>...",
+	Short: "Check orders for errors",
+	Long: `Check orders for errors against the current state of the game.
+
+The orders are carried out on a copy of the game, so nothing is changed.
+Every order that would fail is listed.
+`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		g, err := fargo.LoadGame(argsRoot.game)
+		if err != nil {
+			log.Fatal(err)
+		}
+		failed := false
+		for _, name := range args {
+			fp, err := os.Open(name)
+			if err != nil {
+				log.Fatal(err)
+			}
+			o, err := fargo.ParseOrders(fp)
+			_ = fp.Close()
+			if err != nil {
+				log.Fatalf("%s: %v\n", name, err)
+			}
+			errs, err := g.CheckOrders(o)
+			if err != nil {
+				log.Fatalf("%s: %v\n", name, err)
+			}
+			if len(errs) == 0 {
+				fmt.Printf("%s: %s: %d orders ok\n", name, o.Race, len(o.Orders))
+				continue
+			}
+			failed = true
+			for _, err := range errs {
+				fmt.Printf("%s: %s: %v\n", name, o.Race, err)
+			}
+		}
+		if failed {
+			os.Exit(1)
+		}
+	},
+}
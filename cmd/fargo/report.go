@@ -0,0 +1,30 @@
+// Copyright (c) 2024 Michael D Henderson. All rights reserved.
+
+package main
+
+import (
+	"fmt"
+	"github.com/playbymail/fargo"
+	"github.com/spf13/cobra"
+	"log"
+	"os"
+)
+
+var cmdReport = &cobra.Command{
+	Use:   "report <race>",
+	Short: "Print a race's report for the current turn",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		g, err := fargo.LoadGame(argsRoot.game)
+		if err != nil {
+			log.Fatal(err)
+		}
+		race := g.Race(args[0])
+		if race == nil {
+			log.Fatal(fmt.Errorf("%q: %w", args[0], fargo.ErrUnknownRace))
+		}
+		if err := g.WriteReport(os.Stdout, race); err != nil {
+			log.Fatal(err)
+		}
+	},
+}
@@ -0,0 +1,83 @@
+// Copyright (c) 2024 Michael D Henderson. All rights reserved.
+
+package main
+
+import (
+	"fmt"
+	"github.com/playbymail/fargo"
+	"github.com/playbymail/fargo/internal/aow"
+	"github.com/spf13/cobra"
+	"log"
+)
+
+var argsRoute = struct {
+	cluster   string
+	by        string
+	driveTech int
+	maxJump   float64
+}{}
+
+var cmdRoute = &cobra.Command{
+	Use:   "route <from> <to>",
+	Short: "Print the itinerary between two systems",
+	Long: `Find the shortest route between two systems and print the itinerary.
+
+Systems are identified by id, name or coordinates.
+The route can be optimized for distance, for turns at the given drive tech,
+or for the number of jumps.
+`,
+	Args: cobra.ExactArgs(2),
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		switch argsRoute.by {
+		case "distance", "hops", "turns":
+		default:
+			return fmt.Errorf("by must be one of distance, hops or turns")
+		}
+		if argsRoute.driveTech < 1 {
+			return fmt.Errorf("drive tech must be at least 1")
+		}
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		cluster, err := aow.LoadCatalog(argsRoute.cluster)
+		if err != nil {
+			log.Fatal(err)
+		}
+		from, err := cluster.Lookup(args[0])
+		if err != nil {
+			log.Fatal(err)
+		}
+		to, err := cluster.Lookup(args[1])
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		speed := fargo.DriveSpeed(argsRoute.driveTech)
+		graph := fargo.NewRouteGraph(cluster, argsRoute.maxJump)
+		var route *fargo.Route_t
+		switch argsRoute.by {
+		case "distance":
+			route, err = graph.ShortestByDistance(from, to)
+		case "hops":
+			route, err = graph.ShortestByHops(from, to)
+		case "turns":
+			route, err = graph.ShortestByTurns(from, to, speed)
+		}
+		if err != nil {
+			log.Fatalf("route: %s to %s: %v\n", args[0], args[1], err)
+		}
+
+		// report turns for every leg, even when the route was not found by turns
+		var turns int
+		fmt.Printf("route from %s %s to %s %s by %s (drive %d, %g ly/turn)\n", from.Id, from.Name, to.Id, to.Name, argsRoute.by, argsRoute.driveTech, speed)
+		for n, leg := range route.Legs {
+			legTurns, err := fargo.TurnsToTravel(leg.Distance, speed)
+			if err != nil {
+				log.Fatal(err)
+			}
+			turns += legTurns
+			fmt.Printf("  %3d: %s %-12s %s -> %s %-12s %s %8.3f ly %4d turns\n", n+1, leg.From.Id, leg.From.Name, leg.From.Coordinates.IntString(), leg.To.Id, leg.To.Name, leg.To.Coordinates.IntString(), leg.Distance, legTurns)
+		}
+		fmt.Printf("total: %d jumps %.3f ly %d turns\n", route.Hops(), route.Distance, turns)
+	},
+}
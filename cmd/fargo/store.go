@@ -0,0 +1,16 @@
+// Copyright (c) 2024 Michael D Henderson. All rights reserved.
+
+package main
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var cmdStore = &cobra.Command{
+	Use:   "store",
+	Short: "Manage the game's store",
+	Long: `Manage where the state and history of the game are kept.
+
+A game is kept in files in the game directory (files) or in a bbolt
+database in the game directory (bolt).`,
+}
@@ -0,0 +1,32 @@
+// Copyright (c) 2024 Michael D Henderson. All rights reserved.
+
+package main
+
+import (
+	"github.com/playbymail/fargo"
+	"github.com/spf13/cobra"
+	"log"
+)
+
+var cmdStoreMigrate = &cobra.Command{
+	Use:   "migrate <files|bolt>",
+	Short: "Move the game to another kind of store",
+	Long: `Copy the state and every turn of the game to a store of the kind
+and switch the game to it.
+
+The turn is locked while the game is copied. The old store is left in
+place; remove it by hand once the game has been checked.
+
+A store of the kind left behind by an earlier migration is out of date,
+so it is moved aside before the copy: game.db is renamed to game.db.old,
+and the state, reports and audit log of the file store are moved to a
+files.old directory. The orders directories are shared by both stores
+and are not moved.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := fargo.MigrateStore(argsRoot.game, args[0]); err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("store: migrate: game uses the %s store\n", args[0])
+	},
+}
@@ -0,0 +1,45 @@
+// Copyright (c) 2024 Michael D Henderson. All rights reserved.
+
+package main
+
+import (
+	"fmt"
+	"github.com/playbymail/fargo"
+	"github.com/playbymail/fargo/internal/aow"
+	"github.com/spf13/cobra"
+	"log"
+)
+
+var argsSystem = struct {
+	cluster string
+}{}
+
+var cmdSystem = &cobra.Command{
+	Use:   "system <system>",
+	Short: "Print the star and planets of a system",
+	Long: `Print the star and planets of a system.
+
+Habitability is rated for a race from an Earth-like world.
+`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		cluster, err := aow.LoadCatalog(argsSystem.cluster)
+		if err != nil {
+			log.Fatal(err)
+		}
+		ss, err := cluster.Lookup(args[0])
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		habitat := fargo.DefaultHabitat()
+		fmt.Printf("%s %s %s\n", ss.Id, ss.Name, ss.Coordinates)
+		fmt.Printf("  designation %s, age %.1f Gyr, mass %.2f, luminosity %.4f\n", ss.Designation, ss.Age, ss.Mass, ss.Luminosity)
+		fmt.Printf("  orbit   au   kind                   mass  diam  dens  grav  atmosphere        press  hydro   temp  hab\n")
+		for _, p := range ss.Planets {
+			fmt.Printf("  %5d %5.2f %-20s %6.2f %5.2f %5.2f %5.2f  %-16s %6.2f %5.1f%% %5.0fK %4d\n",
+				p.Orbit, p.Distance, p.Kind, p.Mass, p.Diameter, p.Density, p.Gravity,
+				p.Atmosphere, p.Pressure, p.Hydrographics, p.Temperature, habitat.Habitability(p))
+		}
+	},
+}
@@ -0,0 +1,13 @@
+// Copyright (c) 2024 Michael D Henderson. All rights reserved.
+
+package main
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var cmdTurn = &cobra.Command{
+	Use:   "turn",
+	Short: "Work with turns",
+	Long:  `Process turns and manage the turn history.`,
+}
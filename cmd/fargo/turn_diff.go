@@ -0,0 +1,103 @@
+// Copyright (c) 2024 Michael D Henderson. All rights reserved.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/playbymail/fargo"
+	"github.com/spf13/cobra"
+	"log"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var cmdTurnDiff = &cobra.Command{
+	Use:   "diff <from> <to>",
+	Short: "Summarize the changes between two turns",
+	Long: `Compare the state of the game at the start of two turns and list
+what changed for each race, colony, fleet, ship and system.
+
+Values in nested lists, like what a race knows about each system, are
+counted instead of listed.`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		from, err := strconv.Atoi(args[0])
+		if err != nil {
+			log.Fatalf("turn: diff: %q: %v\n", args[0], fargo.ErrInvalidArguments)
+		}
+		to, err := strconv.Atoi(args[1])
+		if err != nil {
+			log.Fatalf("turn: diff: %q: %v\n", args[1], fargo.ErrInvalidArguments)
+		}
+		s, err := fargo.OpenStore(argsRoot.game, true)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer s.Close()
+		a, err := s.Turn(from)
+		if err != nil {
+			log.Fatal(err)
+		}
+		b, err := s.Turn(to)
+		if err != nil {
+			log.Fatal(err)
+		}
+		changes, err := fargo.DiffGames(a, b)
+		if err != nil {
+			log.Fatal(err)
+		}
+		list := fargo.SummarizeChanges(changes)
+		fmt.Printf("turn %d to turn %d: %d values changed in %d things\n", from, to, len(changes), len(list))
+		for _, sum := range list {
+			switch {
+			case sum.Added:
+				fmt.Printf("%s: added\n", sum.Path)
+				continue
+			case sum.Removed:
+				fmt.Printf("%s: removed\n", sum.Path)
+				continue
+			}
+			if len(sum.Changes) == 1 && sum.Changes[0].Path == "" {
+				fmt.Printf("%s: %s -> %s\n", sum.Path, formatValue(sum.Changes[0].Before), formatValue(sum.Changes[0].After))
+				continue
+			}
+			fmt.Printf("%s:\n", sum.Path)
+			nested := make(map[string]int)
+			for _, c := range sum.Changes {
+				if field, _, ok := strings.Cut(c.Path, "/"); ok {
+					nested[field]++
+				} else {
+					fmt.Printf("  %-24s %s -> %s\n", c.Path, formatValue(c.Before), formatValue(c.After))
+				}
+			}
+			var fields []string
+			for field := range nested {
+				fields = append(fields, field)
+			}
+			sort.Strings(fields)
+			for _, field := range fields {
+				if nested[field] == 1 {
+					fmt.Printf("  %-24s 1 value changed\n", field)
+				} else {
+					fmt.Printf("  %-24s %d values changed\n", field, nested[field])
+				}
+			}
+		}
+	},
+}
+
+// formatValue returns a value from the audit log for printing.
+// Numbers are rounded to two places.
+func formatValue(raw json.RawMessage) string {
+	if raw == nil {
+		return "none"
+	}
+	var n float64
+	if err := json.Unmarshal(raw, &n); err == nil {
+		return strconv.FormatFloat(math.Round(n*100)/100, 'f', -1, 64)
+	}
+	return string(raw)
+}
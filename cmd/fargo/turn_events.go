@@ -0,0 +1,57 @@
+// Copyright (c) 2024 Michael D Henderson. All rights reserved.
+
+package main
+
+import (
+	"fmt"
+	"github.com/playbymail/fargo"
+	"github.com/spf13/cobra"
+	"log"
+	"strconv"
+	"strings"
+)
+
+var cmdTurnEvents = &cobra.Command{
+	Use:   "events <turn>",
+	Short: "Print the audit log for a turn",
+	Long: `Print every change that processing the turn made, with the order
+that caused it and the value before and after.
+
+A turn that was rolled back and processed again has events for each run.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		turn, err := strconv.Atoi(args[0])
+		if err != nil || turn < 1 {
+			log.Fatalf("turn: events: %q: %v\n", args[0], fargo.ErrInvalidArguments)
+		}
+		s, err := fargo.OpenStore(argsRoot.game, true)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer s.Close()
+		events, err := s.Events(turn)
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, e := range events {
+			if argsTurnEvents.race != "" && !strings.EqualFold(e.Race, argsTurnEvents.race) {
+				continue
+			} else if argsTurnEvents.run != 0 && e.Run != argsTurnEvents.run {
+				continue
+			} else if !strings.HasPrefix(e.Path, argsTurnEvents.path) {
+				continue
+			}
+			cause := ""
+			if e.Race != "" {
+				cause = fmt.Sprintf("  %s line %d %q", e.Race, e.Line, e.Order)
+			}
+			fmt.Printf("run %d #%-5d %-12s %s %s -> %s%s\n", e.Run, e.Seq, e.Phase, e.Path, formatValue(e.Before), formatValue(e.After), cause)
+		}
+	},
+}
+
+var argsTurnEvents struct {
+	race string
+	run  int
+	path string
+}
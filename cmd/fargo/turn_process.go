@@ -0,0 +1,33 @@
+// Copyright (c) 2024 Michael D Henderson. All rights reserved.
+
+package main
+
+import (
+	"github.com/playbymail/fargo"
+	"github.com/spf13/cobra"
+	"log"
+)
+
+var cmdTurnProcess = &cobra.Command{
+	Use:   "process",
+	Short: "Process the current turn",
+	Long: `Process the orders for the current turn and advance the game.
+
+Orders are read from the turn's orders directory. Races that did not
+submit orders are processed with no orders. Reports for the next turn
+are written to the next turn's reports directory.
+
+Every change the turn makes is recorded in the audit log, with the
+order that caused it. See "fargo turn events".
+
+The turn is locked while it is processed. If the server is processing
+the turn already, this command fails.
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		g, err := fargo.RunTurn(argsRoot.game)
+		if err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("turn: process: game is ready for turn %d\n", g.Turn)
+	},
+}
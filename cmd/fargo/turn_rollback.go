@@ -0,0 +1,43 @@
+// Copyright (c) 2024 Michael D Henderson. All rights reserved.
+
+package main
+
+import (
+	"github.com/playbymail/fargo"
+	"github.com/spf13/cobra"
+	"log"
+)
+
+var cmdTurnRollback = &cobra.Command{
+	Use:   "rollback",
+	Short: "Restore the game to an earlier turn",
+	Long: `Restore the game to the start of an earlier turn so that the turn
+can be processed again.
+
+The state and reports of the later turns are dropped. The orders
+submitted for the turn are kept in the turn's orders directory; correct
+them there, then run "fargo turn process". The orders submitted for the
+later turns are moved to an "orders.rolled-back" directory next to each
+turn's orders directory, since they were written for a state that is
+gone. Every order file that is kept or moved is logged.
+
+The rollback is recorded in the audit log and the events of the earlier
+runs are kept.
+
+The turn is locked while the game is restored.
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if argsTurnRollback.to < 1 {
+			log.Fatalf("turn: rollback: --to must be a turn number\n")
+		}
+		g, err := fargo.RollbackTurn(argsRoot.game, argsTurnRollback.to)
+		if err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("turn: rollback: game is ready for turn %d\n", g.Turn)
+	},
+}
+
+var argsTurnRollback struct {
+	to int
+}
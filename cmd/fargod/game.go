@@ -0,0 +1,23 @@
+// Copyright (c) 2024 Michael D Henderson. All rights reserved.
+
+package main
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var argsGame = struct {
+	site string
+}{}
+
+var cmdGame = &cobra.Command{
+	Use:   "game",
+	Short: "Manage the games in a site",
+	Long: `Manage the games the server runs.
+
+Each game has its own directory in the site's games directory, with its
+own schedule, orders and settings. The accounts are kept in the site's
+directory, so one player can join several games, playing a different
+race in each.
+`,
+}
@@ -0,0 +1,79 @@
+// Copyright (c) 2024 Michael D Henderson. All rights reserved.
+
+package main
+
+import (
+	"fmt"
+	"github.com/playbymail/fargo"
+	"github.com/spf13/cobra"
+	"log"
+	"os"
+	"time"
+)
+
+var argsGameCreate = struct {
+	name           string
+	seed           string
+	numberOfRaces  int
+	systemsPerRace float64
+	culture        string
+	nameStyle      string
+	from           string
+	storage        string
+}{}
+
+var cmdGameCreate = &cobra.Command{
+	Use:   "create <id>",
+	Short: "Create a game in the site",
+	Long: `Create a new game in the site's games directory.
+
+The id names the game's directory and is used in the game's links, so
+it must be lowercase letters, digits and dashes. The game is created
+like "fargo create game" and starts out active, with no deadline.
+The id is used as the seed unless one is given.
+`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		id := args[0]
+		if !fargo.ValidGameId(id) {
+			log.Fatalf("%q: %v\n", id, fargo.ErrInvalidName)
+		}
+		path := fargo.GamePath(argsGame.site, id)
+		if _, err := os.Stat(path); err == nil {
+			log.Fatalf("%s: game already exists\n", path)
+		}
+		name, seed := argsGameCreate.name, argsGameCreate.seed
+		if name == "" {
+			name = id
+		}
+		if seed == "" {
+			seed = id
+		}
+		if storage := argsGameCreate.storage; storage != fargo.StorageFiles && storage != fargo.StorageBolt {
+			log.Fatalf("%q: storage must be files or bolt\n", storage)
+		}
+		g, err := fargo.CreateGame(fargo.GameOptions_t{
+			Name:           name,
+			Seed:           seed,
+			NumberOfRaces:  argsGameCreate.numberOfRaces,
+			SystemsPerRace: argsGameCreate.systemsPerRace,
+			Culture:        argsGameCreate.culture,
+			NameStyle:      argsGameCreate.nameStyle,
+		})
+		if err != nil {
+			log.Fatal(err)
+		}
+		g.Id = id
+		now := time.Now().UTC()
+		settings := &fargo.Settings_t{State: fargo.StateActive, Created: &now, From: argsGameCreate.from, Storage: argsGameCreate.storage}
+		// the settings choose the store, so they are saved first
+		if err := os.MkdirAll(path, 0755); err != nil {
+			log.Fatal(err)
+		} else if err := settings.Save(path); err != nil {
+			log.Fatal(err)
+		} else if err := g.SaveTurn(path); err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("%s: created %q with %d races\n", id, g.Name, len(g.Races))
+	},
+}
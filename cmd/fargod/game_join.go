@@ -0,0 +1,51 @@
+// Copyright (c) 2024 Michael D Henderson. All rights reserved.
+
+package main
+
+import (
+	"fmt"
+	"github.com/playbymail/fargo"
+	"github.com/spf13/cobra"
+	"log"
+)
+
+var cmdGameJoin = &cobra.Command{
+	Use:   "join <id> <handle> <race>",
+	Short: "Make an account the player of a race",
+	Long: `Make a player account the player of a race in a game.
+
+The account must already exist (see "fargo account add"). An account
+plays one race in each game it joins; joining a game again changes the
+race.
+`,
+	Args: cobra.ExactArgs(3),
+	Run: func(cmd *cobra.Command, args []string) {
+		id, handle := args[0], args[1]
+		if !fargo.ValidGameId(id) {
+			log.Fatalf("%q: %v\n", id, fargo.ErrInvalidName)
+		}
+		g, err := fargo.LoadGame(fargo.GamePath(argsGame.site, id))
+		if err != nil {
+			log.Fatal(err)
+		}
+		race := g.Race(args[2])
+		if race == nil {
+			log.Fatal(fmt.Errorf("%q: %w", args[2], fargo.ErrUnknownRace))
+		}
+		accounts, err := fargo.LoadAccounts(argsGame.site)
+		if err != nil {
+			log.Fatal(err)
+		}
+		acct := accounts.Account(handle)
+		if acct == nil {
+			log.Fatal(fmt.Errorf("%q: %w", handle, fargo.ErrUnknownAccount))
+		} else if acct.Role != fargo.RolePlayer {
+			log.Fatalf("%s: only players join games\n", acct.Handle)
+		}
+		acct.Join(id, race.Id)
+		if err := accounts.Save(argsGame.site); err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("%s: %s plays %s %s\n", id, acct.Handle, race.Id, race.Name)
+	},
+}
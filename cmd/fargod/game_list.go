@@ -0,0 +1,61 @@
+// Copyright (c) 2024 Michael D Henderson. All rights reserved.
+
+package main
+
+import (
+	"fmt"
+	"github.com/playbymail/fargo"
+	"github.com/spf13/cobra"
+	"log"
+)
+
+var argsGameList = struct {
+	all bool
+}{}
+
+var cmdGameList = &cobra.Command{
+	Use:   "list",
+	Short: "List the games in the site",
+	Long: `List the games in the site with their state, turn and deadline.
+
+Archived games are only listed with --all.
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ids, err := fargo.ListGames(argsGame.site)
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, id := range ids {
+			path := fargo.GamePath(argsGame.site, id)
+			settings, err := fargo.LoadSettings(path)
+			if err != nil {
+				log.Fatal(err)
+			} else if settings.State == fargo.StateArchived && !argsGameList.all {
+				continue
+			}
+			g, err := fargo.LoadGame(path)
+			if err != nil {
+				log.Fatal(err)
+			}
+			sched, err := fargo.LoadSchedule(path)
+			if err != nil {
+				log.Fatal(err)
+			}
+			submissions, err := g.Submissions(path)
+			if err != nil {
+				log.Fatal(err)
+			}
+			submitted := 0
+			for _, s := range submissions {
+				if s.Submitted {
+					submitted++
+				}
+			}
+			deadline := "no deadline"
+			if sched.Deadline != nil {
+				deadline = sched.Deadline.Local().Format("Mon, 02 Jan 2006 15:04 MST")
+			}
+			fmt.Printf("%-16s %-8s turn %-4d %2d of %2d orders  %-26s %s\n", id, settings.State, g.Turn, submitted, len(g.Races), deadline, g.Name)
+		}
+	},
+}
@@ -0,0 +1,68 @@
+// Copyright (c) 2024 Michael D Henderson. All rights reserved.
+
+package main
+
+import (
+	"fmt"
+	"github.com/playbymail/fargo"
+	"github.com/spf13/cobra"
+	"log"
+)
+
+var cmdGamePause = &cobra.Command{
+	Use:   "pause <id>",
+	Short: "Stop processing a game's turns on schedule",
+	Long: `Pause a game.
+
+The server doesn't process the turns of a paused game or send reminders,
+but players may still submit orders and the GM may process turns by
+hand. The deadline moves on when the game is resumed.
+`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		setState(args[0], fargo.StatePaused)
+	},
+}
+
+var cmdGameResume = &cobra.Command{
+	Use:   "resume <id>",
+	Short: "Process a paused or archived game's turns again",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		setState(args[0], fargo.StateActive)
+	},
+}
+
+var cmdGameArchive = &cobra.Command{
+	Use:   "archive <id>",
+	Short: "Archive a finished game",
+	Long: `Archive a game that is over.
+
+An archived game stays in the site so that players can read their
+reports, but orders are no longer accepted and turns are no longer
+processed.
+`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		setState(args[0], fargo.StateArchived)
+	},
+}
+
+// setState changes the state of the game in the site.
+func setState(id string, state fargo.State_e) {
+	path := fargo.GamePath(argsGame.site, id)
+	if !fargo.ValidGameId(id) {
+		log.Fatalf("%q: %v\n", id, fargo.ErrInvalidName)
+	} else if _, err := fargo.LoadGame(path); err != nil {
+		log.Fatal(err)
+	}
+	settings, err := fargo.LoadSettings(path)
+	if err != nil {
+		log.Fatal(err)
+	}
+	settings.State = state
+	if err := settings.Save(path); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("%s: %s\n", id, state)
+}
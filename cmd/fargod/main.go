@@ -4,8 +4,10 @@
 package main
 
 import (
+	"github.com/playbymail/fargo"
 	"github.com/spf13/cobra"
 	"log"
+	"time"
 )
 
 func main() {
@@ -17,7 +19,36 @@ func main() {
 }
 
 func Execute() error {
-	cmdRoot.AddCommand(cmdVersion)
+	cmdRoot.AddCommand(cmdGame, cmdSchedule, cmdServe, cmdVersion)
+	cmdGame.AddCommand(cmdGameArchive, cmdGameCreate, cmdGameJoin, cmdGameList, cmdGamePause, cmdGameResume)
+	cmdSchedule.AddCommand(cmdScheduleSet, cmdScheduleShow)
+
+	cmdGame.PersistentFlags().StringVar(&argsGame.site, "site", ".", "site directory")
+	cmdGameCreate.Flags().StringVar(&argsGameCreate.name, "name", "", "name of the game, the id if not given")
+	cmdGameCreate.Flags().StringVar(&argsGameCreate.seed, "seed", "", "seed for the PRNG, the id if not given")
+	cmdGameCreate.Flags().IntVar(&argsGameCreate.numberOfRaces, "races", fargo.DefaultNumberOfRaces, "number of races")
+	cmdGameCreate.Flags().Float64Var(&argsGameCreate.systemsPerRace, "systems-per-race", fargo.DefaultSystemsPerRace, "number of systems per race")
+	cmdGameCreate.Flags().StringVar(&argsGameCreate.culture, "names", "classical", "culture for system names")
+	cmdGameCreate.Flags().StringVar(&argsGameCreate.nameStyle, "name-style", "markov", "style of system names (syllable or markov)")
+	cmdGameCreate.Flags().StringVar(&argsGameCreate.from, "from", "", "address the game's reminders are sent from, if not the site's")
+	cmdGameCreate.Flags().StringVar(&argsGameCreate.storage, "storage", fargo.StorageFiles, "where the game is kept (files or bolt)")
+	cmdGameList.Flags().BoolVar(&argsGameList.all, "all", false, "also list archived games")
+
+	cmdSchedule.PersistentFlags().StringVar(&argsSchedule.game, "game", ".", "game directory")
+	cmdScheduleSet.Flags().StringVar(&argsScheduleSet.deadline, "deadline", "none", "deadline for the current turn")
+	cmdScheduleSet.Flags().DurationVar(&argsScheduleSet.grace, "grace", 0, "time to wait for late orders")
+	cmdScheduleSet.Flags().DurationVar(&argsScheduleSet.period, "period", 0, "time between deadlines, 0 for a single turn")
+	cmdScheduleSet.Flags().BoolVar(&argsScheduleSet.early, "early", false, "process the turn when every race has submitted orders")
+	cmdScheduleSet.Flags().StringVar(&argsScheduleSet.reminders, "reminders", "", "times before the deadline to remind players")
+
+	cmdServe.Flags().StringVar(&argsServe.site, "site", ".", "site directory")
+	cmdServe.Flags().StringVar(&argsServe.addr, "addr", "localhost:8080", "address to listen on")
+	cmdServe.Flags().DurationVar(&argsServe.interval, "interval", time.Minute, "time between checks of the schedule, 0 to never process turns")
+	cmdServe.Flags().StringVar(&argsServe.smtp, "smtp", "", "host and port of the SMTP server for login links and reminders")
+	cmdServe.Flags().StringVar(&argsServe.username, "username", "", "login for the SMTP server, if it needs one")
+	cmdServe.Flags().StringVar(&argsServe.from, "from", "GM <gm@localhost>", "address the mail is sent from")
+	cmdServe.Flags().StringVar(&argsServe.url, "url", "", "address players use to reach the site, for login links")
+	cmdServe.Flags().BoolVar(&argsServe.dev, "dev", false, "write login links to the log, for testing")
 
 	return cmdRoot.Execute()
 }
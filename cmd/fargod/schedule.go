@@ -0,0 +1,21 @@
+// Copyright (c) 2024 Michael D Henderson. All rights reserved.
+
+package main
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var argsSchedule = struct {
+	game string
+}{}
+
+var cmdSchedule = &cobra.Command{
+	Use:   "schedule",
+	Short: "Manage the turn schedule",
+	Long: `Manage when the server processes the game's turns.
+
+The schedule is saved in the game directory and is read by the server
+every time it checks, so it can be changed while the server runs.
+`,
+}
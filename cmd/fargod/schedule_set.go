@@ -0,0 +1,77 @@
+// Copyright (c) 2024 Michael D Henderson. All rights reserved.
+
+package main
+
+import (
+	"fmt"
+	"github.com/playbymail/fargo"
+	"github.com/spf13/cobra"
+	"log"
+	"strings"
+	"time"
+)
+
+var argsScheduleSet = struct {
+	deadline  string
+	grace     time.Duration
+	period    time.Duration
+	early     bool
+	reminders string
+}{}
+
+var cmdScheduleSet = &cobra.Command{
+	Use:   "set",
+	Short: "Set the turn schedule",
+	Long: `Set the deadline for the current turn and the schedule for the turns after it.
+
+The deadline is a time like "2024-09-01 18:00" in the local time zone,
+or "none" to stop processing turns automatically. After each turn, the
+deadline moves forward by the period. Reminders are a comma separated
+list of times before the deadline, like "24h,2h".
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		g, err := fargo.LoadGame(argsSchedule.game)
+		if err != nil {
+			log.Fatal(err)
+		}
+		sched := &fargo.Schedule_t{
+			Grace:  fargo.Duration_t(argsScheduleSet.grace),
+			Period: fargo.Duration_t(argsScheduleSet.period),
+			Early:  argsScheduleSet.early,
+			Turn:   g.Turn,
+		}
+		if argsScheduleSet.deadline != "none" {
+			deadline, err := parseDeadline(argsScheduleSet.deadline)
+			if err != nil {
+				log.Fatal(err)
+			}
+			sched.Deadline = &deadline
+		}
+		for _, field := range strings.Split(argsScheduleSet.reminders, ",") {
+			if field = strings.TrimSpace(field); field == "" {
+				continue
+			}
+			d, err := time.ParseDuration(field)
+			if err != nil || d <= 0 {
+				log.Fatalf("reminders: %q: %v\n", field, fargo.ErrInvalidArguments)
+			}
+			sched.Reminders = append(sched.Reminders, fargo.Duration_t(d))
+		}
+		if err := sched.Save(argsSchedule.game); err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("schedule: set: turn %d\n", g.Turn)
+	},
+}
+
+// parseDeadline accepts a time in RFC 3339 format or a local time without seconds.
+func parseDeadline(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t.UTC(), nil
+	}
+	t, err := time.ParseInLocation("2006-01-02 15:04", s, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("deadline: %q: %w", s, fargo.ErrInvalidArguments)
+	}
+	return t.UTC(), nil
+}
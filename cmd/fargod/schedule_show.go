@@ -0,0 +1,49 @@
+// Copyright (c) 2024 Michael D Henderson. All rights reserved.
+
+package main
+
+import (
+	"fmt"
+	"github.com/playbymail/fargo"
+	"github.com/spf13/cobra"
+	"log"
+	"time"
+)
+
+var cmdScheduleShow = &cobra.Command{
+	Use:   "show",
+	Short: "Show the turn schedule",
+	Run: func(cmd *cobra.Command, args []string) {
+		g, err := fargo.LoadGame(argsSchedule.game)
+		if err != nil {
+			log.Fatal(err)
+		}
+		sched, err := fargo.LoadSchedule(argsSchedule.game)
+		if err != nil {
+			log.Fatal(err)
+		}
+		submissions, err := g.Submissions(argsSchedule.game)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("turn       %d\n", g.Turn)
+		if sched.Deadline == nil {
+			fmt.Printf("deadline   none, turns are processed by hand\n")
+		} else {
+			fmt.Printf("deadline   %s (in %v)\n", sched.Deadline.Local().Format("Mon, 02 Jan 2006 15:04 MST"), time.Until(*sched.Deadline).Round(time.Minute))
+		}
+		fmt.Printf("grace      %v\n", time.Duration(sched.Grace))
+		fmt.Printf("period     %v\n", time.Duration(sched.Period))
+		fmt.Printf("early      %v\n", sched.Early)
+		for _, r := range sched.Reminders {
+			fmt.Printf("reminder   %v before the deadline\n", time.Duration(r))
+		}
+		for _, s := range submissions {
+			status := "waiting"
+			if s.Submitted {
+				status = "submitted " + s.Updated.Local().Format("Mon, 02 Jan 2006 15:04 MST")
+			}
+			fmt.Printf("orders     %s %-24s %s\n", s.Race, s.Name, status)
+		}
+	},
+}
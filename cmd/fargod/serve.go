@@ -0,0 +1,195 @@
+// Copyright (c) 2024 Michael D Henderson. All rights reserved.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/playbymail/fargo"
+	"github.com/playbymail/fargo/internal/mail"
+	"github.com/playbymail/fargo/internal/server"
+	"github.com/spf13/cobra"
+	"log"
+	"net/http"
+	netmail "net/mail"
+	"net/url"
+	"os"
+	"os/signal"
+	"sync"
+	"time"
+)
+
+var argsServe = struct {
+	site     string
+	addr     string
+	interval time.Duration
+	smtp     string
+	username string
+	from     string
+	url      string
+	dev      bool
+}{}
+
+var cmdServe = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve the games in a site",
+	Long: `Serve the web site and the JSON API for the games in the site directory.
+
+Games are kept in the site's games directory (see "fargod game"). Players
+can fetch their reports and maps and submit orders. Orders are
+checked with the same parser as "fargo orders check" and saved in the
+turn's orders directory, where "fargo turn process" reads them.
+
+The server also processes turns on each active game's schedule (see
+"fargod schedule"), checking them every interval. With --smtp, login links and
+reminders are mailed to players; otherwise they are written to the log.
+The password for the SMTP server is read from FARGO_SMTP_PASSWORD.
+
+Login links are only sent when --url gives the address players use to
+reach the site, like https://fargo.example.com. The links are built from
+it, not from the request. Without --smtp, links are not sent at all;
+--dev writes them to the log instead, for testing.
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		s, err := server.New(argsServe.site)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if argsServe.url != "" {
+			u, err := url.Parse(argsServe.url)
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				log.Fatalf("serve: --url: %q: must be like https://fargo.example.com\n", argsServe.url)
+			}
+			s.URL = argsServe.url
+		} else {
+			log.Printf("serve: no --url, login links are disabled\n")
+		}
+		s.Dev = argsServe.dev
+		var m *mailer_t
+		if argsServe.smtp != "" {
+			if m, err = sendMail(s); err != nil {
+				log.Fatal(err)
+			}
+		}
+		srv := &http.Server{
+			Addr:              argsServe.addr,
+			Handler:           s,
+			ReadHeaderTimeout: 10 * time.Second,
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+		go func() {
+			<-ctx.Done()
+			shutdown, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			_ = srv.Shutdown(shutdown)
+		}()
+		if argsServe.interval > 0 {
+			go s.RunScheduler(ctx, argsServe.interval)
+		}
+		if m != nil {
+			go m.run(ctx)
+		}
+
+		log.Printf("serve: listening on %s\n", argsServe.addr)
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal(err)
+		}
+		log.Printf("serve: stopped\n")
+	},
+}
+
+// sendMail sets the server up to mail login links and reminders through
+// the site's outbox. Reminders are sent from the game's address, if it
+// has one. The messages are only spooled; the mailer sends them.
+func sendMail(s *server.Server_t) (*mailer_t, error) {
+	site, err := netmail.ParseAddress(argsServe.from)
+	if err != nil {
+		return nil, fmt.Errorf("from: %w", err)
+	}
+	path, err := fargo.AbsPath(argsServe.site)
+	if err != nil {
+		return nil, err
+	}
+	m := &mailer_t{
+		outbox:    mail.NewOutbox(fargo.MailPath(path)),
+		transport: &mail.SMTP_t{Addr: argsServe.smtp, Username: argsServe.username, Password: os.Getenv("FARGO_SMTP_PASSWORD")},
+		wake:      make(chan struct{}, 1),
+	}
+	s.SendLoginLink = func(acct *fargo.Account_t, link string) error {
+		return m.send(site, acct, "Your login link", fmt.Sprintf("Use this link to log in. It can be used once, within %v.\n\n%s\n", fargo.MagicLinkTTL, link))
+	}
+	s.SendReminder = func(g *fargo.Game_t, acct *fargo.Account_t, deadline time.Time) error {
+		from := site
+		settings, err := fargo.LoadSettings(fargo.GamePath(path, g.Id))
+		if err != nil {
+			return err
+		} else if settings.From != "" {
+			if from, err = netmail.ParseAddress(settings.From); err != nil {
+				return fmt.Errorf("%s: from: %w", g.Id, err)
+			}
+		}
+		return m.send(from, acct, fmt.Sprintf("%s: turn %d orders are due", g.Name, g.Turn),
+			fmt.Sprintf("The orders for %s for turn %d of %s have not been submitted.\nThey are due %s.\n",
+				acct.RaceIn(g.Id), g.Turn, g.Name, deadline.Format("Mon, 02 Jan 2006 15:04 MST")))
+	}
+	return m, nil
+}
+
+// mailerRetry is how often the mailer tries again to send messages that failed.
+const mailerRetry = 5 * time.Minute
+
+// mailer_t sends the messages in the site's outbox in the background, so
+// that a request never waits on the SMTP server. Only one flush runs at a
+// time, so a message is never picked up twice.
+type mailer_t struct {
+	outbox    *mail.Outbox_t
+	transport mail.Transport
+	wake      chan struct{} // signaled when a message is spooled
+	mu        sync.Mutex    // held while the outbox is flushed
+}
+
+// send spools a message to the account and wakes the mailer.
+func (m *mailer_t) send(from *netmail.Address, acct *fargo.Account_t, subject, body string) error {
+	if acct.Email == "" {
+		return fmt.Errorf("%s: no email address", acct.Handle)
+	}
+	msg := &mail.Outgoing_t{From: from, To: &netmail.Address{Name: acct.Handle, Address: acct.Email}, Subject: subject, Body: body}
+	if _, err := msg.Spool(m.outbox.Dir); err != nil {
+		return err
+	}
+	select {
+	case m.wake <- struct{}{}:
+	default: // the mailer has already been woken
+	}
+	return nil
+}
+
+// run flushes the outbox when a message is spooled, and now and then to
+// retry the messages that failed, until the context is done.
+func (m *mailer_t) run(ctx context.Context) {
+	ticker := time.NewTicker(mailerRetry)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-m.wake:
+		case <-ticker.C:
+		}
+		m.flush()
+	}
+}
+
+// flush sends everything in the outbox.
+func (m *mailer_t) flush() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if failed, err := m.outbox.Flush(m.transport); err != nil {
+		log.Printf("serve: mail: %v\n", err)
+	} else if failed != 0 {
+		log.Printf("serve: mail: %d messages failed, will try again in %v\n", failed, mailerRetry)
+	}
+}
@@ -0,0 +1,22 @@
+// Copyright (c) 2024 Michael D Henderson. All rights reserved.
+
+package fargo
+
+const (
+	// HomeWorldPopulation is the population of a home world, in millions, at the start of the game.
+	HomeWorldPopulation = 500.0
+	// HomeWorldInfrastructure is the infrastructure of a home world at the start of the game.
+	HomeWorldInfrastructure = 400.0
+)
+
+// Colony_t is a settlement of a race on a planet.
+type Colony_t struct {
+	Id             string  `json:"id"`
+	Race           string  `json:"race"`               // id of the race that owns the colony
+	System         string  `json:"system"`             // id of the star system
+	Orbit          int     `json:"orbit"`              // orbit of the planet in the system
+	Population     float64 `json:"population"`         // in millions
+	Infrastructure float64 `json:"infrastructure"`     // each unit provides work for one million people
+	Defenses       float64 `json:"defenses,omitempty"` // units of planetary defenses
+	Shipyard       bool    `json:"shipyard"`
+}
@@ -0,0 +1,233 @@
+// Copyright (c) 2024 Michael D Henderson. All rights reserved.
+
+package fargo
+
+import (
+	"fmt"
+	"github.com/playbymail/fargo/internal/aow"
+	"math"
+)
+
+// functions to found, grow and abandon colonies.
+//
+// colonists are loaded into a ship's colonist pods when the ship is built.
+// a fleet in a system can land its colonists on a planet there, founding
+// a colony or adding to one the race already has. the ships that carried
+// the colonists are broken up to give the colony its first infrastructure.
+//
+// every turn the population of a colony grows towards the planet's
+// capacity. the capacity comes from the planet's size and habitability,
+// plus the habitats that the colony's infrastructure provides. a colony
+// with more people than the capacity starves, and a colony that falls
+// below the minimum population dies out.
+//
+// each planet records the race that has a colony on it. a system is owned
+// by the race with the largest population in it.
+
+const (
+	// GrowthRate is the growth of the population each turn on an ideal world that is nearly empty.
+	GrowthRate = 0.1
+	// StarvationRate is the part of the people over the capacity that die each turn.
+	StarvationRate = 0.25
+	// MinimumPopulation is the smallest population, in millions, a colony can have.
+	MinimumPopulation = 0.1
+	// HabitatsPerInfrastructure is the number of people, in millions, that one unit of infrastructure can house.
+	HabitatsPerInfrastructure = 0.5
+	// ShipyardCost is the cost, in credits, of a shipyard.
+	ShipyardCost = 100.0
+	// ShipyardPopulation is the smallest population, in millions, that can run a shipyard.
+	ShipyardPopulation = 50.0
+)
+
+// Capacity returns the number of people, in millions, the colony's planet can support.
+func (g *Game_t) Capacity(colony *Colony_t) float64 {
+	_, planet := g.Planet(colony)
+	race := g.Race(colony.Race)
+	if planet == nil || race == nil {
+		return 0
+	}
+	return naturalCapacity(planet.Kind)*float64(g.Habitability(race, planet))/100 + colony.Infrastructure*HabitatsPerInfrastructure
+}
+
+// naturalCapacity is the population, in millions, an ideal world of the kind supports without habitats.
+func naturalCapacity(kind aow.PlanetKind_e) float64 {
+	switch kind {
+	case aow.AsteroidBelt:
+		return 20
+	case aow.TinyTerrestrial:
+		return 100
+	case aow.SmallTerrestrial:
+		return 400
+	case aow.StandardTerrestrial:
+		return 1000
+	case aow.LargeTerrestrial:
+		return 2000
+	}
+	return 0
+}
+
+// Growth returns the change in the colony's population for one turn.
+func (g *Game_t) Growth(colony *Colony_t) float64 {
+	_, planet := g.Planet(colony)
+	race := g.Race(colony.Race)
+	if planet == nil || race == nil {
+		return 0
+	}
+	capacity := g.Capacity(colony)
+	if colony.Population > capacity {
+		return -(colony.Population - capacity) * StarvationRate
+	}
+	rate := GrowthRate * math.Max(0.1, float64(g.Habitability(race, planet))/100)
+	return colony.Population * rate * (1 - colony.Population/capacity)
+}
+
+// ColonyAt returns the colony on a planet, or nil if there is none.
+func (g *Game_t) ColonyAt(system string, orbit int) *Colony_t {
+	for _, colony := range g.Colonies {
+		if colony.System == system && colony.Orbit == orbit {
+			return colony
+		}
+	}
+	return nil
+}
+
+// removeColony takes a colony out of the game and gives up its planet.
+func (g *Game_t) removeColony(colony *Colony_t) {
+	if _, planet := g.Planet(colony); planet != nil {
+		planet.Owner = ""
+	}
+	for i, c := range g.Colonies {
+		if c == colony {
+			g.Colonies = append(g.Colonies[:i:i], g.Colonies[i+1:]...)
+			break
+		}
+	}
+	if ss, err := g.Cluster.Lookup(colony.System); err == nil {
+		g.updateSystemOwner(ss)
+	}
+}
+
+// updateSystemOwner gives the system to the race with the largest population
+// in it. The current owner keeps the system in a tie.
+func (g *Game_t) updateSystemOwner(ss *aow.StarSystem_t) {
+	population := make(map[string]float64)
+	for _, colony := range g.Colonies {
+		if colony.System == ss.Id {
+			population[colony.Race] += colony.Population
+		}
+	}
+	owner := ss.Owner
+	for _, race := range g.Races {
+		if population[race.Id] > population[owner] {
+			owner = race.Id
+		}
+	}
+	if population[owner] == 0 {
+		owner = ""
+	}
+	ss.Owner = owner
+}
+
+func (t *turn_t) abandonPhase() {
+	for _, race := range t.g.Races {
+		for _, order := range ordersFor[*AbandonOrder_t](t, race) {
+			t.cause(race, order)
+			colony := t.g.Colony(order.Colony)
+			if colony == nil || colony.Race != race.Id {
+				t.reject(race, order.OrderSource_t, fmt.Errorf("%q: %w", order.Colony, ErrUnknownColony))
+				continue
+			}
+			t.g.removeColony(colony)
+			t.g.logf(race, "abandoned %s with %.1f million people", colony.Id, colony.Population)
+		}
+	}
+}
+
+func (t *turn_t) colonizationPhase() {
+	for _, race := range t.g.Races {
+		for _, order := range ordersFor[*ColonizeOrder_t](t, race) {
+			t.cause(race, order)
+			if err := t.colonize(race, order); err != nil {
+				t.reject(race, order.OrderSource_t, err)
+			}
+		}
+	}
+	t.cause(nil, nil)
+	t.g.removeEmptyFleets()
+}
+
+func (t *turn_t) colonize(race *Race_t, order *ColonizeOrder_t) error {
+	fleet := t.g.Fleet(order.Fleet)
+	if fleet == nil || fleet.Race != race.Id {
+		return fmt.Errorf("%q: %w", order.Fleet, ErrUnknownFleet)
+	} else if fleet.InTransit() {
+		return fmt.Errorf("%s: %w", fleet.Id, ErrInTransit)
+	}
+	ss, err := t.g.Cluster.Lookup(fleet.System)
+	if err != nil {
+		return err
+	}
+	var planet *aow.Planet_t
+	for _, p := range ss.Planets {
+		if p.Orbit == order.Orbit {
+			planet = p
+		}
+	}
+	if planet == nil {
+		return fmt.Errorf("%s orbit %d: %w", ss.Id, order.Orbit, ErrUnknownPlanet)
+	} else if planet.Owner != "" && planet.Owner != race.Id {
+		return fmt.Errorf("%s orbit %d: %w", ss.Id, order.Orbit, ErrPlanetOwned)
+	} else if !planet.IsSolid() {
+		return fmt.Errorf("%s orbit %d: %w", ss.Id, order.Orbit, ErrUninhabitable)
+	}
+
+	var colonists, infrastructure float64
+	var ships []*Ship_t
+	for _, id := range fleet.Ships {
+		if ship := t.g.Ship(id); ship != nil && ship.Colonists > 0 {
+			ships = append(ships, ship)
+			colonists += ship.Colonists
+			if d := t.g.DesignOf(ship); d != nil {
+				infrastructure += float64(d.Hull)
+			}
+		}
+	}
+	if len(ships) == 0 {
+		return fmt.Errorf("%s: %w", fleet.Id, ErrNoColonists)
+	}
+
+	colony := t.g.ColonyAt(ss.Id, planet.Orbit)
+	if colony == nil {
+		colony = &Colony_t{Id: t.g.nextId("C"), Race: race.Id, System: ss.Id, Orbit: planet.Orbit}
+		t.g.Colonies = append(t.g.Colonies, colony)
+		planet.Owner = race.Id
+		t.g.logf(race, "founded %s at %s %s orbit %d, habitability %d", colony.Id, ss.Id, ss.Name, planet.Orbit, t.g.Habitability(race, planet))
+	}
+	colony.Population += colonists
+	colony.Infrastructure += infrastructure
+	for _, ship := range ships {
+		t.g.removeShip(ship)
+	}
+	t.g.updateSystemOwner(ss)
+	t.g.logf(race, "landed %.1f million colonists at %s and broke up %d ships for %g infrastructure", colonists, colony.Id, len(ships), infrastructure)
+	return nil
+}
+
+// growthPhase grows or starves the population of every colony.
+func (t *turn_t) growthPhase() {
+	for _, colony := range append([]*Colony_t{}, t.g.Colonies...) {
+		race := t.g.Race(colony.Race)
+		growth := t.g.Growth(colony)
+		colony.Population += growth
+		if colony.Population < MinimumPopulation {
+			t.g.removeColony(colony)
+			t.g.logf(race, "%s has died out", colony.Id)
+			continue
+		} else if growth < 0 {
+			t.g.logf(race, "%s is starving, lost %.1f million people", colony.Id, -growth)
+		}
+		if ss, err := t.g.Cluster.Lookup(colony.System); err == nil {
+			t.g.updateSystemOwner(ss)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+// Copyright (c) 2024 Michael D Henderson. All rights reserved.
+
+package fargo
+
+import (
+	"errors"
+	"fmt"
+	"github.com/playbymail/fargo/internal/aow"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestGrowth(t *testing.T) {
+	g, err := CreateGame(GameOptions_t{Name: "Test", Seed: "test", NumberOfRaces: 2, SystemsPerRace: 4, Culture: "classical", NameStyle: "syllable"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	colony := g.Colony("C001")
+	capacity := g.Capacity(colony)
+	if !(capacity > colony.Population) {
+		t.Fatalf("capacity: want room to grow on the home world, got %g for %g", capacity, colony.Population)
+	}
+	colony.Infrastructure += 10
+	if got := g.Capacity(colony) - capacity; math.Abs(got-10*HabitatsPerInfrastructure) > 1e-9 {
+		t.Errorf("habitats: want %g more, got %g", 10*HabitatsPerInfrastructure, got)
+	}
+	colony.Infrastructure -= 10
+
+	if got := g.Growth(colony); !(got > 0) {
+		t.Errorf("growth: want the population to grow, got %g", got)
+	}
+	colony.Population = capacity
+	if got := g.Growth(colony); math.Abs(got) > 1e-9 {
+		t.Errorf("full: want no growth, got %g", got)
+	}
+	colony.Population = capacity + 100
+	if got := g.Growth(colony); math.Abs(got+100*StarvationRate) > 1e-9 {
+		t.Errorf("starving: want %g, got %g", -100*StarvationRate, got)
+	}
+}
+
+func TestColonize(t *testing.T) {
+	g, err := CreateGame(GameOptions_t{Name: "Test", Seed: "test", NumberOfRaces: 2, SystemsPerRace: 4, Culture: "classical", NameStyle: "syllable"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	turn := func(text string) []*OrderError_t {
+		o, err := ParseOrders(strings.NewReader(text))
+		if err != nil {
+			t.Fatal(err)
+		}
+		results, err := g.ProcessTurn([]*Orders_t{o})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return results["R001"]
+	}
+	if failed := turn("race R001\ndesign Pod hull 10 drive 1 colonists 2\nbuild 1 pod at C001\n"); len(failed) != 0 {
+		t.Fatalf("build: %v", failed)
+	}
+	race := g.Race("R001")
+	fleet := g.Fleet(g.ShipsOf(race)[0].Fleet)
+	ss, err := g.Cluster.Lookup(fleet.System)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var target, giant *aow.Planet_t
+	for _, p := range ss.Planets {
+		if !p.IsSolid() {
+			giant = p
+		} else if p.Owner == "" && target == nil {
+			target = p
+		}
+	}
+	if target == nil {
+		t.Fatalf("%s: no free planet to settle", ss.Id)
+	}
+
+	// orders that fail leave the pod where it is
+	for _, tc := range []struct {
+		name  string
+		order string
+		want  error
+	}{
+		{"unknown fleet", "colonize F999 1", ErrUnknownFleet},
+		{"no planet", "colonize " + fleet.Id + " 99", ErrUnknownPlanet},
+	} {
+		failed := turn("race R001\n" + tc.order + "\n")
+		if len(failed) != 1 || !errors.Is(failed[0].Err, tc.want) {
+			t.Errorf("%s: want %v, got %v", tc.name, tc.want, failed)
+		}
+	}
+	target.Owner = "R002"
+	if failed := turn(fmt.Sprintf("race R001\ncolonize %s %d\n", fleet.Id, target.Orbit)); len(failed) != 1 || !errors.Is(failed[0].Err, ErrPlanetOwned) {
+		t.Errorf("owned: want %v, got %v", ErrPlanetOwned, failed)
+	}
+	target.Owner = ""
+	if giant != nil {
+		if failed := turn(fmt.Sprintf("race R001\ncolonize %s %d\n", fleet.Id, giant.Orbit)); len(failed) != 1 || !errors.Is(failed[0].Err, ErrUninhabitable) {
+			t.Errorf("gas giant: want %v, got %v", ErrUninhabitable, failed)
+		}
+	}
+	if g.Fleet(fleet.Id) == nil {
+		t.Fatalf("fleet: want %s kept after failed orders", fleet.Id)
+	}
+
+	if failed := turn(fmt.Sprintf("race R001\ncolonize %s %d\n", fleet.Id, target.Orbit)); len(failed) != 0 {
+		t.Fatalf("colonize: %v", failed)
+	}
+	colony := g.ColonyAt(ss.Id, target.Orbit)
+	if colony == nil {
+		t.Fatalf("colonize: no colony at %s orbit %d", ss.Id, target.Orbit)
+	} else if colony.Race != race.Id || target.Owner != race.Id {
+		t.Errorf("owner: want %s, got colony %s, planet %s", race.Id, colony.Race, target.Owner)
+	} else if colony.Infrastructure != 10 {
+		t.Errorf("infrastructure: want the 10 unit hull, got %g", colony.Infrastructure)
+	}
+	if g.Fleet(fleet.Id) != nil || len(g.ShipsOf(race)) != 0 {
+		t.Errorf("fleet: want the pod broken up")
+	}
+
+	if failed := turn("race R001\nabandon " + colony.Id + "\n"); len(failed) != 0 {
+		t.Fatalf("abandon: %v", failed)
+	} else if g.Colony(colony.Id) != nil || target.Owner != "" {
+		t.Errorf("abandon: want the colony gone and the planet free")
+	} else if ss.Owner != race.Id {
+		t.Errorf("abandon: want %s to keep the system for its home world, got %q", race.Id, ss.Owner)
+	}
+	if failed := turn("race R001\nabandon C999\n"); len(failed) != 1 || !errors.Is(failed[0].Err, ErrUnknownColony) {
+		t.Errorf("abandon: want %v, got %v", ErrUnknownColony, failed)
+	}
+}
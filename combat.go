@@ -0,0 +1,441 @@
+// Copyright (c) 2024 Michael D Henderson. All rights reserved.
+
+package fargo
+
+import (
+	"fmt"
+	"github.com/playbymail/fargo/internal/aow"
+	"math"
+	"math/rand/v2"
+	"sort"
+)
+
+// functions to resolve combat.
+//
+// a battle is fought in rounds. at the start of each round, groups that
+// have lost too much of their hull try to retreat, and every combatant's
+// shields are recharged. combatants then act in order of initiative,
+// firing each of their weapons at a hostile target. a weapon that hits
+// does damage that is absorbed first by the target's shields and then by
+// its hull. a combatant with no hull left is destroyed.
+//
+// the battle ends when no hostile combatants can reach each other, or
+// after the last round. every roll is drawn from the battle's PRNG, so
+// a battle with the same combatants and the same PRNG always ends the
+// same way.
+
+const (
+	// CombatRounds is the most rounds a battle lasts.
+	CombatRounds = 10
+
+	HitPointsPerHull = 2.0  // hit points for each unit of hull
+	WeaponDamage     = 6.0  // damage done by one hit, before the weapons tech multiplier
+	ShieldStrength   = 2.0  // damage absorbed by one shield each round, before the shields tech multiplier
+	BaseHitChance    = 0.5  // chance that a shot hits
+	SensorHitBonus   = 0.05 // added to the chance to hit for each sensor
+	MaximumHitChance = 0.9
+
+	// DefensesPerBattery is the units of planetary defenses that make up one weapon and one shield.
+	DefensesPerBattery = 10.0
+	// DefenseCost is the cost, in credits, of one unit of planetary defenses.
+	DefenseCost = 1.0
+)
+
+// Combatant_t is a ship or a planet's defenses in a battle.
+type Combatant_t struct {
+	Id      string
+	Name    string // the design, or "defenses"
+	Side    string // id of the race
+	Group   string // combatants in a group retreat together, like the ships in a fleet
+	Weapons int
+	Shields int
+	Sensors int
+	Speed   float64 // light years per turn, 0 if it can't retreat
+	Attack  float64 // multiplier from weapons tech
+	Defense float64 // multiplier from shields tech
+	Hull    float64 // hit points left
+	MaxHull float64
+	Retreat float64 // fraction of the group's hit points lost before it retreats, 0 to fight to the end
+
+	shields    float64 // left this round
+	retreating bool
+	Escaped    bool
+	Destroyed  bool
+}
+
+func (c *Combatant_t) String() string {
+	return fmt.Sprintf("%s %s (%s)", c.Id, c.Name, c.Side)
+}
+
+// active returns true if the combatant is still in the battle.
+func (c *Combatant_t) active() bool {
+	return !c.Destroyed && !c.Escaped
+}
+
+// Battle_t is a fight between combatants.
+type Battle_t struct {
+	Combatants []*Combatant_t
+	Log        []string
+	Rounds     int // rounds fought
+
+	r       *rand.Rand
+	hostile func(a, b string) bool
+}
+
+// NewBattle returns an empty battle. Sides are hostile when the function says so.
+func NewBattle(r *rand.Rand, hostile func(a, b string) bool) *Battle_t {
+	return &Battle_t{r: r, hostile: hostile}
+}
+
+// Add puts combatants into the battle.
+func (b *Battle_t) Add(list ...*Combatant_t) {
+	b.Combatants = append(b.Combatants, list...)
+}
+
+func (b *Battle_t) logf(format string, args ...any) {
+	b.Log = append(b.Log, fmt.Sprintf(format, args...))
+}
+
+// Fight runs the battle for up to the given number of rounds.
+func (b *Battle_t) Fight(rounds int) {
+	// combatants act in a fixed order when their initiative is the same
+	sort.SliceStable(b.Combatants, func(i, j int) bool {
+		if b.Combatants[i].Side != b.Combatants[j].Side {
+			return b.Combatants[i].Side < b.Combatants[j].Side
+		}
+		return b.Combatants[i].Id < b.Combatants[j].Id
+	})
+	// unarmed groups run at once
+	armed := make(map[string]bool)
+	for _, c := range b.Combatants {
+		armed[c.Group] = armed[c.Group] || c.Weapons > 0
+	}
+	for _, c := range b.Combatants {
+		c.retreating = !armed[c.Group] && c.Speed > 0
+	}
+
+	for b.Rounds = 0; b.Rounds < rounds && b.engaged(); {
+		b.Rounds++
+		b.retreat()
+		b.escape()
+
+		type actor_t struct {
+			c          *Combatant_t
+			initiative int
+		}
+		var order []actor_t
+		for _, c := range b.Combatants {
+			if c.active() {
+				c.shields = float64(c.Shields) * ShieldStrength * c.Defense
+				order = append(order, actor_t{c: c, initiative: 1 + b.r.IntN(20) + c.Sensors})
+			}
+		}
+		sort.SliceStable(order, func(i, j int) bool {
+			return order[i].initiative > order[j].initiative
+		})
+		for _, a := range order {
+			if a.c.active() && !a.c.retreating {
+				b.fire(a.c)
+			}
+		}
+	}
+
+	for _, side := range b.Sides() {
+		var left, destroyed, escaped int
+		for _, c := range b.Combatants {
+			if c.Side != side {
+				continue
+			} else if c.Destroyed {
+				destroyed++
+			} else if c.Escaped {
+				escaped++
+			} else {
+				left++
+			}
+		}
+		b.logf("after %d rounds %s has %d left, %d destroyed, %d escaped", b.Rounds, side, left, destroyed, escaped)
+	}
+}
+
+// Sides returns the ids of the sides in the battle, sorted.
+func (b *Battle_t) Sides() []string {
+	var sides []string
+	seen := make(map[string]bool)
+	for _, c := range b.Combatants {
+		if !seen[c.Side] {
+			seen[c.Side] = true
+			sides = append(sides, c.Side)
+		}
+	}
+	sort.Strings(sides)
+	return sides
+}
+
+// engaged returns true while some combatant can still fire on a hostile one.
+func (b *Battle_t) engaged() bool {
+	for _, c := range b.Combatants {
+		if !c.active() || c.retreating || c.Weapons == 0 {
+			continue
+		}
+		for _, o := range b.Combatants {
+			if o.active() && b.hostile(c.Side, o.Side) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// retreat marks the groups that have lost too much of their hull.
+func (b *Battle_t) retreat() {
+	hull, maxHull := make(map[string]float64), make(map[string]float64)
+	for _, c := range b.Combatants {
+		maxHull[c.Group] += c.MaxHull
+		if !c.Destroyed {
+			hull[c.Group] += c.Hull
+		}
+	}
+	for _, c := range b.Combatants {
+		if !c.active() || c.retreating || c.Retreat <= 0 || c.Speed == 0 {
+			continue
+		} else if lost := 1 - hull[c.Group]/maxHull[c.Group]; lost >= c.Retreat {
+			c.retreating = true
+			b.logf("round %d: %s retreats", b.Rounds, c)
+		}
+	}
+}
+
+// escape lets retreating combatants try to get away. The chance depends on
+// their speed compared to the fastest armed hostile combatant that could pursue.
+func (b *Battle_t) escape() {
+	for _, c := range b.Combatants {
+		if !c.active() || !c.retreating {
+			continue
+		}
+		var pursuit float64
+		for _, o := range b.Combatants {
+			if o.active() && !o.retreating && o.Weapons > 0 && b.hostile(o.Side, c.Side) {
+				pursuit = math.Max(pursuit, o.Speed)
+			}
+		}
+		if b.r.Float64() < c.Speed/(c.Speed+pursuit) {
+			c.Escaped = true
+			b.logf("round %d: %s escapes", b.Rounds, c)
+		} else {
+			b.logf("round %d: %s is pursued", b.Rounds, c)
+		}
+	}
+}
+
+// fire shoots every weapon the combatant has.
+func (b *Battle_t) fire(c *Combatant_t) {
+	chance := math.Min(MaximumHitChance, BaseHitChance+SensorHitBonus*float64(c.Sensors))
+	for n := 0; n < c.Weapons; n++ {
+		target := b.target(c)
+		if target == nil {
+			return
+		}
+		if b.r.Float64() >= chance {
+			b.logf("round %d: %s misses %s", b.Rounds, c, target)
+			continue
+		}
+		damage := WeaponDamage * c.Attack
+		absorbed := math.Min(damage, target.shields)
+		target.shields -= absorbed
+		target.Hull -= damage - absorbed
+		if target.Hull <= 1e-9 {
+			target.Hull, target.Destroyed = 0, true
+			b.logf("round %d: %s hits %s for %.1f, shields absorb %.1f, destroyed", b.Rounds, c, target, damage, absorbed)
+		} else {
+			b.logf("round %d: %s hits %s for %.1f, shields absorb %.1f, hull %.1f left", b.Rounds, c, target, damage, absorbed, target.Hull)
+		}
+	}
+}
+
+// target picks a hostile combatant to shoot at. Armed targets are chosen before unarmed ones.
+func (b *Battle_t) target(c *Combatant_t) *Combatant_t {
+	var armed, unarmed []*Combatant_t
+	for _, o := range b.Combatants {
+		if !o.active() || !b.hostile(c.Side, o.Side) {
+			continue
+		} else if o.Weapons > 0 && !o.retreating {
+			armed = append(armed, o)
+		} else {
+			unarmed = append(unarmed, o)
+		}
+	}
+	if len(armed) != 0 {
+		return armed[b.r.IntN(len(armed))]
+	} else if len(unarmed) != 0 {
+		return unarmed[b.r.IntN(len(unarmed))]
+	}
+	return nil
+}
+
+// NewShipCombatant returns a combatant for a ship of the design, using the tech of the race.
+func (g *Game_t) NewShipCombatant(id string, race *Race_t, d *Design_t, group string, retreat float64) *Combatant_t {
+	hull := float64(d.Hull) * HitPointsPerHull
+	return &Combatant_t{
+		Id:      id,
+		Name:    d.Name,
+		Side:    race.Id,
+		Group:   group,
+		Weapons: d.Weapons,
+		Shields: d.Shields,
+		Sensors: d.Sensors,
+		Speed:   g.DesignSpeed(d),
+		Attack:  g.Attack(race),
+		Defense: g.Defense(race),
+		Hull:    hull,
+		MaxHull: hull,
+		Retreat: retreat,
+	}
+}
+
+// NewDefenseCombatant returns a combatant for a colony's planetary defenses.
+// Every unit of defenses is one hit point.
+func (g *Game_t) NewDefenseCombatant(id string, race *Race_t, defenses float64) *Combatant_t {
+	batteries := int(math.Ceil(defenses / DefensesPerBattery))
+	return &Combatant_t{
+		Id:      id,
+		Name:    "defenses",
+		Side:    race.Id,
+		Group:   id,
+		Weapons: batteries,
+		Shields: batteries,
+		Attack:  g.Attack(race),
+		Defense: g.Defense(race),
+		Hull:    defenses,
+		MaxHull: defenses,
+	}
+}
+
+// removeShip takes a destroyed ship out of the game.
+func (g *Game_t) removeShip(ship *Ship_t) {
+	if fleet := g.Fleet(ship.Fleet); fleet != nil {
+		for i, id := range fleet.Ships {
+			if id == ship.Id {
+				fleet.Ships = append(fleet.Ships[:i:i], fleet.Ships[i+1:]...)
+				break
+			}
+		}
+	}
+	for i, s := range g.Ships {
+		if s == ship {
+			g.Ships = append(g.Ships[:i:i], g.Ships[i+1:]...)
+			break
+		}
+	}
+}
+
+// nearestSystem returns the system closest to the position, skipping the excluded system.
+func (g *Game_t) nearestSystem(position aow.Coordinates, exclude string) *aow.StarSystem_t {
+	var nearest *aow.StarSystem_t
+	for _, ss := range g.Cluster.StarSystems {
+		if ss.Id != exclude && (nearest == nil || position.DistanceTo(ss.Coordinates) < position.DistanceTo(nearest.Coordinates)) {
+			nearest = ss
+		}
+	}
+	return nearest
+}
+
+// combatPhase fights a battle wherever hostile fleets met during movement or
+// share a system. The planetary defenses of colonies in the system join in.
+func (t *turn_t) combatPhase() {
+	r := t.prng("combat")
+
+	encounters := t.encounters
+	intercepted := make(map[*Fleet_t]bool)
+	for _, e := range t.encounters {
+		for _, fleet := range e.fleets {
+			intercepted[fleet] = true
+		}
+	}
+	bySystem := make(map[string]*encounter_t)
+	var systems []string
+	for _, fleet := range t.g.Fleets {
+		if fleet.InTransit() || intercepted[fleet] {
+			continue
+		}
+		e, ok := bySystem[fleet.System]
+		if !ok {
+			e = &encounter_t{position: fleet.Position, system: fleet.System}
+			bySystem[fleet.System] = e
+			systems = append(systems, fleet.System)
+		}
+		e.fleets = append(e.fleets, fleet)
+	}
+	sort.Strings(systems)
+	for _, id := range systems {
+		encounters = append(encounters, bySystem[id])
+	}
+
+	for _, e := range encounters {
+		t.battle(r, e)
+	}
+	t.g.removeEmptyFleets()
+}
+
+// battle fights out an encounter and applies the results to the game.
+func (t *turn_t) battle(r *rand.Rand, e *encounter_t) {
+	b := NewBattle(r, func(a, b string) bool {
+		return t.g.HostileAt(a, b, e.system)
+	})
+	fleets := make(map[string]*Fleet_t)
+	for _, fleet := range e.fleets {
+		race := t.g.Race(fleet.Race)
+		fleets[fleet.Id] = fleet
+		for _, id := range fleet.Ships {
+			if ship := t.g.Ship(id); ship != nil {
+				if d := t.g.DesignOf(ship); d != nil {
+					b.Add(t.g.NewShipCombatant(ship.Id, race, d, fleet.Id, float64(fleet.Retreat)/100))
+				}
+			}
+		}
+	}
+	colonies := make(map[string]*Colony_t)
+	if e.system != "" {
+		for _, colony := range t.g.Colonies {
+			if colony.System == e.system && colony.Defenses > 0 {
+				colonies[colony.Id] = colony
+				b.Add(t.g.NewDefenseCombatant(colony.Id, t.g.Race(colony.Race), colony.Defenses))
+			}
+		}
+	}
+	if !b.engaged() {
+		return
+	}
+	b.Fight(CombatRounds)
+
+	where := e.position.String()
+	if ss, err := t.g.Cluster.Lookup(e.system); err == nil {
+		where = fmt.Sprintf("%s %s", ss.Id, ss.Name)
+	}
+	for _, side := range b.Sides() {
+		race := t.g.Race(side)
+		t.g.logf(race, "battle at %s", where)
+		for _, line := range b.Log {
+			t.g.logf(race, "  %s", line)
+		}
+	}
+
+	retreated := make(map[*Fleet_t]bool)
+	for _, c := range b.Combatants {
+		if colony, ok := colonies[c.Id]; ok {
+			colony.Defenses = c.Hull
+		} else if c.Destroyed {
+			t.g.removeShip(t.g.Ship(c.Id))
+		} else if c.Escaped {
+			retreated[fleets[c.Group]] = true
+		}
+	}
+	for _, fleet := range e.fleets {
+		if !retreated[fleet] || len(fleet.Ships) == 0 {
+			continue
+		}
+		if ss := t.g.nearestSystem(fleet.Position, e.system); ss != nil {
+			fleet.Route, fleet.Destination = []string{ss.Id}, ss.Id
+			t.g.logf(t.g.Race(fleet.Race), "%s retreats towards %s %s", fleet.Id, ss.Id, ss.Name)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+// Copyright (c) 2024 Michael D Henderson. All rights reserved.
+
+package fargo
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+)
+
+// functions to simulate battles outside of a game.
+//
+// a scenario describes the sides in a battle. it is plain text, with the
+// same rules for comments and quoting as orders:
+//
+//	side Red weapons 2 shields 1 retreat 50
+//	ships 3 Raider hull 20 drive 1 weapons 2 shields 1
+//	side Blue weapons 2 shields 2
+//	ships 2 Guard hull 20 weapons 3 shields 2
+//	defenses 40
+//
+// tech levels that aren't given are 1. every tech named must be a field
+// in the tech tree the battle is fought with, and a ships line makes no
+// more ships than a build order could.
+
+// Scenario_t is a battle set up by hand, for trying out designs.
+type Scenario_t struct {
+	Sides []*ScenarioSide_t
+}
+
+type ScenarioSide_t struct {
+	Name     string
+	Tech     map[string]int
+	Retreat  int // percent of hull lost before the side retreats
+	Ships    []*ScenarioShips_t
+	Defenses float64
+}
+
+type ScenarioShips_t struct {
+	Count  int
+	Design Design_t
+}
+
+// ParseScenario reads a scenario.
+func ParseScenario(r io.Reader) (*Scenario_t, error) {
+	s := &Scenario_t{}
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		args, err := splitOrder(scanner.Text())
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		} else if len(args) == 0 {
+			continue
+		}
+		if err := s.parseLine(strings.ToLower(args[0]), args[1:]); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	} else if len(s.Sides) < 2 {
+		return nil, fmt.Errorf("scenario needs at least two sides: %w", ErrInvalidArguments)
+	}
+	return s, nil
+}
+
+func (s *Scenario_t) parseLine(verb string, args []string) error {
+	if verb == "side" {
+		if len(args) == 0 || len(args)%2 != 1 {
+			return ErrInvalidArguments
+		}
+		side := &ScenarioSide_t{Name: args[0], Tech: make(map[string]int)}
+		for i := 1; i < len(args); i += 2 {
+			n, err := strconv.Atoi(args[i+1])
+			if err != nil || n < 0 {
+				return ErrInvalidAmount
+			}
+			if key := strings.ToLower(args[i]); key == "retreat" {
+				side.Retreat = min(n, 100)
+			} else {
+				side.Tech[key] = n
+			}
+		}
+		s.Sides = append(s.Sides, side)
+		return nil
+	} else if len(s.Sides) == 0 {
+		return fmt.Errorf("%s before side: %w", verb, ErrInvalidArguments)
+	}
+	side := s.Sides[len(s.Sides)-1]
+	switch verb {
+	case "defenses":
+		if len(args) != 1 {
+			return ErrInvalidArguments
+		}
+		amount, err := parseAmount(args[0])
+		if err != nil {
+			return err
+		}
+		side.Defenses += amount
+		return nil
+	case "ships":
+		// ships <count> <design order arguments>
+		if len(args) < 2 {
+			return ErrInvalidArguments
+		}
+		count, err := strconv.Atoi(args[0])
+		if err != nil || count < 1 || count > MaximumShipsPerBuild {
+			return ErrInvalidAmount
+		}
+		order, err := parseOrder(OrderSource_t{}, "design", args[1:])
+		if err != nil {
+			return err
+		}
+		side.Ships = append(side.Ships, &ScenarioShips_t{Count: count, Design: order.(*DesignOrder_t).Design})
+		return nil
+	}
+	return ErrUnknownOrder
+}
+
+// Battle sets up the scenario as a battle, using the tech tree for the effects of the sides' tech levels.
+// Every tech must be a field in the tree, and every design is checked against its side's tech.
+func (s *Scenario_t) Battle(tree *TechTree_t, r *rand.Rand) (*Battle_t, error) {
+	g := &Game_t{TechTree: tree}
+	b := NewBattle(r, func(a, b string) bool { return a != b })
+	for _, side := range s.Sides {
+		for key := range side.Tech {
+			if tree.Field(key) == nil {
+				return nil, fmt.Errorf("%s: %q: %w", side.Name, key, ErrUnknownTechField)
+			}
+		}
+		race := &Race_t{Id: side.Name, Tech: side.Tech}
+		for _, ships := range side.Ships {
+			d := ships.Design
+			if err := g.ValidateDesign(race, &d); err != nil {
+				return nil, fmt.Errorf("%s: %s: %w", side.Name, d.Name, err)
+			}
+			for n := 1; n <= ships.Count; n++ {
+				b.Add(g.NewShipCombatant(fmt.Sprintf("%s-%d", d.Name, n), race, &d, side.Name, float64(side.Retreat)/100))
+			}
+		}
+		if side.Defenses > 0 {
+			b.Add(g.NewDefenseCombatant(side.Name+"-defenses", race, side.Defenses))
+		}
+	}
+	return b, nil
+}
+
+// Winner returns the only side with combatants left in the battle, or an empty string for a draw.
+func (b *Battle_t) Winner() string {
+	var winner string
+	for _, c := range b.Combatants {
+		if !c.active() {
+			continue
+		} else if winner != "" && winner != c.Side {
+			return ""
+		}
+		winner = c.Side
+	}
+	return winner
+}
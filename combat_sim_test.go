@@ -0,0 +1,61 @@
+// Copyright (c) 2024 Michael D Henderson. All rights reserved.
+
+package fargo
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+const testScenario = `# a raid on a guarded system
+side Red weapons 2 shields 1 retreat 50
+ships 3 Raider hull 20 drive 1 weapons 2 shields 1
+side "Blue Guard" weapons 2 shields 2 # the defenders
+ships 2 Guard hull 20 weapons 3 shields 2
+defenses 40
+`
+
+func TestScenarioBattle(t *testing.T) {
+	s, err := ParseScenario(strings.NewReader(testScenario))
+	if err != nil {
+		t.Fatal(err)
+	} else if len(s.Sides) != 2 || s.Sides[1].Name != "Blue Guard" || s.Sides[1].Defenses != 40 {
+		t.Fatalf("sides: got %+v", s.Sides)
+	}
+	// the same seed fights the same battle
+	var logs [][]string
+	for run := 0; run < 2; run++ {
+		b, err := s.Battle(DefaultTechTree(), NewPRNG("test"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		b.Fight(10)
+		logs = append(logs, b.Log)
+	}
+	if len(logs[0]) == 0 || !slices.Equal(logs[0], logs[1]) {
+		t.Errorf("battle: the same seed fought different battles")
+	}
+}
+
+func TestScenarioErrors(t *testing.T) {
+	for _, tc := range []struct {
+		name, text string
+		want       error
+	}{
+		{"unknown tech", "side Red wepons 3\nside Blue\n", ErrUnknownTechField},
+		{"too many ships", fmt.Sprintf("side Red\nships %d Raider hull 20\nside Blue\n", MaximumShipsPerBuild+1), ErrInvalidAmount},
+		{"ships before side", "ships 1 Raider hull 20\n", ErrInvalidArguments},
+		{"one side", "side Red\n", ErrInvalidArguments},
+	} {
+		s, err := ParseScenario(strings.NewReader(tc.text))
+		if err == nil {
+			_, err = s.Battle(DefaultTechTree(), NewPRNG("test"))
+		}
+		if !errors.Is(err, tc.want) {
+			t.Errorf("%s: want %v, got %v", tc.name, tc.want, err)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+// Copyright (c) 2024 Michael D Henderson. All rights reserved.
+
+package fargo
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBattle(t *testing.T) {
+	war := func(a, b string) bool { return a != b }
+	peace := func(a, b string) bool { return false }
+	gun := func(id, side string, weapons, shields int, hull float64) *Combatant_t {
+		return &Combatant_t{Id: id, Name: "test", Side: side, Group: id, Weapons: weapons, Shields: shields, Speed: 1, Attack: 1, Defense: 1, Hull: hull, MaxHull: hull}
+	}
+
+	t.Run("peace", func(t *testing.T) {
+		b := NewBattle(NewPRNG("test"), peace)
+		b.Add(gun("A", "R001", 2, 0, 10), gun("B", "R002", 2, 0, 10))
+		b.Fight(CombatRounds)
+		if b.Rounds != 0 {
+			t.Errorf("want no fighting, got %d rounds", b.Rounds)
+		}
+	})
+
+	t.Run("shields", func(t *testing.T) {
+		// one weapon can't get through shields that absorb a full hit each round
+		b := NewBattle(NewPRNG("test"), war)
+		target := gun("B", "R002", 1, int(WeaponDamage/ShieldStrength), 10)
+		b.Add(gun("A", "R001", 1, 0, 1000), target)
+		b.Fight(CombatRounds)
+		if b.Rounds != CombatRounds {
+			t.Errorf("want %d rounds, got %d", CombatRounds, b.Rounds)
+		} else if target.Hull != 10 || !target.active() {
+			t.Errorf("want the target untouched, got %+v", target)
+		}
+	})
+
+	t.Run("destroyed", func(t *testing.T) {
+		b := NewBattle(NewPRNG("test"), war)
+		target := gun("B", "R002", 0, 0, 1)
+		b.Add(gun("A", "R001", 4, 0, 10), target)
+		b.Fight(CombatRounds)
+		if !target.Destroyed || target.Hull != 0 {
+			t.Errorf("want the target destroyed, got %+v", target)
+		} else if last := b.Log[len(b.Log)-1]; !strings.HasPrefix(last, "after ") {
+			t.Errorf("want the battle to end with a summary, got %q", last)
+		}
+	})
+
+	t.Run("unarmed", func(t *testing.T) {
+		// unarmed ships run from the first round
+		b := NewBattle(NewPRNG("test"), war)
+		pod := gun("B", "R002", 0, 0, 1000)
+		b.Add(gun("A", "R001", 1, 0, 10), pod)
+		b.Fight(CombatRounds)
+		if !pod.Escaped {
+			t.Errorf("want the pod to escape, got %+v", pod)
+		} else if !strings.Contains(b.Log[0], "B test (R002)") || !strings.HasPrefix(b.Log[0], "round 1: ") {
+			t.Errorf("want the pod to run in round 1, got %q", b.Log[0])
+		}
+	})
+
+	t.Run("retreat", func(t *testing.T) {
+		// a group that has lost half its hull falls back and stops firing
+		b := NewBattle(NewPRNG("test"), war)
+		a := gun("A", "R001", 1, 0, 10)
+		a.Hull, a.Retreat = 4, 0.5
+		b.Add(a, gun("B", "R002", 1, 0, 1000))
+		b.Fight(CombatRounds)
+		if !a.Escaped && !a.Destroyed {
+			t.Errorf("want the group to get away or be destroyed, got %+v", a)
+		}
+		for _, line := range b.Log {
+			if strings.HasPrefix(line, "round 1: A test (R001) hits") || strings.HasPrefix(line, "round 1: A test (R001) misses") {
+				t.Errorf("want no fire from a retreating group, got %q", line)
+			}
+		}
+	})
+}
+
+func TestDefenseCombatant(t *testing.T) {
+	g := &Game_t{TechTree: DefaultTechTree()}
+	race := &Race_t{Id: "R001"}
+	c := g.NewDefenseCombatant("C001", race, 25)
+	if c.Weapons != 3 || c.Shields != 3 || c.Hull != 25 || c.Speed != 0 {
+		t.Errorf("defenses: want 3 batteries with 25 hit points that can't retreat, got %+v", c)
+	}
+	s := g.NewShipCombatant("S001", race, &Design_t{Name: "Raider", Hull: 10, Drive: 1, Weapons: 2}, "F001", 0.5)
+	if s.Hull != 10*HitPointsPerHull || s.Speed != DriveSpeed(1) || s.Retreat != 0.5 || s.Group != "F001" {
+		t.Errorf("ship: got %+v", s)
+	}
+}
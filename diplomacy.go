@@ -0,0 +1,206 @@
+// Copyright (c) 2024 Michael D Henderson. All rights reserved.
+
+package fargo
+
+import (
+	"fmt"
+	"github.com/playbymail/fargo/internal/aow"
+	"sort"
+	"strings"
+)
+
+// functions for diplomacy and messages between races.
+//
+// each race takes a stance toward every other race: war, neutral,
+// non-aggression or alliance. races start out neutral. the status between
+// two races is the less friendly of their two stances, so either race can
+// declare war on its own, but both must agree to a treaty.
+//
+//	war             fleets fight wherever they meet
+//	neutral         fleets fight only in a system that one of the races owns
+//	non-aggression  fleets never fight
+//	alliance        fleets never fight, sensors are shared and fleets may
+//	                travel through each other's systems
+//
+// routes may not pass through a system owned by a race that isn't an ally,
+// though they may end there. routes are checked when they are planned.
+// every change of status is kept in the game's history.
+//
+// messages are delivered in the recipient's report for the next turn.
+
+// Status_e is the relationship between two races, from least to most friendly.
+type Status_e int
+
+const (
+	StatusWar Status_e = iota
+	StatusNeutral
+	StatusNonAggression
+	StatusAlliance
+)
+
+func (s Status_e) String() string {
+	switch s {
+	case StatusWar:
+		return "war"
+	case StatusNeutral:
+		return "neutral"
+	case StatusNonAggression:
+		return "non-aggression"
+	case StatusAlliance:
+		return "alliance"
+	}
+	return "unknown"
+}
+
+// MarshalText saves the status by name, so that the history is readable.
+func (s Status_e) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+func (s *Status_e) UnmarshalText(text []byte) (err error) {
+	*s, err = ParseStatus(string(text))
+	return err
+}
+
+// ParseStatus returns the status with the name.
+func ParseStatus(name string) (Status_e, error) {
+	for s := StatusWar; s <= StatusAlliance; s++ {
+		if strings.EqualFold(name, s.String()) {
+			return s, nil
+		}
+	}
+	return StatusNeutral, fmt.Errorf("%q: %w", name, ErrUnknownStatus)
+}
+
+// StatusChange_t is an entry in the history of relations between races.
+type StatusChange_t struct {
+	Turn  int       `json:"turn"`
+	Races [2]string `json:"races"` // ids of the two races, in order
+	By    string    `json:"by"`    // id of the race whose order changed the status
+	From  Status_e  `json:"from"`
+	To    Status_e  `json:"to"`
+}
+
+// Message_t is a message from one race to another.
+type Message_t struct {
+	Turn int    `json:"turn"` // the turn the message was sent
+	From string `json:"from"`
+	To   string `json:"to"`
+	Text string `json:"text"`
+}
+
+// Stance returns the race's stance toward another race.
+func (r *Race_t) Stance(other string) Status_e {
+	if s, ok := r.Stances[other]; ok {
+		return s
+	}
+	return StatusNeutral
+}
+
+// Status returns the status between two races. A race is allied with itself.
+func (g *Game_t) Status(a, b string) Status_e {
+	if a == b {
+		return StatusAlliance
+	}
+	ra, rb := g.Race(a), g.Race(b)
+	if ra == nil || rb == nil {
+		return StatusNeutral
+	}
+	return min(ra.Stance(b), rb.Stance(a))
+}
+
+// Hostile returns true if the races fight wherever they meet.
+func (g *Game_t) Hostile(a, b string) bool {
+	return g.Status(a, b) == StatusWar
+}
+
+// HostileAt returns true if the races fight when they meet in the system.
+// Neutral races fight in a system that one of them owns.
+func (g *Game_t) HostileAt(a, b, system string) bool {
+	switch g.Status(a, b) {
+	case StatusWar:
+		return true
+	case StatusNeutral:
+		ss, err := g.Cluster.Lookup(system)
+		return err == nil && ss.Owner != "" && (ss.Owner == a || ss.Owner == b)
+	}
+	return false
+}
+
+// Allies returns the races allied with the race, not including the race itself.
+func (g *Game_t) Allies(race *Race_t) []*Race_t {
+	var list []*Race_t
+	for _, other := range g.Races {
+		if other != race && g.Status(race.Id, other.Id) == StatusAlliance {
+			list = append(list, other)
+		}
+	}
+	return list
+}
+
+// MayPass returns true if the race's fleets may travel through the system.
+func (g *Game_t) MayPass(race *Race_t, ss *aow.StarSystem_t) bool {
+	return ss.Owner == "" || g.Status(race.Id, ss.Owner) == StatusAlliance
+}
+
+// diplomacyPhase sets the races' stances and records the changes of status.
+func (t *turn_t) diplomacyPhase() {
+	for _, race := range t.g.Races {
+		for _, order := range ordersFor[*DiplomacyOrder_t](t, race) {
+			t.cause(race, order)
+			if err := t.diplomacy(race, order); err != nil {
+				t.reject(race, order.OrderSource_t, err)
+			}
+		}
+	}
+}
+
+func (t *turn_t) diplomacy(race *Race_t, order *DiplomacyOrder_t) error {
+	other := t.g.Race(order.Race)
+	if other == nil || other == race {
+		return fmt.Errorf("%q: %w", order.Race, ErrUnknownRace)
+	}
+	from := t.g.Status(race.Id, other.Id)
+	if race.Stances == nil {
+		race.Stances = make(map[string]Status_e)
+	}
+	race.Stances[other.Id] = order.Stance
+	t.g.logf(race, "stance toward %s %s is %s", other.Id, other.Name, order.Stance)
+
+	to := t.g.Status(race.Id, other.Id)
+	if to == from {
+		return nil
+	}
+	races := [2]string{race.Id, other.Id}
+	sort.Strings(races[:])
+	t.g.History = append(t.g.History, &StatusChange_t{Turn: t.g.Turn, Races: races, By: race.Id, From: from, To: to})
+	t.g.logf(race, "status with %s %s changed from %s to %s", other.Id, other.Name, from, to)
+	t.g.logf(other, "status with %s %s changed from %s to %s", race.Id, race.Name, from, to)
+	return nil
+}
+
+// messagePhase sends the races' messages. A message to "all" goes to every other race.
+func (t *turn_t) messagePhase() {
+	for _, race := range t.g.Races {
+		for _, order := range ordersFor[*MessageOrder_t](t, race) {
+			t.cause(race, order)
+			var to []*Race_t
+			if strings.EqualFold(order.Race, "all") {
+				for _, other := range t.g.Races {
+					if other != race {
+						to = append(to, other)
+					}
+				}
+			} else if other := t.g.Race(order.Race); other != nil && other != race {
+				to = append(to, other)
+			} else {
+				t.reject(race, order.OrderSource_t, fmt.Errorf("%q: %w", order.Race, ErrUnknownRace))
+				continue
+			}
+			for _, other := range to {
+				t.g.Messages = append(t.g.Messages, &Message_t{Turn: t.g.Turn, From: race.Id, To: other.Id, Text: order.Text})
+			}
+			t.g.logf(race, "message sent to %s", order.Race)
+		}
+	}
+}
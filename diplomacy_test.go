@@ -0,0 +1,123 @@
+// Copyright (c) 2024 Michael D Henderson. All rights reserved.
+
+package fargo
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestStatus(t *testing.T) {
+	g, err := CreateGame(GameOptions_t{Name: "Test", Seed: "test", NumberOfRaces: 2, SystemsPerRace: 4, Culture: "classical", NameStyle: "syllable"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	red, blue := g.Race("R001"), g.Race("R002")
+	home, err := g.Cluster.Lookup(red.HomeSystem)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.Status(red.Id, blue.Id) != StatusNeutral || g.Status(red.Id, red.Id) != StatusAlliance {
+		t.Errorf("start: want neutral races allied with themselves")
+	}
+	if g.Hostile(red.Id, blue.Id) || !g.HostileAt(red.Id, blue.Id, home.Id) || g.MayPass(blue, home) {
+		t.Errorf("neutral: want fighting and no passage only in owned systems")
+	}
+
+	// the status is the less friendly of the two stances
+	red.Stances = map[string]Status_e{blue.Id: StatusAlliance}
+	if got := g.Status(red.Id, blue.Id); got != StatusNeutral {
+		t.Errorf("one sided: want neutral, got %v", got)
+	}
+	blue.Stances = map[string]Status_e{red.Id: StatusNonAggression}
+	if got := g.Status(blue.Id, red.Id); got != StatusNonAggression || g.HostileAt(red.Id, blue.Id, home.Id) {
+		t.Errorf("non-aggression: want no fighting, got %v", got)
+	}
+	blue.Stances[red.Id] = StatusAlliance
+	if !g.MayPass(blue, home) || len(g.Allies(red)) != 1 {
+		t.Errorf("alliance: want allies to pass through each other's systems")
+	}
+	blue.Stances[red.Id] = StatusWar
+	if !g.Hostile(red.Id, blue.Id) {
+		t.Errorf("war: want either race to be able to declare war")
+	}
+
+	for _, name := range []string{"war", "Neutral", "NON-AGGRESSION", "alliance"} {
+		if s, err := ParseStatus(name); err != nil || !strings.EqualFold(s.String(), name) {
+			t.Errorf("%s: got %v, %v", name, s, err)
+		}
+	}
+	if _, err := ParseStatus("truce"); !errors.Is(err, ErrUnknownStatus) {
+		t.Errorf("truce: want %v, got %v", ErrUnknownStatus, err)
+	}
+}
+
+func TestDiplomacy(t *testing.T) {
+	g, err := CreateGame(GameOptions_t{Name: "Test", Seed: "test", NumberOfRaces: 3, SystemsPerRace: 4, Culture: "classical", NameStyle: "syllable"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	turn := func(texts ...string) map[string][]*OrderError_t {
+		var list []*Orders_t
+		for _, text := range texts {
+			o, err := ParseOrders(strings.NewReader(text))
+			if err != nil {
+				t.Fatal(err)
+			}
+			list = append(list, o)
+		}
+		results, err := g.ProcessTurn(list)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return results
+	}
+
+	results := turn("race R001\ndiplomacy R002 alliance\ndiplomacy R001 war\ndiplomacy R009 war\n")
+	if failed := results["R001"]; len(failed) != 2 || !errors.Is(failed[0].Err, ErrUnknownRace) || !errors.Is(failed[1].Err, ErrUnknownRace) {
+		t.Errorf("unknown: want the orders for itself and R009 rejected, got %v", failed)
+	} else if len(g.History) != 0 {
+		t.Errorf("one sided: want no change of status, got %v", g.History)
+	}
+
+	turn("race R001\n", "race R002\ndiplomacy R001 alliance\ndiplomacy R003 war\n")
+	if g.Status("R001", "R002") != StatusAlliance || g.Status("R002", "R003") != StatusWar {
+		t.Fatalf("want R001 and R002 allied and R002 at war with R003")
+	}
+	if len(g.History) != 2 {
+		t.Fatalf("history: want 2 changes, got %d", len(g.History))
+	}
+	if h := g.History[0]; h.Races != [2]string{"R001", "R002"} || h.By != "R002" || h.From != StatusNeutral || h.To != StatusAlliance {
+		t.Errorf("history: got %+v", h)
+	}
+}
+
+func TestMessages(t *testing.T) {
+	g, err := CreateGame(GameOptions_t{Name: "Test", Seed: "test", NumberOfRaces: 3, SystemsPerRace: 4, Culture: "classical", NameStyle: "syllable"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	o, err := ParseOrders(strings.NewReader("race R001\nmessage R002 \"meet at the gate\"\nmessage all \"hello\"\nmessage R001 \"note to self\"\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sent := g.Turn
+	results, err := g.ProcessTurn([]*Orders_t{o})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if failed := results["R001"]; len(failed) != 1 || !errors.Is(failed[0].Err, ErrUnknownRace) {
+		t.Errorf("self: want %v, got %v", ErrUnknownRace, failed)
+	}
+	var got []string
+	for _, msg := range g.Messages {
+		if msg.From != "R001" || msg.Turn != sent {
+			t.Errorf("%+v: want a message from R001 sent on turn %d", msg, sent)
+		}
+		got = append(got, msg.To+":"+msg.Text)
+	}
+	if want := "R002:meet at the gate,R002:hello,R003:hello"; strings.Join(got, ",") != want {
+		t.Errorf("messages: want %s, got %s", want, strings.Join(got, ","))
+	}
+}
@@ -0,0 +1,77 @@
+// Copyright (c) 2024 Michael D Henderson. All rights reserved.
+
+package fargo
+
+import (
+	"math"
+	"sort"
+)
+
+// functions to compute the economic output of colonies and races.
+//
+// every colony puts its population to work mining minerals, generating
+// energy and farming. each unit of infrastructure provides work for one
+// million people; people without infrastructure work at a fraction of
+// the rate. output is scaled by the planet's natural resources and by
+// the race's manufacturing tech, then converted into credits for the race's
+// treasury.
+
+const (
+	// IdleWorkerEfficiency is the output of a worker without infrastructure, relative to one with.
+	IdleWorkerEfficiency = 0.1
+
+	// CreditsPerUnit converts units of production into credits.
+	CreditsPerUnit = 0.1
+
+	// InfrastructureCost is the cost, in credits, of one unit of infrastructure.
+	InfrastructureCost = 1.0
+)
+
+// Production_t is the output of a colony for one turn.
+type Production_t struct {
+	Colony   *Colony_t
+	Workers  float64 // millions of people at work, counting idle workers at their reduced rate
+	Minerals float64
+	Energy   float64
+	Biology  float64
+	Income   float64 // credits
+}
+
+// Production returns the output of the colony for one turn.
+func (g *Game_t) Production(colony *Colony_t) *Production_t {
+	prod := &Production_t{Colony: colony}
+	_, planet := g.Planet(colony)
+	race := g.Race(colony.Race)
+	if planet == nil || race == nil {
+		return prod
+	}
+
+	employed := math.Min(colony.Population, colony.Infrastructure)
+	idle := colony.Population - employed
+	prod.Workers = employed + idle*IdleWorkerEfficiency
+
+	prod.Minerals = prod.Workers * planet.Minerals / 100
+	prod.Energy = prod.Workers * planet.Energy / 100
+	// farming depends on the whole population, not just the workers
+	prod.Biology = colony.Population * planet.Biology / 100
+
+	factor := g.TechEffect(race, TechManufacturing, EffectProduction)
+	prod.Income = (prod.Minerals + prod.Energy + prod.Biology/2) * factor * CreditsPerUnit
+	return prod
+}
+
+// Income returns the total income of the race for one turn along with
+// the output of each of its colonies, sorted by colony id.
+func (g *Game_t) Income(race *Race_t) (float64, []*Production_t) {
+	var total float64
+	var list []*Production_t
+	for _, colony := range g.ColoniesOf(race) {
+		prod := g.Production(colony)
+		total += prod.Income
+		list = append(list, prod)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Colony.Id < list[j].Colony.Id
+	})
+	return total, list
+}
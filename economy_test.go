@@ -0,0 +1,83 @@
+// Copyright (c) 2024 Michael D Henderson. All rights reserved.
+
+package fargo
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestProduction(t *testing.T) {
+	g, err := CreateGame(GameOptions_t{Name: "Test", Seed: "test", NumberOfRaces: 2, SystemsPerRace: 4, Culture: "classical", NameStyle: "syllable"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	colony := g.Colony("C001")
+	pop := colony.Population
+
+	colony.Infrastructure = 0
+	idle := g.Production(colony)
+	if math.Abs(idle.Workers-pop*IdleWorkerEfficiency) > 1e-9 {
+		t.Errorf("idle: want %g workers, got %g", pop*IdleWorkerEfficiency, idle.Workers)
+	}
+	colony.Infrastructure = pop
+	full := g.Production(colony)
+	if full.Workers != pop {
+		t.Errorf("employed: want %g workers, got %g", pop, full.Workers)
+	} else if !(full.Income > idle.Income) {
+		t.Errorf("employed: want more than %g credits, got %g", idle.Income, full.Income)
+	} else if full.Biology != idle.Biology {
+		t.Errorf("biology: want farming to depend on the population only, got %g and %g", idle.Biology, full.Biology)
+	}
+
+	race := g.Race("R001")
+	total, list := g.Income(race)
+	var sum float64
+	for _, prod := range list {
+		sum += prod.Income
+	}
+	if len(list) == 0 || math.Abs(sum-total) > 1e-9 {
+		t.Errorf("income: want the total of %d colonies, got %g and %g", len(list), total, sum)
+	} else if !sort.SliceIsSorted(list, func(i, j int) bool { return list[i].Colony.Id < list[j].Colony.Id }) {
+		t.Errorf("income: colonies are not sorted by id")
+	}
+}
+
+func TestProductionPhase(t *testing.T) {
+	g, err := CreateGame(GameOptions_t{Name: "Test", Seed: "test", NumberOfRaces: 2, SystemsPerRace: 4, Culture: "classical", NameStyle: "syllable"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	run := func(text string) *Game_t {
+		clone, err := g.Clone()
+		if err != nil {
+			t.Fatal(err)
+		}
+		o, err := ParseOrders(strings.NewReader(text))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if results, err := clone.ProcessTurn([]*Orders_t{o}); err != nil {
+			t.Fatal(err)
+		} else if len(results["R001"]) != 0 {
+			t.Fatalf("orders: %v", results["R001"][0])
+		}
+		return clone
+	}
+
+	race := g.Race("R001")
+	income, _ := g.Income(race)
+	idle := run("race R001\n")
+	if got, want := idle.Race("R001").Credits, race.Credits+income; math.Abs(got-want) > 1e-9 {
+		t.Errorf("credits: want %g, got %g", want, got)
+	}
+
+	built := run("race R001\nbuild 10 infrastructure at C001\n")
+	if got, want := built.Colony("C001").Infrastructure, g.Colony("C001").Infrastructure+10; got != want {
+		t.Errorf("infrastructure: want %g, got %g", want, got)
+	} else if !(built.Race("R001").Credits < idle.Race("R001").Credits) {
+		t.Errorf("credits: building didn't cost anything")
+	}
+}
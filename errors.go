@@ -9,7 +9,52 @@ type Error string
 func (e Error) Error() string { return string(e) }
 
 const (
-	ErrNotAFile       = Error("not a file")
-	ErrNotADirectory  = Error("not a directory")
-	ErrNotImplemented = Error("not implemented")
+	ErrAtWar               = Error("at war")
+	ErrDuplicateAccount    = Error("duplicate account")
+	ErrDuplicateDesign     = Error("duplicate design")
+	ErrDuplicateOrders     = Error("duplicate orders")
+	ErrDuplicateRace       = Error("duplicate race")
+	ErrGameArchived        = Error("game is archived")
+	ErrInTransit           = Error("in transit")
+	ErrInsufficientCargo   = Error("insufficient cargo space")
+	ErrInsufficientCredits = Error("insufficient credits")
+	ErrInsufficientPeople  = Error("insufficient population")
+	ErrInvalidAmount       = Error("invalid amount")
+	ErrInvalidArguments    = Error("invalid arguments")
+	ErrInvalidCredentials  = Error("invalid credentials")
+	ErrInvalidDesign       = Error("invalid design")
+	ErrInvalidName         = Error("invalid name")
+	ErrInvalidSpeed        = Error("invalid speed")
+	ErrInvalidTechTree     = Error("invalid tech tree")
+	ErrMaximumTechLevel    = Error("maximum tech level")
+	ErrMissingRace         = Error("missing race")
+	ErrNoShipyard          = Error("no shipyard")
+	ErrNoColonists         = Error("no colonists")
+	ErrNoRoute             = Error("no route")
+	ErrNoSensors           = Error("no sensors")
+	ErrNotAFile            = Error("not a file")
+	ErrNotADirectory       = Error("not a directory")
+	ErrNotAPlayer          = Error("not a player")
+	ErrNotImplemented      = Error("not implemented")
+	ErrNotTogether         = Error("not in the same system")
+	ErrNoTechLead          = Error("tech level not higher")
+	ErrPlanetOwned         = Error("planet owned by another race")
+	ErrTurnChanged         = Error("turn has changed")
+	ErrTurnLocked          = Error("turn is being processed")
+	ErrUnconfirmed         = Error("not confirmed")
+	ErrUninhabitable       = Error("uninhabitable")
+	ErrUnknownAccount      = Error("unknown account")
+	ErrUnknownColony       = Error("unknown colony")
+	ErrUnknownFleet        = Error("unknown fleet")
+	ErrUnknownItem         = Error("unknown item")
+	ErrUnknownOrder        = Error("unknown order")
+	ErrUnknownPlanet       = Error("unknown planet")
+	ErrUnknownRace         = Error("unknown race")
+	ErrUnknownRole         = Error("unknown role")
+	ErrUnknownShip         = Error("unknown ship")
+	ErrUnknownState        = Error("unknown state")
+	ErrUnknownStatus       = Error("unknown status")
+	ErrUnknownSystem       = Error("unknown system")
+	ErrUnknownTechField    = Error("unknown tech field")
+	ErrUnterminatedQuote   = Error("unterminated quote")
 )
@@ -0,0 +1,155 @@
+// Copyright (c) 2024 Michael D Henderson. All rights reserved.
+
+package fargo
+
+import (
+	"github.com/playbymail/fargo/internal/aow"
+	"math"
+	"strings"
+)
+
+// functions to manage fleets.
+//
+// every ship belongs to a fleet, and fleets are what move. a fleet is
+// either in a system or in transit between two systems. ships that are
+// built together start out in a new fleet at the colony's system.
+
+// Fleet_t is a group of ships that travel together.
+type Fleet_t struct {
+	Id          string          `json:"id"`
+	Race        string          `json:"race"`                  // id of the race that owns the fleet
+	Ships       []string        `json:"ships"`                 // ids of the ships in the fleet
+	System      string          `json:"system,omitempty"`      // id of the system the fleet is in, empty while in transit
+	Position    aow.Coordinates `json:"position"`              // in light years
+	Destination string          `json:"destination,omitempty"` // id of the system the fleet is travelling to
+	Route       []string        `json:"route,omitempty"`       // ids of the systems still to be reached, ending with the destination
+	Retreat     int             `json:"retreat,omitempty"`     // percent of hull lost in a battle before the fleet retreats, 0 to fight to the end
+}
+
+// InTransit returns true if the fleet is between systems.
+func (f *Fleet_t) InTransit() bool {
+	return f.System == ""
+}
+
+// Fleet returns the fleet with the id, or nil if there is none.
+// Ids are not case-sensitive.
+func (g *Game_t) Fleet(id string) *Fleet_t {
+	for _, fleet := range g.Fleets {
+		if strings.EqualFold(fleet.Id, id) {
+			return fleet
+		}
+	}
+	return nil
+}
+
+// FleetsOf returns the fleets that belong to the race.
+func (g *Game_t) FleetsOf(race *Race_t) []*Fleet_t {
+	var list []*Fleet_t
+	for _, fleet := range g.Fleets {
+		if fleet.Race == race.Id {
+			list = append(list, fleet)
+		}
+	}
+	return list
+}
+
+// FleetOf returns the fleet the ship belongs to.
+func (g *Game_t) FleetOf(ship *Ship_t) *Fleet_t {
+	return g.Fleet(ship.Fleet)
+}
+
+// newFleet creates a fleet in a system.
+func (g *Game_t) newFleet(race *Race_t, ss *aow.StarSystem_t) *Fleet_t {
+	fleet := &Fleet_t{Id: g.nextId("F"), Race: race.Id, System: ss.Id, Position: ss.Coordinates}
+	g.Fleets = append(g.Fleets, fleet)
+	return fleet
+}
+
+// addShip moves a ship into a fleet.
+func (g *Game_t) addShip(fleet *Fleet_t, ship *Ship_t) {
+	if old := g.Fleet(ship.Fleet); old != nil {
+		for i, id := range old.Ships {
+			if id == ship.Id {
+				old.Ships = append(old.Ships[:i:i], old.Ships[i+1:]...)
+				break
+			}
+		}
+	}
+	ship.Fleet = fleet.Id
+	fleet.Ships = append(fleet.Ships, ship.Id)
+}
+
+// removeEmptyFleets deletes fleets that have no ships left.
+func (g *Game_t) removeEmptyFleets() {
+	var list []*Fleet_t
+	for _, fleet := range g.Fleets {
+		if len(fleet.Ships) != 0 {
+			list = append(list, fleet)
+		}
+	}
+	g.Fleets = list
+}
+
+// FleetSpeed returns the distance the fleet travels in one turn, in light years.
+// A fleet travels at the speed of its slowest ship.
+func (g *Game_t) FleetSpeed(fleet *Fleet_t) float64 {
+	speed := math.Inf(1)
+	for _, id := range fleet.Ships {
+		ship := g.Ship(id)
+		if ship == nil {
+			continue
+		}
+		d := g.DesignOf(ship)
+		if d == nil {
+			return 0
+		}
+		speed = math.Min(speed, g.DesignSpeed(d))
+	}
+	if math.IsInf(speed, 1) {
+		return 0
+	}
+	return speed
+}
+
+// FleetIsArmed returns true if any ship in the fleet has weapons.
+func (g *Game_t) FleetIsArmed(fleet *Fleet_t) bool {
+	for _, id := range fleet.Ships {
+		if ship := g.Ship(id); ship != nil {
+			if d := g.DesignOf(ship); d != nil && d.Weapons > 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// HasSensors returns true if any ship in the fleet has sensors.
+func (g *Game_t) HasSensors(fleet *Fleet_t) bool {
+	for _, id := range fleet.Ships {
+		if ship := g.Ship(id); ship != nil {
+			if d := g.DesignOf(ship); d != nil && d.Sensors > 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// TurnsToArrive returns the number of turns the fleet needs to reach the end of its route.
+// Every leg starts at a system, so a partial turn at the end of a leg counts as a full turn.
+func (g *Game_t) TurnsToArrive(fleet *Fleet_t) (int, error) {
+	speed := g.FleetSpeed(fleet)
+	from, total := fleet.Position, 0
+	for _, id := range fleet.Route {
+		ss, err := g.Cluster.Lookup(id)
+		if err != nil {
+			return 0, err
+		}
+		turns, err := TurnsToTravel(from.DistanceTo(ss.Coordinates), speed)
+		if err != nil {
+			return 0, err
+		}
+		from, total = ss.Coordinates, total+turns
+	}
+	return total, nil
+}
@@ -0,0 +1,316 @@
+// Copyright (c) 2024 Michael D Henderson. All rights reserved.
+
+package fargo
+
+import (
+	"fmt"
+	"github.com/playbymail/fargo/internal/aow"
+	"github.com/playbymail/fargo/internal/names"
+	"log"
+	"math"
+	"math/rand/v2"
+	"path/filepath"
+	"strings"
+)
+
+// functions to create, load and save a game.
+//
+// a game lives in a directory. with the file store (see storage.go), the
+// current state is kept in game.json and cluster.json. orders, reports and
+// a copy of the state at the start of each turn are kept in a directory
+// for the turn. the tech tree is kept in techtree.json and may be edited
+// by the GM between turns.
+//
+//	turns/0001/game.json
+//	turns/0001/cluster.json
+//	turns/0001/techtree.json
+//	turns/0001/orders/R001.txt
+//	turns/0001/reports/R001.txt
+
+const (
+	GameFile    = "game.json"
+	ClusterFile = "cluster.json"
+	LockFile    = "turn.lock" // exists while a turn is being processed
+)
+
+type Game_t struct {
+	Id       string            `json:"id"`
+	Name     string            `json:"name"`
+	Seed     string            `json:"seed"`
+	Turn     int               `json:"turn"` // the turn that players are writing orders for
+	Races    []*Race_t         `json:"races"`
+	Colonies []*Colony_t       `json:"colonies"`
+	Ships    []*Ship_t         `json:"ships,omitempty"`
+	Fleets   []*Fleet_t        `json:"fleets,omitempty"`
+	Log      []*LogEntry_t     `json:"log,omitempty"`
+	Messages []*Message_t      `json:"messages,omitempty"`
+	History  []*StatusChange_t `json:"history,omitempty"` // changes in the status between races
+	NextId   map[string]int    `json:"next-id"`           // the next number for each kind of id
+
+	Cluster  *aow.Catalog_t `json:"-"` // saved in its own file
+	TechTree *TechTree_t    `json:"-"` // saved in its own file so that the GM can edit it
+}
+
+// LogEntry_t is a note for a race's report.
+type LogEntry_t struct {
+	Turn int    `json:"turn"`
+	Race string `json:"race"`
+	Text string `json:"text"`
+}
+
+// GameOptions_t holds the settings for a new game.
+type GameOptions_t struct {
+	Name           string
+	Seed           string
+	NumberOfRaces  int
+	SystemsPerRace float64
+	Culture        string // for system and race names
+	NameStyle      string
+}
+
+// CreateGame creates a new cluster, places the home worlds of the races and
+// returns the game, ready for the first turn.
+func CreateGame(opts GameOptions_t) (*Game_t, error) {
+	if opts.NumberOfRaces < MinimumNumberOfRaces || opts.NumberOfRaces > MaximumNumberOfRaces {
+		return nil, fmt.Errorf("number of races must be between %d and %d", MinimumNumberOfRaces, MaximumNumberOfRaces)
+	} else if opts.SystemsPerRace < MinimumSystemsPerRace || opts.SystemsPerRace > MaximumSystemsPerRace {
+		return nil, fmt.Errorf("number of systems per race must be between %g and %g", MinimumSystemsPerRace, MaximumSystemsPerRace)
+	}
+
+	cluster, err := NewCluster(int(math.Ceil(float64(opts.NumberOfRaces)*opts.SystemsPerRace)), opts.Seed, opts.Culture, opts.NameStyle)
+	if err != nil {
+		return nil, err
+	}
+
+	g := &Game_t{
+		Id:       "fargo",
+		Name:     opts.Name,
+		Seed:     opts.Seed,
+		Turn:     1,
+		NextId:   make(map[string]int),
+		Cluster:  cluster,
+		TechTree: DefaultTechTree(),
+	}
+	r := g.prngFor("setup", 0)
+
+	homes, err := selectHomeSystems(r, cluster, opts.NumberOfRaces)
+	if err != nil {
+		return nil, err
+	}
+
+	raceNames := names.NewUnique(&names.Syllables{
+		Initial:      []string{"ar", "bel", "cor", "dra", "el", "fen", "gal", "hy", "ix", "ka", "lor", "mor", "nar", "or", "qu", "ras", "syl", "tor", "ul", "vor", "xan", "zy"},
+		Middle:       []string{"a", "e", "i", "o", "u", "an", "el", "ir", "on"},
+		Final:        []string{"ans", "ari", "ids", "ini", "ons", "ori", "uun", "yx"},
+		MinSyllables: 2,
+		MaxSyllables: 3,
+	}, r)
+
+	for n, ss := range homes {
+		race := &Race_t{
+			Id:         g.nextId("R"),
+			Name:       raceNames.Next(fmt.Sprintf("Race %d", n+1)),
+			HomeSystem: ss.Id,
+			Credits:    StartingCredits,
+			Tech:       make(map[string]int),
+		}
+		for _, f := range g.TechTree.Fields {
+			race.Tech[f.Id] = 1
+		}
+		home := prepareHomeWorld(r, ss)
+		race.Habitat = NewHabitat(r, home)
+		ss.Owner, home.Owner = race.Id, race.Id
+		g.Races = append(g.Races, race)
+		g.Colonies = append(g.Colonies, &Colony_t{
+			Id:             g.nextId("C"),
+			Race:           race.Id,
+			System:         ss.Id,
+			Orbit:          home.Orbit,
+			Population:     HomeWorldPopulation,
+			Infrastructure: HomeWorldInfrastructure,
+			Shipyard:       true,
+		})
+		log.Printf("game: create: %s %-12s home %s %s orbit %d\n", race.Id, race.Name, ss.Id, ss.Name, home.Orbit)
+	}
+	g.Scan()
+
+	return g, nil
+}
+
+// selectHomeSystems picks systems for the home worlds that are spread out across the cluster.
+// Only systems with a standard or large terrestrial world are considered.
+func selectHomeSystems(r *rand.Rand, cluster *aow.Catalog_t, n int) ([]*aow.StarSystem_t, error) {
+	var candidates []*aow.StarSystem_t
+	for _, ss := range cluster.StarSystems {
+		if homeWorldCandidate(ss) != nil {
+			candidates = append(candidates, ss)
+		}
+	}
+	if len(candidates) < n {
+		return nil, fmt.Errorf("cluster has %d systems that can hold a home world, need %d", len(candidates), n)
+	}
+
+	// start with a random system, then keep adding the candidate that is furthest from all the others
+	homes := []*aow.StarSystem_t{candidates[r.IntN(len(candidates))]}
+	for len(homes) < n {
+		var best *aow.StarSystem_t
+		var bestDistance float64
+		for _, ss := range candidates {
+			nearest := math.Inf(1)
+			for _, home := range homes {
+				nearest = math.Min(nearest, ss.DistanceTo(home))
+			}
+			if nearest > bestDistance {
+				best, bestDistance = ss, nearest
+			}
+		}
+		homes = append(homes, best)
+	}
+	return homes, nil
+}
+
+// homeWorldCandidate returns the planet in the system that is best suited to be a home world.
+// It prefers worlds with breathable air and temperatures closest to Earth's.
+func homeWorldCandidate(ss *aow.StarSystem_t) *aow.Planet_t {
+	var best *aow.Planet_t
+	score := func(p *aow.Planet_t) float64 {
+		s := -math.Abs(p.Temperature - 288)
+		if p.Atmosphere == aow.NitrogenOxygen {
+			s += 1000
+		}
+		return s
+	}
+	for _, p := range ss.Planets {
+		if p.Kind != aow.StandardTerrestrial && p.Kind != aow.LargeTerrestrial {
+			continue
+		}
+		if best == nil || score(p) > score(best) {
+			best = p
+		}
+	}
+	return best
+}
+
+// prepareHomeWorld makes sure that the home world in the system can support its race.
+// Worlds without breathable air are given an Earth-like climate, as if the race
+// had terraformed them long ago.
+func prepareHomeWorld(r *rand.Rand, ss *aow.StarSystem_t) *aow.Planet_t {
+	home := homeWorldCandidate(ss)
+	if home.Atmosphere != aow.NitrogenOxygen || home.Hydrographics < 30 || math.Abs(home.Temperature-288) > 30 {
+		home.Atmosphere = aow.NitrogenOxygen
+		home.Pressure = 0.8 + 0.4*r.Float64()
+		home.Temperature = 278 + 20*r.Float64()
+		home.Hydrographics = 50 + 30*r.Float64()
+	}
+	home.Minerals = math.Max(home.Minerals, 40)
+	home.Energy = math.Max(home.Energy, 40)
+	home.Biology = math.Max(home.Biology, 60)
+	return home
+}
+
+// LoadGame reads the current state of the game in the directory from the game's store.
+// A game in a site gets the id of its directory (see SiteGameId).
+func LoadGame(path string) (*Game_t, error) {
+	s, err := OpenStore(path, true)
+	if err != nil {
+		return nil, err
+	}
+	defer s.Close()
+	g, err := s.Game()
+	if err != nil {
+		return nil, err
+	} else if id, ok := SiteGameId(path); ok {
+		g.Id = id
+	}
+	return g, nil
+}
+
+// TurnPath returns the directory that holds the files for a turn.
+func TurnPath(path string, turn int) string {
+	return filepath.Join(path, "turns", fmt.Sprintf("%04d", turn))
+}
+
+// OrdersPath returns the name of the file that holds a race's orders for a turn.
+func OrdersPath(path string, turn int, race string) string {
+	return filepath.Join(TurnPath(path, turn), "orders", race+".txt")
+}
+
+// ReportPath returns the name of the file that holds a race's report for a turn.
+func ReportPath(path string, turn int, race string) string {
+	return filepath.Join(TurnPath(path, turn), "reports", race+".txt")
+}
+
+// MailPath returns the directory that holds the game's mail.
+// Outgoing mail is spooled in its "outbox" directory.
+func MailPath(path string) string {
+	return filepath.Join(path, "mail")
+}
+
+// Race returns the race with the id, or nil if there is none.
+// Ids are not case-sensitive.
+func (g *Game_t) Race(id string) *Race_t {
+	for _, race := range g.Races {
+		if strings.EqualFold(race.Id, id) {
+			return race
+		}
+	}
+	return nil
+}
+
+// Colony returns the colony with the id, or nil if there is none.
+// Ids are not case-sensitive.
+func (g *Game_t) Colony(id string) *Colony_t {
+	for _, colony := range g.Colonies {
+		if strings.EqualFold(colony.Id, id) {
+			return colony
+		}
+	}
+	return nil
+}
+
+// ColoniesOf returns the colonies that belong to the race.
+func (g *Game_t) ColoniesOf(race *Race_t) []*Colony_t {
+	var list []*Colony_t
+	for _, colony := range g.Colonies {
+		if colony.Race == race.Id {
+			list = append(list, colony)
+		}
+	}
+	return list
+}
+
+// Planet returns the planet a colony is on.
+func (g *Game_t) Planet(colony *Colony_t) (*aow.StarSystem_t, *aow.Planet_t) {
+	ss, err := g.Cluster.Lookup(colony.System)
+	if err != nil {
+		return nil, nil
+	}
+	for _, p := range ss.Planets {
+		if p.Orbit == colony.Orbit {
+			return ss, p
+		}
+	}
+	return ss, nil
+}
+
+// logf adds a note to the race's report for the current turn.
+func (g *Game_t) logf(race *Race_t, format string, args ...any) {
+	g.Log = append(g.Log, &LogEntry_t{Turn: g.Turn, Race: race.Id, Text: fmt.Sprintf(format, args...)})
+}
+
+// nextId returns the next id with the prefix, like R001 or C001.
+func (g *Game_t) nextId(prefix string) string {
+	g.NextId[prefix]++
+	return fmt.Sprintf("%s%03d", prefix, g.NextId[prefix])
+}
+
+// prngFor returns the PRNG for a phase of a turn. Every phase has its own
+// stream so that changes to one phase don't change the results of another.
+func (g *Game_t) prngFor(phase string, turn int) *rand.Rand {
+	return rand.New(g.sourceFor(phase, turn))
+}
+
+// sourceFor returns the source for the PRNG for a phase of a turn.
+func (g *Game_t) sourceFor(phase string, turn int) rand.Source {
+	return newSource(fmt.Sprintf("%s/%s/%d", g.Seed, phase, turn))
+}
@@ -4,13 +4,16 @@ go 1.22.6
 
 require (
 	github.com/fogleman/gg v1.3.0
+	github.com/golang/freetype v0.0.0-20170609003504-e2365dfdc4a0
 	github.com/mdhender/semver v0.0.0-20240121182447-31da48bf9537
 	github.com/spf13/cobra v1.8.1
+	go.etcd.io/bbolt v1.3.11
+	golang.org/x/crypto v0.26.0
+	golang.org/x/image v0.19.0
 )
 
 require (
-	github.com/golang/freetype v0.0.0-20170609003504-e2365dfdc4a0 // indirect
 	github.com/inconshreveable/mousetrap v1.1.0 // indirect
 	github.com/spf13/pflag v1.0.5 // indirect
-	golang.org/x/image v0.19.0 // indirect
+	golang.org/x/sys v0.23.0 // indirect
 )
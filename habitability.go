@@ -0,0 +1,114 @@
+// Copyright (c) 2024 Michael D Henderson. All rights reserved.
+
+package fargo
+
+import (
+	"github.com/playbymail/fargo/internal/aow"
+	"math"
+	"math/rand/v2"
+)
+
+// Habitat_t is the environment that a race prefers.
+// Gravity is relative to Earth, temperature is in Kelvin,
+// pressure is in atmospheres and hydrographics is a percentage.
+type Habitat_t struct {
+	Atmosphere       aow.Atmosphere_e `json:"atmosphere"`
+	MinGravity       float64          `json:"min-gravity"`
+	MaxGravity       float64          `json:"max-gravity"`
+	MinTemperature   float64          `json:"min-temperature"`
+	MaxTemperature   float64          `json:"max-temperature"`
+	MinPressure      float64          `json:"min-pressure"`
+	MaxPressure      float64          `json:"max-pressure"`
+	MinHydrographics float64          `json:"min-hydrographics"`
+}
+
+// DefaultHabitat returns the preferences of a race from an Earth-like world.
+func DefaultHabitat() Habitat_t {
+	return Habitat_t{
+		Atmosphere:       aow.NitrogenOxygen,
+		MinGravity:       0.6,
+		MaxGravity:       1.4,
+		MinTemperature:   260,
+		MaxTemperature:   310,
+		MinPressure:      0.5,
+		MaxPressure:      2.0,
+		MinHydrographics: 30,
+	}
+}
+
+// NewHabitat returns random preferences centered on the home world.
+// The ranges are wide enough that the home world is always a good fit.
+func NewHabitat(r *rand.Rand, home *aow.Planet_t) Habitat_t {
+	vary := func(lo, hi float64) float64 {
+		return lo + (hi-lo)*r.Float64()
+	}
+	return Habitat_t{
+		Atmosphere:       home.Atmosphere,
+		MinGravity:       home.Gravity * vary(0.5, 0.7),
+		MaxGravity:       home.Gravity * vary(1.3, 1.5),
+		MinTemperature:   home.Temperature - vary(15, 35),
+		MaxTemperature:   home.Temperature + vary(15, 35),
+		MinPressure:      home.Pressure * vary(0.3, 0.6),
+		MaxPressure:      home.Pressure * vary(1.5, 2.5),
+		MinHydrographics: math.Max(0, home.Hydrographics-vary(20, 40)),
+	}
+}
+
+// Habitability rates a planet for a race, from 0 (uninhabitable) to 100 (ideal).
+// Each attribute outside of the race's preferred range lowers the rating.
+func (h Habitat_t) Habitability(p *aow.Planet_t) int {
+	if !p.IsSolid() || p.Kind == aow.AsteroidBelt {
+		return 0
+	}
+
+	rating := 100.0
+
+	// the wrong air is a big problem, and no air at all is worse
+	switch {
+	case p.Atmosphere == h.Atmosphere:
+	case p.Atmosphere == aow.NoAtmosphere || p.Atmosphere == aow.TraceAtmosphere:
+		rating -= 60
+	default:
+		rating -= 40
+	}
+
+	// outside the range, lose 50 points for every 100% of gravity
+	if p.Gravity < h.MinGravity {
+		rating -= 50 * (h.MinGravity - p.Gravity)
+	} else if p.Gravity > h.MaxGravity {
+		rating -= 50 * (p.Gravity - h.MaxGravity)
+	}
+
+	// outside the range, lose 1 point for every degree
+	if p.Temperature < h.MinTemperature {
+		rating -= h.MinTemperature - p.Temperature
+	} else if p.Temperature > h.MaxTemperature {
+		rating -= p.Temperature - h.MaxTemperature
+	}
+
+	// outside the range, lose 20 points for every atmosphere
+	if p.Pressure < h.MinPressure {
+		rating -= 20 * (h.MinPressure - p.Pressure)
+	} else if p.Pressure > h.MaxPressure {
+		rating -= math.Min(40, 20*(p.Pressure-h.MaxPressure))
+	}
+
+	if p.Hydrographics < h.MinHydrographics {
+		rating -= (h.MinHydrographics - p.Hydrographics) / 2
+	}
+
+	return int(math.Max(0, math.Min(100, math.Round(rating))))
+}
+
+// Habitability rates a planet for a race, including the effects of the race's
+// life support and terraforming tech. The tech only improves worlds the race
+// could live on already. A world rated 0, which includes every asteroid belt,
+// stays at 0; a colony there lives in the habitats its infrastructure builds.
+func (g *Game_t) Habitability(race *Race_t, p *aow.Planet_t) int {
+	base := race.Habitability(p)
+	if base == 0 {
+		return 0
+	}
+	bonus := g.TechEffect(race, TechLifeSupport, EffectHabitability) + g.TechEffect(race, TechTerraforming, EffectHabitability)
+	return int(math.Min(100, float64(base)+math.Round(bonus)))
+}
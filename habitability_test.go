@@ -0,0 +1,42 @@
+// Copyright (c) 2024 Michael D Henderson. All rights reserved.
+
+package fargo
+
+import (
+	"github.com/playbymail/fargo/internal/aow"
+	"testing"
+)
+
+func TestHabitability(t *testing.T) {
+	home := &aow.Planet_t{Kind: aow.StandardTerrestrial, Gravity: 1, Atmosphere: aow.NitrogenOxygen, Pressure: 1, Hydrographics: 70, Temperature: 288}
+	g := &Game_t{TechTree: DefaultTechTree()}
+	race := &Race_t{Id: "R001", Habitat: NewHabitat(NewPRNG("test"), home), Tech: map[string]int{TechLifeSupport: 10, TechTerraforming: 10}}
+	if got := race.Habitability(home); got != 100 {
+		t.Errorf("home: want 100, got %d", got)
+	}
+
+	// an airless world the race could live on, with help
+	airless := *home
+	airless.Atmosphere = aow.NoAtmosphere
+	base := race.Habitability(&airless)
+	if base == 0 || base == 100 {
+		t.Fatalf("airless: want a rating between 0 and 100, got %d", base)
+	} else if got := g.Habitability(race, &airless); got <= base {
+		t.Errorf("airless: want tech to raise %d, got %d", base, got)
+	}
+
+	// tech doesn't make a world the race can't live on habitable
+	frozen := airless
+	frozen.Temperature = 40
+	belt := *home
+	belt.Kind = aow.AsteroidBelt
+	giant := *home
+	giant.Kind = aow.GasGiant
+	for name, p := range map[string]*aow.Planet_t{"frozen": &frozen, "belt": &belt, "gas giant": &giant} {
+		if got := race.Habitability(p); got != 0 {
+			t.Errorf("%s: base: want 0, got %d", name, got)
+		} else if got := g.Habitability(race, p); got != 0 {
+			t.Errorf("%s: with tech: want 0, got %d", name, got)
+		}
+	}
+}
@@ -5,7 +5,6 @@ package aow
 
 import (
 	"fmt"
-	"github.com/fogleman/gg"
 	"log"
 	"math"
 	"math/rand/v2"
@@ -13,12 +12,12 @@ import (
 )
 
 type Catalog_t struct {
-	Id          string
-	Name        string
-	Description string
+	Id          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
 
-	Radius      float64 // the radius of the map in parsecs
-	StarSystems []*StarSystem_t
+	Radius      float64         `json:"radius"` // the radius of the map in parsecs
+	StarSystems []*StarSystem_t `json:"star-systems"`
 }
 
 // NewSolClusterCatalog returns a generator initialized with the values for a sol-like cluster.
@@ -76,7 +75,7 @@ func NewSolClusterCatalog(n int, tweak float64, prng *rand.Rand) (*Catalog_t, er
 				Age: v.value.BaseAge + v.value.AgeRange*rollPercentile(prng),
 				// use the generated position for the star system
 				Coordinates: coords,
-				color:       StarColor_t(prng.IntN(int(YellowWhite))),
+				Color:       StarColor_t(prng.IntN(int(YellowWhite))),
 			})
 		}
 	}
@@ -94,10 +93,14 @@ func NewSolClusterCatalog(n int, tweak float64, prng *rand.Rand) (*Catalog_t, er
 		return catalog.StarSystems[i].distance < catalog.StarSystems[j].distance
 	})
 
+	// assign the permanent ids now that the order is fixed
 	for n, ss := range catalog.StarSystems {
-		log.Printf("aow: nsc: %4d: %8.3f %s", n+1, ss.distance, ss.Coordinates)
+		ss.Id = SystemId(n + 1)
+		log.Printf("aow: nsc: %s: %8.3f %s", ss.Id, ss.distance, ss.Coordinates)
 	}
 
+	catalog.generatePlanets(prng)
+
 	return &catalog, nil
 }
 
@@ -117,62 +120,3 @@ func (c *Catalog_t) closestNeighbor(coords Coordinates) *StarSystem_t {
 	return closest
 
 }
-
-// SaveAsPNG writes the catalog to a PNG file. The PNG file is a map
-// of the star systems in the catalog using the X and Y coordinates of
-// each star system. Each star system is colored based on its age.
-// The stars that are furthest away from the center of the map are
-// rendered first.
-func (c *Catalog_t) SaveAsPNG(filename string) error {
-	// Define the image size
-	width, height := 4*1024.0, 4*1024.0
-
-	//camera := Coordinates{}
-
-	// Create a new gg context
-	dc := gg.NewContext(int(width), int(height))
-
-	// Set the background color to black
-	dc.SetRGB(0, 0, 0)
-	dc.Clear()
-
-	// Find the maximum X and Y coordinates to scale the star positions
-	var maxX, maxY float64
-	for _, ss := range c.StarSystems {
-		if math.Abs(ss.Coordinates.X) > maxX {
-			maxX = math.Abs(ss.Coordinates.X)
-		}
-		if math.Abs(ss.Coordinates.Y) > maxY {
-			maxY = math.Abs(ss.Coordinates.Y)
-		}
-	}
-	// adjust the maximum X and Y to include a little extra space
-	maxX, maxY = maxX+4, maxY+4
-
-	// Sort star systems by distance from center (furthest first)
-	sort.Slice(c.StarSystems, func(i, j int) bool {
-		return c.StarSystems[i].distance > c.StarSystems[j].distance
-	})
-
-	// Draw the star systems
-	for _, ss := range c.StarSystems {
-		x := (ss.Coordinates.X + maxX) * width / (2 * maxX)
-		y := (ss.Coordinates.Y + maxY) * height / (2 * maxY)
-
-		// Color from the StarColor_t of the star system
-		rgba := ss.color.RGBA()
-		dc.SetRGBA255(int(rgba.R), int(rgba.G), int(rgba.B), int(rgba.A))
-
-		// Draw a filled circle with a radius of 9 pixels
-		dc.DrawCircle(x, y, 9)
-		dc.Fill()
-
-		// Add label showing Z value, position slightly to the right and below the star
-		//dc.SetRGB(1, 1, 1) // Set color to white
-		label := fmt.Sprintf("(%+.1f)", ss.Coordinates.Z)
-		dc.DrawString(label, x+8, y+6)
-	}
-
-	// Save the image as PNG
-	return dc.SavePNG(filename)
-}
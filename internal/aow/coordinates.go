@@ -5,12 +5,14 @@ package aow
 import (
 	"fmt"
 	"math"
+	"strconv"
+	"strings"
 )
 
 type Coordinates struct {
-	X float64
-	Y float64
-	Z float64
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+	Z float64 `json:"z"`
 }
 
 func (c Coordinates) DistanceBetween(o Coordinates) float64 {
@@ -38,6 +40,60 @@ func (c Coordinates) Translate(s Coordinates) Coordinates {
 	}
 }
 
+// Format returns the coordinates with a fixed number of digits after the decimal point.
+func (c Coordinates) Format(precision int) string {
+	return fmt.Sprintf("(%.*f %.*f %.*f)", precision, c.X, precision, c.Y, precision, c.Z)
+}
+
+// Rounded returns the coordinates rounded to the nearest integer.
+func (c Coordinates) Rounded() Coordinates {
+	return Coordinates{
+		X: math.Round(c.X),
+		Y: math.Round(c.Y),
+		Z: math.Round(c.Z),
+	}
+}
+
+// IntString returns the coordinates rounded to integers, which is
+// what players usually type into orders.
+func (c Coordinates) IntString() string {
+	r := c.Rounded()
+	return fmt.Sprintf("(%d %d %d)", int(r.X), int(r.Y), int(r.Z))
+}
+
 func (c Coordinates) String() string {
-	return fmt.Sprintf("(%.2g %.2g %.2g)", c.X, c.Y, c.Z)
+	return c.Format(1)
+}
+
+// ParseCoordinates accepts coordinates as "(x y z)", "x y z" or "x,y,z".
+func ParseCoordinates(s string) (Coordinates, error) {
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, "(") && strings.HasSuffix(s, ")") {
+		s = s[1 : len(s)-1]
+	}
+	fields := strings.FieldsFunc(s, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t'
+	})
+	if len(fields) != 3 {
+		return Coordinates{}, ErrInvalidCoordinates
+	}
+	var v [3]float64
+	for i, field := range fields {
+		f, err := strconv.ParseFloat(field, 64)
+		if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+			return Coordinates{}, ErrInvalidCoordinates
+		}
+		v[i] = f
+	}
+	return Coordinates{X: v[0], Y: v[1], Z: v[2]}, nil
+}
+
+// Toward returns the point that is the given distance from c along the line to o.
+// If o is closer than the distance, o is returned.
+func (c Coordinates) Toward(o Coordinates, distance float64) Coordinates {
+	d := c.DistanceTo(o)
+	if d <= distance {
+		return o
+	}
+	return c.Translate(o.Translate(c.Scale(-1)).Scale(distance / d))
 }
@@ -0,0 +1,17 @@
+// Copyright (c) 2024 Michael D Henderson. All rights reserved.
+
+package aow
+
+// Error defines a constant error
+type Error string
+
+// Error implements the Errors interface
+func (e Error) Error() string { return string(e) }
+
+const (
+	ErrInvalidCoordinates = Error("invalid coordinates")
+	ErrInvalidName        = Error("invalid name")
+	ErrNameTaken          = Error("name taken")
+	ErrNotOwner           = Error("not owner")
+	ErrUnknownSystem      = Error("unknown system")
+)
@@ -0,0 +1,69 @@
+// Copyright (c) 2024 Michael D Henderson. All rights reserved.
+
+package aow
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// CoordinateTolerance is how far, in light years, typed coordinates may be
+// from a system and still match it. It allows players to round coordinates
+// to integers. Systems are always further apart than twice this distance.
+const CoordinateTolerance = 0.9
+
+// SystemId returns the permanent id for the n-th system in a new catalog.
+// Numbers start at 1.
+func SystemId(n int) string {
+	return fmt.Sprintf("S%03d", n)
+}
+
+// Lookup finds a star system by id, designation, name or coordinates.
+// Ids and names are not case-sensitive, and ids may be typed without
+// the leading zeroes or without the "S" prefix.
+func (c *Catalog_t) Lookup(ref string) (*StarSystem_t, error) {
+	ref = strings.TrimSpace(ref)
+	if ref == "" {
+		return nil, ErrUnknownSystem
+	}
+	if ss := c.lookupById(ref); ss != nil {
+		return ss, nil
+	}
+	for _, ss := range c.StarSystems {
+		if strings.EqualFold(ss.Designation, ref) {
+			return ss, nil
+		}
+	}
+	for _, ss := range c.StarSystems {
+		if strings.EqualFold(ss.Name, ref) {
+			return ss, nil
+		}
+	}
+	if coords, err := ParseCoordinates(ref); err == nil {
+		if ss := c.closestNeighbor(coords); ss != nil && ss.Coordinates.DistanceTo(coords) <= CoordinateTolerance {
+			return ss, nil
+		}
+	}
+	return nil, fmt.Errorf("%q: %w", ref, ErrUnknownSystem)
+}
+
+// lookupById returns the system with the id, or nil if there is none.
+func (c *Catalog_t) lookupById(ref string) *StarSystem_t {
+	digits := ref
+	if len(digits) > 1 && (digits[0] == 'S' || digits[0] == 's') {
+		digits = digits[1:]
+	}
+	n, err := strconv.Atoi(digits)
+	if err != nil || n < 1 || n > math.MaxInt32 {
+		return nil
+	}
+	id := SystemId(n)
+	for _, ss := range c.StarSystems {
+		if ss.Id == id {
+			return ss
+		}
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+// Copyright (c) 2024 Michael D Henderson. All rights reserved.
+
+package aow
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseCoordinates(t *testing.T) {
+	for _, tc := range []struct {
+		text string
+		want Coordinates
+		ok   bool
+	}{
+		{"(1 -2 3.5)", Coordinates{1, -2, 3.5}, true},
+		{"1 -2 3.5", Coordinates{1, -2, 3.5}, true},
+		{"1,-2,3.5", Coordinates{1, -2, 3.5}, true},
+		{" ( 1, -2, 3.5 ) ", Coordinates{1, -2, 3.5}, true},
+		{"1 2", Coordinates{}, false},
+		{"1 2 3 4", Coordinates{}, false},
+		{"1 two 3", Coordinates{}, false},
+		{"1 NaN 3", Coordinates{}, false},
+		{"1 2 Inf", Coordinates{}, false},
+	} {
+		got, err := ParseCoordinates(tc.text)
+		if tc.ok && (err != nil || got != tc.want) {
+			t.Errorf("%q: want %v, got %v, %v", tc.text, tc.want, got, err)
+		} else if !tc.ok && !errors.Is(err, ErrInvalidCoordinates) {
+			t.Errorf("%q: want %v, got %v", tc.text, ErrInvalidCoordinates, err)
+		}
+	}
+}
+
+func TestFormatCoordinates(t *testing.T) {
+	c := Coordinates{X: 1.26, Y: -2.5, Z: 0.04}
+	if got := c.String(); got != "(1.3 -2.5 0.0)" {
+		t.Errorf("string: want (1.3 -2.5 0.0), got %s", got)
+	} else if got := c.IntString(); got != "(1 -3 0)" {
+		t.Errorf("int string: want (1 -3 0), got %s", got)
+	}
+	// what a player copies from a report finds the system again
+	back, err := ParseCoordinates(c.IntString())
+	if err != nil || back.DistanceTo(c) > CoordinateTolerance {
+		t.Errorf("round trip: %v is %g ly from %v", back, back.DistanceTo(c), c)
+	}
+}
+
+func TestLookup(t *testing.T) {
+	c := testCatalog(12)
+	c.AssignNames(nil, nil)
+	c.StarSystems[11].Name = "Vega"
+	want := c.StarSystems[11]
+	for _, ref := range []string{"S012", "s012", "S12", "12", "FGC 0012", "fgc 0012", "vega", " Vega ", "(36 0 0)", "36.5 0.4 -0.3"} {
+		if got, err := c.Lookup(ref); err != nil || got != want {
+			t.Errorf("%q: want %s, got %v, %v", ref, want.Id, got, err)
+		}
+	}
+	for _, ref := range []string{"", "S013", "0", "S", "Sirius", "(37 0 0)", "36 1 0"} {
+		if got, err := c.Lookup(ref); !errors.Is(err, ErrUnknownSystem) {
+			t.Errorf("%q: want %v, got %v, %v", ref, ErrUnknownSystem, got, err)
+		}
+	}
+}
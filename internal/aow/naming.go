@@ -0,0 +1,94 @@
+// Copyright (c) 2024 Michael D Henderson. All rights reserved.
+
+package aow
+
+import (
+	"github.com/playbymail/fargo/internal/names"
+	"math/rand/v2"
+	"strings"
+	"unicode"
+)
+
+// NameChange_t records a change to the name of a star system.
+type NameChange_t struct {
+	Turn    int    `json:"turn"`
+	Race    string `json:"race"`
+	OldName string `json:"old-name"`
+	NewName string `json:"new-name"`
+}
+
+// AssignNames gives every star system a catalog designation and a unique name.
+// The designation is based on the system's position in the catalog.
+// If the generator is nil, the designation is used as the name.
+func (c *Catalog_t) AssignNames(g names.Generator, r *rand.Rand) {
+	var u *names.Unique
+	if g != nil {
+		u = names.NewUnique(g, r)
+		// reserve the designations so that no generated name can collide with one
+		for n := range c.StarSystems {
+			u.Reserve(names.Designation(n + 1))
+		}
+	}
+	for n, ss := range c.StarSystems {
+		ss.Designation = names.Designation(n + 1)
+		if u == nil {
+			ss.Name = ss.Designation
+		} else {
+			ss.Name = u.Next(ss.Designation)
+		}
+	}
+}
+
+// NameIsTaken returns true if any system is already using the name, id or designation.
+// Names are not case-sensitive.
+func (c *Catalog_t) NameIsTaken(name string) bool {
+	if c.lookupById(name) != nil {
+		return true
+	}
+	for _, ss := range c.StarSystems {
+		if strings.EqualFold(ss.Name, name) || strings.EqualFold(ss.Designation, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Rename changes the name of a star system that the race owns.
+// The old name is kept in the system's name history.
+func (c *Catalog_t) Rename(ss *StarSystem_t, race, name string, turn int) error {
+	name = strings.TrimSpace(name)
+	if !IsValidName(name) {
+		return ErrInvalidName
+	} else if ss.Owner == "" || ss.Owner != race {
+		return ErrNotOwner
+	} else if strings.EqualFold(ss.Name, name) {
+		return nil
+	} else if c.NameIsTaken(name) {
+		return ErrNameTaken
+	}
+	ss.NameHistory = append(ss.NameHistory, &NameChange_t{
+		Turn:    turn,
+		Race:    race,
+		OldName: ss.Name,
+		NewName: name,
+	})
+	ss.Name = name
+	return nil
+}
+
+// IsValidName returns true if the name can be used for a star system.
+// Names start with a letter and contain only letters, digits, spaces,
+// hyphens and apostrophes. They may be at most 32 characters long.
+func IsValidName(name string) bool {
+	if name == "" || len(name) > 32 || name != strings.TrimSpace(name) {
+		return false
+	}
+	for i, ch := range name {
+		if i == 0 && !unicode.IsLetter(ch) {
+			return false
+		} else if !(unicode.IsLetter(ch) || unicode.IsDigit(ch) || ch == ' ' || ch == '-' || ch == '\'') {
+			return false
+		}
+	}
+	return true
+}
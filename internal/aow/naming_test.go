@@ -0,0 +1,78 @@
+// Copyright (c) 2024 Michael D Henderson. All rights reserved.
+
+package aow
+
+import (
+	"errors"
+	"github.com/playbymail/fargo/internal/names"
+	"math/rand/v2"
+	"strings"
+	"testing"
+)
+
+func testCatalog(n int) *Catalog_t {
+	c := &Catalog_t{}
+	for i := 1; i <= n; i++ {
+		c.StarSystems = append(c.StarSystems, &StarSystem_t{Id: SystemId(i), Coordinates: Coordinates{X: float64(3 * i)}})
+	}
+	return c
+}
+
+func TestAssignNames(t *testing.T) {
+	g, err := names.NewGenerator("classical", "syllable")
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, b := testCatalog(30), testCatalog(30)
+	a.AssignNames(g, rand.New(rand.NewPCG(1, 2)))
+	b.AssignNames(g, rand.New(rand.NewPCG(1, 2)))
+	seen := map[string]bool{}
+	for i, ss := range a.StarSystems {
+		if ss.Name != b.StarSystems[i].Name {
+			t.Errorf("%s: same seed gave %q and %q", ss.Id, ss.Name, b.StarSystems[i].Name)
+		} else if ss.Designation != names.Designation(i+1) {
+			t.Errorf("%s: want designation %q, got %q", ss.Id, names.Designation(i+1), ss.Designation)
+		} else if seen[strings.ToLower(ss.Name)] {
+			t.Errorf("%s: %q used twice", ss.Id, ss.Name)
+		}
+		seen[strings.ToLower(ss.Name)] = true
+	}
+
+	c := testCatalog(2)
+	c.AssignNames(nil, nil)
+	if ss := c.StarSystems[1]; ss.Name != ss.Designation {
+		t.Errorf("no generator: want the designation %q, got %q", ss.Designation, ss.Name)
+	}
+}
+
+func TestRename(t *testing.T) {
+	c := testCatalog(3)
+	c.AssignNames(nil, nil)
+	ss := c.StarSystems[0]
+	ss.Owner = "R001"
+	for _, tc := range []struct {
+		race, name string
+		want       error
+	}{
+		{"R002", "New Hope", ErrNotOwner},
+		{"R001", "1st Hope", ErrInvalidName},
+		{"R001", "Hope!", ErrInvalidName},
+		{"R001", strings.Repeat("x", 33), ErrInvalidName},
+		{"R001", c.StarSystems[1].Name, ErrNameTaken},
+		{"R001", "s3", ErrNameTaken},
+		{"R001", " New Hope ", nil},
+	} {
+		if err := c.Rename(ss, tc.race, tc.name, 4); !errors.Is(err, tc.want) {
+			t.Errorf("%s %q: want %v, got %v", tc.race, tc.name, tc.want, err)
+		}
+	}
+	if ss.Name != "New Hope" || len(ss.NameHistory) != 1 {
+		t.Fatalf("rename: want New Hope with 1 change, got %q with %d", ss.Name, len(ss.NameHistory))
+	} else if h := ss.NameHistory[0]; h.Turn != 4 || h.Race != "R001" || h.OldName != names.Designation(1) || h.NewName != "New Hope" {
+		t.Errorf("history: got %+v", h)
+	}
+	// the same name in another case changes nothing
+	if err := c.Rename(ss, "R001", "new hope", 5); err != nil || len(ss.NameHistory) != 1 {
+		t.Errorf("same name: want no change, got %v, %d changes", err, len(ss.NameHistory))
+	}
+}
@@ -0,0 +1,307 @@
+// Copyright (c) 2024 Michael D Henderson. All rights reserved.
+
+package aow
+
+import (
+	"math"
+	"math/rand/v2"
+)
+
+// functions to create the planets for each star system.
+// these follow the outline of the Architect of Worlds procedures,
+// simplified to the attributes the game needs.
+
+// PlanetKind_e is the broad class of a world.
+type PlanetKind_e int
+
+const (
+	AsteroidBelt PlanetKind_e = iota
+	TinyTerrestrial
+	SmallTerrestrial
+	StandardTerrestrial
+	LargeTerrestrial
+	GasGiant
+)
+
+func (k PlanetKind_e) String() string {
+	switch k {
+	case AsteroidBelt:
+		return "asteroid belt"
+	case TinyTerrestrial:
+		return "tiny terrestrial"
+	case SmallTerrestrial:
+		return "small terrestrial"
+	case StandardTerrestrial:
+		return "standard terrestrial"
+	case LargeTerrestrial:
+		return "large terrestrial"
+	case GasGiant:
+		return "gas giant"
+	}
+	return "unknown"
+}
+
+// Atmosphere_e is the main composition of an atmosphere.
+type Atmosphere_e int
+
+const (
+	NoAtmosphere Atmosphere_e = iota
+	TraceAtmosphere
+	CarbonDioxide
+	Nitrogen
+	NitrogenOxygen // breathable by most carbon-based life
+	Methane
+	HydrogenHelium
+)
+
+func (a Atmosphere_e) String() string {
+	switch a {
+	case NoAtmosphere:
+		return "none"
+	case TraceAtmosphere:
+		return "trace"
+	case CarbonDioxide:
+		return "carbon dioxide"
+	case Nitrogen:
+		return "nitrogen"
+	case NitrogenOxygen:
+		return "nitrogen-oxygen"
+	case Methane:
+		return "methane"
+	case HydrogenHelium:
+		return "hydrogen-helium"
+	}
+	return "unknown"
+}
+
+// Planet_t is a world orbiting a star. Mass, diameter, density and gravity
+// are relative to Earth.
+type Planet_t struct {
+	Orbit         int          `json:"orbit"`           // position from the star, starting at 1
+	Distance      float64      `json:"distance"`        // from the star, in AU
+	Kind          PlanetKind_e `json:"kind"`            //
+	Mass          float64      `json:"mass"`            // Earth = 1
+	Diameter      float64      `json:"diameter"`        // Earth = 1
+	Density       float64      `json:"density"`         // Earth = 1
+	Gravity       float64      `json:"gravity"`         // Earth = 1
+	Atmosphere    Atmosphere_e `json:"atmosphere"`      //
+	Pressure      float64      `json:"pressure"`        // in atmospheres
+	Hydrographics float64      `json:"hydrographics"`   // percent of the surface covered by liquid water
+	Temperature   float64      `json:"temperature"`     // average surface temperature in Kelvin
+	Minerals      float64      `json:"minerals"`        // abundance of metals and ores, 0 to 100
+	Energy        float64      `json:"energy"`          // potential for solar, geothermal and fuel production, 0 to 100
+	Biology       float64      `json:"biology"`         // potential for farming and native life, 0 to 100
+	Owner         string       `json:"owner,omitempty"` // id of the race with a colony on the planet
+}
+
+// IsSolid returns true if the planet has a surface that can be settled.
+func (p *Planet_t) IsSolid() bool {
+	return p.Kind != GasGiant
+}
+
+// starAttributes returns the mass and luminosity, in solar units, for a star of the given color.
+func starAttributes(r *rand.Rand, color StarColor_t) (mass, luminosity float64) {
+	switch color {
+	case BlueWhite:
+		mass = 1.6 + 0.8*r.Float64()
+	case YellowWhite:
+		mass = 1.1 + 0.5*r.Float64()
+	case Yellow:
+		mass = 0.8 + 0.3*r.Float64()
+	case Orange:
+		mass = 0.45 + 0.35*r.Float64()
+	case Red:
+		mass = 0.1 + 0.35*r.Float64()
+	case White:
+		// white dwarfs are dense remnants with very little light
+		mass = 0.5 + 0.3*r.Float64()
+		return mass, 0.0005 + 0.002*r.Float64()
+	default:
+		// grey stars are brown dwarfs
+		mass = 0.02 + 0.06*r.Float64()
+		return mass, 0.00001 + 0.0001*r.Float64()
+	}
+	// main sequence stars follow the mass-luminosity relation
+	return mass, math.Pow(mass, 3.5)
+}
+
+// generatePlanets creates the star's attributes and the planets for every system.
+func (c *Catalog_t) generatePlanets(r *rand.Rand) {
+	for _, ss := range c.StarSystems {
+		ss.Mass, ss.Luminosity = starAttributes(r, ss.Color)
+		ss.Planets = generatePlanets(r, ss)
+	}
+}
+
+func generatePlanets(r *rand.Rand, ss *StarSystem_t) []*Planet_t {
+	// the snow line separates rocky worlds from the gas giants
+	snowLine := 2.7 * math.Sqrt(ss.Mass)
+
+	numberOfOrbits := int(rollD6(r, 2)) - 2
+	if ss.Color == White || ss.Color == Grey {
+		numberOfOrbits /= 2
+	}
+
+	var planets []*Planet_t
+	distance := (0.1 + 0.3*r.Float64()) * ss.Mass
+	for orbit := 1; orbit <= numberOfOrbits; orbit++ {
+		p := &Planet_t{Orbit: orbit, Distance: distance}
+		p.Kind = planetKind(r, distance, snowLine)
+		p.Mass, p.Density = planetMass(r, p.Kind)
+		p.Diameter = math.Cbrt(p.Mass / p.Density)
+		p.Gravity = p.Mass / (p.Diameter * p.Diameter)
+
+		// blackbody temperature, before any greenhouse effect
+		blackbody := 278 * math.Pow(ss.Luminosity, 0.25) / math.Sqrt(distance)
+		p.Atmosphere, p.Pressure = planetAtmosphere(r, p, blackbody, ss.Age)
+		p.Temperature = blackbody * greenhouse(p.Atmosphere, p.Pressure)
+		p.Hydrographics = planetHydrographics(r, p)
+		p.Minerals, p.Energy, p.Biology = planetResources(r, p, ss.Luminosity/(distance*distance))
+
+		planets = append(planets, p)
+
+		// orbits are spaced in a rough geometric series
+		distance *= 1.4 + 0.6*r.Float64()
+	}
+	return planets
+}
+
+func planetKind(r *rand.Rand, distance, snowLine float64) PlanetKind_e {
+	roll := rollD6(r, 3)
+	if distance < snowLine {
+		switch {
+		case roll <= 5:
+			return AsteroidBelt
+		case roll <= 8:
+			return TinyTerrestrial
+		case roll <= 11:
+			return SmallTerrestrial
+		case roll <= 15:
+			return StandardTerrestrial
+		}
+		return LargeTerrestrial
+	}
+	// the first orbits past the snow line are the most likely to hold a gas giant
+	if distance < 3*snowLine && roll >= 8 {
+		return GasGiant
+	} else if roll >= 12 {
+		return GasGiant
+	} else if roll <= 6 {
+		return AsteroidBelt
+	}
+	return TinyTerrestrial
+}
+
+// planetMass returns the mass and density of a planet, relative to Earth.
+func planetMass(r *rand.Rand, kind PlanetKind_e) (mass, density float64) {
+	density = 0.8 + 0.3*r.Float64()
+	switch kind {
+	case AsteroidBelt:
+		return 0.0001 + 0.001*r.Float64(), 0.5 + 0.2*r.Float64()
+	case TinyTerrestrial:
+		return 0.01 + 0.07*r.Float64(), density
+	case SmallTerrestrial:
+		return 0.08 + 0.32*r.Float64(), density
+	case StandardTerrestrial:
+		return 0.4 + 1.2*r.Float64(), density
+	case LargeTerrestrial:
+		return 1.6 + 6.4*r.Float64(), density * 1.1
+	}
+	return 15 + 585*r.Float64()*r.Float64(), 0.1 + 0.2*r.Float64()
+}
+
+// planetAtmosphere returns the composition and pressure of the atmosphere.
+// Small worlds can't hold much atmosphere, and free oxygen needs a warm world
+// that has had life for a few billion years.
+func planetAtmosphere(r *rand.Rand, p *Planet_t, blackbody, age float64) (Atmosphere_e, float64) {
+	switch p.Kind {
+	case AsteroidBelt, TinyTerrestrial:
+		return NoAtmosphere, 0
+	case GasGiant:
+		return HydrogenHelium, 1000
+	case SmallTerrestrial:
+		if blackbody < 150 {
+			return Nitrogen, 0.1 + 0.4*r.Float64()
+		}
+		return TraceAtmosphere, 0.01 * r.Float64()
+	}
+
+	// standard and large worlds
+	pressure := p.Mass * (0.5 + r.Float64())
+	if p.Kind == LargeTerrestrial {
+		pressure *= 2
+	}
+	switch {
+	case blackbody < 150:
+		return Methane, pressure
+	case blackbody > 330:
+		return CarbonDioxide, pressure * 10
+	case blackbody >= 220 && blackbody <= 310 && age > 2 && rollD6(r, 3) >= 9:
+		return NitrogenOxygen, pressure
+	case rollD6(r, 1) <= 3:
+		return CarbonDioxide, pressure
+	}
+	return Nitrogen, pressure
+}
+
+// greenhouse returns the factor that converts the blackbody temperature to
+// the average surface temperature.
+func greenhouse(atmosphere Atmosphere_e, pressure float64) float64 {
+	switch atmosphere {
+	case CarbonDioxide:
+		return 1 + 0.5*math.Min(pressure, 2)
+	case Methane:
+		return 1 + 0.2*math.Min(pressure, 2)
+	case Nitrogen, NitrogenOxygen:
+		return 1 + 0.08*math.Min(pressure, 3)
+	}
+	return 1
+}
+
+// planetHydrographics returns the percent of the surface covered by liquid water.
+func planetHydrographics(r *rand.Rand, p *Planet_t) float64 {
+	if !p.IsSolid() || p.Pressure < 0.1 || p.Temperature < 273 || p.Temperature > 373 {
+		return 0
+	}
+	hydro := 10 * (rollD6(r, 2) - 2)
+	if p.Kind == LargeTerrestrial {
+		hydro += 10
+	}
+	return math.Max(0, math.Min(100, hydro+10*r.Float64()))
+}
+
+// planetResources returns the natural resources of a planet, each from 0 to 100.
+// Flux is the light the planet receives, relative to Earth.
+func planetResources(r *rand.Rand, p *Planet_t, flux float64) (minerals, energy, biology float64) {
+	clamp := func(f float64) float64 {
+		return math.Max(0, math.Min(100, math.Round(f)))
+	}
+
+	switch p.Kind {
+	case AsteroidBelt:
+		minerals = 60 + 40*r.Float64()
+	case GasGiant:
+		// the surface is out of reach, but the atmosphere is full of fuel
+		minerals = 5 * r.Float64()
+		energy = 60 + 40*r.Float64()
+		return clamp(minerals), clamp(energy), 0
+	default:
+		// denser worlds have more heavy metals
+		minerals = 40*p.Density + 40*r.Float64()
+	}
+
+	// solar power, plus some geothermal power on larger worlds
+	energy = 30*math.Sqrt(flux) + 10*math.Min(p.Mass, 2) + 20*r.Float64()
+
+	// life needs liquid water and a reasonable temperature
+	if p.Hydrographics > 0 && p.Temperature > 250 && p.Temperature < 330 {
+		biology = 20 + p.Hydrographics/2 + 10*r.Float64()
+		if p.Atmosphere == NitrogenOxygen {
+			biology += 30
+		}
+	} else {
+		biology = 5 * r.Float64()
+	}
+
+	return clamp(minerals), clamp(energy), clamp(biology)
+}
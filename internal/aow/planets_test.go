@@ -0,0 +1,55 @@
+// Copyright (c) 2024 Michael D Henderson. All rights reserved.
+
+package aow
+
+import (
+	"encoding/json"
+	"math/rand/v2"
+	"testing"
+)
+
+func TestGeneratePlanets(t *testing.T) {
+	newCatalog := func() *Catalog_t {
+		c := testCatalog(40)
+		for i, ss := range c.StarSystems {
+			ss.Color, ss.Age = StarColor_t(i%7), 4.5
+		}
+		c.generatePlanets(rand.New(rand.NewPCG(5, 6)))
+		return c
+	}
+	c := newCatalog()
+	a, _ := json.Marshal(c)
+	b, _ := json.Marshal(newCatalog())
+	if string(a) != string(b) {
+		t.Fatal("planets: the same seed gave different planets")
+	}
+
+	var planets int
+	for _, ss := range c.StarSystems {
+		if !(ss.Mass > 0) || !(ss.Luminosity > 0) {
+			t.Errorf("%s: want a positive mass and luminosity, got %g and %g", ss.Id, ss.Mass, ss.Luminosity)
+		}
+		for i, p := range ss.Planets {
+			planets++
+			if p.Orbit != i+1 {
+				t.Errorf("%s: planet %d: want orbit %d, got %d", ss.Id, i, i+1, p.Orbit)
+			} else if i > 0 && p.Distance <= ss.Planets[i-1].Distance {
+				t.Errorf("%s: orbit %d: not further out than orbit %d", ss.Id, p.Orbit, i)
+			}
+			if !(p.Mass > 0) || !(p.Gravity > 0) || !(p.Temperature > 0) {
+				t.Errorf("%s: orbit %d: want positive mass, gravity and temperature, got %+v", ss.Id, p.Orbit, p)
+			}
+			for name, v := range map[string]float64{"hydrographics": p.Hydrographics, "minerals": p.Minerals, "energy": p.Energy, "biology": p.Biology} {
+				if v < 0 || v > 100 {
+					t.Errorf("%s: orbit %d: %s %g is outside 0 to 100", ss.Id, p.Orbit, name, v)
+				}
+			}
+			if p.IsSolid() == (p.Kind == GasGiant) {
+				t.Errorf("%s: orbit %d: %s: solid is %v", ss.Id, p.Orbit, p.Kind, p.IsSolid())
+			}
+		}
+	}
+	if planets == 0 {
+		t.Errorf("planets: want some, got none")
+	}
+}
@@ -0,0 +1,68 @@
+// Copyright (c) 2024 Michael D Henderson. All rights reserved.
+
+package aow
+
+import (
+	"math"
+	"sort"
+)
+
+// SpatialIndex_t finds the systems near a point without looking at every
+// system in the catalog. Space is divided into cubes and each system is
+// filed under the cube that holds it.
+type SpatialIndex_t struct {
+	size  float64 // length of the side of a cube, in light years
+	cells map[[3]int][]*StarSystem_t
+}
+
+// NewSpatialIndex indexes the systems in the catalog. The cell size should be
+// close to the radius of the queries that will be made.
+func NewSpatialIndex(c *Catalog_t, cellSize float64) *SpatialIndex_t {
+	if !(cellSize > 0) {
+		cellSize = 1
+	}
+	idx := &SpatialIndex_t{size: cellSize, cells: make(map[[3]int][]*StarSystem_t)}
+	for _, ss := range c.StarSystems {
+		cell := idx.cell(ss.Coordinates)
+		idx.cells[cell] = append(idx.cells[cell], ss)
+	}
+	return idx
+}
+
+func (idx *SpatialIndex_t) cell(c Coordinates) [3]int {
+	return [3]int{
+		int(math.Floor(c.X / idx.size)),
+		int(math.Floor(c.Y / idx.size)),
+		int(math.Floor(c.Z / idx.size)),
+	}
+}
+
+// Within returns the systems that are no more than the radius from the point,
+// sorted by distance and then by id.
+func (idx *SpatialIndex_t) Within(center Coordinates, radius float64) []*StarSystem_t {
+	if radius < 0 {
+		return nil
+	}
+	lo := idx.cell(Coordinates{X: center.X - radius, Y: center.Y - radius, Z: center.Z - radius})
+	hi := idx.cell(Coordinates{X: center.X + radius, Y: center.Y + radius, Z: center.Z + radius})
+	var list []*StarSystem_t
+	for x := lo[0]; x <= hi[0]; x++ {
+		for y := lo[1]; y <= hi[1]; y++ {
+			for z := lo[2]; z <= hi[2]; z++ {
+				for _, ss := range idx.cells[[3]int{x, y, z}] {
+					if center.DistanceTo(ss.Coordinates) <= radius {
+						list = append(list, ss)
+					}
+				}
+			}
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		di, dj := center.DistanceTo(list[i].Coordinates), center.DistanceTo(list[j].Coordinates)
+		if di != dj {
+			return di < dj
+		}
+		return list[i].Id < list[j].Id
+	})
+	return list
+}
@@ -5,11 +5,19 @@ package aow
 import "image/color"
 
 type StarSystem_t struct {
-	Population  StellarPopulation_e
-	Age         float64     // in billions of years?
-	Coordinates Coordinates // relative to center of the catalog
-	distance    float64     // working storage for some calculations
-	color       StarColor_t
+	Id          string              `json:"id"` // permanent short id, assigned when the catalog is created
+	Name        string              `json:"name"`
+	Designation string              `json:"designation"`     // catalog-style designation, never changes
+	Owner       string              `json:"owner,omitempty"` // id of the race that controls the system
+	NameHistory []*NameChange_t     `json:"name-history,omitempty"`
+	Population  StellarPopulation_e `json:"population"`
+	Age         float64             `json:"age"`         // in billions of years?
+	Coordinates Coordinates         `json:"coordinates"` // relative to center of the catalog
+	Color       StarColor_t         `json:"color"`
+	Mass        float64             `json:"mass"`       // in solar masses
+	Luminosity  float64             `json:"luminosity"` // relative to Sol
+	Planets     []*Planet_t         `json:"planets,omitempty"`
+	distance    float64             // working storage for some calculations
 }
 
 func (ss *StarSystem_t) DistanceTo(os *StarSystem_t) float64 {
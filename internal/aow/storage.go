@@ -0,0 +1,43 @@
+// Copyright (c) 2024 Michael D Henderson. All rights reserved.
+
+package aow
+
+import (
+	"encoding/json"
+	"os"
+)
+
+// LoadCatalog reads a catalog from a JSON file.
+// The working storage for each star system is restored after loading.
+func LoadCatalog(filename string) (*Catalog_t, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return ParseCatalog(data)
+}
+
+// ParseCatalog reads a catalog from JSON.
+// The working storage for each star system is restored after loading.
+func ParseCatalog(data []byte) (*Catalog_t, error) {
+	var catalog Catalog_t
+	if err := json.Unmarshal(data, &catalog); err != nil {
+		return nil, err
+	}
+
+	var center Coordinates
+	for _, ss := range catalog.StarSystems {
+		ss.distance = ss.Coordinates.DistanceTo(center)
+	}
+
+	return &catalog, nil
+}
+
+// SaveAsJSON writes the catalog to a JSON file.
+func (c *Catalog_t) SaveAsJSON(filename string) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filename, data, 0644)
+}
@@ -0,0 +1,204 @@
+// Copyright (c) 2024 Michael D Henderson. All rights reserved.
+
+// Package mail reads orders from local mailboxes and writes outgoing mail.
+package mail
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Message_t is a message read from a mailbox.
+type Message_t struct {
+	Id      string // the Message-ID header, or a hash of the message if it has none
+	From    *mail.Address
+	Subject string
+	Body    string // the first plain text part of the message
+	Err     error  // set if the message couldn't be parsed; only the id is known
+
+	path string // file holding the message, for a maildir
+}
+
+// ReadMailbox reads every new message in a maildir or an mbox file.
+// A directory is read as a maildir and a file as an mbox. A message that
+// can't be parsed is returned with Err set, so that one bad message
+// doesn't keep the others from being read.
+func ReadMailbox(name string) ([]*Message_t, error) {
+	sb, err := os.Stat(name)
+	if err != nil {
+		return nil, err
+	} else if sb.IsDir() {
+		return ReadMaildir(name)
+	}
+	return ReadMbox(name)
+}
+
+// ReadMaildir reads the messages in the maildir's "new" directory, oldest first.
+func ReadMaildir(dir string) ([]*Message_t, error) {
+	entries, err := os.ReadDir(filepath.Join(dir, "new"))
+	if err != nil {
+		return nil, err
+	}
+	// maildir names start with the time the message was delivered
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name() < entries[j].Name()
+	})
+	var list []*Message_t
+	for _, entry := range entries {
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		name := filepath.Join(dir, "new", entry.Name())
+		data, err := os.ReadFile(name)
+		if err != nil {
+			return nil, err
+		}
+		msg, err := Parse(data)
+		if err != nil {
+			msg = unreadable(data, fmt.Errorf("%s: %w", name, err))
+		}
+		msg.path = name
+		list = append(list, msg)
+	}
+	return list, nil
+}
+
+// ReadMbox reads every message in an mbox file.
+func ReadMbox(name string) ([]*Message_t, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	var list []*Message_t
+	var buf bytes.Buffer
+	flush := func() {
+		if buf.Len() == 0 {
+			return
+		}
+		msg, err := Parse(buf.Bytes())
+		if err != nil {
+			msg = unreadable(buf.Bytes(), fmt.Errorf("%s: message %d: %w", name, len(list)+1, err))
+		}
+		list = append(list, msg)
+		buf.Reset()
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "From ") {
+			flush()
+			continue
+		}
+		// lines that start with "From " in a body are quoted with '>'
+		if strings.HasPrefix(line, ">") && strings.HasPrefix(strings.TrimLeft(line, ">"), "From ") {
+			line = line[1:]
+		}
+		buf.WriteString(line)
+		buf.WriteString("\r\n")
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	flush()
+	return list, nil
+}
+
+// Parse reads a message and finds its plain text body.
+func Parse(data []byte) (*Message_t, error) {
+	m, err := mail.ReadMessage(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	msg := &Message_t{Id: messageId(data, m.Header)}
+	if msg.From, err = mail.ParseAddress(m.Header.Get("From")); err != nil {
+		return nil, fmt.Errorf("from: %w", err)
+	}
+	if msg.Subject, err = new(mime.WordDecoder).DecodeHeader(m.Header.Get("Subject")); err != nil {
+		msg.Subject = m.Header.Get("Subject")
+	}
+	body, err := plainText(m.Header.Get("Content-Type"), m.Header.Get("Content-Transfer-Encoding"), m.Body)
+	if err != nil {
+		return nil, err
+	}
+	msg.Body = strings.ReplaceAll(body, "\r\n", "\n")
+	return msg, nil
+}
+
+// unreadable returns a message that couldn't be parsed. The id is taken
+// from the header if there is one, so that the message is only reported once.
+func unreadable(data []byte, err error) *Message_t {
+	var header mail.Header
+	if m, rerr := mail.ReadMessage(bytes.NewReader(data)); rerr == nil {
+		header = m.Header
+	}
+	return &Message_t{Id: messageId(data, header), Err: err}
+}
+
+// messageId returns the Message-ID header, or a hash of the message if it has none.
+func messageId(data []byte, header mail.Header) string {
+	if id := strings.Trim(header.Get("Message-Id"), "<> "); id != "" {
+		return id
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// Done marks a message from a maildir as read by moving it to "cur".
+// Messages from an mbox are left where they are.
+func (m *Message_t) Done() error {
+	if m.path == "" {
+		return nil
+	}
+	dir := filepath.Dir(filepath.Dir(m.path))
+	return os.Rename(m.path, filepath.Join(dir, "cur", filepath.Base(m.path)+":2,S"))
+}
+
+// plainText returns the first text/plain part of a body.
+func plainText(contentType, encoding string, r io.Reader) (string, error) {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if contentType == "" || err != nil {
+		mediaType = "text/plain"
+	}
+	if strings.HasPrefix(mediaType, "multipart/") {
+		mr := multipart.NewReader(r, params["boundary"])
+		for {
+			part, err := mr.NextRawPart()
+			if errors.Is(err, io.EOF) {
+				return "", ErrNoPlainText
+			} else if err != nil {
+				return "", err
+			}
+			text, err := plainText(part.Header.Get("Content-Type"), part.Header.Get("Content-Transfer-Encoding"), part)
+			if err == nil {
+				return text, nil
+			} else if !errors.Is(err, ErrNoPlainText) {
+				return "", err
+			}
+		}
+	} else if mediaType != "text/plain" {
+		return "", ErrNoPlainText
+	}
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "quoted-printable":
+		r = quotedprintable.NewReader(r)
+	case "base64":
+		r = base64.NewDecoder(base64.StdEncoding, r)
+	}
+	data, err := io.ReadAll(r)
+	return string(data), err
+}
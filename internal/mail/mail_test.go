@@ -0,0 +1,141 @@
+// Copyright (c) 2024 Michael D Henderson. All rights reserved.
+
+package mail
+
+import (
+	"errors"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const goodMessage = "From: Player One <one@example.com>\r\nMessage-Id: <good@example.com>\r\nSubject: orders\r\n\r\nrace R001 secret\r\n"
+
+// the From header has no address
+const badMessage = "From: nobody\r\nMessage-Id: <bad@example.com>\r\nSubject: orders\r\n\r\nrace R002 secret\r\n"
+
+func TestReadMaildirBadMessage(t *testing.T) {
+	dir := t.TempDir()
+	for _, sub := range []string{"new", "cur", "tmp"} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(dir, "new", "1.bad"), []byte(badMessage), 0644); err != nil {
+		t.Fatal(err)
+	} else if err := os.WriteFile(filepath.Join(dir, "new", "2.good"), []byte(goodMessage), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	list, err := ReadMailbox(dir)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(list) != 2 {
+		t.Fatalf("messages: want 2, got %d", len(list))
+	}
+	if bad := list[0]; bad.Err == nil {
+		t.Errorf("bad: want an error")
+	} else if bad.Id != "bad@example.com" {
+		t.Errorf("bad: id: want %q, got %q", "bad@example.com", bad.Id)
+	} else if err := bad.Done(); err != nil {
+		t.Fatal(err)
+	}
+	if good := list[1]; good.Err != nil {
+		t.Errorf("good: %v", good.Err)
+	} else if good.From.Address != "one@example.com" || !strings.HasPrefix(good.Body, "race R001") {
+		t.Errorf("good: got %q %q", good.From.Address, good.Body)
+	}
+
+	// the bad message has been moved out of the way
+	if list, err := ReadMailbox(dir); err != nil {
+		t.Fatal(err)
+	} else if len(list) != 1 {
+		t.Errorf("messages: want 1 after Done, got %d", len(list))
+	}
+}
+
+func TestReadMboxBadMessage(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "mbox")
+	data := "From one@example.com Mon Jan  1 00:00:00 2024\n" + strings.ReplaceAll(badMessage, "\r\n", "\n") +
+		"From one@example.com Mon Jan  1 00:00:01 2024\n" + strings.ReplaceAll(goodMessage, "\r\n", "\n")
+	if err := os.WriteFile(name, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	list, err := ReadMailbox(name)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(list) != 2 {
+		t.Fatalf("messages: want 2, got %d", len(list))
+	} else if list[0].Err == nil || list[1].Err != nil {
+		t.Errorf("errors: want bad then good, got %v, %v", list[0].Err, list[1].Err)
+	}
+}
+
+func TestParse(t *testing.T) {
+	multi := "From: one@example.com\r\nSubject: =?utf-8?q?orders_=E2=9C=93?=\r\nMIME-Version: 1.0\r\n" +
+		"Content-Type: multipart/alternative; boundary=\"b1\"\r\n\r\n" +
+		"--b1\r\nContent-Type: text/html\r\n\r\n<p>race R001</p>\r\n" +
+		"--b1\r\nContent-Type: text/plain; charset=utf-8\r\nContent-Transfer-Encoding: quoted-printable\r\n\r\n" +
+		"race R001 secret\r\nmessage all \"caf=C3=A9 at the =\r\ngate\"\r\n" +
+		"--b1--\r\n"
+	msg, err := Parse([]byte(multi))
+	if err != nil {
+		t.Fatal(err)
+	} else if msg.Subject != "orders \u2713" {
+		t.Errorf("subject: got %q", msg.Subject)
+	} else if want := "race R001 secret\nmessage all \"caf\u00e9 at the gate\""; msg.Body != want {
+		t.Errorf("body: want %q, got %q", want, msg.Body)
+	}
+	// a message without a Message-ID is known by its hash
+	if again, err := Parse([]byte(multi)); err != nil || msg.Id == "" || again.Id != msg.Id {
+		t.Errorf("id: want the same hash twice, got %q and %q", msg.Id, again.Id)
+	}
+
+	encoded := "From: one@example.com\r\nContent-Type: text/plain\r\nContent-Transfer-Encoding: base64\r\n\r\ncmFjZSBSMDAxCg==\r\n"
+	if msg, err := Parse([]byte(encoded)); err != nil {
+		t.Fatal(err)
+	} else if msg.Body != "race R001\n" {
+		t.Errorf("base64: got %q", msg.Body)
+	}
+
+	html := "From: one@example.com\r\nContent-Type: text/html\r\n\r\n<p>race R001</p>\r\n"
+	if _, err := Parse([]byte(html)); !errors.Is(err, ErrNoPlainText) {
+		t.Errorf("html: want %v, got %v", ErrNoPlainText, err)
+	}
+}
+
+func TestReply(t *testing.T) {
+	msg, err := Parse([]byte(goodMessage))
+	if err != nil {
+		t.Fatal(err)
+	}
+	gm := &mail.Address{Name: "Fargo GM", Address: "gm@fargo.example.com"}
+	out := Reply(msg, gm, "orders accepted for turn 3\nline two is long enough that quoted-printable has to wrap it somewhere past the seventy sixth column\n")
+	if out.Subject != "Re: orders" || out.InReplyTo != "good@example.com" || out.To.Address != "one@example.com" {
+		t.Fatalf("reply: got %+v", out)
+	} else if again := Reply(&Message_t{Subject: "RE: orders", From: msg.From}, gm, ""); again.Subject != "RE: orders" {
+		t.Errorf("reply: want no second Re:, got %q", again.Subject)
+	}
+
+	name, err := out.Spool(filepath.Join(t.TempDir(), "outbox"))
+	if err != nil {
+		t.Fatal(err)
+	} else if !strings.HasSuffix(out.Id, "@fargo.example.com") {
+		t.Errorf("id: want one in the sender's domain, got %q", out.Id)
+	}
+	data, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	} else if !strings.Contains(string(data), "\r\nIn-Reply-To: <good@example.com>\r\n") {
+		t.Errorf("spool: want an In-Reply-To header, got\n%s", data)
+	}
+	// what was spooled reads back as it was written
+	back, err := Parse(data)
+	if err != nil {
+		t.Fatal(err)
+	} else if back.Id != out.Id || back.From.Address != gm.Address || back.Subject != out.Subject || back.Body != out.Body {
+		t.Errorf("spool: got %+v", back)
+	}
+}
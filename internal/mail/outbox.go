@@ -0,0 +1,144 @@
+// Copyright (c) 2024 Michael D Henderson. All rights reserved.
+
+package mail
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Outgoing_t is a message waiting to be sent.
+type Outgoing_t struct {
+	Id        string // the Message-ID, set when the message is spooled
+	From      *mail.Address
+	To        *mail.Address
+	Subject   string
+	InReplyTo string // Message-ID of the message this answers, if any
+	Date      time.Time
+	Body      string
+	Files     []*Attachment_t
+}
+
+// Attachment_t is a file sent with a message.
+type Attachment_t struct {
+	Name        string
+	ContentType string
+	Data        []byte
+}
+
+// Reply returns a message that answers the message.
+func Reply(msg *Message_t, from *mail.Address, body string) *Outgoing_t {
+	subject := msg.Subject
+	if !strings.HasPrefix(strings.ToLower(subject), "re:") {
+		subject = "Re: " + subject
+	}
+	return &Outgoing_t{From: from, To: msg.From, Subject: subject, InReplyTo: msg.Id, Body: body}
+}
+
+// Bytes returns the message in the format used for mail.
+func (o *Outgoing_t) Bytes() []byte {
+	var buf bytes.Buffer
+	header := func(key, value string) {
+		fmt.Fprintf(&buf, "%s: %s\r\n", key, value)
+	}
+	header("From", o.From.String())
+	header("To", o.To.String())
+	header("Subject", mime.QEncoding.Encode("utf-8", o.Subject))
+	header("Date", o.Date.Format(time.RFC1123Z))
+	header("Message-ID", "<"+o.Id+">")
+	if o.InReplyTo != "" {
+		header("In-Reply-To", "<"+o.InReplyTo+">")
+		header("References", "<"+o.InReplyTo+">")
+	}
+	header("MIME-Version", "1.0")
+	if len(o.Files) == 0 {
+		header("Content-Type", `text/plain; charset="utf-8"`)
+		header("Content-Transfer-Encoding", "quoted-printable")
+		buf.WriteString("\r\n")
+		writeText(&buf, o.Body)
+		return buf.Bytes()
+	}
+
+	// the body goes first, then the files
+	mw := multipart.NewWriter(&buf)
+	header("Content-Type", mime.FormatMediaType("multipart/mixed", map[string]string{"boundary": mw.Boundary()}))
+	buf.WriteString("\r\n")
+	part, _ := mw.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {`text/plain; charset="utf-8"`},
+		"Content-Transfer-Encoding": {"quoted-printable"},
+	})
+	writeText(part, o.Body)
+	for _, file := range o.Files {
+		part, _ := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {mime.FormatMediaType(file.ContentType, map[string]string{"name": file.Name})},
+			"Content-Disposition":       {mime.FormatMediaType("attachment", map[string]string{"filename": file.Name})},
+			"Content-Transfer-Encoding": {"base64"},
+		})
+		// base64 lines may not be longer than 76 characters
+		encoded := base64.StdEncoding.EncodeToString(file.Data)
+		for len(encoded) > 76 {
+			_, _ = io.WriteString(part, encoded[:76]+"\r\n")
+			encoded = encoded[76:]
+		}
+		_, _ = io.WriteString(part, encoded+"\r\n")
+	}
+	_ = mw.Close()
+	return buf.Bytes()
+}
+
+// writeText writes the text as quoted-printable with CRLF line endings.
+func writeText(w io.Writer, text string) {
+	qp := quotedprintable.NewWriter(w)
+	_, _ = qp.Write([]byte(strings.ReplaceAll(text, "\n", "\r\n")))
+	_ = qp.Close()
+}
+
+// Spool writes the message to the directory, where it waits to be sent.
+// It returns the name of the file.
+func (o *Outgoing_t) Spool(dir string) (string, error) {
+	if o.Date.IsZero() {
+		o.Date = time.Now()
+	}
+	if o.Id == "" {
+		id, err := newId(o.From)
+		if err != nil {
+			return "", err
+		}
+		o.Id = id
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	// the time comes first so that the spool can be sent in order
+	name := filepath.Join(dir, fmt.Sprintf("%d-%s.eml", o.Date.UnixNano(), strings.SplitN(o.Id, "@", 2)[0]))
+	if err := os.WriteFile(name+".tmp", o.Bytes(), 0644); err != nil {
+		return "", err
+	}
+	return name, os.Rename(name+".tmp", name)
+}
+
+// newId returns a Message-ID in the sender's domain.
+func newId(from *mail.Address) (string, error) {
+	buf := make([]byte, 12)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	domain := "localhost"
+	if n := strings.LastIndexByte(from.Address, '@'); n != -1 {
+		domain = from.Address[n+1:]
+	}
+	return hex.EncodeToString(buf) + "@" + domain, nil
+}
@@ -0,0 +1,248 @@
+// Copyright (c) 2024 Michael D Henderson. All rights reserved.
+
+package mail
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Transport delivers a message that has been spooled.
+type Transport interface {
+	Deliver(from string, to []string, data []byte) error
+}
+
+// SMTP_t delivers messages through an SMTP server. The connection is
+// upgraded with STARTTLS when the server offers it. Credentials are
+// only sent over TLS or to a server on the local host.
+type SMTP_t struct {
+	Addr     string // host and port, like "localhost:25"
+	Username string // empty if the server doesn't need a login
+	Password string
+}
+
+func (s *SMTP_t) Deliver(from string, to []string, data []byte) error {
+	var auth smtp.Auth
+	if s.Username != "" {
+		host, _, err := net.SplitHostPort(s.Addr)
+		if err != nil {
+			return err
+		}
+		auth = smtp.PlainAuth("", s.Username, s.Password, host)
+	}
+	return smtp.SendMail(s.Addr, auth, from, to, data)
+}
+
+// Dir_t "delivers" messages by copying them to a directory, which is
+// useful for checking what would be sent.
+type Dir_t string
+
+func (d Dir_t) Deliver(from string, to []string, data []byte) error {
+	if err := os.MkdirAll(string(d), 0755); err != nil {
+		return err
+	}
+	// the name keeps messages to the same person together
+	name := filepath.Join(string(d), fmt.Sprintf("%s-%d.eml", strings.Join(to, ","), time.Now().UnixNano()))
+	return os.WriteFile(name, data, 0644)
+}
+
+// Spooled_t is a message waiting in a spool directory.
+type Spooled_t struct {
+	Name string // the file holding the message
+	Id   string
+	From string
+	To   []string
+	Data []byte
+}
+
+// ReadSpool returns the messages in the spool directory, oldest first.
+// A spool that doesn't exist is empty.
+func ReadSpool(dir string) ([]*Spooled_t, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name() < entries[j].Name()
+	})
+	var list []*Spooled_t
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".eml" {
+			continue
+		}
+		name := filepath.Join(dir, entry.Name())
+		data, err := os.ReadFile(name)
+		if err != nil {
+			return nil, err
+		}
+		m, err := mail.ReadMessage(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		s := &Spooled_t{Name: name, Id: strings.Trim(m.Header.Get("Message-Id"), "<> "), Data: data}
+		from, err := mail.ParseAddress(m.Header.Get("From"))
+		if err != nil {
+			return nil, fmt.Errorf("%s: from: %w", name, err)
+		}
+		s.From = from.Address
+		to, err := m.Header.AddressList("To")
+		if err != nil {
+			return nil, fmt.Errorf("%s: to: %w", name, err)
+		}
+		for _, addr := range to {
+			s.To = append(s.To, addr.Address)
+		}
+		list = append(list, s)
+	}
+	return list, nil
+}
+
+// Send delivers the message, trying again after a delay if it fails.
+// The delay doubles after every failure.
+func (s *Spooled_t) Send(t Transport, retries int, delay time.Duration) error {
+	var err error
+	for try := 0; try <= retries; try++ {
+		if try > 0 {
+			time.Sleep(delay)
+			delay *= 2
+		}
+		if err = t.Deliver(s.From, s.To, s.Data); err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+// Outbox_t is a spool of messages waiting to be sent.
+type Outbox_t struct {
+	Dir        string // messages waiting to be sent
+	Sent       string // messages that have been sent
+	Log        *DeliveryLog_t
+	Retries    int
+	RetryDelay time.Duration
+}
+
+// NewOutbox returns the outbox in a mail directory.
+func NewOutbox(dir string) *Outbox_t {
+	return &Outbox_t{
+		Dir:        filepath.Join(dir, "outbox"),
+		Sent:       filepath.Join(dir, "sent"),
+		Log:        NewDeliveryLog(filepath.Join(dir, "delivery.log")),
+		Retries:    3,
+		RetryDelay: 5 * time.Second,
+	}
+}
+
+// Flush sends every message in the outbox and returns the number that failed.
+// Messages that are sent are moved to the Sent directory. Messages "sent" to
+// a Dir_t are left in the outbox.
+func (o *Outbox_t) Flush(t Transport) (failed int, err error) {
+	spool, err := ReadSpool(o.Dir)
+	if err != nil {
+		return 0, err
+	}
+	_, dryRun := t.(Dir_t)
+	for _, s := range spool {
+		err := s.Send(t, o.Retries, o.RetryDelay)
+		status := "sent"
+		if err != nil {
+			status, failed = "failed", failed+1
+		} else if dryRun {
+			status = "dry-run"
+		}
+		if err := o.Log.Record(s, status, err); err != nil {
+			return failed, err
+		}
+		log.Printf("mail: %s: %s: %s\n", strings.Join(s.To, ","), s.Id, status)
+		if status == "sent" {
+			if err := os.MkdirAll(o.Sent, 0755); err != nil {
+				return failed, err
+			} else if err := os.Rename(s.Name, filepath.Join(o.Sent, filepath.Base(s.Name))); err != nil {
+				return failed, err
+			}
+		}
+	}
+	return failed, nil
+}
+
+// Queued returns the ids of the messages in the outbox and the ones that
+// the log says were sent.
+func (o *Outbox_t) Queued() (map[string]bool, error) {
+	ids, err := o.Log.Sent()
+	if err != nil {
+		return nil, err
+	}
+	spool, err := ReadSpool(o.Dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range spool {
+		ids[s.Id] = true
+	}
+	return ids, nil
+}
+
+// DeliveryLog_t is a file that records every attempt to send a message.
+// Each line has the time, the Message-ID, the recipients, the status and
+// the error, if there was one, separated by tabs.
+type DeliveryLog_t struct {
+	name string
+}
+
+// NewDeliveryLog returns the delivery log in the file.
+func NewDeliveryLog(name string) *DeliveryLog_t {
+	return &DeliveryLog_t{name: name}
+}
+
+// Record adds a line to the log.
+func (l *DeliveryLog_t) Record(s *Spooled_t, status string, err error) error {
+	line := fmt.Sprintf("%s\t%s\t%s\t%s", time.Now().UTC().Format(time.RFC3339), s.Id, strings.Join(s.To, ","), status)
+	if err != nil {
+		line += "\t" + strings.ReplaceAll(err.Error(), "\n", " ")
+	}
+	if err := os.MkdirAll(filepath.Dir(l.name), 0755); err != nil {
+		return err
+	}
+	fp, err := os.OpenFile(l.name, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintln(fp, line); err != nil {
+		_ = fp.Close()
+		return err
+	}
+	return fp.Close()
+}
+
+// Sent returns the ids of the messages that the log says were sent.
+func (l *DeliveryLog_t) Sent() (map[string]bool, error) {
+	sent := map[string]bool{}
+	fp, err := os.Open(l.name)
+	if errors.Is(err, os.ErrNotExist) {
+		return sent, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer fp.Close()
+	scanner := bufio.NewScanner(fp)
+	for scanner.Scan() {
+		fields := strings.Split(scanner.Text(), "\t")
+		if len(fields) >= 4 && fields[3] == "sent" {
+			sent[fields[1]] = true
+		}
+	}
+	return sent, scanner.Err()
+}
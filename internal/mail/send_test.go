@@ -0,0 +1,137 @@
+// Copyright (c) 2024 Michael D Henderson. All rights reserved.
+
+package mail
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// transport_t records what it delivers and fails for some recipients.
+type transport_t struct {
+	fails     map[string]int // failures left by recipient, -1 to always fail
+	delivered []string       // recipients of the messages delivered
+}
+
+func (t *transport_t) Deliver(from string, to []string, data []byte) error {
+	rcpt := strings.Join(to, ",")
+	if n := t.fails[rcpt]; n != 0 {
+		t.fails[rcpt] = n - 1
+		return errors.New("connection refused")
+	}
+	t.delivered = append(t.delivered, rcpt)
+	return nil
+}
+
+func TestAttachments(t *testing.T) {
+	png := bytes.Repeat([]byte{0x89, 'P', 'N', 'G', 0, 1, 2, 0xff}, 40)
+	out := &Outgoing_t{
+		Id:      "report@fargo.example.com",
+		From:    &mail.Address{Address: "gm@fargo.example.com"},
+		To:      &mail.Address{Address: "one@example.com"},
+		Subject: "turn 3",
+		Body:    "your report is attached\n",
+		Files:   []*Attachment_t{{Name: "map.png", ContentType: "image/png", Data: png}},
+	}
+	data := out.Bytes()
+	_, body, _ := strings.Cut(string(data), "\r\n\r\n")
+	for _, line := range strings.Split(body, "\r\n") {
+		if len(line) > 76 {
+			t.Fatalf("body: want lines of at most 76 characters, got %q", line)
+		}
+	}
+
+	// the body is still found by Parse
+	msg, err := Parse(data)
+	if err != nil {
+		t.Fatal(err)
+	} else if msg.Body != out.Body {
+		t.Errorf("body: want %q, got %q", out.Body, msg.Body)
+	}
+
+	m, err := mail.ReadMessage(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, params, err := mime.ParseMediaType(m.Header.Get("Content-Type"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mr := multipart.NewReader(m.Body, params["boundary"])
+	if _, err := mr.NextPart(); err != nil {
+		t.Fatal(err)
+	}
+	part, err := mr.NextPart()
+	if err != nil {
+		t.Fatal(err)
+	} else if part.FileName() != "map.png" || !strings.HasPrefix(part.Header.Get("Content-Type"), "image/png") {
+		t.Errorf("attachment: got %v", part.Header)
+	}
+	got, err := io.ReadAll(base64.NewDecoder(base64.StdEncoding, part))
+	if err != nil {
+		t.Fatal(err)
+	} else if !bytes.Equal(got, png) {
+		t.Errorf("attachment: want the file back unchanged")
+	}
+}
+
+func TestFlush(t *testing.T) {
+	dir := t.TempDir()
+	outbox := NewOutbox(dir)
+	outbox.RetryDelay = 0
+	gm := &mail.Address{Address: "gm@fargo.example.com"}
+	ids := map[string]string{}
+	for _, to := range []string{"one@example.com", "two@example.com", "three@example.com"} {
+		out := &Outgoing_t{From: gm, To: &mail.Address{Address: to}, Subject: "turn 3", Body: "report\n"}
+		if _, err := out.Spool(outbox.Dir); err != nil {
+			t.Fatal(err)
+		}
+		ids[to] = out.Id
+	}
+
+	// two is retried until it goes through; three never does
+	tr := &transport_t{fails: map[string]int{"two@example.com": outbox.Retries, "three@example.com": -1}}
+	failed, err := outbox.Flush(tr)
+	if err != nil {
+		t.Fatal(err)
+	} else if failed != 1 || len(tr.delivered) != 2 {
+		t.Fatalf("flush: want 2 sent and 1 failed, got %v and %d failed", tr.delivered, failed)
+	}
+	if spool, err := ReadSpool(outbox.Dir); err != nil {
+		t.Fatal(err)
+	} else if len(spool) != 1 || spool[0].Id != ids["three@example.com"] {
+		t.Errorf("outbox: want only the failed message left, got %d", len(spool))
+	}
+	if sent, err := os.ReadDir(outbox.Sent); err != nil || len(sent) != 2 {
+		t.Errorf("sent: want 2 messages, got %d, %v", len(sent), err)
+	}
+	sent, err := outbox.Log.Sent()
+	if err != nil {
+		t.Fatal(err)
+	} else if !sent[ids["one@example.com"]] || !sent[ids["two@example.com"]] || sent[ids["three@example.com"]] {
+		t.Errorf("log: got %v", sent)
+	}
+	if queued, err := outbox.Queued(); err != nil || len(queued) != 3 {
+		t.Errorf("queued: want every message, got %v, %v", queued, err)
+	}
+
+	// a dry run copies the message and leaves it to be sent
+	copies := Dir_t(filepath.Join(dir, "copies"))
+	if failed, err := outbox.Flush(copies); err != nil || failed != 0 {
+		t.Fatalf("dry run: got %d failed, %v", failed, err)
+	}
+	if spool, err := ReadSpool(outbox.Dir); err != nil || len(spool) != 1 {
+		t.Errorf("dry run: want the message left in the outbox, got %d, %v", len(spool), err)
+	} else if list, err := os.ReadDir(string(copies)); err != nil || len(list) != 1 {
+		t.Errorf("dry run: want one copy, got %d, %v", len(list), err)
+	}
+}
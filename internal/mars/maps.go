@@ -584,9 +584,9 @@ func (m *Map) getdata() *STARINFO {
 			x:      ss.Coordinates.X,
 			y:      ss.Coordinates.Y,
 			z:      ss.Coordinates.Z,
-			name:   ss.Coordinates.String(),
+			name:   ss.Name,
 			type_:  "star",
-			mass:   1.0,
+			mass:   ss.Mass,
 			next:   nil,
 			planet: nil,
 		}
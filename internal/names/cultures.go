@@ -0,0 +1,58 @@
+// Copyright (c) 2024 Michael D Henderson. All rights reserved.
+
+package names
+
+// culture_t holds the syllables and sample names used to flavor names.
+type culture_t struct {
+	initial []string
+	middle  []string
+	final   []string
+	names   []string // samples for training the Markov generator
+}
+
+var cultures = map[string]culture_t{
+	"classical": {
+		initial: []string{"a", "al", "an", "ar", "be", "ca", "cor", "de", "e", "hy", "i", "le", "ly", "ma", "me", "ne", "o", "pe", "phi", "sa", "te", "the", "vel", "xe"},
+		middle:  []string{"ba", "da", "la", "li", "lo", "ma", "na", "ne", "ra", "ri", "ro", "ta", "thi", "to", "va"},
+		final:   []string{"a", "ae", "as", "es", "ia", "is", "ion", "on", "or", "os", "um", "us", "ys"},
+		names: []string{
+			"achernar", "adhara", "alcyone", "aldebaran", "alhena", "altair", "antares", "arcturus", "bellatrix", "betelgeuse",
+			"canopus", "capella", "castor", "deneb", "electra", "fomalhaut", "hadar", "maia", "menkar", "merope",
+			"mimosa", "mirach", "mizar", "pollux", "procyon", "regulus", "rigel", "sirius", "spica", "taygeta",
+			"thuban", "vega", "zaurak", "zosma",
+		},
+	},
+	"kana": {
+		initial: []string{"a", "ka", "ki", "ko", "ha", "hi", "mi", "mo", "na", "no", "sa", "shi", "su", "ta", "to", "ya", "yu"},
+		middle:  []string{"ka", "ki", "ku", "ma", "mi", "na", "ra", "ri", "ro", "sa", "shi", "ta", "tsu", "wa", "ya"},
+		final:   []string{"ka", "ko", "ma", "mi", "n", "na", "ra", "ri", "ro", "to", "ya", "yo"},
+		names: []string{
+			"akashi", "akita", "amami", "asahi", "chikuma", "fubuki", "fuso", "haruna", "hayate", "hiei",
+			"hokuto", "ise", "kagero", "kaminari", "kasumi", "kirishima", "kongo", "kumano", "mikasa", "mogami",
+			"murasame", "mutsu", "nagato", "naka", "noshiro", "sagami", "sendai", "shikinami", "suzuya", "takao",
+			"tenryu", "yahagi", "yamato", "yubari",
+		},
+	},
+	"nordic": {
+		initial: []string{"al", "as", "bj", "bra", "dag", "ei", "fa", "fro", "gun", "hal", "hel", "ing", "kja", "rag", "sig", "sk", "thor", "ul", "vi", "yg"},
+		middle:  []string{"da", "fa", "ga", "gri", "ha", "li", "mun", "na", "ra", "ri", "sta", "ve"},
+		final:   []string{"borg", "dal", "fell", "gard", "heim", "holm", "mar", "nir", "rik", "stad", "vik", "ald"},
+		names: []string{
+			"alfheim", "asgard", "baldur", "bifrost", "bragi", "dagmar", "eikthyr", "fenrir", "folkvang", "freyja",
+			"gimle", "gjallar", "gladsheim", "hati", "heimdall", "hlidskjalf", "idunn", "jotunheim", "mimir", "mjolnir",
+			"muspel", "nidhogg", "njord", "ratatosk", "sigrun", "skadi", "skoll", "sleipnir", "surtr", "thrudheim",
+			"valaskjalf", "vanaheim", "vidar", "yggdrasil",
+		},
+	},
+	"desert": {
+		initial: []string{"ab", "al", "am", "ba", "da", "fa", "ha", "ja", "ka", "ma", "na", "qa", "ra", "sa", "ta", "za"},
+		middle:  []string{"bah", "dir", "far", "hal", "ja", "kir", "lam", "mir", "ra", "sha", "zar"},
+		final:   []string{"ad", "ah", "an", "ar", "at", "id", "im", "ir", "ra", "ud", "un"},
+		names: []string{
+			"alnilam", "alnitak", "alkaid", "alphard", "algedi", "algol", "almach", "alnair", "alshain", "ankaa",
+			"azha", "baham", "dabih", "diphda", "enif", "hamal", "izar", "kaffaljidhma", "kitalpha", "markab",
+			"matar", "mebsuta", "menkalinan", "mintaka", "nashira", "nihal", "ruchbah", "sabik", "sadr", "saiph",
+			"scheat", "shaula", "tarazed", "unukalhai",
+		},
+	},
+}
@@ -0,0 +1,174 @@
+// Copyright (c) 2024 Michael D Henderson. All rights reserved.
+
+// Package names implements deterministic generators for star system names.
+package names
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"sort"
+	"strings"
+)
+
+// Generator returns a new name each time it is called.
+// Names are not guaranteed to be unique; use Unique for that.
+type Generator interface {
+	Name(r *rand.Rand) string
+}
+
+// Cultures returns the names of the cultures that have name lists.
+func Cultures() []string {
+	var list []string
+	for k := range cultures {
+		list = append(list, k)
+	}
+	sort.Strings(list)
+	return list
+}
+
+// NewGenerator returns a generator for the culture.
+// Style is either "syllable" or "markov".
+func NewGenerator(culture, style string) (Generator, error) {
+	c, ok := cultures[culture]
+	if !ok {
+		return nil, fmt.Errorf("%q: unknown culture", culture)
+	}
+	switch style {
+	case "markov":
+		return NewMarkov(c.names, 2), nil
+	case "syllable":
+		return &Syllables{Initial: c.initial, Middle: c.middle, Final: c.final, MinSyllables: 2, MaxSyllables: 3}, nil
+	}
+	return nil, fmt.Errorf("%q: unknown style", style)
+}
+
+// Designation returns the catalog-style designation for a system number.
+func Designation(n int) string {
+	return fmt.Sprintf("FGC %04d", n)
+}
+
+// Unique wraps a generator and never returns the same name twice.
+// When the generator can't find a new name, Unique returns the fallback.
+type Unique struct {
+	g     Generator
+	r     *rand.Rand
+	taken map[string]bool
+}
+
+// NewUnique returns a generator that won't repeat names.
+func NewUnique(g Generator, r *rand.Rand) *Unique {
+	return &Unique{g: g, r: r, taken: make(map[string]bool)}
+}
+
+// Reserve marks a name as taken. It returns false if the name was already taken.
+func (u *Unique) Reserve(name string) bool {
+	key := strings.ToLower(name)
+	if u.taken[key] {
+		return false
+	}
+	u.taken[key] = true
+	return true
+}
+
+// Next returns a name that hasn't been returned or reserved before.
+// If no new name is found after a reasonable number of tries,
+// it reserves and returns the fallback.
+func (u *Unique) Next(fallback string) string {
+	for tries := 0; tries < 64; tries++ {
+		if name := u.g.Name(u.r); name != "" && u.Reserve(name) {
+			return name
+		}
+	}
+	u.Reserve(fallback)
+	return fallback
+}
+
+// Syllables generates names by joining random syllables.
+type Syllables struct {
+	Initial      []string
+	Middle       []string
+	Final        []string
+	MinSyllables int
+	MaxSyllables int
+}
+
+// Name implements the Generator interface.
+func (s *Syllables) Name(r *rand.Rand) string {
+	n := s.MinSyllables
+	if s.MaxSyllables > s.MinSyllables {
+		n += r.IntN(s.MaxSyllables - s.MinSyllables + 1)
+	}
+	sb := strings.Builder{}
+	for i := 0; i < n; i++ {
+		switch {
+		case i == 0:
+			sb.WriteString(s.Initial[r.IntN(len(s.Initial))])
+		case i == n-1:
+			sb.WriteString(s.Final[r.IntN(len(s.Final))])
+		default:
+			sb.WriteString(s.Middle[r.IntN(len(s.Middle))])
+		}
+	}
+	return capitalize(sb.String())
+}
+
+// Markov generates names from a character-level Markov chain
+// trained on a list of sample names.
+type Markov struct {
+	order  int
+	starts []string
+	chain  map[string][]rune
+	minLen int
+	maxLen int
+}
+
+// NewMarkov returns a chain of the given order trained on the samples.
+func NewMarkov(samples []string, order int) *Markov {
+	m := &Markov{order: order, chain: make(map[string][]rune), minLen: 4, maxLen: 10}
+	for _, sample := range samples {
+		word := []rune(strings.ToLower(sample))
+		if len(word) <= order {
+			continue
+		}
+		m.starts = append(m.starts, string(word[:order]))
+		// a zero rune marks the end of the word
+		word = append(word, 0)
+		for i := order; i < len(word); i++ {
+			key := string(word[i-order : i])
+			m.chain[key] = append(m.chain[key], word[i])
+		}
+	}
+	return m
+}
+
+// Name implements the Generator interface.
+// It returns an empty string if the chain could not build a name of a reasonable length.
+func (m *Markov) Name(r *rand.Rand) string {
+	if len(m.starts) == 0 {
+		return ""
+	}
+	word := []rune(m.starts[r.IntN(len(m.starts))])
+	for len(word) < m.maxLen {
+		next := m.chain[string(word[len(word)-m.order:])]
+		if len(next) == 0 {
+			break
+		}
+		ch := next[r.IntN(len(next))]
+		if ch == 0 {
+			break
+		}
+		word = append(word, ch)
+	}
+	if len(word) < m.minLen {
+		return ""
+	}
+	return capitalize(string(word))
+}
+
+func capitalize(s string) string {
+	if s == "" {
+		return s
+	}
+	runes := []rune(s)
+	return strings.ToUpper(string(runes[0])) + string(runes[1:])
+}
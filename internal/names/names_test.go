@@ -0,0 +1,85 @@
+// Copyright (c) 2024 Michael D Henderson. All rights reserved.
+
+package names
+
+import (
+	"math/rand/v2"
+	"strings"
+	"testing"
+	"unicode"
+)
+
+func TestGenerators(t *testing.T) {
+	if len(Cultures()) == 0 {
+		t.Fatal("cultures: want some, got none")
+	}
+	for _, culture := range Cultures() {
+		for _, style := range []string{"syllable", "markov"} {
+			g, err := NewGenerator(culture, style)
+			if err != nil {
+				t.Fatalf("%s/%s: %v", culture, style, err)
+			}
+			// the same seed gives the same names
+			r1, r2 := rand.New(rand.NewPCG(1, 2)), rand.New(rand.NewPCG(1, 2))
+			var found int
+			for i := 0; i < 20; i++ {
+				a, b := g.Name(r1), g.Name(r2)
+				if a != b {
+					t.Fatalf("%s/%s: same seed gave %q and %q", culture, style, a, b)
+				} else if a == "" {
+					continue
+				}
+				found++
+				if first := []rune(a)[0]; !unicode.IsUpper(first) {
+					t.Errorf("%s/%s: %q: want a capital letter", culture, style, a)
+				}
+			}
+			if found == 0 {
+				t.Errorf("%s/%s: no names in 20 tries", culture, style)
+			}
+		}
+	}
+	if _, err := NewGenerator("klingon", "syllable"); err == nil {
+		t.Errorf("unknown culture: want error, got nil")
+	} else if _, err := NewGenerator(Cultures()[0], "random"); err == nil {
+		t.Errorf("unknown style: want error, got nil")
+	}
+}
+
+// constant_t always returns the same name.
+type constant_t string
+
+func (c constant_t) Name(r *rand.Rand) string { return string(c) }
+
+func TestUnique(t *testing.T) {
+	u := NewUnique(constant_t("Sol"), rand.New(rand.NewPCG(1, 2)))
+	if !u.Reserve("Vega") || u.Reserve("VEGA") {
+		t.Errorf("reserve: want names taken without regard to case")
+	}
+	if got := u.Next("S001"); got != "Sol" {
+		t.Errorf("next: want Sol, got %q", got)
+	}
+	// the generator has nothing new, so the fallback is used
+	if got := u.Next("S002"); got != "S002" {
+		t.Errorf("next: want the fallback S002, got %q", got)
+	}
+
+	g, err := NewGenerator("classical", "syllable")
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, seen := NewUnique(g, rand.New(rand.NewPCG(3, 4))), map[string]bool{}
+	for i := 0; i < 200; i++ {
+		name := strings.ToLower(u.Next(Designation(i)))
+		if seen[name] {
+			t.Fatalf("next: %q returned twice", name)
+		}
+		seen[name] = true
+	}
+}
+
+func TestDesignation(t *testing.T) {
+	if got := Designation(7); got != "FGC 0007" {
+		t.Errorf("want FGC 0007, got %q", got)
+	}
+}
@@ -0,0 +1,125 @@
+// Copyright (c) 2024 Michael D Henderson. All rights reserved.
+
+package render
+
+import (
+	"fmt"
+	"github.com/playbymail/fargo/internal/aow"
+	"image"
+	"image/color/palette"
+	"image/draw"
+	"image/gif"
+	"io"
+	"math"
+	"os"
+	"strings"
+)
+
+// Axis_e is the axis that an animation rotates around.
+type Axis_e int
+
+const (
+	AxisX Axis_e = iota
+	AxisY
+	AxisZ
+)
+
+// ParseAxis accepts "x", "y" or "z".
+func ParseAxis(s string) (Axis_e, error) {
+	switch strings.ToLower(s) {
+	case "x":
+		return AxisX, nil
+	case "y":
+		return AxisY, nil
+	case "z":
+		return AxisZ, nil
+	}
+	return AxisZ, fmt.Errorf("%q: unknown axis", s)
+}
+
+// rotate returns the coordinates rotated around the axis by the angle, in radians.
+func (a Axis_e) rotate(c aow.Coordinates, angle float64) aow.Coordinates {
+	sin, cos := math.Sincos(angle)
+	switch a {
+	case AxisX:
+		return aow.Coordinates{X: c.X, Y: c.Y*cos - c.Z*sin, Z: c.Y*sin + c.Z*cos}
+	case AxisY:
+		return aow.Coordinates{X: c.X*cos + c.Z*sin, Y: c.Y, Z: -c.X*sin + c.Z*cos}
+	}
+	return aow.Coordinates{X: c.X*cos - c.Y*sin, Y: c.X*sin + c.Y*cos, Z: c.Z}
+}
+
+// Frames renders every frame of the rotation. The catalog is rotated around the
+// animation axis through the center, then projected with perspective onto the
+// renderer's plane. The camera sits on the depth axis, far enough out that
+// every system is in view.
+func (r *Renderer) Frames(catalog *aow.Catalog_t) []image.Image {
+	systems := r.visible(catalog)
+	origin := r.center.Scale(-1)
+
+	// the extent is the radius of the sphere that holds every system, so it is the same for every frame
+	var radius float64
+	for _, ss := range systems {
+		radius = max(radius, ss.Coordinates.DistanceTo(r.center))
+	}
+	radius += 4
+	camera := 3 * radius
+	// the nearest systems are magnified, so widen the extent to keep them on the image
+	extent := radius * camera / (camera - radius)
+
+	var frames []image.Image
+	for n := 0; n < r.frames; n++ {
+		angle := float64(n) * r.speed * math.Pi / 180
+		frames = append(frames, r.draw(systems, extent, func(c aow.Coordinates) (h, v, depth, magnification float64) {
+			h, v, depth = r.plane.project(r.axis.rotate(c.Translate(origin), angle))
+			magnification = camera / (camera - depth)
+			return h * magnification, v * magnification, depth, magnification
+		}))
+	}
+	return frames
+}
+
+// SaveGIF renders the rotation to an animated GIF file.
+func SaveGIF(filename string, catalog *aow.Catalog_t, options ...Option) error {
+	return saveAnimation(filename, catalog, (*Renderer).WriteGIF, options...)
+}
+
+// SaveAPNG renders the rotation to an animated PNG file.
+func SaveAPNG(filename string, catalog *aow.Catalog_t, options ...Option) error {
+	return saveAnimation(filename, catalog, (*Renderer).WriteAPNG, options...)
+}
+
+func saveAnimation(filename string, catalog *aow.Catalog_t, write func(*Renderer, io.Writer, *aow.Catalog_t) error, options ...Option) error {
+	r, err := NewRenderer(options...)
+	if err != nil {
+		return err
+	}
+	fp, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	if err = write(r, fp, catalog); err != nil {
+		_ = fp.Close()
+		return err
+	}
+	return fp.Close()
+}
+
+// WriteGIF renders the rotation and writes it to w as an animated GIF that loops forever.
+func (r *Renderer) WriteGIF(w io.Writer, catalog *aow.Catalog_t) error {
+	anim := &gif.GIF{}
+	for _, frame := range r.Frames(catalog) {
+		// gif needs paletted images; the star colors are all close to plan9 colors
+		paletted := image.NewPaletted(frame.Bounds(), palette.Plan9)
+		draw.Draw(paletted, paletted.Rect, frame, frame.Bounds().Min, draw.Src)
+		anim.Image = append(anim.Image, paletted)
+		// gif delays are in hundredths of a second
+		anim.Delay = append(anim.Delay, r.frameDelay/10)
+	}
+	return gif.EncodeAll(w, anim)
+}
+
+// WriteAPNG renders the rotation and writes it to w as an animated PNG that loops forever.
+func (r *Renderer) WriteAPNG(w io.Writer, catalog *aow.Catalog_t) error {
+	return encodeAPNG(w, r.Frames(catalog), r.frameDelay)
+}
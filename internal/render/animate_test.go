@@ -0,0 +1,85 @@
+// Copyright (c) 2024 Michael D Henderson. All rights reserved.
+
+package render
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"github.com/playbymail/fargo/internal/aow"
+	"image/gif"
+	"image/png"
+	"math"
+	"testing"
+)
+
+func TestRotate(t *testing.T) {
+	c := aow.Coordinates{X: 1, Y: 2, Z: 3}
+	for _, tc := range []struct {
+		axis Axis_e
+		want aow.Coordinates
+	}{
+		{AxisX, aow.Coordinates{X: 1, Y: -3, Z: 2}},
+		{AxisY, aow.Coordinates{X: 3, Y: 2, Z: -1}},
+		{AxisZ, aow.Coordinates{X: -2, Y: 1, Z: 3}},
+	} {
+		if got := tc.axis.rotate(c, math.Pi/2); got.DistanceTo(tc.want) > 1e-9 {
+			t.Errorf("%d: quarter turn: want %v, got %v", tc.axis, tc.want, got)
+		} else if got := tc.axis.rotate(c, 2*math.Pi); got.DistanceTo(c) > 1e-9 {
+			t.Errorf("%d: full turn: want %v, got %v", tc.axis, c, got)
+		}
+	}
+	if a, err := ParseAxis("Y"); err != nil || a != AxisY {
+		t.Errorf("axis: want Y, got %v, %v", a, err)
+	} else if _, err := ParseAxis("w"); err == nil {
+		t.Errorf("axis: want an error for w")
+	}
+}
+
+func TestAnimation(t *testing.T) {
+	catalog := testCatalog()
+	before, _ := json.Marshal(catalog)
+	r, err := NewRenderer(WithSize(64, 64), WithFrames(6), WithRotationSpeed(60), WithFrameDelay(200), WithRotationAxis(AxisY))
+	if err != nil {
+		t.Fatal(err)
+	}
+	frames := r.Frames(catalog)
+	if len(frames) != 6 {
+		t.Fatalf("frames: want 6, got %d", len(frames))
+	}
+	if after, _ := json.Marshal(catalog); !bytes.Equal(before, after) {
+		t.Errorf("frames: changed the catalog")
+	}
+
+	var buf bytes.Buffer
+	if err := r.WriteGIF(&buf, catalog); err != nil {
+		t.Fatal(err)
+	}
+	anim, err := gif.DecodeAll(&buf)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(anim.Image) != 6 || anim.Delay[0] != 20 || anim.LoopCount != 0 {
+		t.Errorf("gif: want 6 frames of 20/100s that loop forever, got %d of %d, loop %d", len(anim.Image), anim.Delay[0], anim.LoopCount)
+	}
+
+	buf.Reset()
+	if err := r.WriteAPNG(&buf, catalog); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+	// viewers that don't know APNG show the first frame
+	if img, err := png.Decode(bytes.NewReader(data)); err != nil {
+		t.Fatal(err)
+	} else if b := img.Bounds(); b.Dx() != 64 || b.Dy() != 64 {
+		t.Errorf("apng: want 64x64, got %dx%d", b.Dx(), b.Dy())
+	}
+	n := bytes.Index(data, []byte("acTL"))
+	if n == -1 {
+		t.Fatal("apng: no animation control chunk")
+	} else if frames, plays := binary.BigEndian.Uint32(data[n+4:]), binary.BigEndian.Uint32(data[n+8:]); frames != 6 || plays != 0 {
+		t.Errorf("apng: want 6 frames that loop forever, got %d frames, %d plays", frames, plays)
+	}
+	if fcTL := bytes.Count(data, []byte("fcTL")); fcTL != 6 {
+		t.Errorf("apng: want 6 frame control chunks, got %d", fcTL)
+	}
+}
@@ -0,0 +1,131 @@
+// Copyright (c) 2024 Michael D Henderson. All rights reserved.
+
+package render
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"image"
+	"image/png"
+	"io"
+)
+
+// the standard library doesn't write animated PNGs, so we encode every frame
+// as a regular PNG and re-package the image data into APNG frame chunks.
+// see https://wiki.mozilla.org/APNG_Specification for the chunk layouts.
+
+var pngSignature = []byte{0x89, 'P', 'N', 'G', '\r', '\n', 0x1a, '\n'}
+
+type pngChunk_t struct {
+	kind string
+	data []byte
+}
+
+// encodeAPNG writes the frames as an animated PNG that loops forever.
+// All frames must be the same size. The delay is in milliseconds.
+func encodeAPNG(w io.Writer, frames []image.Image, delay int) error {
+	if len(frames) == 0 {
+		return fmt.Errorf("apng: no frames")
+	}
+	bounds := frames[0].Bounds()
+
+	out := &bytes.Buffer{}
+	out.Write(pngSignature)
+
+	// the sequence number is shared by the frame control and frame data chunks
+	var sequence uint32
+	for n, frame := range frames {
+		if frame.Bounds() != bounds {
+			return fmt.Errorf("apng: frame %d: size does not match first frame", n+1)
+		}
+		chunks, err := pngChunks(frame)
+		if err != nil {
+			return fmt.Errorf("apng: frame %d: %w", n+1, err)
+		}
+
+		if n == 0 {
+			for _, chunk := range chunks {
+				if chunk.kind == "IHDR" {
+					writePNGChunk(out, chunk.kind, chunk.data)
+				}
+			}
+			actl := make([]byte, 8)
+			binary.BigEndian.PutUint32(actl[0:], uint32(len(frames)))
+			binary.BigEndian.PutUint32(actl[4:], 0) // loop forever
+			writePNGChunk(out, "acTL", actl)
+		}
+
+		fctl := make([]byte, 26)
+		binary.BigEndian.PutUint32(fctl[0:], sequence)
+		binary.BigEndian.PutUint32(fctl[4:], uint32(bounds.Dx()))
+		binary.BigEndian.PutUint32(fctl[8:], uint32(bounds.Dy()))
+		binary.BigEndian.PutUint32(fctl[12:], 0) // x offset
+		binary.BigEndian.PutUint32(fctl[16:], 0) // y offset
+		binary.BigEndian.PutUint16(fctl[20:], uint16(delay))
+		binary.BigEndian.PutUint16(fctl[22:], 1000) // delay is in milliseconds
+		fctl[24] = 0                                // dispose: none
+		fctl[25] = 0                                // blend: source
+		writePNGChunk(out, "fcTL", fctl)
+		sequence++
+
+		for _, chunk := range chunks {
+			if chunk.kind != "IDAT" {
+				continue
+			}
+			if n == 0 {
+				// the first frame doubles as the default image
+				writePNGChunk(out, "IDAT", chunk.data)
+				continue
+			}
+			fdat := make([]byte, 4, 4+len(chunk.data))
+			binary.BigEndian.PutUint32(fdat, sequence)
+			writePNGChunk(out, "fdAT", append(fdat, chunk.data...))
+			sequence++
+		}
+	}
+	writePNGChunk(out, "IEND", nil)
+
+	_, err := w.Write(out.Bytes())
+	return err
+}
+
+// pngChunks encodes the image as a PNG and returns its chunks.
+func pngChunks(img image.Image) ([]pngChunk_t, error) {
+	buf := &bytes.Buffer{}
+	if err := png.Encode(buf, img); err != nil {
+		return nil, err
+	}
+	data := buf.Bytes()
+	if !bytes.HasPrefix(data, pngSignature) {
+		return nil, fmt.Errorf("missing png signature")
+	}
+	data = data[len(pngSignature):]
+
+	var chunks []pngChunk_t
+	for len(data) >= 12 {
+		length := int(binary.BigEndian.Uint32(data[0:4]))
+		if len(data) < 12+length {
+			return nil, fmt.Errorf("truncated png chunk")
+		}
+		chunks = append(chunks, pngChunk_t{kind: string(data[4:8]), data: data[8 : 8+length]})
+		data = data[12+length:]
+	}
+	return chunks, nil
+}
+
+func writePNGChunk(w *bytes.Buffer, kind string, data []byte) {
+	var header [8]byte
+	binary.BigEndian.PutUint32(header[0:], uint32(len(data)))
+	copy(header[4:], kind)
+	w.Write(header[:])
+	w.Write(data)
+
+	crc := crc32.NewIEEE()
+	crc.Write(header[4:])
+	crc.Write(data)
+	var sum [4]byte
+	binary.BigEndian.PutUint32(sum[:], crc.Sum32())
+	w.Write(sum[:])
+}
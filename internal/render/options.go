@@ -0,0 +1,260 @@
+// Copyright (c) 2024 Michael D Henderson. All rights reserved.
+
+package render
+
+import (
+	"fmt"
+	"github.com/playbymail/fargo/internal/aow"
+	"image/color"
+	"math"
+	"strconv"
+	"strings"
+)
+
+type Option func(*Renderer) error
+
+// Plane_e is the plane that the map is projected onto.
+type Plane_e int
+
+const (
+	XY Plane_e = iota // looking down the Z axis
+	XZ                // looking down the Y axis
+	YZ                // looking down the X axis
+)
+
+// ParsePlane accepts "xy", "xz" or "yz".
+func ParsePlane(s string) (Plane_e, error) {
+	switch strings.ToLower(s) {
+	case "xy":
+		return XY, nil
+	case "xz":
+		return XZ, nil
+	case "yz":
+		return YZ, nil
+	}
+	return XY, fmt.Errorf("%q: unknown plane", s)
+}
+
+// project returns the horizontal and vertical coordinates on the plane
+// along with the depth, which is the coordinate that was collapsed.
+func (p Plane_e) project(c aow.Coordinates) (h, v, depth float64) {
+	switch p {
+	case XZ:
+		return c.X, c.Z, c.Y
+	case YZ:
+		return c.Y, c.Z, c.X
+	}
+	return c.X, c.Y, c.Z
+}
+
+// Label_e is a field shown in the label next to a system.
+type Label_e int
+
+const (
+	LabelId    Label_e = iota
+	LabelName          // the system's current name
+	LabelDepth         // the collapsed coordinate, which is Z on an XY map
+)
+
+// ParseLabels accepts a comma separated list of "id", "name" and "z" (or "depth").
+// An empty string or "none" means no labels.
+func ParseLabels(s string) ([]Label_e, error) {
+	var labels []Label_e
+	if s == "" || s == "none" {
+		return labels, nil
+	}
+	for _, field := range strings.Split(s, ",") {
+		switch strings.ToLower(strings.TrimSpace(field)) {
+		case "id":
+			labels = append(labels, LabelId)
+		case "name":
+			labels = append(labels, LabelName)
+		case "z", "depth":
+			labels = append(labels, LabelDepth)
+		default:
+			return nil, fmt.Errorf("%q: unknown label", field)
+		}
+	}
+	return labels, nil
+}
+
+// ParseColor accepts a color name or a hex value like "#102030".
+func ParseColor(s string) (color.Color, error) {
+	switch strings.ToLower(s) {
+	case "black":
+		return color.Black, nil
+	case "white":
+		return color.White, nil
+	case "navy":
+		return color.RGBA{R: 0, G: 0, B: 64, A: 255}, nil
+	case "green":
+		return color.RGBA{R: 0, G: 255, B: 0, A: 255}, nil
+	case "cyan":
+		return color.RGBA{R: 0, G: 255, B: 255, A: 255}, nil
+	case "magenta":
+		return color.RGBA{R: 255, G: 0, B: 255, A: 255}, nil
+	}
+	if len(s) == 7 && s[0] == '#' {
+		if v, err := strconv.ParseUint(s[1:], 16, 32); err == nil {
+			return color.RGBA{R: uint8(v >> 16), G: uint8(v >> 8), B: uint8(v), A: 255}, nil
+		}
+	}
+	return nil, fmt.Errorf("%q: unknown color", s)
+}
+
+func WithBackground(c color.Color) Option {
+	return func(r *Renderer) error {
+		r.background = c
+		return nil
+	}
+}
+
+// WithCenter sets the point drawn at the center of the image.
+// Animations rotate around this point.
+func WithCenter(c aow.Coordinates) Option {
+	return func(r *Renderer) error {
+		r.center = c
+		return nil
+	}
+}
+
+func WithDotRadius(pixels float64) Option {
+	return func(r *Renderer) error {
+		if !(pixels > 0) {
+			return fmt.Errorf("invalid dot radius: %g", pixels)
+		}
+		r.dotRadius = pixels
+		return nil
+	}
+}
+
+func WithFontSize(points float64) Option {
+	return func(r *Renderer) error {
+		if !(points > 0) {
+			return fmt.Errorf("invalid font size: %g", points)
+		}
+		r.fontSize = points
+		return nil
+	}
+}
+
+// WithFrameDelay sets the time between frames in an animation, in milliseconds.
+func WithFrameDelay(ms int) Option {
+	return func(r *Renderer) error {
+		if ms < 10 || ms > 65535 {
+			return fmt.Errorf("invalid frame delay: %d", ms)
+		}
+		r.frameDelay = ms
+		return nil
+	}
+}
+
+// WithFrames sets the number of frames in an animation.
+func WithFrames(n int) Option {
+	return func(r *Renderer) error {
+		if n < 1 {
+			return fmt.Errorf("invalid number of frames: %d", n)
+		}
+		r.frames = n
+		return nil
+	}
+}
+
+// MinimumGridSpacing is the closest that grid lines may be, in light years.
+const MinimumGridSpacing = 0.1
+
+// WithGrid draws grid lines every spacing light years.
+// A spacing of zero turns the grid off.
+func WithGrid(spacing float64) Option {
+	return func(r *Renderer) error {
+		if spacing < 0 || (spacing > 0 && spacing < MinimumGridSpacing) {
+			return fmt.Errorf("invalid grid spacing: %g: must be 0 or at least %g", spacing, MinimumGridSpacing)
+		}
+		r.gridSpacing = spacing
+		return nil
+	}
+}
+
+// WithHighlights circles the systems with the given ids.
+// It may be used more than once; the first set that contains a system wins.
+func WithHighlights(c color.Color, ids ...string) Option {
+	return func(r *Renderer) error {
+		h := highlight_t{color: c, systems: make(map[string]bool)}
+		for _, id := range ids {
+			h.systems[id] = true
+		}
+		r.highlights = append(r.highlights, h)
+		return nil
+	}
+}
+
+func WithLabelColor(c color.Color) Option {
+	return func(r *Renderer) error {
+		r.labelColor = c
+		return nil
+	}
+}
+
+// WithLabels sets the fields shown next to each system. No fields means no labels.
+func WithLabels(labels ...Label_e) Option {
+	return func(r *Renderer) error {
+		r.labels = labels
+		return nil
+	}
+}
+
+func WithPlane(p Plane_e) Option {
+	return func(r *Renderer) error {
+		r.plane = p
+		return nil
+	}
+}
+
+func WithRotationAxis(a Axis_e) Option {
+	return func(r *Renderer) error {
+		r.axis = a
+		return nil
+	}
+}
+
+// WithRotationSpeed sets the degrees of rotation between frames in an animation.
+func WithRotationSpeed(degrees float64) Option {
+	return func(r *Renderer) error {
+		if math.IsNaN(degrees) || math.IsInf(degrees, 0) {
+			return fmt.Errorf("invalid rotation speed: %g", degrees)
+		}
+		r.speed = degrees
+		return nil
+	}
+}
+
+func WithScaleBar() Option {
+	return func(r *Renderer) error {
+		r.scaleBar = true
+		return nil
+	}
+}
+
+// MaximumSize is the widest or tallest map, in pixels.
+const MaximumSize = 4096
+
+func WithSize(width, height int) Option {
+	return func(r *Renderer) error {
+		if width < 1 || height < 1 || width > MaximumSize || height > MaximumSize {
+			return fmt.Errorf("invalid size: %dx%d: must be 1 to %d pixels", width, height, MaximumSize)
+		}
+		r.width, r.height = width, height
+		return nil
+	}
+}
+
+// WithSystems limits the map to the systems with the given ids.
+func WithSystems(ids ...string) Option {
+	return func(r *Renderer) error {
+		r.systems = make(map[string]bool)
+		for _, id := range ids {
+			r.systems[id] = true
+		}
+		return nil
+	}
+}
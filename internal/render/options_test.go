@@ -0,0 +1,46 @@
+// Copyright (c) 2024 Michael D Henderson. All rights reserved.
+
+package render
+
+import (
+	"math"
+	"testing"
+)
+
+func TestWithSize(t *testing.T) {
+	for _, tc := range []struct {
+		width, height int
+		ok            bool
+	}{
+		{1, 1, true},
+		{MaximumSize, MaximumSize, true},
+		{0, 512, false},
+		{512, -1, false},
+		{MaximumSize + 1, 512, false},
+		{512, 1 << 30, false},
+	} {
+		_, err := NewRenderer(WithSize(tc.width, tc.height))
+		if ok := err == nil; ok != tc.ok {
+			t.Errorf("%dx%d: want ok %v, got %v", tc.width, tc.height, tc.ok, err)
+		}
+	}
+}
+
+func TestWithRotationSpeed(t *testing.T) {
+	for _, tc := range []struct {
+		degrees float64
+		ok      bool
+	}{
+		{5, true},
+		{-5, true},
+		{0, true},
+		{math.NaN(), false},
+		{math.Inf(1), false},
+		{math.Inf(-1), false},
+	} {
+		_, err := NewRenderer(WithRotationSpeed(tc.degrees))
+		if ok := err == nil; ok != tc.ok {
+			t.Errorf("%g: want ok %v, got %v", tc.degrees, tc.ok, err)
+		}
+	}
+}
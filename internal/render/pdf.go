@@ -0,0 +1,87 @@
+// Copyright (c) 2024 Michael D Henderson. All rights reserved.
+
+package render
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"github.com/playbymail/fargo/internal/aow"
+	"io"
+)
+
+// WritePDF renders the catalog and writes it to w as a single page PDF.
+func WritePDF(w io.Writer, catalog *aow.Catalog_t, options ...Option) error {
+	r, err := NewRenderer(options...)
+	if err != nil {
+		return err
+	}
+	return r.WritePDF(w, catalog)
+}
+
+// WritePDF draws the map with Render and writes it to w as a single page
+// PDF. The page is the size of the image, one point to a pixel.
+func (r *Renderer) WritePDF(w io.Writer, catalog *aow.Catalog_t) error {
+	img := r.Render(catalog)
+	bounds := img.Bounds()
+
+	// the image is stored as compressed RGB samples
+	var pixels bytes.Buffer
+	zw := zlib.NewWriter(&pixels)
+	row := make([]byte, 0, 3*bounds.Dx())
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		row = row[:0]
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			red, green, blue, _ := img.At(x, y).RGBA()
+			row = append(row, byte(red>>8), byte(green>>8), byte(blue>>8))
+		}
+		if _, err := zw.Write(row); err != nil {
+			return err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+
+	width, height := bounds.Dx(), bounds.Dy()
+	content := fmt.Sprintf("q %d 0 0 %d 0 0 cm /Im0 Do Q\n", width, height)
+	objects := [][]byte{
+		[]byte("<< /Type /Catalog /Pages 2 0 R >>"),
+		[]byte("<< /Type /Pages /Kids [3 0 R] /Count 1 >>"),
+		[]byte(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /XObject << /Im0 5 0 R >> >> /Contents 4 0 R >>", width, height)),
+		pdfStream("", []byte(content)),
+		pdfStream(fmt.Sprintf("/Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /DeviceRGB /BitsPerComponent 8 /Filter /FlateDecode", width, height), pixels.Bytes()),
+	}
+	return writePDF(w, objects)
+}
+
+// pdfStream returns a stream object with the entries added to its dictionary.
+func pdfStream(entries string, data []byte) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "<< %s /Length %d >>\nstream\n", entries, len(data))
+	buf.Write(data)
+	buf.WriteString("\nendstream")
+	return buf.Bytes()
+}
+
+// writePDF writes the objects, numbered from 1, with the cross-reference
+// table that a reader needs to find them. The first object is the catalog.
+func writePDF(w io.Writer, objects [][]byte) error {
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	offsets := make([]int, len(objects))
+	for i, object := range objects {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n", i+1)
+		buf.Write(object)
+		buf.WriteString("\nendobj\n")
+	}
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	_, err := w.Write(buf.Bytes())
+	return err
+}
@@ -0,0 +1,292 @@
+// Copyright (c) 2024 Michael D Henderson. All rights reserved.
+
+// Package render draws maps of a cluster catalog as images.
+// Rendering never changes the catalog.
+package render
+
+import (
+	"fmt"
+	"github.com/fogleman/gg"
+	"github.com/golang/freetype/truetype"
+	"github.com/playbymail/fargo/internal/aow"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/goregular"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Renderer draws a catalog onto an image.
+type Renderer struct {
+	width, height int
+	plane         Plane_e
+	background    color.Color
+	labelColor    color.Color
+	gridColor     color.Color
+	gridSpacing   float64 // light years between grid lines, zero for no grid
+	scaleBar      bool
+	dotRadius     float64 // pixels
+	fontSize      float64 // points
+	labels        []Label_e
+	highlights    []highlight_t
+	systems       map[string]bool // when not nil, only these systems are drawn
+	center        aow.Coordinates // the point drawn at the center of the image
+
+	// settings for animations
+	frames     int
+	axis       Axis_e
+	speed      float64 // degrees of rotation per frame
+	frameDelay int     // milliseconds between frames
+}
+
+type highlight_t struct {
+	color   color.Color
+	systems map[string]bool
+}
+
+// NewRenderer returns a renderer with the defaults adjusted by the options.
+// The defaults are a 4096 pixel square XY map on a black background with
+// labels showing the Z coordinate.
+func NewRenderer(options ...Option) (*Renderer, error) {
+	r := &Renderer{
+		width:      4 * 1024,
+		height:     4 * 1024,
+		plane:      XY,
+		background: color.Black,
+		labelColor: color.RGBA{R: 192, G: 192, B: 192, A: 255},
+		gridColor:  color.RGBA{R: 48, G: 48, B: 64, A: 255},
+		dotRadius:  9,
+		labels:     []Label_e{LabelDepth},
+		frames:     36,
+		axis:       AxisZ,
+		speed:      10,
+		frameDelay: 100,
+	}
+	for _, option := range options {
+		if err := option(r); err != nil {
+			return nil, err
+		}
+	}
+	if r.fontSize == 0 {
+		r.fontSize = math.Max(8, float64(min(r.width, r.height))/256)
+	}
+	return r, nil
+}
+
+// SavePNG renders the catalog to a PNG file.
+func SavePNG(filename string, catalog *aow.Catalog_t, options ...Option) error {
+	fp, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	if err = WritePNG(fp, catalog, options...); err != nil {
+		_ = fp.Close()
+		return err
+	}
+	return fp.Close()
+}
+
+// WritePNG renders the catalog and writes it to w as a PNG.
+func WritePNG(w io.Writer, catalog *aow.Catalog_t, options ...Option) error {
+	r, err := NewRenderer(options...)
+	if err != nil {
+		return err
+	}
+	return r.WritePNG(w, catalog)
+}
+
+// WritePNG renders the catalog and writes it to w as a PNG.
+func (r *Renderer) WritePNG(w io.Writer, catalog *aow.Catalog_t) error {
+	return png.Encode(w, r.Render(catalog))
+}
+
+// Render draws the catalog and returns the image.
+func (r *Renderer) Render(catalog *aow.Catalog_t) image.Image {
+	systems := r.visible(catalog)
+	extent, project := r.flat(systems)
+	return r.draw(systems, extent, project)
+}
+
+// flat returns the extent and projection for a flat map of the systems.
+func (r *Renderer) flat(systems []*aow.StarSystem_t) (float64, projection_t) {
+	origin := r.center.Scale(-1)
+
+	// find the extent of the map so that every system fits, with a little extra space
+	var extent float64
+	for _, ss := range systems {
+		h, v, _ := r.plane.project(ss.Coordinates.Translate(origin))
+		extent = max(extent, math.Abs(h), math.Abs(v))
+	}
+	extent += 4
+
+	return extent, func(c aow.Coordinates) (h, v, depth, magnification float64) {
+		h, v, depth = r.plane.project(c.Translate(origin))
+		return h, v, depth, 1
+	}
+}
+
+// scaleBarLength returns a round number of light years that fits in a quarter of the extent.
+func scaleBarLength(extent float64) float64 {
+	length := 1.0
+	for _, l := range []float64{1, 2, 5, 10, 20, 50, 100} {
+		if l <= extent/4 {
+			length = l
+		}
+	}
+	return length
+}
+
+// projection_t maps coordinates onto the horizontal and vertical axes of the image.
+// Systems with a lower depth are further from the viewer. Magnification scales
+// the size of the dot.
+type projection_t func(c aow.Coordinates) (h, v, depth, magnification float64)
+
+// draw renders the systems. The extent is the distance, in light years, from
+// the center of the image to the nearest edge.
+func (r *Renderer) draw(systems []*aow.StarSystem_t, extent float64, project projection_t) image.Image {
+	dc := gg.NewContext(r.width, r.height)
+	dc.SetColor(r.background)
+	dc.Clear()
+	if face, err := fontFace(r.fontSize); err == nil {
+		dc.SetFontFace(face)
+	}
+
+	scale := float64(min(r.width, r.height)) / (2 * extent)
+	cx, cy := float64(r.width)/2, float64(r.height)/2
+
+	if r.gridSpacing > 0 {
+		r.drawGrid(dc, extent, scale, cx, cy)
+	}
+
+	type point_t struct {
+		ss                      *aow.StarSystem_t
+		x, y, depth, magnifying float64
+	}
+	var points []point_t
+	for _, ss := range systems {
+		h, v, depth, magnification := project(ss.Coordinates)
+		// the vertical axis is flipped so that positive values are up
+		points = append(points, point_t{ss: ss, x: cx + h*scale, y: cy - v*scale, depth: depth, magnifying: magnification})
+	}
+
+	// draw the systems furthest from the viewer first
+	sort.SliceStable(points, func(i, j int) bool {
+		return points[i].depth < points[j].depth
+	})
+	for _, p := range points {
+		radius := r.dotRadius * p.magnifying
+
+		if hc, ok := r.highlightFor(p.ss); ok {
+			dc.SetColor(hc)
+			dc.SetLineWidth(math.Max(2, radius/3))
+			dc.DrawCircle(p.x, p.y, radius*2)
+			dc.Stroke()
+		}
+
+		dc.SetColor(p.ss.Color.RGBA())
+		dc.DrawCircle(p.x, p.y, radius)
+		dc.Fill()
+
+		if label := r.label(p.ss, p.depth); label != "" {
+			dc.SetColor(r.labelColor)
+			dc.DrawString(label, p.x+radius+2, p.y+radius/2)
+		}
+	}
+
+	if r.scaleBar {
+		r.drawScaleBar(dc, extent, scale)
+	}
+
+	return dc.Image()
+}
+
+// visible returns a new slice with the systems that should be drawn.
+// It is a copy so that sorting never re-orders the catalog.
+func (r *Renderer) visible(catalog *aow.Catalog_t) []*aow.StarSystem_t {
+	var systems []*aow.StarSystem_t
+	for _, ss := range catalog.StarSystems {
+		if r.systems != nil && !r.systems[ss.Id] {
+			continue
+		}
+		systems = append(systems, ss)
+	}
+	return systems
+}
+
+func (r *Renderer) highlightFor(ss *aow.StarSystem_t) (color.Color, bool) {
+	for _, h := range r.highlights {
+		if h.systems[ss.Id] {
+			return h.color, true
+		}
+	}
+	return nil, false
+}
+
+func (r *Renderer) label(ss *aow.StarSystem_t, depth float64) string {
+	var fields []string
+	for _, l := range r.labels {
+		switch l {
+		case LabelId:
+			fields = append(fields, ss.Id)
+		case LabelName:
+			fields = append(fields, ss.Name)
+		case LabelDepth:
+			fields = append(fields, fmt.Sprintf("(%+.1f)", depth))
+		}
+	}
+	return strings.Join(fields, " ")
+}
+
+// MaximumGridLines is the most grid lines drawn on each side of the center.
+const MaximumGridLines = 50
+
+// gridStep returns the distance between grid lines. The spacing is doubled
+// until no more than MaximumGridLines fit between the center and the edge.
+func (r *Renderer) gridStep(extent float64) float64 {
+	step := r.gridSpacing
+	for extent/step > MaximumGridLines {
+		step *= 2
+	}
+	return step
+}
+
+func (r *Renderer) drawGrid(dc *gg.Context, extent, scale, cx, cy float64) {
+	dc.SetColor(r.gridColor)
+	dc.SetLineWidth(1)
+	step := r.gridStep(extent)
+	for d := 0.0; d <= extent; d += step {
+		for _, offset := range []float64{-d, d} {
+			x, y := cx+offset*scale, cy+offset*scale
+			dc.DrawLine(x, 0, x, float64(r.height))
+			dc.DrawLine(0, y, float64(r.width), y)
+		}
+	}
+	dc.Stroke()
+}
+
+// drawScaleBar draws a bar in the bottom left corner with a length that is a round number of light years.
+func (r *Renderer) drawScaleBar(dc *gg.Context, extent, scale float64) {
+	length := scaleBarLength(extent)
+	margin := r.fontSize * 2
+	x, y := margin, float64(r.height)-margin
+	dc.SetColor(r.labelColor)
+	dc.SetLineWidth(math.Max(2, r.dotRadius/3))
+	dc.DrawLine(x, y, x+length*scale, y)
+	dc.DrawLine(x, y-r.fontSize/2, x, y+r.fontSize/2)
+	dc.DrawLine(x+length*scale, y-r.fontSize/2, x+length*scale, y+r.fontSize/2)
+	dc.Stroke()
+	dc.DrawString(fmt.Sprintf("%g ly", length), x, y-r.fontSize)
+}
+
+func fontFace(points float64) (font.Face, error) {
+	f, err := truetype.Parse(goregular.TTF)
+	if err != nil {
+		return nil, err
+	}
+	return truetype.NewFace(f, &truetype.Options{Size: points}), nil
+}
@@ -0,0 +1,105 @@
+// Copyright (c) 2024 Michael D Henderson. All rights reserved.
+
+package render
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/playbymail/fargo/internal/aow"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+// testCatalog returns S001 at the center, with S002 and S003 off to the sides
+// and listed out of depth order.
+func testCatalog() *aow.Catalog_t {
+	return &aow.Catalog_t{StarSystems: []*aow.StarSystem_t{
+		{Id: "S001", Name: "Sol", Coordinates: aow.Coordinates{}},
+		{Id: "S002", Name: "Vega", Coordinates: aow.Coordinates{X: 10, Z: 3}},
+		{Id: "S003", Name: "Rigel", Coordinates: aow.Coordinates{X: -10, Y: 5, Z: -2}},
+	}}
+}
+
+func sameColor(a, b color.Color) bool {
+	r1, g1, b1, a1 := a.RGBA()
+	r2, g2, b2, a2 := b.RGBA()
+	return r1 == r2 && g1 == g2 && b1 == b2 && a1 == a2
+}
+
+func TestRender(t *testing.T) {
+	catalog := testCatalog()
+	before, _ := json.Marshal(catalog)
+	navy, _ := ParseColor("navy")
+	r, err := NewRenderer(WithSize(128, 96), WithBackground(navy), WithLabels(LabelId, LabelDepth), WithScaleBar())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var first, second bytes.Buffer
+	if err := r.WritePNG(&first, catalog); err != nil {
+		t.Fatal(err)
+	} else if err := r.WritePNG(&second, catalog); err != nil {
+		t.Fatal(err)
+	} else if !bytes.Equal(first.Bytes(), second.Bytes()) {
+		t.Errorf("png: the same catalog gave different images")
+	}
+	if after, _ := json.Marshal(catalog); !bytes.Equal(before, after) {
+		t.Errorf("render: changed the catalog")
+	}
+
+	img, err := png.Decode(&first)
+	if err != nil {
+		t.Fatal(err)
+	} else if b := img.Bounds(); b.Dx() != 128 || b.Dy() != 96 {
+		t.Fatalf("size: want 128x96, got %dx%d", b.Dx(), b.Dy())
+	}
+	if got := img.At(0, 0); !sameColor(got, navy) {
+		t.Errorf("corner: want the background, got %v", got)
+	} else if got := img.At(64, 48); sameColor(got, navy) {
+		t.Errorf("center: want S001, got the background")
+	}
+
+	// a map limited to other systems leaves the center empty
+	r, err = NewRenderer(WithSize(128, 96), WithBackground(navy), WithLabels(), WithSystems("S002", "S003"))
+	if err != nil {
+		t.Fatal(err)
+	} else if got := r.Render(catalog).At(64, 48); !sameColor(got, navy) {
+		t.Errorf("systems: want an empty center, got %v", got)
+	}
+}
+
+func TestGridStep(t *testing.T) {
+	r, err := NewRenderer(WithGrid(MinimumGridSpacing))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, extent := range []float64{1, 4, 100, 1e6} {
+		if step := r.gridStep(extent); extent/step > MaximumGridLines {
+			t.Errorf("%g: step %g draws %g lines", extent, step, extent/step)
+		}
+	}
+	if _, err := NewRenderer(WithGrid(MinimumGridSpacing / 2)); err == nil {
+		t.Errorf("grid: want an error for a spacing below the minimum")
+	}
+}
+
+func TestParseOptions(t *testing.T) {
+	if p, err := ParsePlane("XZ"); err != nil || p != XZ {
+		t.Errorf("plane: want XZ, got %v, %v", p, err)
+	} else if _, err := ParsePlane("xx"); err == nil {
+		t.Errorf("plane: want an error for xx")
+	}
+	if labels, err := ParseLabels("id, name,z"); err != nil || len(labels) != 3 || labels[2] != LabelDepth {
+		t.Errorf("labels: got %v, %v", labels, err)
+	} else if labels, err := ParseLabels("none"); err != nil || len(labels) != 0 {
+		t.Errorf("labels: none: got %v, %v", labels, err)
+	} else if _, err := ParseLabels("id,mass"); err == nil {
+		t.Errorf("labels: want an error for mass")
+	}
+	if c, err := ParseColor("#102030"); err != nil || !sameColor(c, color.RGBA{R: 0x10, G: 0x20, B: 0x30, A: 255}) {
+		t.Errorf("color: got %v, %v", c, err)
+	} else if _, err := ParseColor("#10203"); err == nil {
+		t.Errorf("color: want an error for a short hex value")
+	}
+}
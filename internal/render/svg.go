@@ -0,0 +1,99 @@
+// Copyright (c) 2024 Michael D Henderson. All rights reserved.
+
+package render
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/playbymail/fargo/internal/aow"
+	"html"
+	"image/color"
+	"io"
+	"math"
+	"sort"
+)
+
+// WriteSVG renders the catalog and writes it to w as an SVG.
+func WriteSVG(w io.Writer, catalog *aow.Catalog_t, options ...Option) error {
+	r, err := NewRenderer(options...)
+	if err != nil {
+		return err
+	}
+	return r.WriteSVG(w, catalog)
+}
+
+// WriteSVG draws the same flat map as Render, but as an SVG.
+// Every system is a group with the system's id, so that a page showing
+// the map can find it.
+func (r *Renderer) WriteSVG(w io.Writer, catalog *aow.Catalog_t) error {
+	systems := r.visible(catalog)
+	extent, project := r.flat(systems)
+	scale := float64(min(r.width, r.height)) / (2 * extent)
+	cx, cy := float64(r.width)/2, float64(r.height)/2
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "<svg xmlns=%q width=\"%d\" height=\"%d\" viewBox=\"0 0 %d %d\" font-family=\"sans-serif\" font-size=\"%g\">\n",
+		"http://www.w3.org/2000/svg", r.width, r.height, r.width, r.height, r.fontSize)
+	fmt.Fprintf(bw, "<rect width=\"100%%\" height=\"100%%\" fill=\"%s\"/>\n", svgColor(r.background))
+
+	if r.gridSpacing > 0 {
+		fmt.Fprintf(bw, "<g stroke=\"%s\" stroke-width=\"1\">\n", svgColor(r.gridColor))
+		step := r.gridStep(extent)
+		for d := 0.0; d <= extent; d += step {
+			for _, offset := range []float64{-d, d} {
+				x, y := cx+offset*scale, cy+offset*scale
+				fmt.Fprintf(bw, "<line x1=\"%.1f\" y1=\"0\" x2=\"%.1f\" y2=\"%d\"/>\n", x, x, r.height)
+				fmt.Fprintf(bw, "<line x1=\"0\" y1=\"%.1f\" x2=\"%d\" y2=\"%.1f\"/>\n", y, r.width, y)
+			}
+		}
+		fmt.Fprintf(bw, "</g>\n")
+	}
+
+	type point_t struct {
+		ss          *aow.StarSystem_t
+		x, y, depth float64
+	}
+	var points []point_t
+	for _, ss := range systems {
+		h, v, depth, _ := project(ss.Coordinates)
+		points = append(points, point_t{ss: ss, x: cx + h*scale, y: cy - v*scale, depth: depth})
+	}
+	sort.SliceStable(points, func(i, j int) bool {
+		return points[i].depth < points[j].depth
+	})
+	for _, p := range points {
+		fmt.Fprintf(bw, "<g id=%q class=\"system\">", p.ss.Id)
+		fmt.Fprintf(bw, "<title>%s %s</title>", p.ss.Id, html.EscapeString(p.ss.Name))
+		if hc, ok := r.highlightFor(p.ss); ok {
+			fmt.Fprintf(bw, "<circle cx=\"%.1f\" cy=\"%.1f\" r=\"%g\" fill=\"none\" stroke=\"%s\" stroke-width=\"%g\"/>",
+				p.x, p.y, r.dotRadius*2, svgColor(hc), math.Max(2, r.dotRadius/3))
+		}
+		fmt.Fprintf(bw, "<circle cx=\"%.1f\" cy=\"%.1f\" r=\"%g\" fill=\"%s\"/>", p.x, p.y, r.dotRadius, svgColor(p.ss.Color.RGBA()))
+		if label := r.label(p.ss, p.depth); label != "" {
+			fmt.Fprintf(bw, "<text x=\"%.1f\" y=\"%.1f\" fill=\"%s\">%s</text>",
+				p.x+r.dotRadius+2, p.y+r.dotRadius/2, svgColor(r.labelColor), html.EscapeString(label))
+		}
+		fmt.Fprintf(bw, "</g>\n")
+	}
+
+	if r.scaleBar {
+		length := scaleBarLength(extent)
+		margin := r.fontSize * 2
+		x, y := margin, float64(r.height)-margin
+		fmt.Fprintf(bw, "<g stroke=\"%s\" stroke-width=\"%g\">", svgColor(r.labelColor), math.Max(2, r.dotRadius/3))
+		fmt.Fprintf(bw, "<line x1=\"%.1f\" y1=\"%.1f\" x2=\"%.1f\" y2=\"%.1f\"/>", x, y, x+length*scale, y)
+		fmt.Fprintf(bw, "<line x1=\"%.1f\" y1=\"%.1f\" x2=\"%.1f\" y2=\"%.1f\"/>", x, y-r.fontSize/2, x, y+r.fontSize/2)
+		fmt.Fprintf(bw, "<line x1=\"%.1f\" y1=\"%.1f\" x2=\"%.1f\" y2=\"%.1f\"/>", x+length*scale, y-r.fontSize/2, x+length*scale, y+r.fontSize/2)
+		fmt.Fprintf(bw, "</g>\n")
+		fmt.Fprintf(bw, "<text x=\"%.1f\" y=\"%.1f\" fill=\"%s\">%g ly</text>\n", x, y-r.fontSize, svgColor(r.labelColor), length)
+	}
+
+	fmt.Fprintf(bw, "</svg>\n")
+	return bw.Flush()
+}
+
+// svgColor returns the color as "#rrggbb".
+func svgColor(c color.Color) string {
+	rgba := color.RGBAModel.Convert(c).(color.RGBA)
+	return fmt.Sprintf("#%02x%02x%02x", rgba.R, rgba.G, rgba.B)
+}
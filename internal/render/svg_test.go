@@ -0,0 +1,24 @@
+// Copyright (c) 2024 Michael D Henderson. All rights reserved.
+
+package render
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestRenderFormats(t *testing.T) {
+	catalog := testCatalog()
+	var svg, pdf bytes.Buffer
+	if err := WriteSVG(&svg, catalog, WithSize(128, 128), WithLabels(LabelName)); err != nil {
+		t.Fatal(err)
+	} else if !strings.Contains(svg.String(), "<svg") || !strings.Contains(svg.String(), "Vega") {
+		t.Errorf("svg: want a drawing with labels, got %.60q", svg.String())
+	}
+	if err := WritePDF(&pdf, catalog, WithSize(128, 128)); err != nil {
+		t.Fatal(err)
+	} else if !bytes.HasPrefix(pdf.Bytes(), []byte("%PDF-")) {
+		t.Errorf("pdf: want a PDF header, got %.16q", pdf.Bytes())
+	}
+}
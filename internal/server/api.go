@@ -0,0 +1,357 @@
+// Copyright (c) 2024 Michael D Henderson. All rights reserved.
+
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"github.com/playbymail/fargo"
+	"github.com/playbymail/fargo/internal/render"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+)
+
+// functions to handle the requests in the API.
+//
+// every request needs an account. players may only ask about their own race.
+//
+//	GET /api/games                                  games the account plays in, every game for a GM
+//	GET /api/games/{game}                           the game and its races
+//	GET /api/games/{game}/schedule                  the deadline and the rest of the schedule
+//	PUT /api/games/{game}/schedule                  change the schedule, GMs only
+//	GET /api/games/{game}/submissions               which races have submitted orders, GMs only
+//	GET /api/games/{game}/races/{race}/report       the race's report, ?turn=N for an old one
+//	GET /api/games/{game}/races/{race}/map.png      map with the race's colonies highlighted
+//	GET /api/games/{game}/races/{race}/orders       the race's orders for the current turn
+//	PUT /api/games/{game}/races/{race}/orders       submit orders, checked like "fargo orders check"
+//	POST /api/games/{game}/races/{race}/orders/check check orders without submitting them
+//	GET /api/games/{game}/races/{race}/systems      what the race knows about every system
+//	GET /api/games/{game}/races/{race}/map.svg      the map as an SVG
+
+// MaximumOrdersSize is the largest orders file, in bytes, the server accepts.
+const MaximumOrdersSize = 1 << 20
+
+type game_t struct {
+	Id    string   `json:"id"`
+	Name  string   `json:"name"`
+	State string   `json:"state,omitempty"`
+	Turn  int      `json:"turn"`
+	Race  string   `json:"race,omitempty"` // the race the account plays
+	Races []race_t `json:"races,omitempty"`
+}
+
+type race_t struct {
+	Id   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type report_t struct {
+	Game string `json:"game"`
+	Race string `json:"race"`
+	Turn int    `json:"turn"`
+	Text string `json:"text"`
+}
+
+type orders_t struct {
+	*fargo.Submission_t
+	Turn   int            `json:"turn"`
+	Text   string         `json:"text,omitempty"`
+	Errors []orderError_t `json:"errors,omitempty"`
+}
+
+type orderError_t struct {
+	Line  int    `json:"line"`
+	Text  string `json:"text"`
+	Error string `json:"error"`
+}
+
+func (s *Server_t) getGames(w http.ResponseWriter, r *http.Request, acct *fargo.Account_t) {
+	games, err := s.dashboard(acct)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	list := []game_t{}
+	for _, st := range games {
+		list = append(list, game_t{Id: st.Id, Name: st.Name, State: st.State, Turn: st.Turn, Race: st.Race})
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+func (s *Server_t) getGame(w http.ResponseWriter, r *http.Request, acct *fargo.Account_t) {
+	g := s.loadGame(w, r, acct)
+	if g == nil {
+		return
+	}
+	settings, err := fargo.LoadSettings(s.gamePath(g.Id))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	game := game_t{Id: g.Id, Name: g.Name, State: settings.State.String(), Turn: g.Turn, Race: acct.RaceIn(g.Id)}
+	for _, race := range g.Races {
+		game.Races = append(game.Races, race_t{Id: race.Id, Name: race.Name})
+	}
+	writeJSON(w, http.StatusOK, game)
+}
+
+func (s *Server_t) getSchedule(w http.ResponseWriter, r *http.Request, acct *fargo.Account_t) {
+	g := s.loadGame(w, r, acct)
+	if g == nil {
+		return
+	}
+	s.smu.Lock()
+	defer s.smu.Unlock()
+	sched, err := fargo.LoadSchedule(s.gamePath(g.Id))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, sched)
+}
+
+// putSchedule replaces the schedule. The deadline is for the current turn
+// and no reminders have been sent for it.
+func (s *Server_t) putSchedule(w http.ResponseWriter, r *http.Request, acct *fargo.Account_t) {
+	g := s.loadGame(w, r, acct)
+	if g == nil {
+		return
+	}
+	var sched fargo.Schedule_t
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<16)).Decode(&sched); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	} else if sched.Grace < 0 || sched.Period < 0 {
+		writeError(w, http.StatusBadRequest, fargo.ErrInvalidArguments)
+		return
+	}
+	sched.Turn, sched.Reminded = g.Turn, nil
+	s.smu.Lock()
+	defer s.smu.Unlock()
+	if err := sched.Save(s.gamePath(g.Id)); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	log.Printf("server: schedule: %s: turn %d: deadline %s\n", acct.Handle, g.Turn, deadline(&sched))
+	writeJSON(w, http.StatusOK, sched)
+}
+
+func (s *Server_t) getSubmissions(w http.ResponseWriter, r *http.Request, acct *fargo.Account_t) {
+	g := s.loadGame(w, r, acct)
+	if g == nil {
+		return
+	}
+	list, err := g.Submissions(s.gamePath(g.Id))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+func (s *Server_t) getReport(w http.ResponseWriter, r *http.Request, acct *fargo.Account_t) {
+	g, race := s.loadRace(w, r, acct)
+	if g == nil {
+		return
+	}
+	report := report_t{Game: g.Id, Race: race.Id, Turn: g.Turn}
+	if turn := r.URL.Query().Get("turn"); turn != "" {
+		n, err := strconv.Atoi(turn)
+		if err != nil || n < 1 || n > g.Turn {
+			writeError(w, http.StatusBadRequest, fargo.ErrInvalidArguments)
+			return
+		}
+		report.Turn = n
+	}
+	// the report for the current turn is written fresh; older ones come from the game's store
+	if report.Turn == g.Turn {
+		var buf bytes.Buffer
+		if err := g.WriteReport(&buf, race); err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		report.Text = buf.String()
+	} else {
+		data, err := fargo.ReadReport(s.gamePath(g.Id), report.Turn, race.Id)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		report.Text = string(data)
+	}
+	writeJSON(w, http.StatusOK, report)
+}
+
+func (s *Server_t) getMap(w http.ResponseWriter, r *http.Request, acct *fargo.Account_t) {
+	g, race := s.loadRace(w, r, acct)
+	if g == nil {
+		return
+	}
+	size, plane := 1024, render.XY
+	if value := r.URL.Query().Get("size"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 64 || n > render.MaximumSize {
+			writeError(w, http.StatusBadRequest, fargo.ErrInvalidArguments)
+			return
+		}
+		size = n
+	}
+	if value := r.URL.Query().Get("plane"); value != "" {
+		p, err := render.ParsePlane(value)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		plane = p
+	}
+	var colonies []string
+	for _, colony := range g.ColoniesOf(race) {
+		colonies = append(colonies, colony.System)
+	}
+	highlight, _ := render.ParseColor("cyan")
+	var buf bytes.Buffer
+	err := render.WritePNG(&buf, g.Cluster,
+		render.WithSize(size, size),
+		render.WithPlane(plane),
+		render.WithLabels(render.LabelId),
+		render.WithHighlights(highlight, colonies...),
+	)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.Header().Set("Content-Type", "image/png")
+	_, _ = w.Write(buf.Bytes())
+}
+
+func (s *Server_t) getOrders(w http.ResponseWriter, r *http.Request, acct *fargo.Account_t) {
+	g, race := s.loadRace(w, r, acct)
+	if g == nil {
+		return
+	}
+	submission, err := g.Submission(s.gamePath(g.Id), race)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	orders := orders_t{Submission_t: submission, Turn: g.Turn}
+	if submission.Submitted {
+		data, err := os.ReadFile(fargo.OrdersPath(s.gamePath(g.Id), g.Turn, race.Id))
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		orders.Text = string(data)
+	}
+	writeJSON(w, http.StatusOK, orders)
+}
+
+func (s *Server_t) putOrders(w http.ResponseWriter, r *http.Request, acct *fargo.Account_t) {
+	g, race := s.loadRace(w, r, acct)
+	if g == nil {
+		return
+	}
+	text, err := io.ReadAll(http.MaxBytesReader(w, r.Body, MaximumOrdersSize))
+	if err != nil {
+		writeError(w, http.StatusRequestEntityTooLarge, err)
+		return
+	}
+	errs, err := g.SubmitOrders(s.gamePath(g.Id), race, text)
+	if errors.Is(err, fargo.ErrTurnLocked) || errors.Is(err, fargo.ErrTurnChanged) || errors.Is(err, fargo.ErrGameArchived) {
+		writeError(w, http.StatusConflict, err)
+		return
+	} else if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	submission, err := g.Submission(s.gamePath(g.Id), race)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	orders := orders_t{Submission_t: submission, Turn: g.Turn, Text: string(text), Errors: newOrderErrors(errs)}
+	log.Printf("server: orders: %s: turn %d: %d errors\n", race.Id, g.Turn, len(errs))
+	s.Publish(g, "orders", race.Id, struct {
+		Errors  int        `json:"errors"`
+		Updated *time.Time `json:"updated,omitempty"`
+	}{len(errs), submission.Updated})
+	writeJSON(w, http.StatusOK, orders)
+}
+
+// postCheckOrders checks orders the way putOrders does, but doesn't save them.
+func (s *Server_t) postCheckOrders(w http.ResponseWriter, r *http.Request, acct *fargo.Account_t) {
+	g, race := s.loadRace(w, r, acct)
+	if g == nil {
+		return
+	}
+	text, err := io.ReadAll(http.MaxBytesReader(w, r.Body, MaximumOrdersSize))
+	if err != nil {
+		writeError(w, http.StatusRequestEntityTooLarge, err)
+		return
+	}
+	o, errs, err := g.CheckSubmission(race, text)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, struct {
+		Turn   int            `json:"turn"`
+		Orders int            `json:"orders"`
+		Errors []orderError_t `json:"errors"`
+	}{Turn: g.Turn, Orders: len(o.Orders), Errors: newOrderErrors(errs)})
+}
+
+func (s *Server_t) getSystems(w http.ResponseWriter, r *http.Request, acct *fargo.Account_t) {
+	g, race := s.loadRace(w, r, acct)
+	if g == nil {
+		return
+	}
+	list := []*fargo.SystemView_t{}
+	for _, ss := range g.Cluster.StarSystems {
+		list = append(list, g.View(race, ss))
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+func (s *Server_t) getMapSVG(w http.ResponseWriter, r *http.Request, acct *fargo.Account_t) {
+	g, race := s.loadRace(w, r, acct)
+	if g == nil {
+		return
+	}
+	var buf bytes.Buffer
+	if err := writeMapSVG(&buf, g, race); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.Header().Set("Content-Type", "image/svg+xml")
+	_, _ = w.Write(buf.Bytes())
+}
+
+// writeMapSVG draws the cluster with the race's colonies highlighted.
+func writeMapSVG(w io.Writer, g *fargo.Game_t, race *fargo.Race_t) error {
+	var colonies []string
+	for _, colony := range g.ColoniesOf(race) {
+		colonies = append(colonies, colony.System)
+	}
+	highlight, _ := render.ParseColor("cyan")
+	return render.WriteSVG(w, g.Cluster,
+		render.WithSize(1024, 1024),
+		render.WithLabels(render.LabelId),
+		render.WithHighlights(highlight, colonies...),
+		render.WithGrid(10),
+		render.WithScaleBar(),
+	)
+}
+
+func newOrderErrors(errs []*fargo.OrderError_t) []orderError_t {
+	list := []orderError_t{}
+	for _, e := range errs {
+		list = append(list, orderError_t{Line: e.Line, Text: e.Text, Error: e.Err.Error()})
+	}
+	return list
+}
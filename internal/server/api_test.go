@@ -0,0 +1,128 @@
+// Copyright (c) 2024 Michael D Henderson. All rights reserved.
+
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestGetGame(t *testing.T) {
+	site := newTestSite(t)
+	w := site.do("GET", "/api/games/alpha", site.player)
+	if w.Code != http.StatusOK {
+		t.Fatalf("game: want 200, got %d: %s", w.Code, w.Body)
+	}
+	var game game_t
+	if err := json.Unmarshal(w.Body.Bytes(), &game); err != nil {
+		t.Fatal(err)
+	} else if game.Id != "alpha" || game.Race != "R001" || len(game.Races) != 2 || game.Turn != 1 {
+		t.Errorf("game: got %+v", game)
+	}
+	if w := site.do("GET", "/api/games/gamma", site.gm); w.Code != http.StatusNotFound {
+		t.Errorf("unknown game: want 404, got %d", w.Code)
+	}
+}
+
+func TestGetReport(t *testing.T) {
+	site := newTestSite(t)
+	w := site.do("GET", "/api/games/alpha/races/R001/report", site.player)
+	if w.Code != http.StatusOK {
+		t.Fatalf("report: want 200, got %d: %s", w.Code, w.Body)
+	}
+	var report report_t
+	if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil {
+		t.Fatal(err)
+	} else if report.Race != "R001" || report.Turn != 1 || report.Text == "" {
+		t.Errorf("report: got %+v", report)
+	}
+	for _, turn := range []string{"0", "2", "x"} {
+		if w := site.do("GET", "/api/games/alpha/races/R001/report?turn="+turn, site.player); w.Code != http.StatusBadRequest {
+			t.Errorf("turn %s: want 400, got %d", turn, w.Code)
+		}
+	}
+	if w := site.do("GET", "/api/games/alpha/races/R009/report", site.gm); w.Code != http.StatusNotFound {
+		t.Errorf("unknown race: want 404, got %d", w.Code)
+	}
+}
+
+func TestGetMap(t *testing.T) {
+	site := newTestSite(t)
+	w := site.do("GET", "/api/games/alpha/races/R001/map.png?size=128&plane=xz", site.player)
+	if w.Code != http.StatusOK {
+		t.Fatalf("map: want 200, got %d: %s", w.Code, w.Body)
+	} else if w.Header().Get("Content-Type") != "image/png" || !bytes.HasPrefix(w.Body.Bytes(), []byte("\x89PNG")) {
+		t.Errorf("map: want a PNG, got %q", w.Header().Get("Content-Type"))
+	}
+	for _, query := range []string{"size=10", "size=100000", "size=big", "plane=up"} {
+		if w := site.do("GET", "/api/games/alpha/races/R001/map.png?"+query, site.player); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: want 400, got %d", query, w.Code)
+		}
+	}
+	w = site.do("GET", "/api/games/alpha/races/R001/map.svg", site.player)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "<svg") {
+		t.Errorf("svg: want 200 and an SVG, got %d", w.Code)
+	}
+}
+
+func TestOrders(t *testing.T) {
+	site := newTestSite(t)
+	get := func() orders_t {
+		t.Helper()
+		w := site.do("GET", "/api/games/alpha/races/R001/orders", site.player)
+		if w.Code != http.StatusOK {
+			t.Fatalf("orders: want 200, got %d: %s", w.Code, w.Body)
+		}
+		var orders orders_t
+		if err := json.Unmarshal(w.Body.Bytes(), &orders); err != nil {
+			t.Fatal(err)
+		}
+		return orders
+	}
+	if orders := get(); orders.Submitted || orders.Text != "" {
+		t.Fatalf("orders: want nothing submitted, got %+v", orders)
+	}
+
+	text := "race R001\nresearch drive 10\nbuild 1 frigate at C001\n"
+	w := site.send("POST", "/api/games/alpha/races/R001/orders/check", site.player, text)
+	if w.Code != http.StatusOK {
+		t.Fatalf("check: want 200, got %d: %s", w.Code, w.Body)
+	}
+	var check struct {
+		Orders int            `json:"orders"`
+		Errors []orderError_t `json:"errors"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &check); err != nil {
+		t.Fatal(err)
+	} else if check.Orders != 2 || len(check.Errors) != 1 || check.Errors[0].Line != 3 {
+		t.Errorf("check: want the unknown design found on line 3, got %+v", check)
+	} else if get().Submitted {
+		t.Errorf("check: want the orders not saved")
+	}
+
+	// orders that fail a check are saved anyway, with the failures returned
+	w = site.send("PUT", "/api/games/alpha/races/R001/orders", site.player, text)
+	if w.Code != http.StatusOK {
+		t.Fatalf("submit: want 200, got %d: %s", w.Code, w.Body)
+	}
+	var submitted orders_t
+	if err := json.Unmarshal(w.Body.Bytes(), &submitted); err != nil {
+		t.Fatal(err)
+	} else if !submitted.Submitted || len(submitted.Errors) != 1 {
+		t.Errorf("submit: got %+v", submitted)
+	}
+	if orders := get(); !orders.Submitted || orders.Text != text {
+		t.Errorf("orders: want the submitted text back, got %+v", orders)
+	}
+
+	if w := site.send("PUT", "/api/games/alpha/races/R001/orders", site.player, "race R002\n"); w.Code != http.StatusBadRequest {
+		t.Errorf("wrong race: want 400, got %d", w.Code)
+	}
+	w = site.do("GET", "/api/games/alpha/submissions", site.gm)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"submitted": true`) {
+		t.Errorf("submissions: want R001 listed as submitted, got %d: %s", w.Code, w.Body)
+	}
+}
@@ -0,0 +1,424 @@
+// Copyright (c) 2024 Michael D Henderson. All rights reserved.
+
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/playbymail/fargo"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// functions to authenticate requests and manage credentials.
+//
+// clients send a token in the Authorization header as "Bearer <token>".
+// browsers are also given the session token in a cookie when they log in.
+// a player can log in with a password or with a one-time link, and can
+// create API tokens for scripts. a login link opens a page that asks the
+// player to confirm (see web.go); only the POST uses the link up, so mail
+// clients that fetch links ahead of time can't spend it.
+//
+//	POST   /api/login                {"handle": "...", "password": "..."}
+//	POST   /api/login/magic          {"email": "..."}, sends a login link
+//	POST   /api/login/magic/{token}  uses a login link
+//	POST   /api/logout
+//	GET    /api/me
+//	GET    /api/tokens
+//	POST   /api/tokens               {"name": "..."}, returns the token once
+//	DELETE /api/tokens/{id}
+//	GET    /api/accounts             admins only
+//	POST   /api/accounts             admins only
+
+const (
+	// ErrForbidden is returned when an account asks for something it may not see.
+	ErrForbidden = fargo.Error("forbidden")
+	// ErrNoLoginLinks is returned when the server has no URL to put in a login link.
+	ErrNoLoginLinks = fargo.Error("login links are not enabled")
+	// ErrUnknownGame is returned when the site has no game with the id.
+	ErrUnknownGame = fargo.Error("unknown game")
+
+	sessionCookie = "fargo-session"
+)
+
+type handler_f func(w http.ResponseWriter, r *http.Request, acct *fargo.Account_t)
+
+type account_t struct {
+	Handle string            `json:"handle"`
+	Email  string            `json:"email,omitempty"`
+	Role   string            `json:"role"`
+	Races  map[string]string `json:"races,omitempty"` // race ids by game id
+}
+
+type token_t struct {
+	Id      string     `json:"id"`
+	Kind    string     `json:"kind"`
+	Name    string     `json:"name,omitempty"`
+	Token   string     `json:"token,omitempty"` // only when the token is created
+	Created time.Time  `json:"created"`
+	Expires *time.Time `json:"expires,omitempty"`
+}
+
+func newAccount(acct *fargo.Account_t) account_t {
+	return account_t{Handle: acct.Handle, Email: acct.Email, Role: acct.Role.String(), Races: acct.Races}
+}
+
+func newToken(t *fargo.Token_t, secret string) token_t {
+	return token_t{Id: t.Id, Kind: t.Kind, Name: t.Name, Token: secret, Created: t.Created, Expires: t.Expires}
+}
+
+// authenticated wraps a handler that needs a logged in account.
+func (s *Server_t) authenticated(h handler_f) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		acct, _, err := s.caller(r)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		} else if acct == nil {
+			writeError(w, http.StatusUnauthorized, fargo.ErrInvalidCredentials)
+			return
+		}
+		h(w, r, acct)
+	}
+}
+
+// gm wraps a handler that needs a GM or an admin.
+func (s *Server_t) gm(h handler_f) http.HandlerFunc {
+	return s.authenticated(func(w http.ResponseWriter, r *http.Request, acct *fargo.Account_t) {
+		if acct.Role < fargo.RoleGM {
+			writeError(w, http.StatusForbidden, ErrForbidden)
+			return
+		}
+		h(w, r, acct)
+	})
+}
+
+// admin wraps a handler that needs an admin.
+func (s *Server_t) admin(h handler_f) http.HandlerFunc {
+	return s.authenticated(func(w http.ResponseWriter, r *http.Request, acct *fargo.Account_t) {
+		if acct.Role < fargo.RoleAdmin {
+			writeError(w, http.StatusForbidden, ErrForbidden)
+			return
+		}
+		h(w, r, acct)
+	})
+}
+
+// caller returns the account and token that made the request, or nil if the request has no valid token.
+func (s *Server_t) caller(r *http.Request) (*fargo.Account_t, *fargo.Token_t, error) {
+	secret, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		if cookie, err := r.Cookie(sessionCookie); err == nil {
+			secret = cookie.Value
+		}
+	}
+	if secret == "" {
+		return nil, nil, nil
+	}
+	accounts, err := s.readAccounts()
+	if err != nil {
+		return nil, nil, err
+	}
+	acct, t := accounts.Authenticate(secret, time.Now(), "session", "api")
+	return acct, t, nil
+}
+
+// readAccounts loads the accounts. They are loaded for every request so
+// that accounts added with the CLI are seen at once.
+func (s *Server_t) readAccounts() (*fargo.Accounts_t, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return fargo.LoadAccounts(s.path)
+}
+
+// updateAccounts loads the accounts, lets fn change them and saves them if fn succeeds.
+func (s *Server_t) updateAccounts(fn func(a *fargo.Accounts_t) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	a, err := fargo.LoadAccounts(s.path)
+	if err != nil {
+		return err
+	} else if err := fn(a); err != nil {
+		return err
+	}
+	return a.Save(s.path)
+}
+
+// setSessionCookie gives the browser the session token.
+func setSessionCookie(w http.ResponseWriter, r *http.Request, secret string, t *fargo.Token_t) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookie,
+		Value:    secret,
+		Path:     "/",
+		Expires:  *t.Expires,
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// writeSession sends a new session token as a cookie and in the response.
+func writeSession(w http.ResponseWriter, r *http.Request, acct *fargo.Account_t, secret string, t *fargo.Token_t) {
+	setSessionCookie(w, r, secret, t)
+	writeJSON(w, http.StatusOK, struct {
+		account_t
+		Session token_t `json:"session"`
+	}{newAccount(acct), newToken(t, secret)})
+}
+
+func (s *Server_t) postLogin(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		Handle   string `json:"handle"`
+		Password string `json:"password"`
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 4096)).Decode(&input); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	acct, secret, t, err := s.login(input.Handle, input.Password)
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, fargo.ErrInvalidCredentials)
+		return
+	}
+	writeSession(w, r, acct, secret, t)
+}
+
+// login checks the password and starts a session.
+func (s *Server_t) login(handle, password string) (acct *fargo.Account_t, secret string, t *fargo.Token_t, err error) {
+	err = s.updateAccounts(func(a *fargo.Accounts_t) (err error) {
+		if acct, err = a.CheckPassword(handle, password); err != nil {
+			return err
+		}
+		secret, t, err = a.NewToken(acct, "session", "", fargo.SessionTTL, time.Now())
+		return err
+	})
+	if err != nil {
+		log.Printf("server: login: %q: %v\n", handle, err)
+		return nil, "", nil, err
+	}
+	return acct, secret, t, nil
+}
+
+// postMagicLink sends a login link to the account with the email address.
+// The response is the same whether or not there is such an account. The
+// link is built from the server's URL, so that a request with a forged
+// Host header can't send a player a link to another site.
+func (s *Server_t) postMagicLink(w http.ResponseWriter, r *http.Request) {
+	if s.URL == "" {
+		writeError(w, http.StatusServiceUnavailable, ErrNoLoginLinks)
+		return
+	}
+	var input struct {
+		Email string `json:"email"`
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 4096)).Decode(&input); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	var acct *fargo.Account_t
+	var secret string
+	err := s.updateAccounts(func(a *fargo.Accounts_t) (err error) {
+		if acct = a.AccountByEmail(input.Email); acct == nil {
+			return fmt.Errorf("%q: %w", input.Email, fargo.ErrUnknownAccount)
+		}
+		secret, _, err = a.NewToken(acct, "magic", "", fargo.MagicLinkTTL, time.Now())
+		return err
+	})
+	if err == nil {
+		err = s.SendLoginLink(acct, strings.TrimSuffix(s.URL, "/")+"/login/magic/"+secret)
+	}
+	if err != nil {
+		log.Printf("server: login link: %v\n", err)
+	}
+	writeJSON(w, http.StatusAccepted, struct{}{})
+}
+
+func (s *Server_t) postUseMagicLink(w http.ResponseWriter, r *http.Request) {
+	acct, secret, t, err := s.useMagicLink(r.PathValue("token"))
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, fargo.ErrInvalidCredentials)
+		return
+	}
+	writeSession(w, r, acct, secret, t)
+}
+
+// useMagicLink spends the login link and starts a session.
+func (s *Server_t) useMagicLink(link string) (acct *fargo.Account_t, secret string, t *fargo.Token_t, err error) {
+	err = s.updateAccounts(func(a *fargo.Accounts_t) (err error) {
+		var magic *fargo.Token_t
+		if acct, magic = a.Authenticate(link, time.Now(), "magic"); acct == nil {
+			return fargo.ErrInvalidCredentials
+		}
+		// a link can only be used once
+		a.Revoke(magic)
+		secret, t, err = a.NewToken(acct, "session", "", fargo.SessionTTL, time.Now())
+		return err
+	})
+	if err != nil {
+		return nil, "", nil, err
+	}
+	return acct, secret, t, nil
+}
+
+func (s *Server_t) postLogout(w http.ResponseWriter, r *http.Request, acct *fargo.Account_t) {
+	if err := s.logout(w, r, acct); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, struct{}{})
+}
+
+// logout ends the session that made the request and clears the cookie.
+// The session may have been revoked since the request was authenticated.
+func (s *Server_t) logout(w http.ResponseWriter, r *http.Request, acct *fargo.Account_t) error {
+	_, current, err := s.caller(r)
+	if err != nil {
+		return err
+	}
+	err = s.updateAccounts(func(a *fargo.Accounts_t) error {
+		if current == nil {
+			return nil
+		}
+		for _, t := range a.TokensOf(acct) {
+			if t.Kind == "session" && t.Id == current.Id {
+				a.Revoke(t)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	http.SetCookie(w, &http.Cookie{Name: sessionCookie, Value: "", Path: "/", MaxAge: -1})
+	return nil
+}
+
+func (s *Server_t) getMe(w http.ResponseWriter, r *http.Request, acct *fargo.Account_t) {
+	writeJSON(w, http.StatusOK, newAccount(acct))
+}
+
+func (s *Server_t) getTokens(w http.ResponseWriter, r *http.Request, acct *fargo.Account_t) {
+	a, err := s.readAccounts()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	list := []token_t{}
+	for _, t := range a.TokensOf(acct) {
+		if t.Kind == "api" {
+			list = append(list, newToken(t, ""))
+		}
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+func (s *Server_t) postToken(w http.ResponseWriter, r *http.Request, acct *fargo.Account_t) {
+	var input struct {
+		Name string `json:"name"`
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 4096)).Decode(&input); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	var created token_t
+	err := s.updateAccounts(func(a *fargo.Accounts_t) error {
+		secret, t, err := a.NewToken(acct, "api", input.Name, 0, time.Now())
+		created = newToken(t, secret)
+		return err
+	})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, created)
+}
+
+func (s *Server_t) deleteToken(w http.ResponseWriter, r *http.Request, acct *fargo.Account_t) {
+	found := false
+	err := s.updateAccounts(func(a *fargo.Accounts_t) error {
+		for _, t := range a.TokensOf(acct) {
+			if t.Kind == "api" && t.Id == r.PathValue("id") {
+				a.Revoke(t)
+				found = true
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	} else if !found {
+		writeError(w, http.StatusNotFound, fmt.Errorf("%q: unknown token", r.PathValue("id")))
+		return
+	}
+	writeJSON(w, http.StatusOK, struct{}{})
+}
+
+func (s *Server_t) getAccounts(w http.ResponseWriter, r *http.Request, acct *fargo.Account_t) {
+	a, err := s.readAccounts()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	list := []account_t{}
+	for _, acct := range a.Accounts {
+		list = append(list, newAccount(acct))
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+func (s *Server_t) postAccount(w http.ResponseWriter, r *http.Request, admin *fargo.Account_t) {
+	var input struct {
+		account_t
+		Password string `json:"password"`
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 4096)).Decode(&input); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	role, err := fargo.ParseRole(input.Role)
+	if input.Role == "" {
+		role, err = fargo.RolePlayer, nil
+	}
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	acct := &fargo.Account_t{Handle: input.Handle, Email: input.Email, Role: role}
+	for id, race := range input.Races {
+		if !fargo.ValidGameId(id) {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("%q: %w", id, ErrUnknownGame))
+			return
+		}
+		g, err := s.loadGameById(id)
+		if errors.Is(err, os.ErrNotExist) {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("%q: %w", id, ErrUnknownGame))
+			return
+		} else if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		} else if g.Race(race) == nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("%s: %q: %w", id, race, fargo.ErrUnknownRace))
+			return
+		}
+		acct.Join(id, g.Race(race).Id)
+	}
+	err = s.updateAccounts(func(a *fargo.Accounts_t) error {
+		if input.Password != "" {
+			if err := a.SetPassword(acct, input.Password); err != nil {
+				return err
+			}
+		}
+		return a.Add(acct)
+	})
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	log.Printf("server: accounts: %s added %s (%s)\n", admin.Handle, acct.Handle, acct.Role)
+	writeJSON(w, http.StatusCreated, newAccount(acct))
+}
@@ -0,0 +1,90 @@
+// Copyright (c) 2024 Michael D Henderson. All rights reserved.
+
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func TestRaceAccess(t *testing.T) {
+	site := newTestSite(t)
+	for _, path := range []string{"report", "map.png?size=64", "map.svg", "orders", "systems"} {
+		for _, tc := range []struct {
+			name   string
+			target string
+			token  string
+			want   int
+		}{
+			{"anonymous", "/api/games/alpha/races/R001/", "", http.StatusUnauthorized},
+			{"bad token", "/api/games/alpha/races/R001/", "not-a-token", http.StatusUnauthorized},
+			{"own race", "/api/games/alpha/races/R001/", site.player, http.StatusOK},
+			{"own race, any case", "/api/games/alpha/races/r001/", site.player, http.StatusOK},
+			{"other race", "/api/games/alpha/races/R002/", site.player, http.StatusForbidden},
+			{"other game", "/api/games/beta/races/R001/", site.player, http.StatusForbidden},
+			{"gm", "/api/games/beta/races/R002/", site.gm, http.StatusOK},
+		} {
+			if w := site.do("GET", tc.target+path, tc.token); w.Code != tc.want {
+				t.Errorf("%s: %s: want %d, got %d: %s", path, tc.name, tc.want, w.Code, w.Body)
+			}
+		}
+	}
+	w := site.send("PUT", "/api/games/alpha/races/R002/orders", site.player, "race R002\n")
+	if w.Code != http.StatusForbidden {
+		t.Errorf("submit for another race: want 403, got %d", w.Code)
+	}
+}
+
+func TestRoleAccess(t *testing.T) {
+	site := newTestSite(t)
+	for _, tc := range []struct {
+		method, target string
+		player, gm     int
+	}{
+		{"GET", "/api/me", http.StatusOK, http.StatusOK},
+		{"GET", "/api/games/alpha", http.StatusOK, http.StatusOK},
+		{"GET", "/api/games/beta", http.StatusForbidden, http.StatusOK},
+		{"GET", "/api/games/alpha/submissions", http.StatusForbidden, http.StatusOK},
+		{"GET", "/api/dashboard", http.StatusForbidden, http.StatusOK},
+		{"GET", "/api/accounts", http.StatusForbidden, http.StatusForbidden},
+	} {
+		if w := site.do(tc.method, tc.target, site.player); w.Code != tc.player {
+			t.Errorf("%s %s: player: want %d, got %d", tc.method, tc.target, tc.player, w.Code)
+		}
+		if w := site.do(tc.method, tc.target, site.gm); w.Code != tc.gm {
+			t.Errorf("%s %s: gm: want %d, got %d", tc.method, tc.target, tc.gm, w.Code)
+		}
+	}
+}
+
+func TestRevokeToken(t *testing.T) {
+	site := newTestSite(t)
+	w := site.send("POST", "/api/tokens", site.player, `{"name":"laptop"}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create: want 201, got %d: %s", w.Code, w.Body)
+	}
+	var created token_t
+	if err := json.Unmarshal(w.Body.Bytes(), &created); err != nil {
+		t.Fatal(err)
+	} else if created.Token == "" {
+		t.Fatalf("create: want the secret returned once, got %+v", created)
+	}
+	if w := site.do("GET", "/api/me", created.Token); w.Code != http.StatusOK {
+		t.Fatalf("new token: want 200, got %d", w.Code)
+	}
+
+	// one account can't revoke another's token
+	if w := site.do("DELETE", "/api/tokens/"+created.Id, site.gm); w.Code != http.StatusNotFound {
+		t.Errorf("gm revoke: want 404, got %d", w.Code)
+	}
+	if w := site.do("DELETE", "/api/tokens/"+created.Id, site.player); w.Code != http.StatusOK {
+		t.Fatalf("revoke: want 200, got %d: %s", w.Code, w.Body)
+	}
+	if w := site.do("GET", "/api/me", created.Token); w.Code != http.StatusUnauthorized {
+		t.Errorf("revoked token: want 401, got %d", w.Code)
+	}
+	if w := site.do("GET", "/api/me", site.player); w.Code != http.StatusOK {
+		t.Errorf("other token: want 200, got %d", w.Code)
+	}
+}
@@ -0,0 +1,129 @@
+// Copyright (c) 2024 Michael D Henderson. All rights reserved.
+
+package server
+
+import (
+	"github.com/playbymail/fargo"
+	"log"
+	"net/http"
+	"sort"
+	"time"
+)
+
+// functions to show the state of every game in the site.
+//
+// a player sees the games they have joined. a GM sees every game, with
+// how many races have submitted orders and which are still waiting, so
+// that one page shows which games are held up and by whom.
+//
+//	GET /api/dashboard  every game's turn, deadline and orders, GMs only
+//	GET /games          the dashboard, or the player's games
+
+// status_t is the state of a game's current turn.
+type status_t struct {
+	Id        string     `json:"id"`
+	Name      string     `json:"name"`
+	State     string     `json:"state"` // "unavailable" if the game can't be loaded
+	Turn      int        `json:"turn"`
+	Deadline  *time.Time `json:"deadline,omitempty"`
+	Locked    bool       `json:"locked,omitempty"` // the turn is being processed
+	Race      string     `json:"race,omitempty"`   // the race the account plays
+	Orders    bool       `json:"orders,omitempty"` // the account's race has submitted orders
+	Races     int        `json:"races"`
+	Submitted int        `json:"submitted"`         // races that have submitted orders, for GMs
+	Waiting   []string   `json:"waiting,omitempty"` // races without orders, for GMs
+}
+
+// dashboard returns the state of the games the account may see. Archived
+// games come after the others. A game that can't be loaded is listed as
+// unavailable, so that one broken game doesn't hide the others.
+func (s *Server_t) dashboard(acct *fargo.Account_t) ([]*status_t, error) {
+	ids, err := fargo.ListGames(s.path)
+	if err != nil {
+		return nil, err
+	}
+	list := []*status_t{}
+	for _, id := range ids {
+		if acct.Role == fargo.RolePlayer && acct.RaceIn(id) == "" {
+			continue
+		}
+		st, err := s.gameStatus(acct, id)
+		if err != nil {
+			log.Printf("server: dashboard: %s: %v\n", id, err)
+			st = &status_t{Id: id, Name: id, State: "unavailable", Race: acct.RaceIn(id)}
+		}
+		list = append(list, st)
+	}
+	sort.SliceStable(list, func(i, j int) bool {
+		return list[i].State != "archived" && list[j].State == "archived"
+	})
+	return list, nil
+}
+
+// gameStatus returns the state of the game's current turn for the account.
+func (s *Server_t) gameStatus(acct *fargo.Account_t, id string) (*status_t, error) {
+	path := s.gamePath(id)
+	g, err := s.loadGameById(id)
+	if err != nil {
+		return nil, err
+	}
+	settings, err := fargo.LoadSettings(path)
+	if err != nil {
+		return nil, err
+	}
+	s.smu.Lock()
+	sched, err := fargo.LoadSchedule(path)
+	s.smu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	submissions, err := g.Submissions(path)
+	if err != nil {
+		return nil, err
+	}
+	st := &status_t{
+		Id:       g.Id,
+		Name:     g.Name,
+		State:    settings.State.String(),
+		Turn:     g.Turn,
+		Deadline: sched.Deadline,
+		Locked:   fargo.TurnLocked(path),
+		Race:     acct.RaceIn(id),
+		Races:    len(g.Races),
+	}
+	for _, sub := range submissions {
+		if sub.Race == st.Race {
+			st.Orders = sub.Submitted
+		}
+		if acct.Role == fargo.RolePlayer {
+			continue
+		} else if sub.Submitted {
+			st.Submitted++
+		} else {
+			st.Waiting = append(st.Waiting, sub.Race)
+		}
+	}
+	return st, nil
+}
+
+func (s *Server_t) getDashboard(w http.ResponseWriter, r *http.Request, acct *fargo.Account_t) {
+	list, err := s.dashboard(acct)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+func (s *Server_t) getGamesPage(w http.ResponseWriter, r *http.Request, acct *fargo.Account_t) {
+	list, err := s.dashboard(acct)
+	if err != nil {
+		s.writePageError(w, http.StatusInternalServerError, err)
+		return
+	}
+	title := "Games"
+	if acct.Role != fargo.RolePlayer {
+		title = "Dashboard"
+	}
+	s.writePage(w, http.StatusOK, "games", &page_t{Title: title, Account: acct, Data: list})
+}
@@ -0,0 +1,216 @@
+// Copyright (c) 2024 Michael D Henderson. All rights reserved.
+
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/playbymail/fargo"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// functions to push events to players.
+//
+// events are sent as server-sent events. the web pages and bots read the
+// same stream:
+//
+//	GET /api/games/{game}/events
+//
+// a player only receives the events for their race and the events for
+// everyone. a GM receives every event, or only one race's with ?race=.
+//
+//	turn      a turn was processed and the reports are ready
+//	message   another race sent the race a message
+//	orders    the race's orders were received and checked
+//	deadline  the deadline is near and the race hasn't submitted orders
+//
+// the last few events are kept so that a client that reconnects with
+// a Last-Event-ID header doesn't miss any. events are not saved, so a
+// restarted server starts over.
+
+const (
+	// RecentEvents is the number of events kept for clients that reconnect.
+	RecentEvents = 256
+	// heartbeat is how often an idle stream is sent a comment, so that
+	// proxies don't close it.
+	heartbeat = 30 * time.Second
+)
+
+// Event_t is something a player may want to know about at once.
+type Event_t struct {
+	Id   int64     `json:"id"`
+	Kind string    `json:"kind"`
+	Game string    `json:"game"`
+	Race string    `json:"race,omitempty"` // empty if the event is for every race
+	Turn int       `json:"turn"`
+	Time time.Time `json:"time"`
+	Data any       `json:"data,omitempty"`
+}
+
+// events_t sends events to the streams that are open.
+type events_t struct {
+	mu          sync.Mutex
+	next        int64
+	recent      []*Event_t
+	subscribers map[chan *Event_t]bool
+}
+
+// publish gives the event an id and sends it to every subscriber.
+// A subscriber that can't keep up misses the event.
+func (ev *events_t) publish(e *Event_t) {
+	ev.mu.Lock()
+	defer ev.mu.Unlock()
+	ev.next++
+	e.Id, e.Time = ev.next, time.Now().UTC()
+	ev.recent = append(ev.recent, e)
+	if len(ev.recent) > RecentEvents {
+		ev.recent = ev.recent[len(ev.recent)-RecentEvents:]
+	}
+	for ch := range ev.subscribers {
+		select {
+		case ch <- e:
+		default:
+		}
+	}
+}
+
+// subscribe returns a channel for new events and the recent events after the id.
+func (ev *events_t) subscribe(after int64) (chan *Event_t, []*Event_t) {
+	ev.mu.Lock()
+	defer ev.mu.Unlock()
+	if ev.subscribers == nil {
+		ev.subscribers = make(map[chan *Event_t]bool)
+	}
+	ch := make(chan *Event_t, 16)
+	ev.subscribers[ch] = true
+	var missed []*Event_t
+	for _, e := range ev.recent {
+		if e.Id > after {
+			missed = append(missed, e)
+		}
+	}
+	return ch, missed
+}
+
+func (ev *events_t) unsubscribe(ch chan *Event_t) {
+	ev.mu.Lock()
+	defer ev.mu.Unlock()
+	delete(ev.subscribers, ch)
+}
+
+// recheck authenticates the request again and returns the account, or nil
+// if the session is gone or the account may no longer see the game or race.
+func (s *Server_t) recheck(r *http.Request, handle, game, race string) *fargo.Account_t {
+	acct, _, err := s.caller(r)
+	if err != nil {
+		log.Printf("server: events: %s: %v\n", handle, err)
+		return nil
+	} else if acct == nil || acct.Handle != handle {
+		log.Printf("server: events: %s: session ended\n", handle)
+		return nil
+	} else if acct.Role == fargo.RolePlayer && acct.RaceIn(game) == "" || race != "" && !acct.CanSee(game, race) {
+		log.Printf("server: events: %s: %s: access removed\n", handle, game)
+		return nil
+	}
+	return acct
+}
+
+// Publish sends an event to the streams that may see it.
+func (s *Server_t) Publish(g *fargo.Game_t, kind, race string, data any) {
+	s.events.publish(&Event_t{Kind: kind, Game: g.Id, Race: race, Turn: g.Turn, Data: data})
+}
+
+// publishTurn announces a new turn, and the messages sent during the last one.
+func (s *Server_t) publishTurn(g *fargo.Game_t) {
+	s.Publish(g, "turn", "", nil)
+	for _, msg := range g.Messages {
+		if msg.Turn == g.Turn-1 {
+			s.Publish(g, "message", msg.To, struct {
+				From string `json:"from"`
+				Text string `json:"text"`
+			}{msg.From, msg.Text})
+		}
+	}
+}
+
+func (s *Server_t) getEvents(w http.ResponseWriter, r *http.Request, acct *fargo.Account_t) {
+	g := s.loadGame(w, r, acct)
+	if g == nil {
+		return
+	}
+	race := r.URL.Query().Get("race")
+	if race != "" && !acct.CanSee(g.Id, race) {
+		writeError(w, http.StatusForbidden, ErrForbidden)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, fargo.ErrNotImplemented)
+		return
+	}
+	// wants returns true if the stream should carry the event
+	wants := func(e *Event_t) bool {
+		if e.Game != g.Id {
+			return false
+		} else if e.Race == "" {
+			return true
+		} else if race != "" {
+			return strings.EqualFold(e.Race, race)
+		}
+		return acct.CanSee(g.Id, e.Race)
+	}
+
+	after, _ := strconv.ParseInt(r.Header.Get("Last-Event-ID"), 10, 64)
+	ch, missed := s.events.subscribe(after)
+	defer s.events.unsubscribe(ch)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	write := func(e *Event_t) error {
+		data, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", e.Id, e.Kind, data)
+		return err
+	}
+	for _, e := range missed {
+		if wants(e) {
+			if err := write(e); err != nil {
+				return
+			}
+		}
+	}
+	flusher.Flush()
+
+	ticker := time.NewTicker(heartbeat)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ticker.C:
+			// the stream outlives the request's check, so the caller is
+			// checked again in case the session ended or the account changed
+			if acct = s.recheck(r, acct.Handle, g.Id, race); acct == nil {
+				return
+			} else if _, err := fmt.Fprintf(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+		case e := <-ch:
+			if !wants(e) {
+				continue
+			} else if err := write(e); err != nil {
+				log.Printf("server: events: %s: %v\n", acct.Handle, err)
+				return
+			}
+		}
+		flusher.Flush()
+	}
+}
@@ -0,0 +1,117 @@
+// Copyright (c) 2024 Michael D Henderson. All rights reserved.
+
+package server
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"github.com/playbymail/fargo"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// stream_t is an open event stream.
+type stream_t struct {
+	resp    *http.Response
+	scanner *bufio.Scanner
+	cancel  context.CancelFunc
+}
+
+// openStream opens the event stream of the game with the token.
+func openStream(t *testing.T, url, token, lastId string) *stream_t {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	r, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Header.Set("Authorization", "Bearer "+token)
+	if lastId != "" {
+		r.Header.Set("Last-Event-ID", lastId)
+	}
+	resp, err := http.DefaultClient.Do(r)
+	if err != nil {
+		cancel()
+		t.Fatal(err)
+	}
+	st := &stream_t{resp: resp, scanner: bufio.NewScanner(resp.Body), cancel: cancel}
+	t.Cleanup(st.close)
+	return st
+}
+
+func (st *stream_t) close() {
+	st.cancel()
+	_ = st.resp.Body.Close()
+}
+
+// next returns the next event on the stream.
+func (st *stream_t) next(t *testing.T) *Event_t {
+	t.Helper()
+	for st.scanner.Scan() {
+		data, ok := strings.CutPrefix(st.scanner.Text(), "data: ")
+		if !ok {
+			continue
+		}
+		var e Event_t
+		if err := json.Unmarshal([]byte(data), &e); err != nil {
+			t.Fatal(err)
+		}
+		return &e
+	}
+	t.Fatalf("stream ended: %v", st.scanner.Err())
+	return nil
+}
+
+func TestEvents(t *testing.T) {
+	site := newTestSite(t)
+	ts := httptest.NewServer(site.server)
+	t.Cleanup(ts.Close)
+	alpha, beta := &fargo.Game_t{Id: "alpha", Turn: 1}, &fargo.Game_t{Id: "beta", Turn: 1}
+	site.server.Publish(alpha, "turn", "", nil)
+	site.server.Publish(alpha, "message", "R002", nil)
+	site.server.Publish(alpha, "orders", "R001", nil)
+	site.server.Publish(beta, "turn", "", nil)
+
+	// the player catches up on the events for their race and for everyone
+	st := openStream(t, ts.URL+"/api/games/alpha/events", site.player, "0")
+	if st.resp.StatusCode != http.StatusOK || st.resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("stream: want 200 and an event stream, got %d %s", st.resp.StatusCode, st.resp.Header.Get("Content-Type"))
+	}
+	if e := st.next(t); e.Id != 1 || e.Kind != "turn" {
+		t.Errorf("replay: want the turn, got %+v", e)
+	}
+	if e := st.next(t); e.Id != 3 || e.Kind != "orders" {
+		t.Errorf("replay: want R001's orders, got %+v", e)
+	}
+
+	// and then gets new events as they happen
+	site.server.Publish(alpha, "message", "R002", nil)
+	site.server.Publish(alpha, "deadline", "R001", nil)
+	if e := st.next(t); e.Id != 6 || e.Kind != "deadline" || e.Race != "R001" {
+		t.Errorf("live: want the deadline for R001, got %+v", e)
+	}
+	st.close()
+
+	// a client that reconnects only gets what it missed
+	st = openStream(t, ts.URL+"/api/games/alpha/events?race=R002", site.gm, "2")
+	if e := st.next(t); e.Id != 5 || e.Kind != "message" {
+		t.Errorf("reconnect: want the second message to R002, got %+v", e)
+	}
+
+	for _, tc := range []struct {
+		target, token string
+		want          int
+	}{
+		{"/api/games/alpha/events?race=R002", site.player, http.StatusForbidden},
+		{"/api/games/beta/events", site.player, http.StatusForbidden},
+		{"/api/games/alpha/events", "", http.StatusUnauthorized},
+	} {
+		if w := site.do("GET", tc.target, tc.token); w.Code != tc.want {
+			t.Errorf("%s: want %d, got %d", tc.target, tc.want, w.Code)
+		}
+	}
+}
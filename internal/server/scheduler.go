@@ -0,0 +1,155 @@
+// Copyright (c) 2024 Michael D Henderson. All rights reserved.
+
+package server
+
+import (
+	"context"
+	"github.com/playbymail/fargo"
+	"log"
+	"time"
+)
+
+// functions to process turns on schedule.
+//
+// the scheduler wakes up every interval and checks every game in the
+// site. it loads the schedule and the game from disk, and decides whether
+// to send reminders or process the turn. it also notices turns processed
+// by hand, so that they are announced. nothing is kept in memory between
+// checks, so a restarted server picks up where the last one stopped.
+//
+// paused games are only watched for turns processed by hand. archived
+// games are skipped.
+
+// RunScheduler checks the schedule every interval until the context is done.
+func (s *Server_t) RunScheduler(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		s.checkSchedules(time.Now())
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// checkSchedules checks the schedule of every game in the site.
+// A game that fails is logged and doesn't hold up the others.
+func (s *Server_t) checkSchedules(now time.Time) {
+	ids, err := fargo.ListGames(s.path)
+	if err != nil {
+		log.Printf("server: schedule: %v\n", err)
+		return
+	}
+	for _, id := range ids {
+		if err := s.checkSchedule(id, now); err != nil {
+			log.Printf("server: schedule: %s: %v\n", id, err)
+		}
+	}
+}
+
+// checkSchedule processes the game's turn if it is due, or sends the reminder that is due.
+func (s *Server_t) checkSchedule(id string, now time.Time) error {
+	s.smu.Lock()
+	defer s.smu.Unlock()
+
+	path := s.gamePath(id)
+	settings, err := fargo.LoadSettings(path)
+	if err != nil {
+		return err
+	} else if settings.State == fargo.StateArchived {
+		return nil
+	}
+	if lock, err := fargo.ReadTurnLock(path, now); err != nil {
+		return err
+	} else if lock != nil && lock.Stale() {
+		// the process that took the lock crashed without releasing it
+		log.Printf("server: schedule: %s: breaking stale turn lock held by pid %d for %v\n", id, lock.Pid, lock.Age.Round(time.Second))
+		if err := fargo.BreakTurnLock(path); err != nil {
+			return err
+		}
+	} else if lock != nil {
+		// someone is processing the turn by hand
+		log.Printf("server: schedule: %s: turn lock held by pid %d for %v\n", id, lock.Pid, lock.Age.Round(time.Second))
+		return nil
+	}
+	g, err := s.loadGameById(id)
+	if err != nil {
+		return err
+	}
+	if turn := s.turns[id]; turn != g.Turn {
+		// announce turns processed by hand, but not the turn the server started on
+		if turn != 0 {
+			s.publishTurn(g)
+		}
+		s.turns[id] = g.Turn
+	}
+	if settings.State == fargo.StatePaused {
+		return nil
+	}
+	sched, err := fargo.LoadSchedule(path)
+	if err != nil {
+		return err
+	} else if sched.Deadline == nil {
+		return nil
+	}
+	if sched.Turn != g.Turn {
+		// the turn was processed by hand, so the deadline moves on
+		sched.Advance(g.Turn, now)
+		log.Printf("server: schedule: %s: turn %d: deadline %s\n", id, g.Turn, deadline(sched))
+		return sched.Save(path)
+	}
+
+	submissions, err := g.Submissions(path)
+	if err != nil {
+		return err
+	}
+	var late []*fargo.Race_t
+	for _, sub := range submissions {
+		if !sub.Submitted {
+			late = append(late, g.Race(sub.Race))
+		}
+	}
+
+	if sched.Due(now, len(late) == 0) {
+		log.Printf("server: schedule: %s: turn %d: processing, %d of %d races submitted orders\n", id, g.Turn, len(g.Races)-len(late), len(g.Races))
+		g, err = fargo.RunTurn(path)
+		if err != nil {
+			return err
+		}
+		g.Id, s.turns[id] = id, g.Turn
+		s.publishTurn(g)
+		sched.Advance(g.Turn, now)
+		log.Printf("server: schedule: %s: turn %d: deadline %s\n", id, g.Turn, deadline(sched))
+		return sched.Save(path)
+	}
+
+	reminder, ok := sched.DueReminder(now)
+	if !ok {
+		return nil
+	}
+	accounts, err := s.readAccounts()
+	if err != nil {
+		return err
+	}
+	for _, race := range late {
+		s.Publish(g, "deadline", race.Id, struct {
+			Deadline time.Time `json:"deadline"`
+		}{*sched.Deadline})
+		for _, acct := range accounts.PlayersOf(g.Id, race.Id) {
+			if err := s.SendReminder(g, acct, *sched.Deadline); err != nil {
+				log.Printf("server: schedule: reminder: %s: %v\n", acct.Handle, err)
+			}
+		}
+	}
+	sched.Remind(reminder)
+	return sched.Save(path)
+}
+
+func deadline(sched *fargo.Schedule_t) string {
+	if sched.Deadline == nil {
+		return "none"
+	}
+	return sched.Deadline.Format(time.RFC3339)
+}
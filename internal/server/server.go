@@ -0,0 +1,200 @@
+// Copyright (c) 2024 Michael D Henderson. All rights reserved.
+
+// Package server implements the HTTP API for fargo games.
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/playbymail/fargo"
+	"html/template"
+	"log"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// Server_t serves the JSON API for the games in a site directory.
+//
+// Games are loaded from disk for every request, so the server always
+// sees the latest turn, even when turns are processed by the CLI while
+// the server is running. Games created in the site are seen at once.
+type Server_t struct {
+	path  string
+	mux   *http.ServeMux
+	mu    sync.Mutex     // guards the accounts file
+	smu   sync.Mutex     // guards the schedule files and turns
+	turns map[string]int // the last turn the scheduler saw, by game id
+
+	events events_t
+
+	pages map[string]*template.Template
+
+	// URL is the address players use to reach the site, like
+	// https://fargo.example.com. Login links are built from it, never
+	// from the request, and aren't sent if it isn't set.
+	URL string
+
+	// Dev makes the default SendLoginLink write the link itself to the
+	// log, for a GM testing the server without mail.
+	Dev bool
+
+	// SendLoginLink delivers a one-time login link to the account.
+	// The default only logs that a link was sent, unless Dev is set.
+	SendLoginLink func(acct *fargo.Account_t, link string) error
+
+	// SendReminder tells a player that their race hasn't submitted orders
+	// and when the deadline is. The default writes to the log.
+	SendReminder func(g *fargo.Game_t, acct *fargo.Account_t, deadline time.Time) error
+}
+
+// New returns a server for the games in the site directory.
+func New(path string) (*Server_t, error) {
+	path, err := fargo.AbsPath(path)
+	if err != nil {
+		return nil, err
+	}
+	s := &Server_t{path: path, mux: http.NewServeMux(), turns: make(map[string]int)}
+	s.SendLoginLink = func(acct *fargo.Account_t, link string) error {
+		if s.Dev {
+			log.Printf("server: login link for %s: %s\n", acct.Handle, link)
+			return nil
+		}
+		log.Printf("server: login link for %s, good for %v, not sent: no mail server\n", acct.Handle, fargo.MagicLinkTTL)
+		return nil
+	}
+	s.SendReminder = func(g *fargo.Game_t, acct *fargo.Account_t, deadline time.Time) error {
+		log.Printf("server: reminder for %s: %s: turn %d orders are due %s\n", acct.Handle, acct.RaceIn(g.Id), g.Turn, deadline.Format(time.RFC3339))
+		return nil
+	}
+	s.routes()
+	return s, nil
+}
+
+func (s *Server_t) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+func (s *Server_t) routes() {
+	s.mux.HandleFunc("POST /api/login", s.postLogin)
+	s.mux.HandleFunc("POST /api/login/magic", s.postMagicLink)
+	s.mux.HandleFunc("POST /api/login/magic/{token}", s.postUseMagicLink)
+	s.mux.HandleFunc("POST /api/logout", s.authenticated(s.postLogout))
+	s.mux.HandleFunc("GET /api/me", s.authenticated(s.getMe))
+	s.mux.HandleFunc("GET /api/tokens", s.authenticated(s.getTokens))
+	s.mux.HandleFunc("POST /api/tokens", s.authenticated(s.postToken))
+	s.mux.HandleFunc("DELETE /api/tokens/{id}", s.authenticated(s.deleteToken))
+	s.mux.HandleFunc("GET /api/accounts", s.admin(s.getAccounts))
+	s.mux.HandleFunc("POST /api/accounts", s.admin(s.postAccount))
+
+	s.mux.HandleFunc("GET /api/dashboard", s.gm(s.getDashboard))
+	s.mux.HandleFunc("GET /api/games", s.authenticated(s.getGames))
+	s.mux.HandleFunc("GET /api/games/{game}", s.authenticated(s.getGame))
+	s.mux.HandleFunc("GET /api/games/{game}/events", s.authenticated(s.getEvents))
+	s.mux.HandleFunc("GET /api/games/{game}/schedule", s.authenticated(s.getSchedule))
+	s.mux.HandleFunc("PUT /api/games/{game}/schedule", s.gm(s.putSchedule))
+	s.mux.HandleFunc("GET /api/games/{game}/submissions", s.gm(s.getSubmissions))
+	s.mux.HandleFunc("GET /api/games/{game}/races/{race}/report", s.authenticated(s.getReport))
+	s.mux.HandleFunc("GET /api/games/{game}/races/{race}/map.png", s.authenticated(s.getMap))
+	s.mux.HandleFunc("GET /api/games/{game}/races/{race}/orders", s.authenticated(s.getOrders))
+	s.mux.HandleFunc("PUT /api/games/{game}/races/{race}/orders", s.authenticated(s.putOrders))
+	s.mux.HandleFunc("POST /api/games/{game}/races/{race}/orders/check", s.authenticated(s.postCheckOrders))
+	s.mux.HandleFunc("GET /api/games/{game}/races/{race}/systems", s.authenticated(s.getSystems))
+	s.mux.HandleFunc("GET /api/games/{game}/races/{race}/map.svg", s.authenticated(s.getMapSVG))
+
+	s.webRoutes()
+}
+
+// loadGame loads the game named in the request.
+// It writes an error response and returns nil if the game can't be loaded
+// or if the account hasn't joined it.
+func (s *Server_t) loadGame(w http.ResponseWriter, r *http.Request, acct *fargo.Account_t) *fargo.Game_t {
+	g, status, err := s.game(r, acct)
+	if err != nil {
+		writeError(w, status, err)
+		return nil
+	}
+	return g
+}
+
+// loadRace loads the game and the race named in the request.
+// It writes an error response and returns nil if either can't be found
+// or if the account may not see the race.
+func (s *Server_t) loadRace(w http.ResponseWriter, r *http.Request, acct *fargo.Account_t) (*fargo.Game_t, *fargo.Race_t) {
+	g, race, status, err := s.race(r, acct)
+	if err != nil {
+		writeError(w, status, err)
+		return nil, nil
+	}
+	return g, race
+}
+
+// game loads the game named in the request, if the account may see it.
+// If it fails, it returns the error and the HTTP status for it.
+func (s *Server_t) game(r *http.Request, acct *fargo.Account_t) (*fargo.Game_t, int, error) {
+	id := r.PathValue("game")
+	if !fargo.ValidGameId(id) {
+		return nil, http.StatusNotFound, fmt.Errorf("%q: %w", id, ErrUnknownGame)
+	}
+	g, err := s.loadGameById(id)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, http.StatusNotFound, fmt.Errorf("%q: %w", id, ErrUnknownGame)
+	} else if err != nil {
+		log.Printf("server: %s: %v\n", r.URL.Path, err)
+		return nil, http.StatusInternalServerError, err
+	} else if acct.Role == fargo.RolePlayer && acct.RaceIn(g.Id) == "" {
+		return nil, http.StatusForbidden, ErrForbidden
+	}
+	return g, http.StatusOK, nil
+}
+
+// loadGameById loads the game in the site. The game's directory names the
+// game (see fargo.SiteGameId), so a game copied from another directory
+// keeps working.
+func (s *Server_t) loadGameById(id string) (*fargo.Game_t, error) {
+	return fargo.LoadGame(s.gamePath(id))
+}
+
+// gamePath returns the directory of the game in the site.
+func (s *Server_t) gamePath(id string) string {
+	return fargo.GamePath(s.path, id)
+}
+
+// race loads the game and the race named in the request, if the account may see the race.
+func (s *Server_t) race(r *http.Request, acct *fargo.Account_t) (*fargo.Game_t, *fargo.Race_t, int, error) {
+	g, status, err := s.game(r, acct)
+	if err != nil {
+		return nil, nil, status, err
+	} else if !acct.CanSee(g.Id, r.PathValue("race")) {
+		return nil, nil, http.StatusForbidden, ErrForbidden
+	}
+	race := g.Race(r.PathValue("race"))
+	if race == nil {
+		return nil, nil, http.StatusNotFound, fmt.Errorf("%q: %w", r.PathValue("race"), fargo.ErrUnknownRace)
+	}
+	return g, race, http.StatusOK, nil
+}
+
+// writeJSON writes the value as the response.
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = w.Write(append(data, '\n'))
+}
+
+// writeError writes the error as a JSON response.
+func writeError(w http.ResponseWriter, status int, err error) {
+	if errors.Is(err, os.ErrNotExist) {
+		status = http.StatusNotFound
+	}
+	writeJSON(w, status, struct {
+		Error string `json:"error"`
+	}{Error: err.Error()})
+}
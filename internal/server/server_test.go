@@ -0,0 +1,146 @@
+// Copyright (c) 2024 Michael D Henderson. All rights reserved.
+
+package server
+
+import (
+	"encoding/json"
+	"github.com/playbymail/fargo"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testSite_t is a site with two games, a GM and a player of R001 in alpha.
+type testSite_t struct {
+	path   string
+	server *Server_t
+	gm     string // api token for the GM
+	player string // api token for the player
+}
+
+func newTestSite(t *testing.T) *testSite_t {
+	t.Helper()
+	site := &testSite_t{path: t.TempDir()}
+	for _, id := range []string{"alpha", "beta"} {
+		g, err := fargo.CreateGame(fargo.GameOptions_t{Name: id, Seed: id, NumberOfRaces: 2, SystemsPerRace: 4, Culture: "classical", NameStyle: "syllable"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		g.Id = id
+		if err := g.SaveTurn(fargo.GamePath(site.path, id)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	accounts := &fargo.Accounts_t{}
+	gm := &fargo.Account_t{Handle: "gm", Role: fargo.RoleGM}
+	player := &fargo.Account_t{Handle: "player", Role: fargo.RolePlayer}
+	player.Join("alpha", "R001")
+	for _, acct := range []*fargo.Account_t{gm, player} {
+		if err := accounts.Add(acct); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var err error
+	if site.gm, _, err = accounts.NewToken(gm, "api", "test", 0, time.Now()); err != nil {
+		t.Fatal(err)
+	} else if site.player, _, err = accounts.NewToken(player, "api", "test", 0, time.Now()); err != nil {
+		t.Fatal(err)
+	} else if err := accounts.Save(site.path); err != nil {
+		t.Fatal(err)
+	}
+	if site.server, err = New(site.path); err != nil {
+		t.Fatal(err)
+	}
+	return site
+}
+
+// do sends a request with the token and returns the response.
+func (site *testSite_t) do(method, target, token string) *httptest.ResponseRecorder {
+	return site.send(method, target, token, "")
+}
+
+// send sends a request with the token and the body and returns the response.
+func (site *testSite_t) send(method, target, token, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, target, strings.NewReader(body))
+	if token != "" {
+		r.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	site.server.ServeHTTP(w, r)
+	return w
+}
+
+func TestDashboardBrokenGame(t *testing.T) {
+	site := newTestSite(t)
+	if err := os.WriteFile(filepath.Join(fargo.GamePath(site.path, "beta"), fargo.GameFile), []byte("{"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	w := site.do("GET", "/api/dashboard", site.gm)
+	if w.Code != http.StatusOK {
+		t.Fatalf("dashboard: want 200, got %d: %s", w.Code, w.Body)
+	}
+	var list []*status_t
+	if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil {
+		t.Fatal(err)
+	}
+	states := map[string]string{}
+	for _, st := range list {
+		states[st.Id] = st.State
+	}
+	if states["alpha"] != "active" || states["beta"] != "unavailable" {
+		t.Errorf("dashboard: got %v", states)
+	}
+
+	// the player only sees the game they joined, which still works
+	if w := site.do("GET", "/api/games", site.player); w.Code != http.StatusOK {
+		t.Errorf("games: want 200, got %d: %s", w.Code, w.Body)
+	}
+}
+
+func TestGames(t *testing.T) {
+	site := newTestSite(t)
+	settings := &fargo.Settings_t{State: fargo.StateArchived}
+	if err := settings.Save(fargo.GamePath(site.path, "alpha")); err != nil {
+		t.Fatal(err)
+	}
+	list := func(token string) map[string]game_t {
+		t.Helper()
+		w := site.do("GET", "/api/games", token)
+		if w.Code != http.StatusOK {
+			t.Fatalf("games: want 200, got %d: %s", w.Code, w.Body)
+		}
+		var games []game_t
+		if err := json.Unmarshal(w.Body.Bytes(), &games); err != nil {
+			t.Fatal(err)
+		}
+		byId := map[string]game_t{}
+		for _, g := range games {
+			byId[g.Id] = g
+		}
+		return byId
+	}
+	if games := list(site.player); len(games) != 1 || games["alpha"].Race != "R001" || games["alpha"].State != "archived" {
+		t.Errorf("player: want only alpha, archived, got %v", games)
+	}
+	if games := list(site.gm); len(games) != 2 || games["beta"].State != "active" {
+		t.Errorf("gm: want both games, got %v", games)
+	}
+
+	// an archived game takes no more orders, while the other game does
+	if w := site.send("PUT", "/api/games/alpha/races/R001/orders", site.player, "race R001\n"); w.Code != http.StatusConflict {
+		t.Errorf("archived: want 409, got %d: %s", w.Code, w.Body)
+	}
+	if w := site.send("PUT", "/api/games/beta/races/R002/orders", site.gm, "race R002\n"); w.Code != http.StatusOK {
+		t.Errorf("active: want 200, got %d: %s", w.Code, w.Body)
+	}
+	for _, id := range []string{"Alpha", "a.b", "-x"} {
+		if w := site.do("GET", "/api/games/"+id, site.gm); w.Code != http.StatusNotFound {
+			t.Errorf("%q: want 404, got %d", id, w.Code)
+		}
+	}
+}
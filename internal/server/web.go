@@ -0,0 +1,352 @@
+// Copyright (c) 2024 Michael D Henderson. All rights reserved.
+
+package server
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"github.com/playbymail/fargo"
+	"html/template"
+	"io/fs"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// functions to serve the pages of the web site.
+//
+// pages are rendered on the server from templates. the templates, the
+// style sheet and the scripts are embedded in the binary, so the site
+// works without a network connection. the scripts only add to the pages;
+// they call the JSON API to check orders while they are typed and to
+// submit them.
+//
+//	GET  /                                          the account's race if it plays one, or the games
+//	GET  /login                                     the login form
+//	POST /login
+//	GET  /login/magic/{token}                       asks the player to confirm a login link
+//	POST /login/magic/{token}                       uses the link
+//	POST /logout
+//	GET  /static/...                                style sheet and scripts
+//	GET  /games                                     the dashboard for a GM, the player's games otherwise
+//	GET  /games/{game}                              the races and their orders, GMs only
+//	GET  /games/{game}/races/{race}                 the map and the current report
+//	GET  /games/{game}/races/{race}/orders          the order editor
+//	GET  /games/{game}/races/{race}/reports         the reports for past turns
+//	GET  /games/{game}/races/{race}/reports/{turn}
+
+//go:embed web
+var webFS embed.FS
+
+// page_t is the data passed to every template.
+type page_t struct {
+	Title   string
+	Account *fargo.Account_t
+	Game    *fargo.Game_t
+	Race    *fargo.Race_t
+	Data    any
+}
+
+func (s *Server_t) webRoutes() {
+	s.pages = make(map[string]*template.Template)
+	names, err := fs.Glob(webFS, "web/templates/*.html")
+	if err != nil {
+		panic(err)
+	}
+	for _, name := range names {
+		if strings.HasSuffix(name, "/layout.html") {
+			continue
+		}
+		key := strings.TrimSuffix(name[strings.LastIndexByte(name, '/')+1:], ".html")
+		s.pages[key] = template.Must(template.ParseFS(webFS, "web/templates/layout.html", name))
+	}
+	static, err := fs.Sub(webFS, "web/static")
+	if err != nil {
+		panic(err)
+	}
+
+	s.mux.Handle("GET /static/", http.StripPrefix("/static/", http.FileServerFS(static)))
+	s.mux.HandleFunc("GET /{$}", s.page(s.getHome))
+	s.mux.HandleFunc("GET /login", s.getLoginPage)
+	s.mux.HandleFunc("POST /login", s.postLoginPage)
+	s.mux.HandleFunc("GET /login/magic/{token}", s.getMagicLinkPage)
+	s.mux.HandleFunc("POST /login/magic/{token}", s.postMagicLinkPage)
+	s.mux.HandleFunc("POST /logout", s.page(s.postLogoutPage))
+	s.mux.HandleFunc("GET /games", s.page(s.getGamesPage))
+	s.mux.HandleFunc("GET /games/{game}", s.page(s.getGamePage))
+	s.mux.HandleFunc("GET /games/{game}/races/{race}", s.page(s.getRacePage))
+	s.mux.HandleFunc("GET /games/{game}/races/{race}/orders", s.page(s.getOrdersPage))
+	s.mux.HandleFunc("GET /games/{game}/races/{race}/reports", s.page(s.getReportsPage))
+	s.mux.HandleFunc("GET /games/{game}/races/{race}/reports/{turn}", s.page(s.getReportPage))
+}
+
+// page wraps a handler for a page that needs a logged in account.
+// Visitors who aren't logged in are sent to the login page.
+func (s *Server_t) page(h handler_f) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		acct, _, err := s.caller(r)
+		if err != nil {
+			s.writePageError(w, http.StatusInternalServerError, err)
+			return
+		} else if acct == nil {
+			http.Redirect(w, r, "/login?next="+url.QueryEscape(r.URL.Path), http.StatusSeeOther)
+			return
+		}
+		h(w, r, acct)
+	}
+}
+
+// writePage renders the template for the page.
+func (s *Server_t) writePage(w http.ResponseWriter, status int, name string, p *page_t) {
+	var buf bytes.Buffer
+	if err := s.pages[name].ExecuteTemplate(&buf, "layout", p); err != nil {
+		log.Printf("server: page: %s: %v\n", name, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	_, _ = w.Write(buf.Bytes())
+}
+
+// writePageError renders the error page.
+func (s *Server_t) writePageError(w http.ResponseWriter, status int, err error) {
+	if errors.Is(err, os.ErrNotExist) {
+		status = http.StatusNotFound
+	}
+	s.writePage(w, status, "error", &page_t{Title: http.StatusText(status), Data: err.Error()})
+}
+
+// getHome sends a player who plays in one game to their race. Everyone
+// else goes to the list of games.
+func (s *Server_t) getHome(w http.ResponseWriter, r *http.Request, acct *fargo.Account_t) {
+	if acct.Role == fargo.RolePlayer && len(acct.Races) == 1 {
+		for game, race := range acct.Races {
+			http.Redirect(w, r, "/games/"+game+"/races/"+race, http.StatusSeeOther)
+			return
+		}
+	}
+	http.Redirect(w, r, "/games", http.StatusSeeOther)
+}
+
+func (s *Server_t) getLoginPage(w http.ResponseWriter, r *http.Request) {
+	s.writePage(w, http.StatusOK, "login", &page_t{Title: "Log in", Data: loginForm_t{Next: r.URL.Query().Get("next")}})
+}
+
+type loginForm_t struct {
+	Handle string
+	Next   string
+	Error  string
+}
+
+func (s *Server_t) postLoginPage(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, 4096)
+	form := loginForm_t{Handle: r.PostFormValue("handle"), Next: r.PostFormValue("next")}
+	_, secret, t, err := s.login(form.Handle, r.PostFormValue("password"))
+	if err != nil {
+		form.Error = "The handle or password is not right."
+		s.writePage(w, http.StatusUnauthorized, "login", &page_t{Title: "Log in", Data: form})
+		return
+	}
+	setSessionCookie(w, r, secret, t)
+	// only go back to pages on this site
+	next := form.Next
+	if !strings.HasPrefix(next, "/") || strings.HasPrefix(next, "//") {
+		next = "/"
+	}
+	http.Redirect(w, r, next, http.StatusSeeOther)
+}
+
+// getMagicLinkPage asks the player to confirm the login. The link isn't
+// checked or used here, since mail clients may fetch it before the
+// player clicks it.
+func (s *Server_t) getMagicLinkPage(w http.ResponseWriter, r *http.Request) {
+	s.writePage(w, http.StatusOK, "magic", &page_t{Title: "Log in", Data: r.PathValue("token")})
+}
+
+func (s *Server_t) postMagicLinkPage(w http.ResponseWriter, r *http.Request) {
+	_, secret, t, err := s.useMagicLink(r.PathValue("token"))
+	if err != nil {
+		form := loginForm_t{Error: "The login link has expired or has been used. Log in with your password or ask for a new link."}
+		s.writePage(w, http.StatusUnauthorized, "login", &page_t{Title: "Log in", Data: form})
+		return
+	}
+	setSessionCookie(w, r, secret, t)
+	http.Redirect(w, r, "/", http.StatusSeeOther)
+}
+
+func (s *Server_t) postLogoutPage(w http.ResponseWriter, r *http.Request, acct *fargo.Account_t) {
+	if err := s.logout(w, r, acct); err != nil {
+		s.writePageError(w, http.StatusInternalServerError, err)
+		return
+	}
+	http.Redirect(w, r, "/login", http.StatusSeeOther)
+}
+
+func (s *Server_t) getGamePage(w http.ResponseWriter, r *http.Request, acct *fargo.Account_t) {
+	g, status, err := s.game(r, acct)
+	if err != nil {
+		s.writePageError(w, status, err)
+		return
+	} else if acct.Role == fargo.RolePlayer {
+		http.Redirect(w, r, "/games/"+g.Id+"/races/"+acct.RaceIn(g.Id), http.StatusSeeOther)
+		return
+	}
+	settings, err := fargo.LoadSettings(s.gamePath(g.Id))
+	if err != nil {
+		s.writePageError(w, http.StatusInternalServerError, err)
+		return
+	}
+	submissions, err := g.Submissions(s.gamePath(g.Id))
+	if err != nil {
+		s.writePageError(w, http.StatusInternalServerError, err)
+		return
+	}
+	s.smu.Lock()
+	sched, err := fargo.LoadSchedule(s.gamePath(g.Id))
+	s.smu.Unlock()
+	if err != nil {
+		s.writePageError(w, http.StatusInternalServerError, err)
+		return
+	}
+	s.writePage(w, http.StatusOK, "game", &page_t{Title: g.Name, Account: acct, Game: g, Data: struct {
+		Settings    *fargo.Settings_t
+		Submissions []*fargo.Submission_t
+		Schedule    *fargo.Schedule_t
+	}{settings, submissions, sched}})
+}
+
+func (s *Server_t) getRacePage(w http.ResponseWriter, r *http.Request, acct *fargo.Account_t) {
+	g, race, status, err := s.race(r, acct)
+	if err != nil {
+		s.writePageError(w, status, err)
+		return
+	}
+	var report, svg bytes.Buffer
+	if err := g.WriteReport(&report, race); err != nil {
+		s.writePageError(w, http.StatusInternalServerError, err)
+		return
+	} else if err := writeMapSVG(&svg, g, race); err != nil {
+		s.writePageError(w, http.StatusInternalServerError, err)
+		return
+	}
+	var systems []*fargo.SystemView_t
+	for _, ss := range g.Cluster.StarSystems {
+		systems = append(systems, g.View(race, ss))
+	}
+	s.writePage(w, http.StatusOK, "race", &page_t{Title: race.Name, Account: acct, Game: g, Race: race, Data: struct {
+		Map     template.HTML // drawn by the server, so it is safe
+		Systems []*fargo.SystemView_t
+		Report  string
+	}{template.HTML(svg.String()), systems, report.String()}})
+}
+
+func (s *Server_t) getOrdersPage(w http.ResponseWriter, r *http.Request, acct *fargo.Account_t) {
+	g, race, status, err := s.race(r, acct)
+	if err != nil {
+		s.writePageError(w, status, err)
+		return
+	}
+	submission, err := g.Submission(s.gamePath(g.Id), race)
+	if err != nil {
+		s.writePageError(w, http.StatusInternalServerError, err)
+		return
+	}
+	text := "race " + race.Id + "\n"
+	if submission.Submitted {
+		data, err := os.ReadFile(fargo.OrdersPath(s.gamePath(g.Id), g.Turn, race.Id))
+		if err != nil {
+			s.writePageError(w, http.StatusInternalServerError, err)
+			return
+		}
+		text = string(data)
+	}
+
+	// the ids the player is likely to need, for the order form
+	ids := map[string][]string{}
+	for _, colony := range g.ColoniesOf(race) {
+		ids["colony"] = append(ids["colony"], colony.Id)
+	}
+	for _, fleet := range g.FleetsOf(race) {
+		ids["fleet"] = append(ids["fleet"], fleet.Id)
+	}
+	for _, ss := range g.Cluster.StarSystems {
+		ids["system"] = append(ids["system"], ss.Id)
+	}
+	for _, other := range g.Races {
+		if other != race {
+			ids["race"] = append(ids["race"], other.Id)
+		}
+	}
+	for _, design := range race.Designs {
+		ids["design"] = append(ids["design"], design.Name)
+	}
+	for _, field := range g.TechTree.Fields {
+		ids["field"] = append(ids["field"], field.Id)
+	}
+	s.writePage(w, http.StatusOK, "orders", &page_t{Title: "Orders", Account: acct, Game: g, Race: race, Data: struct {
+		Submission *fargo.Submission_t
+		Text       string
+		Ids        map[string][]string
+	}{submission, text, ids}})
+}
+
+func (s *Server_t) getReportsPage(w http.ResponseWriter, r *http.Request, acct *fargo.Account_t) {
+	g, race, status, err := s.race(r, acct)
+	if err != nil {
+		s.writePageError(w, status, err)
+		return
+	}
+	store, err := fargo.OpenStore(s.gamePath(g.Id), true)
+	if err != nil {
+		s.writePageError(w, http.StatusInternalServerError, err)
+		return
+	}
+	defer store.Close()
+	var turns []int
+	for turn := g.Turn; turn >= 1; turn-- {
+		if _, err := store.Report(turn, race.Id); err == nil || turn == g.Turn {
+			turns = append(turns, turn)
+		}
+	}
+	s.writePage(w, http.StatusOK, "reports", &page_t{Title: "Reports", Account: acct, Game: g, Race: race, Data: turns})
+}
+
+func (s *Server_t) getReportPage(w http.ResponseWriter, r *http.Request, acct *fargo.Account_t) {
+	g, race, status, err := s.race(r, acct)
+	if err != nil {
+		s.writePageError(w, status, err)
+		return
+	}
+	turn, err := strconv.Atoi(r.PathValue("turn"))
+	if err != nil || turn < 1 || turn > g.Turn {
+		s.writePageError(w, http.StatusNotFound, fargo.ErrInvalidArguments)
+		return
+	}
+	var text []byte
+	if turn == g.Turn {
+		var buf bytes.Buffer
+		if err := g.WriteReport(&buf, race); err != nil {
+			s.writePageError(w, http.StatusInternalServerError, err)
+			return
+		}
+		text = buf.Bytes()
+	} else if text, err = fargo.ReadReport(s.gamePath(g.Id), turn, race.Id); err != nil {
+		s.writePageError(w, http.StatusInternalServerError, err)
+		return
+	}
+	next := turn + 1
+	if next > g.Turn {
+		next = 0
+	}
+	s.writePage(w, http.StatusOK, "report", &page_t{Title: "Report", Account: acct, Game: g, Race: race, Data: struct {
+		Turn     int
+		Previous int // zero if there is none
+		Next     int
+		Text     string
+	}{Turn: turn, Previous: turn - 1, Next: next, Text: string(text)}})
+}
@@ -0,0 +1,40 @@
+// Copyright (c) 2024 Michael D Henderson. All rights reserved.
+
+// show the events for the race as they happen. the stream is the same
+// one that bots read from /api/games/{game}/events.
+(function () {
+  "use strict";
+  const notice = document.getElementById("notice");
+  const game = notice.dataset.game, race = notice.dataset.race;
+  const source = new EventSource(`/api/games/${game}/events?race=${encodeURIComponent(race)}`);
+
+  function show(text, warning, link) {
+    notice.replaceChildren(text);
+    if (link) {
+      const a = document.createElement("a");
+      a.href = link.href;
+      a.textContent = link.text;
+      notice.append(" ", a);
+    }
+    notice.classList.toggle("warning", !!warning);
+    notice.hidden = false;
+  }
+
+  source.addEventListener("turn", e => {
+    const event = JSON.parse(e.data);
+    show(`Turn ${event.turn} has been processed.`, false, { href: `/games/${game}/races/${race}`, text: "Read the report" });
+  });
+  source.addEventListener("message", e => {
+    const event = JSON.parse(e.data);
+    show(`Message from ${event.data.from}: ${event.data.text}`);
+  });
+  source.addEventListener("orders", e => {
+    const event = JSON.parse(e.data);
+    const errors = event.data.errors;
+    show(`Orders for turn ${event.turn} were received. ${errors === 0 ? "Every order passed the check." : `${errors} orders failed the check.`}`, errors !== 0);
+  });
+  source.addEventListener("deadline", e => {
+    const event = JSON.parse(e.data);
+    show(`Orders for turn ${event.turn} are due ${new Date(event.data.deadline).toLocaleString()}.`, true, { href: `/games/${game}/races/${race}/orders`, text: "Write orders" });
+  });
+})();
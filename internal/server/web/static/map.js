@@ -0,0 +1,143 @@
+// Copyright (c) 2024 Michael D Henderson. All rights reserved.
+
+// pan and zoom the map by changing the SVG's view box, and show what the
+// race knows about a system when it is clicked.
+(function () {
+  "use strict";
+  const view = document.getElementById("map");
+  const svg = view.querySelector("svg");
+  const popover = document.getElementById("popover");
+  const systems = new Map(JSON.parse(document.getElementById("systems").textContent).map(s => [s.id, s]));
+  const turn = Number(view.dataset.turn);
+  const full = svg.viewBox.baseVal;
+  let box = { x: full.x, y: full.y, width: full.width, height: full.height };
+  const initial = Object.assign({}, box);
+
+  function apply() {
+    svg.setAttribute("viewBox", `${box.x} ${box.y} ${box.width} ${box.height}`);
+  }
+
+  // toPoint converts a position on the screen to a point in the view box
+  function toPoint(clientX, clientY) {
+    const r = svg.getBoundingClientRect();
+    const scale = Math.max(box.width / r.width, box.height / r.height);
+    return {
+      x: box.x + (clientX - r.left - (r.width - box.width / scale) / 2) * scale,
+      y: box.y + (clientY - r.top - (r.height - box.height / scale) / 2) * scale,
+      scale: scale,
+    };
+  }
+
+  view.addEventListener("wheel", e => {
+    e.preventDefault();
+    const p = toPoint(e.clientX, e.clientY);
+    const factor = e.deltaY < 0 ? 0.8 : 1.25;
+    const width = Math.min(Math.max(box.width * factor, full.width / 40), full.width * 4);
+    const f = width / box.width;
+    box = { x: p.x - (p.x - box.x) * f, y: p.y - (p.y - box.y) * f, width: box.width * f, height: box.height * f };
+    apply();
+  }, { passive: false });
+
+  let drag = null;
+  view.addEventListener("pointerdown", e => {
+    drag = { x: e.clientX, y: e.clientY, box: Object.assign({}, box), moved: false };
+    view.setPointerCapture(e.pointerId);
+  });
+  view.addEventListener("pointermove", e => {
+    if (!drag) {
+      return;
+    }
+    const dx = e.clientX - drag.x, dy = e.clientY - drag.y;
+    if (Math.abs(dx) + Math.abs(dy) > 3) {
+      drag.moved = true;
+      view.classList.add("dragging");
+    }
+    const scale = toPoint(0, 0).scale;
+    box.x = drag.box.x - dx * scale;
+    box.y = drag.box.y - dy * scale;
+    apply();
+  });
+  view.addEventListener("pointerup", e => {
+    const moved = drag && drag.moved;
+    drag = null;
+    view.classList.remove("dragging");
+    if (!moved) {
+      // pointer capture sends the event to the view, so find the system under the pointer
+      const target = document.elementFromPoint(e.clientX, e.clientY);
+      const group = target && target.closest(".system");
+      if (group) {
+        show(group, e);
+      } else {
+        hide();
+      }
+    }
+  });
+
+  document.getElementById("map-reset").addEventListener("click", () => {
+    box = Object.assign({}, initial);
+    apply();
+    hide();
+  });
+
+  // dim the systems the race has only located
+  for (const group of svg.querySelectorAll(".system")) {
+    const s = systems.get(group.id);
+    if (s) {
+      group.classList.add(s.detail);
+    }
+  }
+
+  function text(tag, value) {
+    const el = document.createElement(tag);
+    el.textContent = value;
+    return el;
+  }
+
+  function show(group, e) {
+    const s = systems.get(group.id);
+    if (!s) {
+      return;
+    }
+    for (const el of svg.querySelectorAll(".selected")) {
+      el.classList.remove("selected");
+    }
+    group.classList.add("selected");
+    popover.replaceChildren();
+    popover.append(text("h3", `${s.id} ${s.name}`));
+    const c = s.coordinates;
+    let summary = `(${c.x.toFixed(1)}, ${c.y.toFixed(1)}, ${c.z.toFixed(1)}), ${s.detail}`;
+    if (s.turn) {
+      summary += s.turn === turn ? ", seen this turn" : `, last seen on turn ${s.turn}`;
+    }
+    summary += s.owner ? `, owned by ${s.owner}` : ", unclaimed";
+    popover.append(text("p", summary));
+    if (s.planets && s.planets.length) {
+      const table = document.createElement("table");
+      const head = table.insertRow();
+      for (const h of ["Orbit", "Kind", "Hab", "Min", "Eng", "Bio", "Colony"]) {
+        head.append(text("th", h));
+      }
+      for (const p of s.planets) {
+        const row = table.insertRow();
+        const value = v => (v === undefined ? "" : String(Math.round(v)));
+        for (const v of [p.orbit, p.kind, value(p.habitability), value(p.minerals), value(p.energy), value(p.biology), p.colony ? `${p.colony} ${p.race}` : ""]) {
+          row.append(text("td", v));
+        }
+      }
+      popover.append(table);
+    } else if (s.detail === "located") {
+      popover.append(text("p", "Nothing is known about the planets."));
+    }
+    const r = view.getBoundingClientRect();
+    popover.style.left = `${Math.min(e.clientX - r.left + 12, r.width - 300)}px`;
+    popover.style.top = `${e.clientY - r.top + 12}px`;
+    popover.hidden = false;
+  }
+
+  function hide() {
+    popover.hidden = true;
+    for (const el of svg.querySelectorAll(".selected")) {
+      el.classList.remove("selected");
+    }
+  }
+})();
@@ -0,0 +1,157 @@
+// Copyright (c) 2024 Michael D Henderson. All rights reserved.
+
+// check orders with the server while they are typed, help write them
+// with a form, and submit them.
+(function () {
+  "use strict";
+  const editor = document.getElementById("orders");
+  const errors = document.getElementById("errors");
+  const checked = document.getElementById("checked");
+  const status = document.getElementById("status");
+  const api = `/api/games/${editor.dataset.game}/races/${editor.dataset.race}/orders`;
+
+  // the orders the form can write. words in angle brackets are fields;
+  // the name before the colon picks the list of ids to suggest.
+  const templates = {
+    move: "move <fleet:fleet> to <system:system>",
+    build: "build <quantity> <item:design> at <colony:colony>",
+    design: "design <name> hull <hull> drive <drive> weapons <weapons> shields <shields> sensors <sensors> cargo <cargo>",
+    merge: "merge <fleet:fleet> into <into:fleet>",
+    split: "split <fleet:fleet> <ships>",
+    retreat: "retreat <fleet:fleet> <percent>",
+    colonize: "colonize <fleet:fleet> <orbit>",
+    survey: "survey <fleet:fleet>",
+    abandon: "abandon <colony:colony>",
+    research: "research <field:field> <credits>",
+    name: "name <system:system> <name>",
+    diplomacy: "diplomacy <race:race> <stance>",
+    message: "message <race:race> <text>",
+    trade: "trade <race:race> give <kind> <what> get <kind2> <what2>",
+    transfer: "transfer <kind> <what> to <race:race>",
+  };
+
+  const verb = document.getElementById("verb");
+  const fields = document.getElementById("fields");
+  for (const name of Object.keys(templates)) {
+    verb.append(new Option(name, name));
+  }
+
+  function showFields() {
+    fields.replaceChildren();
+    for (const m of templates[verb.value].matchAll(/<(\w+)(?::(\w+))?>/g)) {
+      const input = document.createElement("input");
+      input.name = m[1];
+      input.placeholder = m[1];
+      if (m[2]) {
+        input.setAttribute("list", `ids-${m[2]}`);
+      }
+      fields.append(input);
+    }
+  }
+  verb.addEventListener("change", showFields);
+  showFields();
+
+  // quote values with spaces, the way the parser expects them
+  function quote(value) {
+    return /\s/.test(value) ? `"${value.replaceAll('"', "")}"` : value;
+  }
+
+  document.getElementById("order-form").addEventListener("submit", e => {
+    e.preventDefault();
+    const values = {};
+    for (const input of fields.querySelectorAll("input")) {
+      values[input.name] = input.value.trim();
+    }
+    // a field left empty drops the word before it, so a design can leave out parts
+    const tokens = templates[verb.value].split(" ");
+    const words = [];
+    tokens.forEach((token, i) => {
+      const m = token.match(/^<(\w+)(?::\w+)?>$/);
+      if (!m) {
+        words.push(token);
+      } else if (values[m[1]]) {
+        words.push(quote(values[m[1]]));
+      } else if (i > 1 && !tokens[i - 1].startsWith("<")) {
+        words.pop();
+      }
+    });
+    const line = words.join(" ");
+    const text = editor.value.replace(/\n*$/, "\n");
+    editor.value = text + line + "\n";
+    for (const input of fields.querySelectorAll("input")) {
+      input.value = "";
+    }
+    check();
+  });
+
+  function showErrors(result) {
+    errors.replaceChildren();
+    if (result.error) {
+      const li = document.createElement("li");
+      li.className = "error";
+      li.textContent = result.error;
+      errors.append(li);
+      checked.textContent = "";
+      return;
+    }
+    for (const e of result.errors || []) {
+      const li = document.createElement("li");
+      li.className = "error";
+      li.textContent = `line ${e.line}: ${e.text}: ${e.error}`;
+      li.addEventListener("click", () => select(e.line));
+      errors.append(li);
+    }
+    if (!result.errors || result.errors.length === 0) {
+      const li = document.createElement("li");
+      li.className = "ok";
+      li.textContent = "Every order passed the check.";
+      errors.append(li);
+    }
+  }
+
+  // select puts the cursor on a line of the orders
+  function select(line) {
+    const lines = editor.value.split("\n");
+    const start = lines.slice(0, line - 1).reduce((n, l) => n + l.length + 1, 0);
+    editor.focus();
+    editor.setSelectionRange(start, start + lines[line - 1].length);
+  }
+
+  let timer = null;
+  let latest = 0;
+  async function check() {
+    const request = ++latest;
+    try {
+      const response = await fetch(`${api}/check`, { method: "POST", body: editor.value, credentials: "same-origin" });
+      const result = await response.json();
+      // ignore answers to older requests
+      if (request === latest) {
+        showErrors(result);
+        if (!result.error) {
+          checked.textContent = `${result.orders} orders checked against turn ${result.turn}.`;
+        }
+      }
+    } catch (err) {
+      checked.textContent = `Could not check the orders: ${err}`;
+    }
+  }
+  editor.addEventListener("input", () => {
+    clearTimeout(timer);
+    timer = setTimeout(check, 500);
+  });
+
+  document.getElementById("submit").addEventListener("click", async () => {
+    try {
+      const response = await fetch(api, { method: "PUT", body: editor.value, credentials: "same-origin" });
+      const result = await response.json();
+      showErrors(result);
+      if (!result.error) {
+        status.textContent = `Submitted ${new Date(result.updated).toLocaleString()}.`;
+      }
+    } catch (err) {
+      status.textContent = `Could not submit the orders: ${err}`;
+    }
+  });
+
+  check();
+})();
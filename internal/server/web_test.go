@@ -0,0 +1,95 @@
+// Copyright (c) 2024 Michael D Henderson. All rights reserved.
+
+package server
+
+import (
+	"github.com/playbymail/fargo"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestPages(t *testing.T) {
+	site := newTestSite(t)
+	for _, tc := range []struct {
+		name, target, token string
+		want                int
+		location            string // for a redirect
+		contains            string // for a page
+	}{
+		{"anonymous", "/games", "", http.StatusSeeOther, "/login?next=%2Fgames", ""},
+		{"player home", "/", site.player, http.StatusSeeOther, "/games/alpha/races/R001", ""},
+		{"gm home", "/", site.gm, http.StatusSeeOther, "/games", ""},
+		{"player game", "/games/alpha", site.player, http.StatusSeeOther, "/games/alpha/races/R001", ""},
+		{"gm game", "/games/alpha", site.gm, http.StatusOK, "", "R002"},
+		{"race", "/games/alpha/races/R001", site.player, http.StatusOK, "", "<svg"},
+		{"other race", "/games/alpha/races/R002", site.player, http.StatusForbidden, "", ""},
+		{"orders", "/games/alpha/races/R001/orders", site.player, http.StatusOK, "", "race R001"},
+		{"reports", "/games/alpha/races/R001/reports", site.player, http.StatusOK, "", "/reports/1"},
+		{"report", "/games/alpha/races/R001/reports/1", site.player, http.StatusOK, "", "Report for turn 1"},
+		{"future report", "/games/alpha/races/R001/reports/2", site.player, http.StatusNotFound, "", ""},
+		{"unknown game", "/games/gamma/races/R001", site.gm, http.StatusNotFound, "", ""},
+	} {
+		w := site.do("GET", tc.target, tc.token)
+		if w.Code != tc.want {
+			t.Errorf("%s: want %d, got %d", tc.name, tc.want, w.Code)
+		} else if tc.location != "" && w.Header().Get("Location") != tc.location {
+			t.Errorf("%s: want a redirect to %s, got %s", tc.name, tc.location, w.Header().Get("Location"))
+		} else if !strings.Contains(w.Body.String(), tc.contains) {
+			t.Errorf("%s: want %q in the page", tc.name, tc.contains)
+		}
+	}
+}
+
+func TestLoginPage(t *testing.T) {
+	site := newTestSite(t)
+	accounts, err := fargo.LoadAccounts(site.path)
+	if err != nil {
+		t.Fatal(err)
+	} else if err := accounts.SetPassword(accounts.Account("player"), "correct horse"); err != nil {
+		t.Fatal(err)
+	} else if err := accounts.Save(site.path); err != nil {
+		t.Fatal(err)
+	}
+	login := func(password, next string) *httptest.ResponseRecorder {
+		form := url.Values{"handle": {"player"}, "password": {password}, "next": {next}}
+		r := httptest.NewRequest("POST", "/login", strings.NewReader(form.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		site.server.ServeHTTP(w, r)
+		return w
+	}
+
+	if w := login("wrong", "/games"); w.Code != http.StatusUnauthorized || len(w.Result().Cookies()) != 0 {
+		t.Errorf("wrong password: want 401 and no session, got %d", w.Code)
+	}
+	w := login("correct horse", "/games/alpha/races/R001/orders")
+	if w.Code != http.StatusSeeOther || w.Header().Get("Location") != "/games/alpha/races/R001/orders" {
+		t.Fatalf("login: want a redirect to the page asked for, got %d %s", w.Code, w.Header().Get("Location"))
+	}
+	var session *http.Cookie
+	for _, c := range w.Result().Cookies() {
+		if c.Name == sessionCookie {
+			session = c
+		}
+	}
+	if session == nil || !session.HttpOnly {
+		t.Fatalf("login: want an http-only session cookie, got %v", w.Result().Cookies())
+	}
+	r := httptest.NewRequest("GET", "/games/alpha/races/R001", nil)
+	r.AddCookie(session)
+	w = httptest.NewRecorder()
+	site.server.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Errorf("session: want 200, got %d", w.Code)
+	}
+
+	// only pages on this site are followed after a login
+	for _, next := range []string{"//evil.example.com/", "https://evil.example.com/"} {
+		if w := login("correct horse", next); w.Header().Get("Location") != "/" {
+			t.Errorf("%s: want a redirect to /, got %s", next, w.Header().Get("Location"))
+		}
+	}
+}
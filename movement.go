@@ -0,0 +1,347 @@
+// Copyright (c) 2024 Michael D Henderson. All rights reserved.
+
+package fargo
+
+import (
+	"fmt"
+	"github.com/playbymail/fargo/internal/aow"
+	"math"
+)
+
+// functions to move fleets.
+//
+// a fleet with a destination follows the route with the fewest turns at
+// its speed. each turn it flies in a straight line towards the next system
+// on the route. a fleet that reaches a system stops there for the rest of
+// the turn, so a partial turn at the end of a leg counts as a full turn,
+// just as it does when routes are planned.
+//
+// all fleets move at the same time. when fleets of different races come
+// within the interception range of each other and at least one of them is
+// armed, both stop where they met. fleets waiting in a system can't be
+// intercepted; other fleets meet them by arriving in the system. hostile
+// fleets that set out from the same system meet before they leave, so
+// both stay and fight in the system.
+
+const (
+	// InterceptionRange is how close, in light years, fleets must come for one to intercept the other.
+	InterceptionRange = 1.0
+)
+
+// encounter_t is a meeting of fleets from different races.
+type encounter_t struct {
+	position aow.Coordinates
+	system   string // id of the system, empty if the fleets met in deep space
+	fleets   []*Fleet_t
+}
+
+// trajectory_t is the path of a fleet during the movement phase.
+// The fleet is at position + velocity * (t - time) until it is halted.
+type trajectory_t struct {
+	fleet    *Fleet_t
+	race     *Race_t
+	armed    bool
+	start    aow.Coordinates
+	position aow.Coordinates // at time
+	velocity aow.Coordinates // light years per turn
+	time     float64         // fraction of the turn
+	next     *aow.StarSystem_t
+	halted   bool
+}
+
+func (tr *trajectory_t) at(t float64) aow.Coordinates {
+	return tr.position.Translate(tr.velocity.Scale(t - tr.time))
+}
+
+func (tr *trajectory_t) moving() bool {
+	return tr.velocity != aow.Coordinates{}
+}
+
+// move sets the destination of a fleet and plans its route.
+// A fleet in transit must finish its current leg before it can turn.
+func (t *turn_t) move(race *Race_t, order *MoveOrder_t) error {
+	fleet := t.g.Fleet(order.Fleet)
+	if fleet == nil || fleet.Race != race.Id {
+		return fmt.Errorf("%q: %w", order.Fleet, ErrUnknownFleet)
+	}
+	to, err := t.g.Cluster.Lookup(order.System)
+	if err != nil {
+		return err
+	}
+	speed := t.g.FleetSpeed(fleet)
+	if !(speed > 0) {
+		return fmt.Errorf("%s: %w", fleet.Id, ErrInvalidSpeed)
+	}
+	from := fleet.System
+	if fleet.InTransit() {
+		from = fleet.Route[0]
+	}
+	origin, err := t.g.Cluster.Lookup(from)
+	if err != nil {
+		return err
+	}
+	var route []string
+	if origin != to {
+		if t.routes == nil {
+			t.routes = NewRouteGraph(t.g.Cluster, DefaultMaximumJump)
+		}
+		closed := func(ss *aow.StarSystem_t) bool {
+			return !t.g.MayPass(race, ss)
+		}
+		path, err := t.routes.Closed(closed).ShortestByTurns(origin, to, speed)
+		if err != nil {
+			return fmt.Errorf("%s to %s: %w", origin.Id, to.Id, err)
+		}
+		for _, leg := range path.Legs {
+			route = append(route, leg.To.Id)
+		}
+	}
+	if fleet.InTransit() {
+		route = append([]string{origin.Id}, route...)
+	}
+	fleet.Route, fleet.Destination = route, to.Id
+	if len(route) == 0 {
+		fleet.Destination = ""
+	}
+	turns, err := t.g.TurnsToArrive(fleet)
+	if err != nil {
+		return err
+	}
+	t.g.logf(race, "%s ordered to %s %s, %d jumps, %d turns", fleet.Id, to.Id, to.Name, len(route), turns)
+	return nil
+}
+
+func (t *turn_t) movementPhase() {
+	for _, race := range t.g.Races {
+		for _, order := range ordersFor[*MoveOrder_t](t, race) {
+			t.cause(race, order)
+			if err := t.move(race, order); err != nil {
+				t.reject(race, order.OrderSource_t, err)
+			}
+		}
+	}
+	t.cause(nil, nil)
+
+	var trajectories []*trajectory_t
+	for _, fleet := range t.g.Fleets {
+		tr := &trajectory_t{fleet: fleet, race: t.g.Race(fleet.Race), armed: t.g.FleetIsArmed(fleet), start: fleet.Position, position: fleet.Position}
+		if len(fleet.Route) != 0 {
+			if next, err := t.g.Cluster.Lookup(fleet.Route[0]); err == nil {
+				tr.next = next
+				tr.velocity = fleet.Position.Toward(next.Coordinates, t.g.FleetSpeed(fleet)).Translate(fleet.Position.Scale(-1))
+			}
+		}
+		trajectories = append(trajectories, tr)
+	}
+
+	t.intercept(trajectories)
+
+	for _, tr := range trajectories {
+		fleet, race := tr.fleet, tr.race
+		if !tr.halted && !tr.moving() {
+			continue
+		}
+		fleet.Position = tr.at(1)
+		if tr.halted {
+			fleet.Position = tr.position
+		}
+		switch {
+		case tr.next != nil && fleet.Position.DistanceTo(tr.next.Coordinates) < 1e-9:
+			fleet.Position, fleet.System, fleet.Route = tr.next.Coordinates, tr.next.Id, fleet.Route[1:]
+			if len(fleet.Route) == 0 {
+				fleet.Destination = ""
+				t.g.logf(race, "%s arrived at %s %s", fleet.Id, tr.next.Id, tr.next.Name)
+			} else {
+				t.g.logf(race, "%s reached %s %s", fleet.Id, tr.next.Id, tr.next.Name)
+			}
+		case fleet.Position.DistanceTo(tr.start) < 1e-9:
+			// halted before it could leave
+		default:
+			fleet.System = ""
+			t.g.logf(race, "%s in transit at %s, %.1f ly from %s %s", fleet.Id, fleet.Position, fleet.Position.DistanceTo(tr.next.Coordinates), tr.next.Id, tr.next.Name)
+		}
+	}
+}
+
+// intercept finds the fleets that meet while moving and halts them where they meet.
+// Meetings are found in the order they happen, since a fleet that is halted
+// may then be met by fleets that would otherwise have missed it.
+func (t *turn_t) intercept(trajectories []*trajectory_t) {
+	// fleets that set out from the same system meet before they leave.
+	// they wait in the system, where the combat phase finds them.
+	var held []*trajectory_t
+	for i, a := range trajectories {
+		for _, b := range trajectories[i+1:] {
+			if a.fleet.System == "" || a.fleet.System != b.fleet.System || !a.moving() || !b.moving() || !t.canIntercept(a, b) {
+				continue
+			}
+			held = append(held, a, b)
+			t.g.logf(a.race, "%s was stopped in %s by %s fleet %s", a.fleet.Id, a.fleet.System, b.race.Name, b.fleet.Id)
+			t.g.logf(b.race, "%s was stopped in %s by %s fleet %s", b.fleet.Id, b.fleet.System, a.race.Name, a.fleet.Id)
+		}
+	}
+	for _, tr := range held {
+		tr.velocity = aow.Coordinates{}
+	}
+
+	met := make(map[[2]*Fleet_t]bool)
+	encounterOf := make(map[*Fleet_t]*encounter_t)
+	for now := 0.0; ; {
+		var first [2]*trajectory_t
+		when := math.Inf(1)
+		for i, a := range trajectories {
+			for _, b := range trajectories[i+1:] {
+				if met[[2]*Fleet_t{a.fleet, b.fleet}] || !t.canIntercept(a, b) {
+					continue
+				}
+				if dt, ok := timeToRange(a.at(now).Translate(b.at(now).Scale(-1)), a.velocity.Translate(b.velocity.Scale(-1)), InterceptionRange); ok && now+dt <= 1 && now+dt < when {
+					first, when = [2]*trajectory_t{a, b}, now+dt
+				}
+			}
+		}
+		if first[0] == nil {
+			break
+		}
+		now = when
+		a, b := first[0], first[1]
+		met[[2]*Fleet_t{a.fleet, b.fleet}] = true
+		for _, tr := range first {
+			tr.position, tr.time, tr.velocity, tr.halted = tr.at(now), now, aow.Coordinates{}, true
+		}
+
+		// fleets that meet the same fleet are part of the same encounter
+		e := encounterOf[a.fleet]
+		if e == nil {
+			e = encounterOf[b.fleet]
+		}
+		if e == nil {
+			e = &encounter_t{position: a.position}
+			t.encounters = append(t.encounters, e)
+		}
+		for _, tr := range first {
+			if encounterOf[tr.fleet] == nil {
+				encounterOf[tr.fleet] = e
+				e.fleets = append(e.fleets, tr.fleet)
+			}
+		}
+		t.g.logf(a.race, "%s intercepted %s fleet %s at %s", a.fleet.Id, b.race.Name, b.fleet.Id, a.position)
+		t.g.logf(b.race, "%s intercepted %s fleet %s at %s", b.fleet.Id, a.race.Name, a.fleet.Id, b.position)
+	}
+}
+
+// canIntercept returns true if the fleets would stop each other if they met.
+func (t *turn_t) canIntercept(a, b *trajectory_t) bool {
+	if !t.g.Hostile(a.race.Id, b.race.Id) || !(a.armed || b.armed) {
+		return false
+	} else if !a.moving() && !b.moving() {
+		return false
+	}
+	// fleets waiting in a system are met in the system, not on the way
+	inSystem := func(tr *trajectory_t) bool {
+		return !tr.moving() && !tr.halted && !tr.fleet.InTransit()
+	}
+	return !inSystem(a) && !inSystem(b)
+}
+
+// timeToRange returns the time until two objects first come within the range of each other.
+// p is the position of one relative to the other and v is its relative velocity.
+// Objects that start in range and are not moving apart meet at once.
+func timeToRange(p, v aow.Coordinates, r float64) (float64, bool) {
+	dot := func(a, b aow.Coordinates) float64 { return a.X*b.X + a.Y*b.Y + a.Z*b.Z }
+	a, b, c := dot(v, v), 2*dot(p, v), dot(p, p)-r*r
+	if c <= 0 {
+		return 0, b <= 0
+	} else if a == 0 {
+		return 0, false
+	}
+	disc := b*b - 4*a*c
+	if disc < 0 {
+		return 0, false
+	}
+	dt := (-b - math.Sqrt(disc)) / (2 * a)
+	return dt, dt >= 0
+}
+
+// fleetPhase merges and splits fleets and sets their orders for battle.
+func (t *turn_t) fleetPhase() {
+	for _, race := range t.g.Races {
+		if o, ok := t.orders[race.Id]; ok {
+			for _, order := range o.Orders {
+				var err error
+				switch order := order.(type) {
+				case *MergeOrder_t:
+					t.cause(race, order)
+					err = t.merge(race, order)
+				case *RetreatOrder_t:
+					t.cause(race, order)
+					err = t.setRetreat(race, order)
+				case *SplitOrder_t:
+					t.cause(race, order)
+					err = t.split(race, order)
+				default:
+					continue
+				}
+				if err != nil {
+					t.reject(race, order.Source(), err)
+				}
+			}
+		}
+	}
+	t.cause(nil, nil)
+	t.g.removeEmptyFleets()
+}
+
+func (t *turn_t) merge(race *Race_t, order *MergeOrder_t) error {
+	from, into := t.g.Fleet(order.Fleet), t.g.Fleet(order.Into)
+	if from == nil || from.Race != race.Id {
+		return fmt.Errorf("%q: %w", order.Fleet, ErrUnknownFleet)
+	} else if into == nil || into.Race != race.Id {
+		return fmt.Errorf("%q: %w", order.Into, ErrUnknownFleet)
+	} else if from == into {
+		return ErrInvalidArguments
+	} else if from.InTransit() || from.System != into.System {
+		return fmt.Errorf("%s and %s: %w", from.Id, into.Id, ErrNotTogether)
+	}
+	for _, id := range append([]string{}, from.Ships...) {
+		t.g.addShip(into, t.g.Ship(id))
+	}
+	t.g.logf(race, "merged %s into %s", from.Id, into.Id)
+	return nil
+}
+
+func (t *turn_t) split(race *Race_t, order *SplitOrder_t) error {
+	from := t.g.Fleet(order.Fleet)
+	if from == nil || from.Race != race.Id {
+		return fmt.Errorf("%q: %w", order.Fleet, ErrUnknownFleet)
+	} else if from.InTransit() {
+		return fmt.Errorf("%s: %w", from.Id, ErrInTransit)
+	}
+	var ships []*Ship_t
+	for _, id := range order.Ships {
+		ship := t.g.Ship(id)
+		if ship == nil || ship.Fleet != from.Id {
+			return fmt.Errorf("%q: %w", id, ErrUnknownShip)
+		}
+		ships = append(ships, ship)
+	}
+	ss, err := t.g.Cluster.Lookup(from.System)
+	if err != nil {
+		return err
+	}
+	fleet := t.g.newFleet(race, ss)
+	for _, ship := range ships {
+		t.g.addShip(fleet, ship)
+	}
+	t.g.logf(race, "split %d ships from %s into %s", len(ships), from.Id, fleet.Id)
+	return nil
+}
+
+func (t *turn_t) setRetreat(race *Race_t, order *RetreatOrder_t) error {
+	fleet := t.g.Fleet(order.Fleet)
+	if fleet == nil || fleet.Race != race.Id {
+		return fmt.Errorf("%q: %w", order.Fleet, ErrUnknownFleet)
+	}
+	fleet.Retreat = order.Percent
+	t.g.logf(race, "%s will retreat after losing %d%% of its hull", fleet.Id, fleet.Retreat)
+	return nil
+}
@@ -0,0 +1,175 @@
+// Copyright (c) 2024 Michael D Henderson. All rights reserved.
+
+package fargo
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/playbymail/fargo/internal/aow"
+)
+
+func TestTimeToRange(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		p, v aow.Coordinates
+		dt   float64
+		ok   bool
+	}{
+		{"closing", aow.Coordinates{X: 5}, aow.Coordinates{X: -2}, 2, true},
+		{"opening", aow.Coordinates{X: 5}, aow.Coordinates{X: 2}, 0, false},
+		{"missing", aow.Coordinates{X: 5, Y: 3}, aow.Coordinates{X: -2}, 0, false},
+		{"still", aow.Coordinates{X: 5}, aow.Coordinates{}, 0, false},
+		{"in range", aow.Coordinates{X: 0.5}, aow.Coordinates{X: -1}, 0, true},
+		{"leaving range", aow.Coordinates{X: 0.5}, aow.Coordinates{X: 1}, 0, false},
+	} {
+		dt, ok := timeToRange(tc.p, tc.v, 1)
+		if ok != tc.ok || (ok && math.Abs(dt-tc.dt) > 1e-9) {
+			t.Errorf("%s: want %g, %v, got %g, %v", tc.name, tc.dt, tc.ok, dt, ok)
+		}
+	}
+}
+
+func TestMove(t *testing.T) {
+	g, err := CreateGame(GameOptions_t{Name: "Test", Seed: "test", NumberOfRaces: 2, SystemsPerRace: 4, Culture: "classical", NameStyle: "syllable"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	turn := func(text string) []*OrderError_t {
+		o, err := ParseOrders(strings.NewReader(text))
+		if err != nil {
+			t.Fatal(err)
+		}
+		results, err := g.ProcessTurn([]*Orders_t{o})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return results["R001"]
+	}
+	if failed := turn("race R001\ndesign Pod hull 10 drive 1\nbuild 1 pod at C001\n"); len(failed) != 0 {
+		t.Fatalf("build: %v", failed)
+	}
+	race := g.Race("R001")
+	ships := g.ShipsOf(race)
+	if len(ships) != 1 {
+		t.Fatalf("ships: want 1, got %d", len(ships))
+	}
+	fleet := g.Fleet(ships[0].Fleet)
+	to, err := g.Cluster.Lookup(g.Race("R002").HomeSystem)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if failed := turn("race R001\nmove " + fleet.Id + " to S999\n"); len(failed) != 1 {
+		t.Errorf("unknown system: want the move to fail, got %v", failed)
+	}
+	if failed := turn("race R001\nmove F999 to " + to.Id + "\n"); len(failed) != 1 {
+		t.Errorf("unknown fleet: want the move to fail, got %v", failed)
+	}
+
+	// the fleet covers at most its speed each turn and arrives on the turn it was told it would
+	clone, err := g.Clone()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := &turn_t{g: clone}
+	if err := tr.move(clone.Race("R001"), &MoveOrder_t{Fleet: fleet.Id, System: to.Id}); err != nil {
+		t.Fatal(err)
+	}
+	turns, err := clone.TurnsToArrive(clone.Fleet(fleet.Id))
+	if err != nil {
+		t.Fatal(err)
+	} else if turns < 2 {
+		t.Fatalf("route: want a trip of more than one turn, got %d", turns)
+	}
+	speed := g.FleetSpeed(fleet)
+	orders := "race R001\nmove " + fleet.Id + " to " + to.Id + "\n"
+	for n := 1; n <= turns; n++ {
+		from := fleet.Position
+		if failed := turn(orders); len(failed) != 0 {
+			t.Fatalf("turn %d: %v", n, failed)
+		}
+		orders = "race R001\n"
+		if d := from.DistanceTo(fleet.Position); d > speed+1e-9 || d == 0 {
+			t.Errorf("turn %d: want the fleet to move up to %g ly, moved %g", n, speed, d)
+		}
+		if n < turns && fleet.System == to.Id {
+			t.Errorf("turn %d: arrived early, want %d turns", n, turns)
+		}
+	}
+	if fleet.System != to.Id || fleet.Destination != "" || len(fleet.Route) != 0 || fleet.Position != to.Coordinates {
+		t.Errorf("arrival: want the fleet at rest in %s, got %+v", to.Id, fleet)
+	}
+}
+
+func TestIntercept(t *testing.T) {
+	g := &Game_t{Races: []*Race_t{
+		{Id: "R001", Name: "Red", Stances: map[string]Status_e{"R002": StatusWar}},
+		{Id: "R002", Name: "Blue", Stances: map[string]Status_e{"R001": StatusWar}},
+	}}
+	red, blue := g.Races[0], g.Races[1]
+	trajectory := func(race *Race_t, armed bool, system string, x, vx float64) *trajectory_t {
+		p := aow.Coordinates{X: x}
+		return &trajectory_t{fleet: &Fleet_t{Id: race.Id, Race: race.Id, System: system, Position: p}, race: race, armed: armed, start: p, position: p, velocity: aow.Coordinates{X: vx}}
+	}
+
+	t.Run("head on", func(t *testing.T) {
+		a, b := trajectory(red, true, "", 0, 4), trajectory(blue, false, "", 8, -4)
+		tt := &turn_t{g: g}
+		tt.intercept([]*trajectory_t{a, b})
+		if !a.halted || !b.halted || len(tt.encounters) != 1 {
+			t.Fatalf("want both fleets halted in one encounter, got %v %v %d", a.halted, b.halted, len(tt.encounters))
+		}
+		// they close at 8 ly a turn and stop 1 ly apart
+		if got := a.at(1).X; math.Abs(got-3.5) > 1e-9 {
+			t.Errorf("position: want 3.5, got %g", got)
+		} else if got := b.at(1).DistanceTo(a.at(1)); math.Abs(got-InterceptionRange) > 1e-9 {
+			t.Errorf("range: want %g, got %g", InterceptionRange, got)
+		}
+	})
+	t.Run("unarmed", func(t *testing.T) {
+		a, b := trajectory(red, false, "", 0, 4), trajectory(blue, false, "", 10, -4)
+		tt := &turn_t{g: g}
+		tt.intercept([]*trajectory_t{a, b})
+		if a.halted || b.halted {
+			t.Errorf("want unarmed fleets to pass each other")
+		}
+	})
+	t.Run("at peace", func(t *testing.T) {
+		peace := &Game_t{Races: []*Race_t{{Id: "R001"}, {Id: "R002"}}}
+		a, b := trajectory(peace.Races[0], true, "", 0, 4), trajectory(peace.Races[1], true, "", 10, -4)
+		tt := &turn_t{g: peace}
+		tt.intercept([]*trajectory_t{a, b})
+		if a.halted || b.halted {
+			t.Errorf("want neutral fleets to pass each other")
+		}
+	})
+	t.Run("waiting in a system", func(t *testing.T) {
+		a, b := trajectory(red, true, "", 0, 4), trajectory(blue, true, "S001", 6, 0)
+		tt := &turn_t{g: g}
+		tt.intercept([]*trajectory_t{a, b})
+		if a.halted || b.halted {
+			t.Errorf("want a fleet in a system to be met there, not on the way")
+		}
+	})
+	t.Run("leaving together", func(t *testing.T) {
+		a, b := trajectory(red, true, "S001", 0, 4), trajectory(blue, true, "S001", 0, -4)
+		tt := &turn_t{g: g}
+		tt.intercept([]*trajectory_t{a, b})
+		if a.moving() || b.moving() || a.at(1) != b.at(1) {
+			t.Errorf("want fleets leaving the same system held there, got %v and %v", a.at(1), b.at(1))
+		}
+	})
+	t.Run("chain", func(t *testing.T) {
+		// the first pair meet early in the turn; the third fleet then runs into them
+		a, b, c := trajectory(red, true, "", 0, 2), trajectory(blue, true, "", 1.5, -2), trajectory(blue, true, "", 5, -4)
+		tt := &turn_t{g: g}
+		tt.intercept([]*trajectory_t{a, b, c})
+		if !a.halted || !b.halted || !c.halted {
+			t.Fatalf("want all three halted, got %v %v %v", a.halted, b.halted, c.halted)
+		} else if len(tt.encounters) != 1 || len(tt.encounters[0].fleets) != 3 {
+			t.Errorf("want one encounter of three fleets, got %d", len(tt.encounters))
+		}
+	})
+}
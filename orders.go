@@ -0,0 +1,447 @@
+// Copyright (c) 2024 Michael D Henderson. All rights reserved.
+
+package fargo
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// functions to parse orders.
+//
+// orders are plain text, one order per line. the first order must name
+// the race. blank lines and anything after a '#' are ignored. words are
+// not case-sensitive, and arguments with spaces must be quoted. orders
+// sent by mail add the player's secret to the first line:
+//
+//	race R001 [secret]
+//	design Scout hull 10 drive 1 sensors 1
+//	build 50 infrastructure at C001
+//	build 2 scout at C001
+//	move F001 to S012
+//	merge F002 into F001
+//	split F001 SH003 SH004
+//	retreat F001 50
+//	colonize F002 3
+//	survey F001
+//	abandon C004
+//	research drive 25
+//	name S012 "New Hope"
+//	diplomacy R002 alliance
+//	message R002 "Shall we talk?"
+//	trade R002 give credits 100 get tech drive
+//	transfer fleet F003 to R002
+
+// Order is implemented by every kind of order.
+type Order interface {
+	Source() OrderSource_t
+}
+
+// OrderSource_t is where an order came from.
+type OrderSource_t struct {
+	Line int    // line number in the orders file
+	Text string // the order as the player typed it
+}
+
+func (s OrderSource_t) Source() OrderSource_t {
+	return s
+}
+
+// AbandonOrder_t gives up a colony. Its people are lost.
+type AbandonOrder_t struct {
+	OrderSource_t
+	Colony string
+}
+
+// BuildOrder_t spends credits to build something at a colony.
+type BuildOrder_t struct {
+	OrderSource_t
+	Quantity float64
+	Item     string // what to build, like "infrastructure"
+	Colony   string
+}
+
+// ColonizeOrder_t lands the colonists in a fleet on a planet in the fleet's system.
+type ColonizeOrder_t struct {
+	OrderSource_t
+	Fleet string
+	Orbit int
+}
+
+// DesignOrder_t saves a new class of ship for the race.
+type DesignOrder_t struct {
+	OrderSource_t
+	Design Design_t
+}
+
+// DiplomacyOrder_t sets the race's stance toward another race.
+type DiplomacyOrder_t struct {
+	OrderSource_t
+	Race   string
+	Stance Status_e
+}
+
+// MergeOrder_t moves every ship in a fleet into another fleet in the same system.
+type MergeOrder_t struct {
+	OrderSource_t
+	Fleet string
+	Into  string
+}
+
+// MessageOrder_t sends a message to another race, or to every race.
+type MessageOrder_t struct {
+	OrderSource_t
+	Race string // id of the race, or "all"
+	Text string
+}
+
+// MoveOrder_t sends a fleet to a system.
+type MoveOrder_t struct {
+	OrderSource_t
+	Fleet  string
+	System string
+}
+
+// NameOrder_t renames a system the race owns.
+type NameOrder_t struct {
+	OrderSource_t
+	System string
+	Name   string
+}
+
+// ResearchOrder_t spends credits on research in a field of technology.
+type ResearchOrder_t struct {
+	OrderSource_t
+	Field  string // like "drive" or "life-support"
+	Amount float64
+}
+
+// RetreatOrder_t sets how much of its hull a fleet can lose in a battle before it retreats.
+type RetreatOrder_t struct {
+	OrderSource_t
+	Fleet   string
+	Percent int // 0 to fight to the end
+}
+
+// SplitOrder_t moves ships out of a fleet into a new fleet.
+type SplitOrder_t struct {
+	OrderSource_t
+	Fleet string
+	Ships []string
+}
+
+// SurveyOrder_t has a fleet with sensors survey the system it is in.
+type SurveyOrder_t struct {
+	OrderSource_t
+	Fleet string
+}
+
+// TradeOrder_t is one race's half of a trade. The other race must write the other half.
+type TradeOrder_t struct {
+	OrderSource_t
+	Race string
+	Give []TradeItem_t
+	Get  []TradeItem_t
+}
+
+// TransferOrder_t gives something to another race.
+type TransferOrder_t struct {
+	OrderSource_t
+	Item TradeItem_t
+	Race string
+}
+
+// Orders_t is the set of orders from one race for one turn.
+type Orders_t struct {
+	Race   string
+	Secret string // from the race order, empty if the orders didn't give one
+	Orders []Order
+	Errors []*OrderError_t
+}
+
+// OrderError_t is an order that could not be parsed or carried out.
+type OrderError_t struct {
+	OrderSource_t
+	Err error
+}
+
+func (e *OrderError_t) Error() string {
+	return fmt.Sprintf("line %d: %q: %v", e.Line, e.Text, e.Err)
+}
+
+func (e *OrderError_t) Unwrap() error {
+	return e.Err
+}
+
+// ParseOrders reads a set of orders. Orders that can't be parsed are
+// returned in the Errors list; the rest of the orders are still returned.
+// The only error returned is from reading the input.
+func ParseOrders(r io.Reader) (*Orders_t, error) {
+	orders := &Orders_t{}
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		src := OrderSource_t{Line: line, Text: strings.TrimSpace(scanner.Text())}
+		args, err := splitOrder(src.Text)
+		if err != nil {
+			orders.Errors = append(orders.Errors, &OrderError_t{OrderSource_t: src, Err: err})
+			continue
+		} else if len(args) == 0 {
+			continue
+		}
+		verb := strings.ToLower(args[0])
+		if verb == "race" {
+			if orders.Race != "" {
+				orders.Errors = append(orders.Errors, &OrderError_t{OrderSource_t: src, Err: ErrDuplicateRace})
+			} else if len(args) != 2 && len(args) != 3 {
+				orders.Errors = append(orders.Errors, &OrderError_t{OrderSource_t: src, Err: ErrInvalidArguments})
+			} else {
+				orders.Race = strings.ToUpper(args[1])
+				if len(args) == 3 {
+					// the secret is case-sensitive
+					orders.Secret = args[2]
+				}
+			}
+			continue
+		} else if orders.Race == "" {
+			orders.Errors = append(orders.Errors, &OrderError_t{OrderSource_t: src, Err: ErrMissingRace})
+			continue
+		}
+		order, err := parseOrder(src, verb, args[1:])
+		if err != nil {
+			orders.Errors = append(orders.Errors, &OrderError_t{OrderSource_t: src, Err: err})
+			continue
+		}
+		orders.Orders = append(orders.Orders, order)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return orders, nil
+}
+
+// parseOrder parses the arguments for a single order.
+func parseOrder(src OrderSource_t, verb string, args []string) (Order, error) {
+	switch verb {
+	case "abandon":
+		// abandon <colony>
+		if len(args) != 1 {
+			return nil, ErrInvalidArguments
+		}
+		return &AbandonOrder_t{OrderSource_t: src, Colony: strings.ToUpper(args[0])}, nil
+	case "build":
+		// build <quantity> <item> at <colony>
+		if len(args) != 4 || !strings.EqualFold(args[2], "at") {
+			return nil, ErrInvalidArguments
+		}
+		quantity, err := parseAmount(args[0])
+		if err != nil {
+			return nil, err
+		}
+		return &BuildOrder_t{OrderSource_t: src, Quantity: quantity, Item: strings.ToLower(args[1]), Colony: strings.ToUpper(args[3])}, nil
+	case "colonize":
+		// colonize <fleet> <orbit>
+		if len(args) != 2 {
+			return nil, ErrInvalidArguments
+		}
+		orbit, err := strconv.Atoi(args[1])
+		if err != nil || orbit < 1 {
+			return nil, ErrInvalidArguments
+		}
+		return &ColonizeOrder_t{OrderSource_t: src, Fleet: strings.ToUpper(args[0]), Orbit: orbit}, nil
+	case "design":
+		// design <name> [<component> <count>]...
+		if len(args) < 3 || len(args)%2 != 1 {
+			return nil, ErrInvalidArguments
+		}
+		order := &DesignOrder_t{OrderSource_t: src, Design: Design_t{Name: args[0]}}
+		d := &order.Design
+		components := map[string]*int{
+			"hull": &d.Hull, "drive": &d.Drive, "weapons": &d.Weapons, "shields": &d.Shields,
+			"cargo": &d.Cargo, "colonists": &d.Colonists, "sensors": &d.Sensors,
+		}
+		seen := make(map[string]bool)
+		for i := 1; i < len(args); i += 2 {
+			component := strings.ToLower(args[i])
+			ptr, ok := components[component]
+			if !ok || seen[component] {
+				return nil, ErrInvalidArguments
+			}
+			seen[component] = true
+			n, err := strconv.Atoi(args[i+1])
+			if err != nil || n < 0 {
+				return nil, ErrInvalidAmount
+			}
+			*ptr = n
+		}
+		return order, nil
+	case "diplomacy":
+		// diplomacy <race> <status>
+		if len(args) != 2 {
+			return nil, ErrInvalidArguments
+		}
+		stance, err := ParseStatus(args[1])
+		if err != nil {
+			return nil, err
+		}
+		return &DiplomacyOrder_t{OrderSource_t: src, Race: strings.ToUpper(args[0]), Stance: stance}, nil
+	case "merge":
+		// merge <fleet> into <fleet>
+		if len(args) != 3 || !strings.EqualFold(args[1], "into") {
+			return nil, ErrInvalidArguments
+		}
+		return &MergeOrder_t{OrderSource_t: src, Fleet: strings.ToUpper(args[0]), Into: strings.ToUpper(args[2])}, nil
+	case "message":
+		// message <race> <text>
+		if len(args) != 2 {
+			return nil, ErrInvalidArguments
+		}
+		race := strings.ToUpper(args[0])
+		if race == "ALL" {
+			race = "all"
+		}
+		return &MessageOrder_t{OrderSource_t: src, Race: race, Text: args[1]}, nil
+	case "move":
+		// move <fleet> to <system>
+		if len(args) != 3 || !strings.EqualFold(args[1], "to") {
+			return nil, ErrInvalidArguments
+		}
+		return &MoveOrder_t{OrderSource_t: src, Fleet: strings.ToUpper(args[0]), System: args[2]}, nil
+	case "name":
+		// name <system> <new name>
+		if len(args) != 2 {
+			return nil, ErrInvalidArguments
+		}
+		return &NameOrder_t{OrderSource_t: src, System: args[0], Name: args[1]}, nil
+	case "research":
+		// research <field> <amount>
+		if len(args) != 2 {
+			return nil, ErrInvalidArguments
+		}
+		amount, err := parseAmount(args[1])
+		if err != nil {
+			return nil, err
+		}
+		return &ResearchOrder_t{OrderSource_t: src, Field: strings.ToLower(args[0]), Amount: amount}, nil
+	case "retreat":
+		// retreat <fleet> <percent>
+		if len(args) != 2 {
+			return nil, ErrInvalidArguments
+		}
+		percent, err := strconv.Atoi(strings.TrimSuffix(args[1], "%"))
+		if err != nil || percent < 0 || percent > 100 {
+			return nil, ErrInvalidAmount
+		}
+		return &RetreatOrder_t{OrderSource_t: src, Fleet: strings.ToUpper(args[0]), Percent: percent}, nil
+	case "split":
+		// split <fleet> <ship>...
+		if len(args) < 2 {
+			return nil, ErrInvalidArguments
+		}
+		order := &SplitOrder_t{OrderSource_t: src, Fleet: strings.ToUpper(args[0])}
+		for _, arg := range args[1:] {
+			order.Ships = append(order.Ships, strings.ToUpper(arg))
+		}
+		return order, nil
+	case "survey":
+		// survey <fleet>
+		if len(args) != 1 {
+			return nil, ErrInvalidArguments
+		}
+		return &SurveyOrder_t{OrderSource_t: src, Fleet: strings.ToUpper(args[0])}, nil
+	case "trade":
+		// trade <race> give <item>... get <item>...
+		if len(args) < 2 {
+			return nil, ErrInvalidArguments
+		}
+		order := &TradeOrder_t{OrderSource_t: src, Race: strings.ToUpper(args[0])}
+		var list *[]TradeItem_t
+		for rest := args[1:]; len(rest) != 0; {
+			switch strings.ToLower(rest[0]) {
+			case "give":
+				list, rest = &order.Give, rest[1:]
+				continue
+			case "get":
+				list, rest = &order.Get, rest[1:]
+				continue
+			}
+			if list == nil || len(rest) < 2 {
+				return nil, ErrInvalidArguments
+			}
+			item, err := parseTradeItem(rest[0], rest[1])
+			if err != nil {
+				return nil, err
+			}
+			*list, rest = append(*list, item), rest[2:]
+		}
+		if len(order.Give) == 0 && len(order.Get) == 0 {
+			return nil, ErrInvalidArguments
+		}
+		return order, nil
+	case "transfer":
+		// transfer <kind> <value> to <race>
+		if len(args) != 4 || !strings.EqualFold(args[2], "to") {
+			return nil, ErrInvalidArguments
+		}
+		item, err := parseTradeItem(args[0], args[1])
+		if err != nil {
+			return nil, err
+		}
+		return &TransferOrder_t{OrderSource_t: src, Item: item, Race: strings.ToUpper(args[3])}, nil
+	}
+	return nil, ErrUnknownOrder
+}
+
+// parseTradeItem accepts an item to trade, like "credits 100" or "fleet F003".
+func parseTradeItem(kind, value string) (TradeItem_t, error) {
+	switch kind = strings.ToLower(kind); kind {
+	case "credits":
+		amount, err := parseAmount(value)
+		if err != nil {
+			return TradeItem_t{}, err
+		}
+		return TradeItem_t{Kind: kind, Amount: amount}, nil
+	case "colony", "fleet":
+		return TradeItem_t{Kind: kind, Id: strings.ToUpper(value)}, nil
+	case "tech":
+		return TradeItem_t{Kind: kind, Id: strings.ToLower(value)}, nil
+	}
+	return TradeItem_t{}, fmt.Errorf("%q: %w", kind, ErrUnknownItem)
+}
+
+// parseAmount accepts a positive number.
+func parseAmount(s string) (float64, error) {
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil || !(f > 0) || f > 1e9 {
+		return 0, ErrInvalidAmount
+	}
+	return f, nil
+}
+
+// splitOrder splits a line into words. Double quotes group words with spaces.
+// A # outside quotes starts a comment that runs to the end of the line.
+func splitOrder(text string) ([]string, error) {
+	var args []string
+	for text = strings.TrimSpace(text); text != ""; text = strings.TrimSpace(text) {
+		if text[0] == '#' {
+			break
+		} else if text[0] == '"' {
+			n := strings.IndexByte(text[1:], '"')
+			if n == -1 {
+				return nil, ErrUnterminatedQuote
+			}
+			args = append(args, text[1:n+1])
+			text = text[n+2:]
+			continue
+		}
+		n := strings.IndexAny(text, " \t\"#")
+		if n == -1 {
+			n = len(text)
+		}
+		args = append(args, text[:n])
+		text = text[n:]
+	}
+	return args, nil
+}
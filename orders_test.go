@@ -0,0 +1,49 @@
+// Copyright (c) 2024 Michael D Henderson. All rights reserved.
+
+package fargo
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParseOrders(t *testing.T) {
+	text := `# orders for turn 1
+race r001 Secret
+build 10 infrastructure at c001 # more mines next turn
+research drive "25"
+name C001 "New Hope"
+bogus order
+message R002 "we are #1" # and don't forget it
+message R002 "unterminated
+`
+	orders, err := ParseOrders(strings.NewReader(text))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if orders.Race != "R001" || orders.Secret != "Secret" {
+		t.Errorf("race: want R001 Secret, got %s %s", orders.Race, orders.Secret)
+	}
+	if len(orders.Orders) != 4 {
+		t.Fatalf("orders: want 4, got %d", len(orders.Orders))
+	}
+	if o, ok := orders.Orders[0].(*BuildOrder_t); !ok || o.Quantity != 10 || o.Item != "infrastructure" || o.Colony != "C001" {
+		t.Errorf("build: got %+v", orders.Orders[0])
+	}
+	if o, ok := orders.Orders[2].(*NameOrder_t); !ok || o.Name != "New Hope" {
+		t.Errorf("name: got %+v", orders.Orders[2])
+	}
+	if o, ok := orders.Orders[3].(*MessageOrder_t); !ok || o.Race != "R002" || o.Text != "we are #1" {
+		t.Errorf("message: got %+v", orders.Orders[3])
+	}
+	if len(orders.Errors) != 2 {
+		t.Fatalf("errors: want 2, got %v", orders.Errors)
+	}
+	if e := orders.Errors[0]; e.Line != 6 || !errors.Is(e, ErrUnknownOrder) {
+		t.Errorf("errors: want line 6 %v, got %v", ErrUnknownOrder, e)
+	}
+	if e := orders.Errors[1]; e.Line != 8 || !errors.Is(e, ErrUnterminatedQuote) {
+		t.Errorf("errors: want line 8 %v, got %v", ErrUnterminatedQuote, e)
+	}
+}
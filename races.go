@@ -2,6 +2,8 @@
 
 package fargo
 
+import "github.com/playbymail/fargo/internal/aow"
+
 const (
 	MinimumNumberOfRaces = 1
 	DefaultNumberOfRaces = 15
@@ -14,10 +16,33 @@ const (
 	MinimumRadiusScaleFactor = 0.1
 	DefaultRadiusScaleFactor = 1.0
 	MaximumRadiusScaleFactor = 5.0
+
+	// StartingCredits is the treasury of a race at the start of the game.
+	StartingCredits = 100.0
 )
 
 type Race_t struct {
-	Id          string
-	Name        string
-	Description string
+	Id          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+
+	Habitat    Habitat_t `json:"habitat"`     // the environment the race prefers
+	HomeSystem string    `json:"home-system"` // id of the system with the race's home world
+
+	Credits  float64            `json:"credits"`            // the race's treasury
+	Tech     map[string]int     `json:"tech"`               // level in each field of technology
+	Research map[string]float64 `json:"research,omitempty"` // points spent towards the next level in each field
+
+	Designs []*Design_t `json:"designs,omitempty"` // classes of ship the race can build
+
+	Knowledge map[string]*Knowledge_t `json:"knowledge,omitempty"` // what the race knows about each system, by system id
+	Contacts  []*Contact_t            `json:"contacts,omitempty"`  // fleets of other races seen at the end of the last turn
+
+	Stances map[string]Status_e `json:"stances,omitempty"` // stance toward other races, by race id; neutral if not set
+}
+
+// Habitability rates a planet for the race, from 0 (uninhabitable) to 100 (ideal).
+// The rating does not include the race's technology; see Game_t.Habitability.
+func (r *Race_t) Habitability(p *aow.Planet_t) int {
+	return r.Habitat.Habitability(p)
 }
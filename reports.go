@@ -0,0 +1,157 @@
+// Copyright (c) 2024 Michael D Henderson. All rights reserved.
+
+package fargo
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// functions to write the turn reports for each race.
+
+// WriteReport writes the race's report for the current turn.
+// It includes the results of the race's orders from the previous turn.
+func (g *Game_t) WriteReport(w io.Writer, race *Race_t) error {
+	bw := bufio.NewWriter(w)
+
+	fmt.Fprintf(bw, "%s, turn %d\n", g.Name, g.Turn)
+	fmt.Fprintf(bw, "Report for %s %s\n", race.Id, race.Name)
+
+	fmt.Fprintf(bw, "\nTreasury\n")
+	income, production := g.Income(race)
+	fmt.Fprintf(bw, "  credits    %10.1f\n", race.Credits)
+	fmt.Fprintf(bw, "  income     %10.1f per turn\n", income)
+
+	fmt.Fprintf(bw, "\nTechnology\n")
+	for _, f := range g.TechTree.Fields {
+		level := race.TechLevel(f.Id)
+		if level >= f.MaxLevel {
+			fmt.Fprintf(bw, "  %-14s level %2d (maximum)\n", f.Name, level)
+			continue
+		}
+		fmt.Fprintf(bw, "  %-14s level %2d  research %8.1f of %8.1f for level %d\n", f.Name, level, race.Research[f.Id], f.LevelCost(level+1), level+1)
+	}
+	fmt.Fprintf(bw, "  speed %g ly/turn, scan range %g ly, attack %.2f, defense %.2f\n", g.Speed(race), g.ScanRange(race), g.Attack(race), g.Defense(race))
+
+	fmt.Fprintf(bw, "\nColonies\n")
+	for _, prod := range production {
+		colony := prod.Colony
+		ss, planet := g.Planet(colony)
+		if ss == nil || planet == nil {
+			continue
+		}
+		shipyard := ""
+		if colony.Shipyard {
+			shipyard = ", shipyard"
+		}
+		fmt.Fprintf(bw, "  %s at %s %s orbit %d%s\n", colony.Id, ss.Id, ss.Name, colony.Orbit, shipyard)
+		fmt.Fprintf(bw, "    population %8.1f  infrastructure %8.1f  workers %8.1f  defenses %6.1f\n", colony.Population, colony.Infrastructure, prod.Workers, colony.Defenses)
+		fmt.Fprintf(bw, "    capacity   %8.1f  growth %+.1f per turn\n", g.Capacity(colony), g.Growth(colony))
+		fmt.Fprintf(bw, "    planet     %s, gravity %.2f, %s atmosphere %.2f atm, %.0fK, water %.0f%%, habitability %d\n",
+			planet.Kind, planet.Gravity, planet.Atmosphere, planet.Pressure, planet.Temperature, planet.Hydrographics, g.Habitability(race, planet))
+		fmt.Fprintf(bw, "    resources  minerals %3.0f  energy %3.0f  biology %3.0f\n", planet.Minerals, planet.Energy, planet.Biology)
+		fmt.Fprintf(bw, "    output     minerals %6.1f  energy %6.1f  biology %6.1f  income %6.1f\n", prod.Minerals, prod.Energy, prod.Biology, prod.Income)
+	}
+
+	fmt.Fprintf(bw, "\nDesigns\n")
+	for _, d := range race.Designs {
+		fmt.Fprintf(bw, "  %-16s %s, space %d of %d, cost %.1f, speed %g ly/turn\n", d.Name, d.String(), d.Space(), d.Hull, d.Cost(), g.DesignSpeed(d))
+	}
+
+	fmt.Fprintf(bw, "\nFleets\n")
+	for _, fleet := range g.FleetsOf(race) {
+		speed := g.FleetSpeed(fleet)
+		if fleet.InTransit() {
+			fmt.Fprintf(bw, "  %s in transit at %s, speed %g ly/turn\n", fleet.Id, fleet.Position, speed)
+		} else if ss, err := g.Cluster.Lookup(fleet.System); err == nil {
+			fmt.Fprintf(bw, "  %s at %s %s, speed %g ly/turn\n", fleet.Id, ss.Id, ss.Name, speed)
+		}
+		if fleet.Retreat != 0 {
+			fmt.Fprintf(bw, "    retreats after losing %d%% of its hull\n", fleet.Retreat)
+		}
+		if len(fleet.Route) != 0 {
+			dest, _ := g.Cluster.Lookup(fleet.Destination)
+			next, _ := g.Cluster.Lookup(fleet.Route[0])
+			turns, _ := g.TurnsToArrive(fleet)
+			if dest != nil && next != nil {
+				fmt.Fprintf(bw, "    bound for %s %s, next %s %s %.1f ly away, %d turns to arrive\n", dest.Id, dest.Name, next.Id, next.Name, fleet.Position.DistanceTo(next.Coordinates), turns)
+			}
+		}
+		for _, id := range fleet.Ships {
+			if ship := g.Ship(id); ship == nil {
+				continue
+			} else if ship.Colonists > 0 {
+				fmt.Fprintf(bw, "    %-6s %s, %.1f million colonists\n", ship.Id, ship.Design, ship.Colonists)
+			} else {
+				fmt.Fprintf(bw, "    %-6s %s\n", ship.Id, ship.Design)
+			}
+		}
+	}
+
+	// systems the race's sensors have reached, as they were when they were last seen
+	fmt.Fprintf(bw, "\nSystems\n")
+	for _, ss := range g.Cluster.StarSystems {
+		if k, ok := race.Knowledge[ss.Id]; !ok || k.Detail < DetailScanned {
+			continue
+		}
+		v := g.View(race, ss)
+		owner := "unclaimed"
+		if v.Owner != "" {
+			owner = "owned by " + v.Owner
+		}
+		fmt.Fprintf(bw, "  %s %-16s %s, %s, %s on turn %d\n", v.Id, v.Name, v.Coordinates, owner, v.Detail, v.Turn)
+		for _, p := range v.Planets {
+			line := fmt.Sprintf("    orbit %2d  %-20s", p.Orbit, p.Kind)
+			if p.Habitability != nil {
+				line += fmt.Sprintf("  habitability %3d", *p.Habitability)
+			}
+			if p.Minerals != nil {
+				line += fmt.Sprintf("  minerals %3.0f  energy %3.0f  biology %3.0f", *p.Minerals, *p.Energy, *p.Biology)
+			}
+			if p.Colony != "" {
+				line += fmt.Sprintf("  colony %s of %s", p.Colony, p.Race)
+			}
+			fmt.Fprintf(bw, "%s\n", strings.TrimRight(line, " "))
+		}
+	}
+
+	fmt.Fprintf(bw, "\nContacts\n")
+	for _, contact := range race.Contacts {
+		if ss, err := g.Cluster.Lookup(contact.System); err == nil {
+			fmt.Fprintf(bw, "  %s of %s, %d ships, at %s %s\n", contact.Fleet, contact.Race, contact.Ships, ss.Id, ss.Name)
+		} else {
+			fmt.Fprintf(bw, "  %s of %s, %d ships, in transit at %s\n", contact.Fleet, contact.Race, contact.Ships, contact.Position)
+		}
+	}
+
+	fmt.Fprintf(bw, "\nDiplomacy\n")
+	for _, other := range g.Races {
+		if other != race {
+			fmt.Fprintf(bw, "  %s %-12s %-14s  our stance %-14s  their stance %s\n", other.Id, other.Name, g.Status(race.Id, other.Id), race.Stance(other.Id), other.Stance(race.Id))
+		}
+	}
+
+	if g.Turn > 1 {
+		fmt.Fprintf(bw, "\nMessages\n")
+		for _, msg := range g.Messages {
+			if msg.Turn == g.Turn-1 && msg.To == race.Id {
+				from := msg.From
+				if sender := g.Race(msg.From); sender != nil {
+					from = fmt.Sprintf("%s %s", sender.Id, sender.Name)
+				}
+				fmt.Fprintf(bw, "  from %s: %s\n", from, msg.Text)
+			}
+		}
+
+		fmt.Fprintf(bw, "\nResults of turn %d\n", g.Turn-1)
+		for _, entry := range g.Log {
+			if entry.Turn == g.Turn-1 && entry.Race == race.Id {
+				fmt.Fprintf(bw, "  %s\n", entry.Text)
+			}
+		}
+	}
+
+	return bw.Flush()
+}
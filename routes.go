@@ -0,0 +1,306 @@
+// Copyright (c) 2024 Michael D Henderson. All rights reserved.
+
+package fargo
+
+import (
+	"container/heap"
+	"github.com/playbymail/fargo/internal/aow"
+	"math"
+	"sort"
+	"sync"
+)
+
+// functions to find routes between the systems in a cluster.
+// all distances are in light years.
+
+const (
+	// DefaultMaximumJump is the length of the longest single jump, in light years.
+	DefaultMaximumJump = 10.0
+)
+
+// DriveSpeed returns the number of light years per turn a ship travels at the given
+// drive tech, using the default tech tree. Games use the race's speed instead.
+func DriveSpeed(driveTech int) float64 {
+	return defaultDriveField().Effect(EffectSpeed, driveTech)
+}
+
+// defaultDriveField parses the built-in tree once. DefaultTechTree returns
+// a fresh copy because callers may change it, but nothing changes this one.
+var defaultDriveField = sync.OnceValue(func() *TechField_t {
+	return DefaultTechTree().Field(TechDrive)
+})
+
+// TurnsToTravel returns the number of turns needed to travel a distance at the given speed.
+// Any fraction of a turn counts as a full turn.
+func TurnsToTravel(distance, speed float64) (int, error) {
+	if !(speed > 0) {
+		return 0, ErrInvalidSpeed
+	}
+	// the epsilon keeps rounding errors from adding a turn to exact multiples
+	return int(math.Ceil(distance/speed - 1e-9)), nil
+}
+
+// RouteGraph_t is the graph of jumps between the systems in a catalog.
+// Two systems are connected when the distance between them is no
+// more than the maximum jump.
+type RouteGraph_t struct {
+	catalog *aow.Catalog_t
+	maxJump float64
+	index   map[*aow.StarSystem_t]int
+	edges   [][]routeEdge_t
+	closed  func(*aow.StarSystem_t) bool // systems that routes may end at but not pass through
+}
+
+type routeEdge_t struct {
+	to       int
+	distance float64
+}
+
+// Route_t is an itinerary between two systems.
+type Route_t struct {
+	Legs     []*Leg_t
+	Distance float64 // total distance in light years
+	Turns    int     // total turns, only set when the route was found at a given speed
+}
+
+// Leg_t is a single jump in a route.
+type Leg_t struct {
+	From     *aow.StarSystem_t
+	To       *aow.StarSystem_t
+	Distance float64
+	Turns    int
+}
+
+// Hops returns the number of jumps in the route.
+func (r *Route_t) Hops() int {
+	return len(r.Legs)
+}
+
+// Reachable_t is a system that can be reached from an origin.
+type Reachable_t struct {
+	System   *aow.StarSystem_t
+	Distance float64
+	Turns    int
+	Hops     int
+}
+
+// NewRouteGraph returns the route graph for the catalog.
+// If maxJump is zero or less, every system is connected to every other system.
+func NewRouteGraph(catalog *aow.Catalog_t, maxJump float64) *RouteGraph_t {
+	g := &RouteGraph_t{
+		catalog: catalog,
+		maxJump: maxJump,
+		index:   make(map[*aow.StarSystem_t]int),
+		edges:   make([][]routeEdge_t, len(catalog.StarSystems)),
+	}
+	for i, ss := range catalog.StarSystems {
+		g.index[ss] = i
+	}
+	for i, from := range catalog.StarSystems {
+		for j := i + 1; j < len(catalog.StarSystems); j++ {
+			distance := from.DistanceTo(catalog.StarSystems[j])
+			if maxJump > 0 && distance > maxJump {
+				continue
+			}
+			g.edges[i] = append(g.edges[i], routeEdge_t{to: j, distance: distance})
+			g.edges[j] = append(g.edges[j], routeEdge_t{to: i, distance: distance})
+		}
+	}
+	return g
+}
+
+// Closed returns a copy of the graph in which routes may end at, but not
+// pass through, the systems that the function says are closed.
+func (g *RouteGraph_t) Closed(closed func(*aow.StarSystem_t) bool) *RouteGraph_t {
+	c := *g
+	c.closed = closed
+	return &c
+}
+
+// MaximumJump returns the length of the longest jump allowed in the graph.
+func (g *RouteGraph_t) MaximumJump() float64 {
+	return g.maxJump
+}
+
+// ShortestByDistance returns the route with the shortest total distance.
+func (g *RouteGraph_t) ShortestByDistance(from, to *aow.StarSystem_t) (*Route_t, error) {
+	return g.shortest(from, to, 0, func(e routeEdge_t) routeCost_t {
+		return routeCost_t{primary: e.distance, secondary: 1}
+	})
+}
+
+// ShortestByTurns returns the route that takes the fewest turns at the given speed.
+// Every leg starts at a system, so a partial turn at the end of a leg counts as a full turn.
+// Ties are broken by distance.
+func (g *RouteGraph_t) ShortestByTurns(from, to *aow.StarSystem_t, speed float64) (*Route_t, error) {
+	if !(speed > 0) {
+		return nil, ErrInvalidSpeed
+	}
+	return g.shortest(from, to, speed, func(e routeEdge_t) routeCost_t {
+		turns, _ := TurnsToTravel(e.distance, speed)
+		return routeCost_t{primary: float64(turns), secondary: e.distance}
+	})
+}
+
+// ShortestByHops returns the route with the fewest jumps.
+// Ties are broken by distance.
+func (g *RouteGraph_t) ShortestByHops(from, to *aow.StarSystem_t) (*Route_t, error) {
+	return g.shortest(from, to, 0, func(e routeEdge_t) routeCost_t {
+		return routeCost_t{primary: 1, secondary: e.distance}
+	})
+}
+
+// ReachableWithin returns the systems that can be reached from the origin in
+// no more than the given number of turns at the given speed.
+// The origin is not included. Results are sorted by turns, then by distance.
+func (g *RouteGraph_t) ReachableWithin(from *aow.StarSystem_t, speed float64, turns int) ([]*Reachable_t, error) {
+	if !(speed > 0) {
+		return nil, ErrInvalidSpeed
+	}
+	origin, ok := g.index[from]
+	if !ok {
+		return nil, ErrUnknownSystem
+	}
+	costs, _ := g.search(origin, -1, func(e routeEdge_t) routeCost_t {
+		turns, _ := TurnsToTravel(e.distance, speed)
+		return routeCost_t{primary: float64(turns), secondary: e.distance}
+	}, float64(turns))
+
+	var reachable []*Reachable_t
+	for i, cost := range costs {
+		if i == origin || !cost.found {
+			continue
+		}
+		reachable = append(reachable, &Reachable_t{
+			System:   g.catalog.StarSystems[i],
+			Distance: cost.secondary,
+			Turns:    int(cost.primary),
+			Hops:     cost.hops,
+		})
+	}
+	sort.Slice(reachable, func(i, j int) bool {
+		if reachable[i].Turns != reachable[j].Turns {
+			return reachable[i].Turns < reachable[j].Turns
+		}
+		return reachable[i].Distance < reachable[j].Distance
+	})
+	return reachable, nil
+}
+
+// routeCost_t is the cost of a path. Paths are compared on the primary
+// cost first, then on the secondary cost.
+type routeCost_t struct {
+	primary   float64
+	secondary float64
+	hops      int
+	found     bool
+}
+
+func (c routeCost_t) add(o routeCost_t) routeCost_t {
+	return routeCost_t{primary: c.primary + o.primary, secondary: c.secondary + o.secondary, hops: c.hops + 1, found: true}
+}
+
+func (c routeCost_t) less(o routeCost_t) bool {
+	if c.primary != o.primary {
+		return c.primary < o.primary
+	}
+	return c.secondary < o.secondary
+}
+
+func (g *RouteGraph_t) shortest(from, to *aow.StarSystem_t, speed float64, cost func(routeEdge_t) routeCost_t) (*Route_t, error) {
+	origin, ok := g.index[from]
+	if !ok {
+		return nil, ErrUnknownSystem
+	}
+	target, ok := g.index[to]
+	if !ok {
+		return nil, ErrUnknownSystem
+	}
+	costs, prev := g.search(origin, target, cost, -1)
+	if !costs[target].found {
+		return nil, ErrNoRoute
+	}
+
+	// walk the path backwards from the target to the origin
+	var path []int
+	for n := target; n != origin; n = prev[n] {
+		path = append(path, n)
+	}
+	path = append(path, origin)
+
+	route := &Route_t{}
+	for i := len(path) - 1; i > 0; i-- {
+		leg := &Leg_t{
+			From: g.catalog.StarSystems[path[i]],
+			To:   g.catalog.StarSystems[path[i-1]],
+		}
+		leg.Distance = leg.From.DistanceTo(leg.To)
+		if speed > 0 {
+			leg.Turns, _ = TurnsToTravel(leg.Distance, speed)
+		}
+		route.Legs = append(route.Legs, leg)
+		route.Distance += leg.Distance
+		route.Turns += leg.Turns
+	}
+	return route, nil
+}
+
+// search runs Dijkstra's algorithm from the origin. It stops early when the
+// target (if not negative) is settled. Paths with a primary cost greater
+// than the limit (if not negative) are not explored.
+func (g *RouteGraph_t) search(origin, target int, cost func(routeEdge_t) routeCost_t, limit float64) ([]routeCost_t, []int) {
+	costs := make([]routeCost_t, len(g.edges))
+	prev := make([]int, len(g.edges))
+	settled := make([]bool, len(g.edges))
+	for i := range prev {
+		prev[i] = -1
+	}
+	costs[origin] = routeCost_t{found: true}
+
+	pq := &routeQueue_t{{node: origin, cost: costs[origin]}}
+	for pq.Len() > 0 {
+		item := heap.Pop(pq).(routeQueueItem_t)
+		if settled[item.node] {
+			continue
+		}
+		settled[item.node] = true
+		if item.node == target {
+			break
+		} else if item.node != origin && g.closed != nil && g.closed(g.catalog.StarSystems[item.node]) {
+			continue
+		}
+		for _, e := range g.edges[item.node] {
+			if settled[e.to] {
+				continue
+			}
+			c := item.cost.add(cost(e))
+			if limit >= 0 && c.primary > limit {
+				continue
+			}
+			if !costs[e.to].found || c.less(costs[e.to]) {
+				costs[e.to], prev[e.to] = c, item.node
+				heap.Push(pq, routeQueueItem_t{node: e.to, cost: c})
+			}
+		}
+	}
+	return costs, prev
+}
+
+type routeQueueItem_t struct {
+	node int
+	cost routeCost_t
+}
+
+// routeQueue_t implements heap.Interface for the search
+type routeQueue_t []routeQueueItem_t
+
+func (q routeQueue_t) Len() int           { return len(q) }
+func (q routeQueue_t) Less(i, j int) bool { return q[i].cost.less(q[j].cost) }
+func (q routeQueue_t) Swap(i, j int)      { q[i], q[j] = q[j], q[i] }
+func (q *routeQueue_t) Push(x any)        { *q = append(*q, x.(routeQueueItem_t)) }
+func (q *routeQueue_t) Pop() any {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
@@ -0,0 +1,120 @@
+// Copyright (c) 2024 Michael D Henderson. All rights reserved.
+
+package fargo
+
+import (
+	"errors"
+	"github.com/playbymail/fargo/internal/aow"
+	"testing"
+)
+
+// testRouteCatalog returns systems A, B and C on a line 4 ly apart, D off
+// the line 5 ly from A and C, and E far away from the rest.
+func testRouteCatalog() *aow.Catalog_t {
+	c := &aow.Catalog_t{}
+	for _, s := range []struct {
+		id   string
+		x, y float64
+	}{{"A", 0, 0}, {"B", 4, 0}, {"C", 8, 0}, {"D", 4, 3}, {"E", 100, 0}} {
+		c.StarSystems = append(c.StarSystems, &aow.StarSystem_t{Id: s.id, Coordinates: aow.Coordinates{X: s.x, Y: s.y}})
+	}
+	return c
+}
+
+func routeIds(r *Route_t) string {
+	ids := r.Legs[0].From.Id
+	for _, leg := range r.Legs {
+		ids += leg.To.Id
+	}
+	return ids
+}
+
+func TestTurnsToTravel(t *testing.T) {
+	for _, tc := range []struct {
+		distance, speed float64
+		want            int
+	}{
+		{0, 4, 0},
+		{8, 4, 2},
+		{8.1, 4, 3},
+		{0.3 * 3, 0.3, 3},
+	} {
+		if got, err := TurnsToTravel(tc.distance, tc.speed); err != nil || got != tc.want {
+			t.Errorf("%g at %g: want %d, got %d, %v", tc.distance, tc.speed, tc.want, got, err)
+		}
+	}
+	if _, err := TurnsToTravel(8, 0); !errors.Is(err, ErrInvalidSpeed) {
+		t.Errorf("speed 0: want %v, got %v", ErrInvalidSpeed, err)
+	}
+	if !(DriveSpeed(2) > DriveSpeed(1)) || !(DriveSpeed(1) > 0) {
+		t.Errorf("drive speed: want faster with more tech, got %g and %g", DriveSpeed(1), DriveSpeed(2))
+	}
+}
+
+func TestShortestRoutes(t *testing.T) {
+	c := testRouteCatalog()
+	a, cc, e := c.StarSystems[0], c.StarSystems[2], c.StarSystems[4]
+	g := NewRouteGraph(c, 5)
+
+	r, err := g.ShortestByDistance(a, cc)
+	if err != nil {
+		t.Fatal(err)
+	} else if routeIds(r) != "ABC" || r.Distance != 8 || r.Hops() != 2 {
+		t.Errorf("distance: want ABC 8 ly, got %s %g ly", routeIds(r), r.Distance)
+	}
+	// both routes are two hops, so the shorter one wins
+	if r, err = g.ShortestByHops(a, cc); err != nil {
+		t.Fatal(err)
+	} else if routeIds(r) != "ABC" {
+		t.Errorf("hops: want ABC, got %s", routeIds(r))
+	}
+	if r, err = g.ShortestByTurns(a, cc, 4); err != nil {
+		t.Fatal(err)
+	} else if routeIds(r) != "ABC" || r.Turns != 2 {
+		t.Errorf("turns: want ABC in 2 turns, got %s in %d", routeIds(r), r.Turns)
+	}
+	// a closed system can't be passed through
+	closed := g.Closed(func(ss *aow.StarSystem_t) bool { return ss.Id == "B" })
+	if r, err = closed.ShortestByDistance(a, cc); err != nil {
+		t.Fatal(err)
+	} else if routeIds(r) != "ADC" || r.Distance != 10 {
+		t.Errorf("closed: want ADC 10 ly, got %s %g ly", routeIds(r), r.Distance)
+	}
+
+	if _, err := g.ShortestByDistance(a, e); !errors.Is(err, ErrNoRoute) {
+		t.Errorf("unreachable: want %v, got %v", ErrNoRoute, err)
+	} else if _, err := g.ShortestByDistance(a, &aow.StarSystem_t{Id: "X"}); !errors.Is(err, ErrUnknownSystem) {
+		t.Errorf("unknown: want %v, got %v", ErrUnknownSystem, err)
+	} else if _, err := g.ShortestByTurns(a, cc, 0); !errors.Is(err, ErrInvalidSpeed) {
+		t.Errorf("speed 0: want %v, got %v", ErrInvalidSpeed, err)
+	}
+	// with no maximum jump every system is connected
+	if r, err = NewRouteGraph(c, 0).ShortestByHops(a, e); err != nil || r.Hops() != 1 {
+		t.Errorf("no maximum: want 1 hop, got %v, %v", r, err)
+	}
+}
+
+func TestReachableWithin(t *testing.T) {
+	c := testRouteCatalog()
+	g := NewRouteGraph(c, 5)
+	for _, tc := range []struct {
+		turns int
+		want  string
+	}{
+		{0, ""},
+		{1, "B"},
+		{2, "BDC"},
+	} {
+		list, err := g.ReachableWithin(c.StarSystems[0], 4, tc.turns)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got string
+		for _, r := range list {
+			got += r.System.Id
+		}
+		if got != tc.want {
+			t.Errorf("%d turns: want %q, got %q", tc.turns, tc.want, got)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+// Copyright (c) 2024 Michael D Henderson. All rights reserved.
+
+package fargo
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// functions to decide when a turn is processed.
+//
+// the schedule is kept in schedule.json in the game directory, so that
+// it survives a restart of the server. a turn is processed at the
+// deadline if every race has submitted orders. otherwise the server
+// waits for the grace period and then processes the turn with the
+// orders it has. when early processing is allowed, the turn is
+// processed as soon as every race has submitted orders.
+//
+// reminders are sent to races without orders at set times before the
+// deadline. each reminder is sent once per turn.
+
+const ScheduleFile = "schedule.json"
+
+// Schedule_t is when the game's turns are processed.
+type Schedule_t struct {
+	Deadline  *time.Time   `json:"deadline,omitempty"` // nil if turns are only processed by hand
+	Grace     Duration_t   `json:"grace,omitempty"`    // how long to wait for late orders
+	Period    Duration_t   `json:"period,omitempty"`   // time between deadlines, zero to stop after one turn
+	Early     bool         `json:"early,omitempty"`    // process when every race has submitted orders
+	Reminders []Duration_t `json:"reminders,omitempty"`
+	Turn      int          `json:"turn"`               // the turn the deadline is for
+	Reminded  []Duration_t `json:"reminded,omitempty"` // reminders sent for the turn
+}
+
+// Duration_t is a time.Duration that is saved as text, like "36h".
+type Duration_t time.Duration
+
+func (d Duration_t) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+func (d *Duration_t) UnmarshalText(text []byte) error {
+	v, err := time.ParseDuration(string(text))
+	*d = Duration_t(v)
+	return err
+}
+
+// LoadSchedule reads the schedule in the game directory.
+// A game without a schedule file has no deadline.
+func LoadSchedule(path string) (*Schedule_t, error) {
+	data, err := os.ReadFile(filepath.Join(path, ScheduleFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return &Schedule_t{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var s Schedule_t
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// Save writes the schedule to the game directory.
+func (s *Schedule_t) Save(path string) error {
+	sort.Slice(s.Reminders, func(i, j int) bool {
+		return s.Reminders[i] > s.Reminders[j]
+	})
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	name := filepath.Join(path, ScheduleFile)
+	if err := os.WriteFile(name+".tmp", data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(name+".tmp", name)
+}
+
+// Due returns true if the turn should be processed now.
+func (s *Schedule_t) Due(now time.Time, allSubmitted bool) bool {
+	if s.Deadline == nil {
+		return false
+	} else if allSubmitted && (s.Early || !now.Before(*s.Deadline)) {
+		return true
+	}
+	return !now.Before(s.Deadline.Add(time.Duration(s.Grace)))
+}
+
+// DueReminder returns the reminder that should be sent now, if any.
+// Only the latest reminder is returned when several are due, so that
+// a server that was down doesn't send a burst of them.
+func (s *Schedule_t) DueReminder(now time.Time) (Duration_t, bool) {
+	if s.Deadline == nil || !now.Before(*s.Deadline) {
+		return 0, false
+	}
+	var due Duration_t
+	found := false
+	for _, r := range s.Reminders {
+		if now.Before(s.Deadline.Add(-time.Duration(r))) {
+			continue
+		} else if !found || r < due {
+			due, found = r, true
+		}
+	}
+	if !found {
+		return 0, false
+	}
+	for _, r := range s.Reminded {
+		if r <= due {
+			return 0, false
+		}
+	}
+	return due, true
+}
+
+// Remind records that the reminder, and every earlier one, has been sent.
+func (s *Schedule_t) Remind(r Duration_t) {
+	sent := map[Duration_t]bool{}
+	for _, o := range s.Reminded {
+		sent[o] = true
+	}
+	for _, o := range s.Reminders {
+		if o >= r && !sent[o] {
+			s.Reminded = append(s.Reminded, o)
+		}
+	}
+}
+
+// Advance sets the deadline for the next turn. The deadline moves by the
+// period until it is in the future. A schedule without a period has no
+// deadline after this.
+func (s *Schedule_t) Advance(turn int, now time.Time) {
+	s.Turn, s.Reminded = turn, nil
+	if s.Deadline == nil {
+		return
+	} else if s.Period <= 0 {
+		s.Deadline = nil
+		return
+	}
+	deadline := *s.Deadline
+	for !deadline.After(now) {
+		deadline = deadline.Add(time.Duration(s.Period))
+	}
+	s.Deadline = &deadline
+}
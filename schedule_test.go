@@ -0,0 +1,115 @@
+// Copyright (c) 2024 Michael D Henderson. All rights reserved.
+
+package fargo
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDue(t *testing.T) {
+	deadline := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	before, at, late := deadline.Add(-time.Hour), deadline, deadline.Add(2*time.Hour)
+	for _, tc := range []struct {
+		name         string
+		s            Schedule_t
+		now          time.Time
+		allSubmitted bool
+		want         bool
+	}{
+		{"no deadline", Schedule_t{}, late, true, false},
+		{"before", Schedule_t{Deadline: &deadline}, before, true, false},
+		{"early", Schedule_t{Deadline: &deadline, Early: true}, before, true, true},
+		{"early, missing orders", Schedule_t{Deadline: &deadline, Early: true}, before, false, false},
+		{"at the deadline", Schedule_t{Deadline: &deadline}, at, true, true},
+		{"at the deadline, no grace", Schedule_t{Deadline: &deadline}, at, false, true},
+		{"in the grace period", Schedule_t{Deadline: &deadline, Grace: Duration_t(3 * time.Hour)}, late, false, false},
+		{"in the grace period, all in", Schedule_t{Deadline: &deadline, Grace: Duration_t(3 * time.Hour)}, late, true, true},
+		{"after the grace period", Schedule_t{Deadline: &deadline, Grace: Duration_t(time.Hour)}, late, false, true},
+	} {
+		if got := tc.s.Due(tc.now, tc.allSubmitted); got != tc.want {
+			t.Errorf("%s: want %v, got %v", tc.name, tc.want, got)
+		}
+	}
+}
+
+func TestDueReminder(t *testing.T) {
+	deadline := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	day, hour := Duration_t(24*time.Hour), Duration_t(time.Hour)
+	s := &Schedule_t{Deadline: &deadline, Reminders: []Duration_t{hour, day}}
+
+	if _, ok := s.DueReminder(deadline.Add(-48 * time.Hour)); ok {
+		t.Errorf("two days out: want no reminder")
+	}
+	r, ok := s.DueReminder(deadline.Add(-12 * time.Hour))
+	if !ok || r != day {
+		t.Fatalf("half a day out: want %v, got %v, %v", day, r, ok)
+	}
+	s.Remind(r)
+	if _, ok := s.DueReminder(deadline.Add(-6 * time.Hour)); ok {
+		t.Errorf("after reminding: want the day reminder sent once")
+	}
+
+	// a server that was down sends only the latest reminder, then none
+	s.Reminded = nil
+	if r, ok := s.DueReminder(deadline.Add(-30 * time.Minute)); !ok || r != hour {
+		t.Fatalf("catching up: want %v, got %v, %v", hour, r, ok)
+	}
+	s.Remind(hour)
+	if len(s.Reminded) != 2 {
+		t.Errorf("catching up: want both reminders recorded, got %v", s.Reminded)
+	}
+	if _, ok := s.DueReminder(deadline.Add(time.Minute)); ok {
+		t.Errorf("past the deadline: want no reminder")
+	}
+}
+
+func TestAdvance(t *testing.T) {
+	deadline := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	s := &Schedule_t{Deadline: &deadline, Period: Duration_t(24 * time.Hour), Turn: 3, Reminded: []Duration_t{Duration_t(time.Hour)}}
+
+	// a server that was down for three days skips to the next deadline in the future
+	s.Advance(4, deadline.Add(3*24*time.Hour+time.Minute))
+	if want := deadline.Add(4 * 24 * time.Hour); s.Turn != 4 || !s.Deadline.Equal(want) || s.Reminded != nil {
+		t.Errorf("advance: want turn 4 due %v, got %+v", want, s)
+	}
+
+	one := &Schedule_t{Deadline: &deadline}
+	one.Advance(4, deadline)
+	if one.Deadline != nil || one.Turn != 4 {
+		t.Errorf("no period: want no deadline, got %+v", one)
+	}
+}
+
+func TestScheduleSave(t *testing.T) {
+	path := t.TempDir()
+	if s, err := LoadSchedule(path); err != nil || s.Deadline != nil {
+		t.Fatalf("missing: want no deadline, got %+v, %v", s, err)
+	}
+	deadline := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	s := &Schedule_t{Deadline: &deadline, Grace: Duration_t(36 * time.Hour), Reminders: []Duration_t{Duration_t(time.Hour), Duration_t(24 * time.Hour)}, Turn: 2}
+	if err := s.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(path, ScheduleFile))
+	if err != nil {
+		t.Fatal(err)
+	} else if !strings.Contains(string(data), `"grace": "36h0m0s"`) {
+		t.Errorf("save: want durations saved as text, got\n%s", data)
+	}
+	got, err := LoadSchedule(path)
+	if err != nil {
+		t.Fatal(err)
+	} else if !got.Deadline.Equal(deadline) || got.Grace != s.Grace || got.Turn != 2 || len(got.Reminders) != 2 || got.Reminders[0] != Duration_t(24*time.Hour) {
+		t.Errorf("load: want the reminders latest first, got %+v", got)
+	}
+
+	if err := os.WriteFile(filepath.Join(path, ScheduleFile), []byte(`{"grace": "soon"}`), 0644); err != nil {
+		t.Fatal(err)
+	} else if _, err := LoadSchedule(path); err == nil {
+		t.Errorf("bad duration: want an error")
+	}
+}
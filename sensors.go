@@ -0,0 +1,241 @@
+// Copyright (c) 2024 Michael D Henderson. All rights reserved.
+
+package fargo
+
+import (
+	"fmt"
+	"github.com/playbymail/fargo/internal/aow"
+	"sort"
+)
+
+// functions to scan the cluster and track what each race knows.
+//
+// every star can be seen from anywhere in the cluster, so every race
+// knows where every system is. more detail comes from sensors. colonies
+// and fleets with sensors scan every system within the race's scan range.
+// systems in the inner half of the range are seen in more detail, as are
+// systems where the race has a fleet. a fleet with sensors can survey the
+// system it is in, and a race always knows everything about the systems
+// where it has colonies.
+//
+// what a race learns is kept until it is replaced by a better look, so
+// reports may show systems as they were when they were last seen.
+
+// Detail_e is how much a race knows about a system.
+type Detail_e int
+
+const (
+	DetailLocated  Detail_e = iota // the position and the star
+	DetailScanned                  // the planets and the owner
+	DetailDetailed                 // the environment of each planet
+	DetailSurveyed                 // the resources of each planet
+)
+
+func (d Detail_e) String() string {
+	switch d {
+	case DetailLocated:
+		return "located"
+	case DetailScanned:
+		return "scanned"
+	case DetailDetailed:
+		return "detailed"
+	case DetailSurveyed:
+		return "surveyed"
+	}
+	return "unknown"
+}
+
+// Knowledge_t is what a race knows about a system.
+type Knowledge_t struct {
+	Detail Detail_e `json:"detail"`
+	Turn   int      `json:"turn"`            // the last turn the system was seen
+	Owner  string   `json:"owner,omitempty"` // the owner when the system was last seen
+}
+
+// Contact_t is a fleet of another race seen by a race's sensors.
+type Contact_t struct {
+	Fleet    string          `json:"fleet"`
+	Race     string          `json:"race"`
+	Ships    int             `json:"ships"`
+	System   string          `json:"system,omitempty"` // empty when the fleet is in transit
+	Position aow.Coordinates `json:"position"`
+}
+
+// SystemView_t is a system as a race knows it.
+type SystemView_t struct {
+	Id          string          `json:"id"`
+	Name        string          `json:"name"`
+	Coordinates aow.Coordinates `json:"coordinates"`
+	Detail      string          `json:"detail"`
+	Turn        int             `json:"turn,omitempty"`  // the last turn the system was seen
+	Owner       string          `json:"owner,omitempty"` // the owner when the system was last seen
+	Planets     []*PlanetView_t `json:"planets,omitempty"`
+}
+
+// PlanetView_t is a planet as a race knows it. Fields the race hasn't
+// seen are nil.
+type PlanetView_t struct {
+	Orbit        int      `json:"orbit"`
+	Kind         string   `json:"kind"`
+	Habitability *int     `json:"habitability,omitempty"`
+	Minerals     *float64 `json:"minerals,omitempty"`
+	Energy       *float64 `json:"energy,omitempty"`
+	Biology      *float64 `json:"biology,omitempty"`
+	Colony       string   `json:"colony,omitempty"` // only if the system was seen this turn
+	Race         string   `json:"race,omitempty"`   // the colony's race
+}
+
+// View returns what the race knows about the system.
+func (g *Game_t) View(race *Race_t, ss *aow.StarSystem_t) *SystemView_t {
+	v := &SystemView_t{Id: ss.Id, Name: ss.Name, Coordinates: ss.Coordinates, Detail: DetailLocated.String()}
+	k, ok := race.Knowledge[ss.Id]
+	if !ok {
+		return v
+	}
+	v.Detail, v.Turn, v.Owner = k.Detail.String(), k.Turn, k.Owner
+	if k.Detail < DetailScanned {
+		return v
+	}
+	for _, p := range ss.Planets {
+		pv := &PlanetView_t{Orbit: p.Orbit, Kind: p.Kind.String()}
+		if k.Detail >= DetailDetailed {
+			habitability := g.Habitability(race, p)
+			pv.Habitability = &habitability
+		}
+		if k.Detail >= DetailSurveyed {
+			minerals, energy, biology := p.Minerals, p.Energy, p.Biology
+			pv.Minerals, pv.Energy, pv.Biology = &minerals, &energy, &biology
+		}
+		if colony := g.ColonyAt(ss.Id, p.Orbit); colony != nil && k.Turn == g.Turn {
+			pv.Colony, pv.Race = colony.Id, colony.Race
+		}
+		v.Planets = append(v.Planets, pv)
+	}
+	return v
+}
+
+// Detail returns how much the race knows about the system.
+func (r *Race_t) Detail(ss *aow.StarSystem_t) Detail_e {
+	if k, ok := r.Knowledge[ss.Id]; ok {
+		return k.Detail
+	}
+	return DetailLocated
+}
+
+// observe records that the race has seen the system at a level of detail.
+func (r *Race_t) observe(ss *aow.StarSystem_t, detail Detail_e, turn int) {
+	if r.Knowledge == nil {
+		r.Knowledge = make(map[string]*Knowledge_t)
+	}
+	k, ok := r.Knowledge[ss.Id]
+	if !ok {
+		k = &Knowledge_t{}
+		r.Knowledge[ss.Id] = k
+	}
+	k.Detail = max(k.Detail, detail)
+	k.Turn, k.Owner = turn, ss.Owner
+}
+
+// sensor_t is a place a race scans from.
+type sensor_t struct {
+	position  aow.Coordinates
+	system    string   // id of the system the sensor is in, if any
+	scanRange float64  // 0 for a fleet without sensors, which sees only where it is
+	detail    Detail_e // what the sensor sees of its own system
+}
+
+// Scan updates what every race knows about the cluster and the fleets of other races.
+// Allies share what their sensors see.
+func (g *Game_t) Scan() {
+	idx := aow.NewSpatialIndex(g.Cluster, DefaultMaximumJump)
+	own := make(map[*Race_t][]sensor_t)
+	for _, race := range g.Races {
+		own[race] = g.sensorsOf(race)
+	}
+	for _, race := range g.Races {
+		sensors := append([]sensor_t{}, own[race]...)
+		for _, ally := range g.Allies(race) {
+			sensors = append(sensors, own[ally]...)
+		}
+
+		for _, s := range sensors {
+			for _, ss := range idx.Within(s.position, s.scanRange) {
+				detail := DetailScanned
+				if s.position.DistanceTo(ss.Coordinates) <= s.scanRange/2 {
+					detail = DetailDetailed
+				}
+				race.observe(ss, detail, g.Turn)
+			}
+			if ss, err := g.Cluster.Lookup(s.system); err == nil {
+				race.observe(ss, s.detail, g.Turn)
+			}
+		}
+
+		race.Contacts = nil
+		for _, fleet := range g.Fleets {
+			if fleet.Race == race.Id {
+				continue
+			}
+			for _, s := range sensors {
+				seen := s.position.DistanceTo(fleet.Position) <= s.scanRange
+				seen = seen || (s.system != "" && s.system == fleet.System)
+				if seen {
+					race.Contacts = append(race.Contacts, &Contact_t{Fleet: fleet.Id, Race: fleet.Race, Ships: len(fleet.Ships), System: fleet.System, Position: fleet.Position})
+					break
+				}
+			}
+		}
+		sort.Slice(race.Contacts, func(i, j int) bool {
+			return race.Contacts[i].Fleet < race.Contacts[j].Fleet
+		})
+	}
+}
+
+// sensorsOf returns the places the race scans from.
+func (g *Game_t) sensorsOf(race *Race_t) []sensor_t {
+	var sensors []sensor_t
+	scanRange := g.ScanRange(race)
+	for _, colony := range g.ColoniesOf(race) {
+		if ss, err := g.Cluster.Lookup(colony.System); err == nil {
+			sensors = append(sensors, sensor_t{position: ss.Coordinates, system: ss.Id, scanRange: scanRange, detail: DetailSurveyed})
+		}
+	}
+	for _, fleet := range g.FleetsOf(race) {
+		s := sensor_t{position: fleet.Position, system: fleet.System, detail: DetailDetailed}
+		if g.HasSensors(fleet) {
+			s.scanRange = scanRange
+		}
+		sensors = append(sensors, s)
+	}
+	return sensors
+}
+
+// surveyPhase lets fleets with sensors survey the systems they are in.
+func (t *turn_t) surveyPhase() {
+	for _, race := range t.g.Races {
+		for _, order := range ordersFor[*SurveyOrder_t](t, race) {
+			t.cause(race, order)
+			if err := t.survey(race, order); err != nil {
+				t.reject(race, order.OrderSource_t, err)
+			}
+		}
+	}
+}
+
+func (t *turn_t) survey(race *Race_t, order *SurveyOrder_t) error {
+	fleet := t.g.Fleet(order.Fleet)
+	if fleet == nil || fleet.Race != race.Id {
+		return fmt.Errorf("%q: %w", order.Fleet, ErrUnknownFleet)
+	} else if fleet.InTransit() {
+		return fmt.Errorf("%s: %w", fleet.Id, ErrInTransit)
+	} else if !t.g.HasSensors(fleet) {
+		return fmt.Errorf("%s: %w", fleet.Id, ErrNoSensors)
+	}
+	ss, err := t.g.Cluster.Lookup(fleet.System)
+	if err != nil {
+		return err
+	}
+	race.observe(ss, DetailSurveyed, t.g.Turn)
+	t.g.logf(race, "%s surveyed %s %s", fleet.Id, ss.Id, ss.Name)
+	return nil
+}
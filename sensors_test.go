@@ -0,0 +1,136 @@
+// Copyright (c) 2024 Michael D Henderson. All rights reserved.
+
+package fargo
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestScan(t *testing.T) {
+	g, err := CreateGame(GameOptions_t{Name: "Test", Seed: "test", NumberOfRaces: 2, SystemsPerRace: 4, Culture: "classical", NameStyle: "syllable"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	race := g.Race("R001")
+	home, err := g.Cluster.Lookup(race.HomeSystem)
+	if err != nil {
+		t.Fatal(err)
+	}
+	scanRange := g.ScanRange(race)
+	g.Scan()
+
+	var seen, unseen int
+	for _, ss := range g.Cluster.StarSystems {
+		want, d := DetailLocated, home.Coordinates.DistanceTo(ss.Coordinates)
+		switch {
+		case ss == home:
+			want = DetailSurveyed
+		case d <= scanRange/2:
+			want = DetailDetailed
+		case d <= scanRange:
+			want = DetailScanned
+		}
+		if got := race.Detail(ss); got != want {
+			t.Errorf("%s: %.1f ly: want %v, got %v", ss.Id, d, want, got)
+		}
+		if want == DetailLocated {
+			unseen++
+			if v := g.View(race, ss); v.Planets != nil {
+				t.Errorf("%s: want no planets for an unseen system", ss.Id)
+			}
+		} else {
+			seen++
+		}
+	}
+	if seen < 2 || unseen == 0 {
+		t.Fatalf("want systems both in and out of range, got %d and %d", seen, unseen)
+	}
+	v := g.View(race, home)
+	if len(v.Planets) != len(home.Planets) || v.Planets[0].Minerals == nil || v.Planets[0].Habitability == nil {
+		t.Errorf("view: want every planet of the home system surveyed, got %+v", v)
+	}
+
+	// what was seen is kept until a better look replaces it
+	race.observe(home, DetailScanned, g.Turn)
+	if race.Detail(home) != DetailSurveyed {
+		t.Errorf("observe: want a poorer look to keep the survey, got %v", race.Detail(home))
+	}
+}
+
+func TestContacts(t *testing.T) {
+	g, err := CreateGame(GameOptions_t{Name: "Test", Seed: "test", NumberOfRaces: 2, SystemsPerRace: 4, Culture: "classical", NameStyle: "syllable"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	red, blue := g.Race("R001"), g.Race("R002")
+	home, err := g.Cluster.Lookup(red.HomeSystem)
+	if err != nil {
+		t.Fatal(err)
+	}
+	away, err := g.Cluster.Lookup(blue.HomeSystem)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if home.Coordinates.DistanceTo(away.Coordinates) <= g.ScanRange(red) {
+		t.Fatalf("want the home systems out of scan range of each other")
+	}
+	g.Fleets = append(g.Fleets,
+		&Fleet_t{Id: "F901", Race: blue.Id, System: home.Id, Position: home.Coordinates},
+		&Fleet_t{Id: "F902", Race: blue.Id, System: away.Id, Position: away.Coordinates})
+
+	g.Scan()
+	if len(red.Contacts) != 1 || red.Contacts[0].Fleet != "F901" || red.Contacts[0].System != home.Id {
+		t.Errorf("contacts: want only the fleet in range, got %v", red.Contacts)
+	}
+
+	// allies share what their sensors see
+	red.Stances = map[string]Status_e{blue.Id: StatusAlliance}
+	blue.Stances = map[string]Status_e{red.Id: StatusAlliance}
+	g.Scan()
+	if len(red.Contacts) != 2 {
+		t.Errorf("allies: want both fleets seen, got %v", red.Contacts)
+	} else if red.Detail(away) != DetailSurveyed {
+		t.Errorf("allies: want the ally's home system surveyed, got %v", red.Detail(away))
+	}
+}
+
+func TestSurvey(t *testing.T) {
+	g, err := CreateGame(GameOptions_t{Name: "Test", Seed: "test", NumberOfRaces: 2, SystemsPerRace: 4, Culture: "classical", NameStyle: "syllable"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	turn := func(text string) []*OrderError_t {
+		o, err := ParseOrders(strings.NewReader(text))
+		if err != nil {
+			t.Fatal(err)
+		}
+		results, err := g.ProcessTurn([]*Orders_t{o})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return results["R001"]
+	}
+	if failed := turn("race R001\ndesign Pod hull 10 drive 1\ndesign Scout hull 10 drive 1 sensors 1\nbuild 1 pod at C001\nbuild 1 scout at C001\n"); len(failed) != 0 {
+		t.Fatalf("build: %v", failed)
+	}
+	race := g.Race("R001")
+	var pod, scout string
+	for _, ship := range g.ShipsOf(race) {
+		if g.DesignOf(ship).Sensors > 0 {
+			scout = ship.Fleet
+		} else {
+			pod = ship.Fleet
+		}
+	}
+	if pod == "" || scout == "" || pod == scout {
+		t.Fatalf("want the pod and the scout in their own fleets, got %q and %q", pod, scout)
+	}
+	if failed := turn("race R001\nsurvey " + pod + "\n"); len(failed) != 1 || !errors.Is(failed[0].Err, ErrNoSensors) {
+		t.Errorf("pod: want %v, got %v", ErrNoSensors, failed)
+	}
+	if failed := turn("race R001\nsurvey " + scout + "\n"); len(failed) != 0 {
+		t.Errorf("scout: %v", failed)
+	}
+}
@@ -0,0 +1,182 @@
+// Copyright (c) 2024 Michael D Henderson. All rights reserved.
+
+package fargo
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// functions to design and build ships.
+//
+// a design is a class of ship. the hull sets the space available and
+// every component takes up space in it. engines take a fifth of the
+// hull, rounded up, and every other component takes one unit of space.
+// the race's technology limits the size of the hull, the level of the
+// engines and the number of weapons, shields and sensors. ships are
+// built from a design at a colony with a shipyard.
+
+const (
+	// MaximumDesignNameLength is the longest name a design can have.
+	MaximumDesignNameLength = 24
+	// MaximumShipsPerBuild is the most ships one build order can make.
+	MaximumShipsPerBuild = 100
+
+	// costs, in credits, of each component of a ship
+	HullCost        = 2.0 // per unit of hull
+	DriveCost       = 0.5 // per unit of hull for each level of drive
+	WeaponCost      = 5.0
+	ShieldCost      = 5.0
+	CargoCost       = 1.0
+	ColonistPodCost = 2.0
+	SensorCost      = 3.0
+
+	// CargoPerHold is the number of units of cargo one hold carries.
+	CargoPerHold = 10.0
+	// ColonistsPerPod is the number of people, in millions, one colonist pod carries.
+	ColonistsPerPod = 1.0
+)
+
+// colonyItems are the things other than ships that can be built at a colony.
+// Designs can't use these names.
+var colonyItems = []string{"defenses", "infrastructure", "shipyard"}
+
+// Design_t is a class of ship.
+type Design_t struct {
+	Name      string `json:"name"`
+	Hull      int    `json:"hull"`      // units of space
+	Drive     int    `json:"drive"`     // level of the engines, 0 for a ship that can't move
+	Weapons   int    `json:"weapons"`   // number of weapons
+	Shields   int    `json:"shields"`   // number of shield generators
+	Cargo     int    `json:"cargo"`     // number of cargo holds
+	Colonists int    `json:"colonists"` // number of colonist pods
+	Sensors   int    `json:"sensors"`   // number of sensor arrays
+}
+
+// Ship_t is a ship built from one of its race's designs.
+type Ship_t struct {
+	Id     string `json:"id"`
+	Race   string `json:"race"`   // id of the race that owns the ship
+	Design string `json:"design"` // name of the design
+	Fleet  string `json:"fleet"`  // id of the fleet the ship belongs to
+
+	Colonists float64 `json:"colonists,omitempty"` // millions of people in the colonist pods
+}
+
+// EngineSpace returns the units of hull taken up by the engines.
+func (d *Design_t) EngineSpace() int {
+	if d.Drive == 0 {
+		return 0
+	}
+	return (d.Hull + 4) / 5
+}
+
+// Space returns the units of hull taken up by all the components.
+func (d *Design_t) Space() int {
+	return d.EngineSpace() + d.Weapons + d.Shields + d.Cargo + d.Colonists + d.Sensors
+}
+
+// Cost returns the cost, in credits, of building one ship of the design.
+func (d *Design_t) Cost() float64 {
+	return float64(d.Hull)*HullCost +
+		float64(d.Hull*d.Drive)*DriveCost +
+		float64(d.Weapons)*WeaponCost +
+		float64(d.Shields)*ShieldCost +
+		float64(d.Cargo)*CargoCost +
+		float64(d.Colonists)*ColonistPodCost +
+		float64(d.Sensors)*SensorCost
+}
+
+// String returns the design in the form used by the design order.
+func (d *Design_t) String() string {
+	return fmt.Sprintf("hull %d drive %d weapons %d shields %d cargo %d colonists %d sensors %d",
+		d.Hull, d.Drive, d.Weapons, d.Shields, d.Cargo, d.Colonists, d.Sensors)
+}
+
+// Design returns the race's design with the name, or nil if there is none.
+// Names are not case-sensitive.
+func (r *Race_t) Design(name string) *Design_t {
+	for _, d := range r.Designs {
+		if strings.EqualFold(d.Name, name) {
+			return d
+		}
+	}
+	return nil
+}
+
+// ValidateDesign checks that the design fits in its hull and can be built with the race's technology.
+func (g *Game_t) ValidateDesign(race *Race_t, d *Design_t) error {
+	if d.Name == "" || len(d.Name) > MaximumDesignNameLength {
+		return fmt.Errorf("%q: %w", d.Name, ErrInvalidName)
+	}
+	for _, item := range colonyItems {
+		if strings.EqualFold(d.Name, item) {
+			return fmt.Errorf("%q: %w", d.Name, ErrInvalidName)
+		}
+	}
+	for _, n := range []int{d.Hull, d.Drive, d.Weapons, d.Shields, d.Cargo, d.Colonists, d.Sensors} {
+		if n < 0 {
+			return fmt.Errorf("%w: negative component", ErrInvalidDesign)
+		}
+	}
+	if d.Hull < 1 {
+		return fmt.Errorf("%w: no hull", ErrInvalidDesign)
+	} else if maxHull := g.mounts(race, TechManufacturing, EffectMaxHull); d.Hull > maxHull {
+		return fmt.Errorf("%w: hull %d is larger than %d", ErrInvalidDesign, d.Hull, maxHull)
+	} else if level := race.TechLevel(TechDrive); d.Drive > level {
+		return fmt.Errorf("%w: drive %d is above drive tech %d", ErrInvalidDesign, d.Drive, level)
+	} else if n := g.mounts(race, TechWeapons, EffectMounts); d.Weapons > n {
+		return fmt.Errorf("%w: %d weapons, weapons tech allows %d", ErrInvalidDesign, d.Weapons, n)
+	} else if n = g.mounts(race, TechShields, EffectMounts); d.Shields > n {
+		return fmt.Errorf("%w: %d shields, shields tech allows %d", ErrInvalidDesign, d.Shields, n)
+	} else if n = g.mounts(race, TechSensors, EffectMounts); d.Sensors > n {
+		return fmt.Errorf("%w: %d sensors, sensors tech allows %d", ErrInvalidDesign, d.Sensors, n)
+	} else if d.Space() > d.Hull {
+		return fmt.Errorf("%w: components need %d units of space, hull has %d", ErrInvalidDesign, d.Space(), d.Hull)
+	}
+	return nil
+}
+
+// mounts returns a limit from the race's technology, rounded down.
+func (g *Game_t) mounts(race *Race_t, field, effect string) int {
+	return int(math.Floor(g.TechEffect(race, field, effect)))
+}
+
+// DesignSpeed returns the distance a ship of the design travels in one turn, in light years.
+func (g *Game_t) DesignSpeed(d *Design_t) float64 {
+	if d.Drive == 0 {
+		return 0
+	}
+	return g.TechTree.Field(TechDrive).Effect(EffectSpeed, d.Drive)
+}
+
+// Ship returns the ship with the id, or nil if there is none.
+// Ids are not case-sensitive.
+func (g *Game_t) Ship(id string) *Ship_t {
+	for _, ship := range g.Ships {
+		if strings.EqualFold(ship.Id, id) {
+			return ship
+		}
+	}
+	return nil
+}
+
+// ShipsOf returns the ships that belong to the race.
+func (g *Game_t) ShipsOf(race *Race_t) []*Ship_t {
+	var list []*Ship_t
+	for _, ship := range g.Ships {
+		if ship.Race == race.Id {
+			list = append(list, ship)
+		}
+	}
+	return list
+}
+
+// DesignOf returns the design a ship was built from.
+func (g *Game_t) DesignOf(ship *Ship_t) *Design_t {
+	if race := g.Race(ship.Race); race != nil {
+		return race.Design(ship.Design)
+	}
+	return nil
+}
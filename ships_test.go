@@ -0,0 +1,100 @@
+// Copyright (c) 2024 Michael D Henderson. All rights reserved.
+
+package fargo
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestDesign(t *testing.T) {
+	d := &Design_t{Name: "Scout", Hull: 11, Drive: 2, Sensors: 1, Cargo: 2}
+	if got := d.EngineSpace(); got != 3 {
+		t.Errorf("engines: want 3 units for an 11 unit hull, got %d", got)
+	} else if got := d.Space(); got != 6 {
+		t.Errorf("space: want 6, got %d", got)
+	} else if got, want := d.Cost(), 11*HullCost+22*DriveCost+SensorCost+2*CargoCost; got != want {
+		t.Errorf("cost: want %g, got %g", want, got)
+	}
+
+	g := &Game_t{TechTree: DefaultTechTree()}
+	race := &Race_t{Id: "R001"}
+	maxHull, weapons := g.mounts(race, TechManufacturing, EffectMaxHull), g.mounts(race, TechWeapons, EffectMounts)
+	for _, tc := range []struct {
+		name string
+		d    Design_t
+		want error
+	}{
+		{"ok", Design_t{Name: "Raider", Hull: 10, Drive: 1, Weapons: 1}, nil},
+		{"no name", Design_t{Hull: 10}, ErrInvalidName},
+		{"long name", Design_t{Name: strings.Repeat("x", MaximumDesignNameLength+1), Hull: 10}, ErrInvalidName},
+		{"colony item", Design_t{Name: "Shipyard", Hull: 10}, ErrInvalidName},
+		{"no hull", Design_t{Name: "Ghost"}, ErrInvalidDesign},
+		{"negative", Design_t{Name: "Odd", Hull: 10, Cargo: -1}, ErrInvalidDesign},
+		{"big hull", Design_t{Name: "Titan", Hull: maxHull + 1}, ErrInvalidDesign},
+		{"fast drive", Design_t{Name: "Dart", Hull: 10, Drive: 2}, ErrInvalidDesign},
+		{"many weapons", Design_t{Name: "Gunboat", Hull: maxHull, Weapons: weapons + 1}, ErrInvalidDesign},
+		{"too full", Design_t{Name: "Barge", Hull: 5, Drive: 1, Cargo: 5}, ErrInvalidDesign},
+	} {
+		if err := g.ValidateDesign(race, &tc.d); !errors.Is(err, tc.want) {
+			t.Errorf("%s: want %v, got %v", tc.name, tc.want, err)
+		}
+	}
+	if g.DesignSpeed(&Design_t{Hull: 10}) != 0 {
+		t.Errorf("speed: want 0 for a ship without engines")
+	} else if g.DesignSpeed(&Design_t{Hull: 10, Drive: 1}) != DriveSpeed(1) {
+		t.Errorf("speed: want %g for drive 1", DriveSpeed(1))
+	}
+}
+
+func TestBuildShips(t *testing.T) {
+	g, err := CreateGame(GameOptions_t{Name: "Test", Seed: "test", NumberOfRaces: 2, SystemsPerRace: 4, Culture: "classical", NameStyle: "syllable"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	run := func(text string) (*Game_t, []*OrderError_t) {
+		clone, err := g.Clone()
+		if err != nil {
+			t.Fatal(err)
+		}
+		o, err := ParseOrders(strings.NewReader(text))
+		if err != nil {
+			t.Fatal(err)
+		}
+		results, err := clone.ProcessTurn([]*Orders_t{o})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return clone, results["R001"]
+	}
+	want, _ := run("race R001\ndesign Pod hull 10 drive 1 colonists 2\n")
+	got, failed := run("race R001\ndesign Pod hull 10 drive 1 colonists 2\nbuild 3 pod at C001\nbuild 101 pod at C001\nbuild 1 frigate at C001\n")
+	if len(failed) != 2 {
+		t.Fatalf("orders: want the oversized build and the unknown design to fail, got %v", failed)
+	}
+
+	race := got.Race("R001")
+	d := race.Design("pod")
+	if d == nil {
+		t.Fatal("design: pod was not added")
+	}
+	ships := got.ShipsOf(race)
+	if len(ships) != 3 {
+		t.Fatalf("ships: want 3, got %d", len(ships))
+	}
+	for _, ship := range ships {
+		if ship.Fleet != ships[0].Fleet || got.DesignOf(ship) != d || ship.Colonists != 2*ColonistsPerPod {
+			t.Errorf("%s: want a loaded pod in fleet %s, got %+v", ship.Id, ships[0].Fleet, ship)
+		}
+	}
+	// the colonists and the cost came from the colony and the treasury; the
+	// smaller population earns and grows a little less, so allow for that
+	if lost := want.Colony("C001").Population - got.Colony("C001").Population; math.Abs(lost-6*ColonistsPerPod) > 0.5 {
+		t.Errorf("population: want about %g fewer people, got %g", 6*ColonistsPerPod, lost)
+	}
+	if spent := want.Race("R001").Credits - race.Credits; spent < 3*d.Cost()-1e-9 || spent > 3*d.Cost()+1 {
+		t.Errorf("credits: want about %g spent, got %g", 3*d.Cost(), spent)
+	}
+}
@@ -0,0 +1,188 @@
+// Copyright (c) 2024 Michael D Henderson. All rights reserved.
+
+package fargo
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// functions to run several games from one site.
+//
+// a site is a directory with the accounts for every game and a games
+// directory that holds a game directory for each game. the name of a
+// game's directory is the game's id.
+//
+//	accounts.json
+//	mail/
+//	games/
+//	  alpha/
+//	    game.json
+//	    settings.json
+//	    schedule.json
+//	    ...
+//
+// the games share the accounts, so one player can join several games,
+// playing a different race in each. the schedule, the orders and the
+// settings belong to the game.
+//
+//	active    turns are processed on schedule
+//	paused    turns are only processed by hand and no reminders are sent
+//	archived  the game is over; orders are no longer accepted
+
+const (
+	GamesDir     = "games"
+	SettingsFile = "settings.json"
+)
+
+// State_e is whether a game is being played.
+type State_e int
+
+const (
+	StateActive State_e = iota
+	StatePaused
+	StateArchived
+)
+
+func (s State_e) String() string {
+	switch s {
+	case StateActive:
+		return "active"
+	case StatePaused:
+		return "paused"
+	case StateArchived:
+		return "archived"
+	}
+	return "unknown"
+}
+
+func (s State_e) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+func (s *State_e) UnmarshalText(text []byte) (err error) {
+	*s, err = ParseState(string(text))
+	return err
+}
+
+// ParseState returns the state with the name.
+func ParseState(name string) (State_e, error) {
+	for s := StateActive; s <= StateArchived; s++ {
+		if strings.EqualFold(name, s.String()) {
+			return s, nil
+		}
+	}
+	return StateActive, fmt.Errorf("%q: %w", name, ErrUnknownState)
+}
+
+// Settings_t is how the site runs a game.
+type Settings_t struct {
+	State   State_e    `json:"state"`
+	Created *time.Time `json:"created,omitempty"`
+	From    string     `json:"from,omitempty"`    // address the game's mail is sent from, if not the site's
+	Storage string     `json:"storage,omitempty"` // kind of store the game is kept in, files if empty
+}
+
+// LoadSettings reads the settings in the game directory.
+// A game without a settings file is active.
+func LoadSettings(path string) (*Settings_t, error) {
+	data, err := os.ReadFile(filepath.Join(path, SettingsFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return &Settings_t{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var s Settings_t
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// Save writes the settings to the game directory.
+func (s *Settings_t) Save(path string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	name := filepath.Join(path, SettingsFile)
+	if err := os.WriteFile(name+".tmp", data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(name+".tmp", name)
+}
+
+// GamePath returns the directory for the game in the site.
+func GamePath(site, id string) string {
+	return filepath.Join(site, GamesDir, id)
+}
+
+// ValidGameId returns true if the id can name a game directory.
+// Ids are lowercase letters, digits and dashes.
+func ValidGameId(id string) bool {
+	if id == "" || len(id) > 32 || id[0] == '-' {
+		return false
+	}
+	for _, ch := range id {
+		if !(('a' <= ch && ch <= 'z') || ('0' <= ch && ch <= '9') || ch == '-') {
+			return false
+		}
+	}
+	return true
+}
+
+// ListGames returns the ids of the games in the site, sorted.
+func ListGames(site string) ([]string, error) {
+	entries, err := os.ReadDir(filepath.Join(site, GamesDir))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var ids []string
+	for _, entry := range entries {
+		if !entry.IsDir() || !ValidGameId(entry.Name()) {
+			continue
+		}
+		for _, name := range []string{GameFile, BoltFile} {
+			if _, err := os.Stat(filepath.Join(site, GamesDir, entry.Name(), name)); err == nil {
+				ids = append(ids, entry.Name())
+				break
+			}
+		}
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+// AccountsPath returns the directory that holds the accounts for the game
+// in the directory. That is the site's directory if the game is in a site.
+func AccountsPath(path string) string {
+	if site, _, ok := siteOf(path); ok {
+		return site
+	}
+	return path
+}
+
+// SiteGameId returns the id of the game in the directory, if the game is
+// in a site. A game in a site is named by its directory, whatever id it
+// was saved with, so that a game copied into a site keeps working.
+func SiteGameId(path string) (string, bool) {
+	_, id, ok := siteOf(path)
+	return id, ok
+}
+
+// siteOf returns the site and the id of the game in the directory, if the game is in a site.
+func siteOf(path string) (site, id string, ok bool) {
+	abs, err := filepath.Abs(path)
+	if err != nil || filepath.Base(filepath.Dir(abs)) != GamesDir || !ValidGameId(filepath.Base(abs)) {
+		return "", "", false
+	}
+	return filepath.Dir(filepath.Dir(abs)), filepath.Base(abs), true
+}
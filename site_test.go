@@ -0,0 +1,111 @@
+// Copyright (c) 2024 Michael D Henderson. All rights reserved.
+
+package fargo
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSiteGameId(t *testing.T) {
+	site := t.TempDir()
+	path := GamePath(site, "beta")
+	g, err := CreateGame(GameOptions_t{Name: "Test", Seed: "test", NumberOfRaces: 2, SystemsPerRace: 4, Culture: "classical", NameStyle: "syllable"})
+	if err != nil {
+		t.Fatal(err)
+	} else if err := g.SaveTurn(path); err != nil {
+		t.Fatal(err)
+	}
+
+	// the game was saved with the default id, but the site names it by its directory
+	loaded, err := LoadGame(path)
+	if err != nil {
+		t.Fatal(err)
+	} else if loaded.Id != "beta" {
+		t.Errorf("id: want %q, got %q", "beta", loaded.Id)
+	}
+	if got := AccountsPath(path); got != site {
+		t.Errorf("accounts: want %q, got %q", site, got)
+	}
+
+	// a game on its own keeps its id and its accounts
+	alone := filepath.Join(t.TempDir(), "beta")
+	if err := g.SaveTurn(alone); err != nil {
+		t.Fatal(err)
+	} else if loaded, err := LoadGame(alone); err != nil {
+		t.Fatal(err)
+	} else if loaded.Id != g.Id {
+		t.Errorf("id: want %q, got %q", g.Id, loaded.Id)
+	}
+	if got := AccountsPath(alone); got != alone {
+		t.Errorf("accounts: want %q, got %q", alone, got)
+	}
+}
+
+func TestValidGameId(t *testing.T) {
+	for id, want := range map[string]bool{
+		"alpha": true, "game-2": true, "7": true, strings.Repeat("x", 32): true,
+		"": false, "-alpha": false, "Alpha": false, "a_b": false, "a.b": false, "..": false, "a/b": false, strings.Repeat("x", 33): false,
+	} {
+		if got := ValidGameId(id); got != want {
+			t.Errorf("%q: want %v, got %v", id, want, got)
+		}
+	}
+}
+
+func TestListGames(t *testing.T) {
+	site := t.TempDir()
+	if ids, err := ListGames(site); err != nil || ids != nil {
+		t.Fatalf("empty site: want no games, got %v, %v", ids, err)
+	}
+	g, err := CreateGame(GameOptions_t{Name: "Test", Seed: "test", NumberOfRaces: 2, SystemsPerRace: 4, Culture: "classical", NameStyle: "syllable"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []string{"gamma", "alpha"} {
+		if err := g.SaveTurn(GamePath(site, id)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// directories that aren't games are skipped
+	for _, dir := range []string{"empty", "Bad_Name"} {
+		if err := os.MkdirAll(GamePath(site, dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(GamePath(site, "Bad_Name"), GameFile), []byte("{}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	ids, err := ListGames(site)
+	if err != nil {
+		t.Fatal(err)
+	} else if strings.Join(ids, ",") != "alpha,gamma" {
+		t.Errorf("games: want alpha,gamma, got %v", ids)
+	}
+}
+
+func TestSettings(t *testing.T) {
+	path := t.TempDir()
+	if s, err := LoadSettings(path); err != nil || s.State != StateActive {
+		t.Fatalf("missing: want an active game, got %+v, %v", s, err)
+	}
+	s := &Settings_t{State: StateArchived, From: "gm@example.com"}
+	if err := s.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(path, SettingsFile))
+	if err != nil {
+		t.Fatal(err)
+	} else if !strings.Contains(string(data), `"state": "archived"`) {
+		t.Errorf("save: want the state saved by name, got\n%s", data)
+	}
+	if got, err := LoadSettings(path); err != nil || *got != *s {
+		t.Errorf("load: want %+v, got %+v, %v", s, got, err)
+	}
+	if _, err := ParseState("finished"); !errors.Is(err, ErrUnknownState) {
+		t.Errorf("state: want %v, got %v", ErrUnknownState, err)
+	}
+}
@@ -0,0 +1,416 @@
+// Copyright (c) 2024 Michael D Henderson. All rights reserved.
+
+package fargo
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/playbymail/fargo/internal/aow"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+// functions to keep the state of a game and its history.
+//
+// the engine reads and writes a game through a Store. the game's settings
+// choose the kind of store:
+//
+//	files  the game directory, with a directory for each turn (the default)
+//	bolt   a bbolt database, game.db, in the game directory
+//
+// either way, the orders for the current turn are submitted as files in
+// the turn's orders directory, since that is where players, the mail
+// ingester and the server put them. they are copied into the store when
+// the turn is processed.
+//
+// a turn is committed in one step: the state at the start of the new
+// turn, the reports for it and the orders for the turn that was processed.
+// the bolt store does this in one transaction. the file store writes the
+// turn's directory first and the current state last, so a crash leaves
+// the game at the old turn.
+//
+// every turn's state is kept, so the history can be queried: where was a
+// fleet on turn 12, how did a colony grow. a game can be moved from one
+// kind of store to the other with MigrateStore.
+//
+// the store keeps the audit log too (see audit.go). events are only ever
+// appended. rolling a game back to an earlier turn makes that turn's state
+// current and drops the state and reports of the turns after it; the
+// orders players submitted for those turns are left in their directories.
+
+const (
+	StorageFiles = "files"
+	StorageBolt  = "bolt"
+)
+
+// Store keeps the state and history of a game.
+type Store interface {
+	// Game returns the current state of the game.
+	Game() (*Game_t, error)
+	// Turn returns the state of the game at the start of the turn.
+	Turn(turn int) (*Game_t, error)
+	// TurnState returns the state of the game at the start of the turn
+	// without the cluster and the tech tree. It is quicker than Turn for
+	// queries about races, colonies and fleets.
+	TurnState(turn int) (*Game_t, error)
+	// Turns returns the turns that have been saved, in order.
+	Turns() ([]int, error)
+	// Report returns the race's report for the turn.
+	Report(turn int, race string) ([]byte, error)
+	// Orders returns the race's orders for a turn that has been processed.
+	Orders(turn int, race string) ([]byte, error)
+	// Events returns the audit log for the turn, or the whole log if the
+	// turn is 0, in the order the events were appended.
+	Events(turn int) ([]*Event_t, error)
+	// Commit saves a turn.
+	Commit(c *Commit_t) error
+	// Rollback makes the state at the start of the turn current, drops the
+	// turns after it and appends the events to the audit log.
+	Rollback(turn int, events []*Event_t) error
+	Close() error
+}
+
+// Commit_t is everything that is saved when a turn starts.
+type Commit_t struct {
+	Game    *Game_t           // the state at the start of the turn
+	Reports map[string][]byte // the reports for the turn, by race id
+	Orders  map[string][]byte // the orders for the turn before it, by race id
+	Events  []*Event_t        // appended to the audit log
+}
+
+// OpenStore opens the store for the game in the directory. A store
+// opened read-only doesn't keep others from reading the game.
+func OpenStore(path string, readOnly bool) (Store, error) {
+	settings, err := LoadSettings(path)
+	if err != nil {
+		return nil, err
+	}
+	return openStore(path, settings.Storage, readOnly)
+}
+
+func openStore(path, kind string, readOnly bool) (Store, error) {
+	switch kind {
+	case "", StorageFiles:
+		return NewFileStore(path), nil
+	case StorageBolt:
+		return OpenBoltStore(path, readOnly)
+	}
+	return nil, fmt.Errorf("storage: %q: %w", kind, ErrInvalidArguments)
+}
+
+// ReadReport returns the race's report for the turn from the game's store.
+func ReadReport(path string, turn int, race string) ([]byte, error) {
+	s, err := OpenStore(path, true)
+	if err != nil {
+		return nil, err
+	}
+	defer s.Close()
+	return s.Report(turn, race)
+}
+
+// MigrateStore copies the state and history of the game in the directory
+// to a new store of the kind and switches the game's settings to it. The
+// turn lock is held while the game is copied. The old store is left in
+// place so that it can be removed by hand once the new one is checked.
+// A store of the kind left behind by an earlier migration is moved aside
+// first, since its state and audit log are out of date.
+func MigrateStore(path, kind string) error {
+	settings, err := LoadSettings(path)
+	if err != nil {
+		return err
+	} else if kind == settings.Storage || (kind == StorageFiles && settings.Storage == "") {
+		return fmt.Errorf("storage: game already uses %s: %w", kind, ErrInvalidArguments)
+	} else if kind != StorageFiles && kind != StorageBolt {
+		return fmt.Errorf("storage: %q: %w", kind, ErrInvalidArguments)
+	}
+	unlock, err := LockTurn(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if err := setStoreAside(path, kind); err != nil {
+		return err
+	}
+
+	from, err := openStore(path, settings.Storage, true)
+	if err != nil {
+		return err
+	}
+	defer from.Close()
+	to, err := openStore(path, kind, false)
+	if err != nil {
+		return err
+	}
+	defer to.Close()
+
+	current, err := from.Game()
+	if err != nil {
+		return err
+	}
+	turns, err := from.Turns()
+	if err != nil {
+		return err
+	}
+	// the audit log is copied with the first commit
+	events, err := from.Events(0)
+	if err != nil {
+		return err
+	}
+	for _, turn := range turns {
+		// the current state may have a tech tree the GM changed since the turn started
+		g := current
+		if turn != current.Turn {
+			if g, err = from.Turn(turn); err != nil {
+				return err
+			}
+		}
+		c := &Commit_t{Game: g, Reports: make(map[string][]byte), Orders: make(map[string][]byte), Events: events}
+		events = nil
+		for _, race := range g.Races {
+			if data, err := from.Report(turn, race.Id); err == nil {
+				c.Reports[race.Id] = data
+			} else if !errors.Is(err, os.ErrNotExist) {
+				return err
+			}
+			if data, err := from.Orders(turn-1, race.Id); err == nil {
+				c.Orders[race.Id] = data
+			} else if !errors.Is(err, os.ErrNotExist) {
+				return err
+			}
+		}
+		if err := to.Commit(c); err != nil {
+			return err
+		}
+	}
+	if len(turns) == 0 || turns[len(turns)-1] != current.Turn {
+		if err := to.Commit(&Commit_t{Game: current, Events: events}); err != nil {
+			return err
+		}
+	}
+	if err := to.Close(); err != nil {
+		return err
+	}
+	settings.Storage = kind
+	if err := settings.Save(path); err != nil {
+		return err
+	}
+	log.Printf("storage: migrate: copied %d turns to %s\n", len(turns), kind)
+	return nil
+}
+
+// setStoreAside moves an old store of the kind out of the way, so that a
+// migration starts from an empty store. A database is renamed to game.db.old.
+// The file store's state, reports and audit log are moved to a files.old
+// directory; the orders directories are shared by both stores and stay.
+func setStoreAside(path, kind string) error {
+	if kind == StorageBolt {
+		name := filepath.Join(path, BoltFile)
+		if _, err := os.Stat(name); errors.Is(err, os.ErrNotExist) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		aside, err := unusedName(name + ".old")
+		if err != nil {
+			return err
+		} else if err := os.Rename(name, aside); err != nil {
+			return err
+		}
+		log.Printf("storage: migrate: moved %s to %s\n", name, aside)
+		return nil
+	}
+	names := []string{GameFile, ClusterFile, TechFile, EventsFile}
+	turns, err := NewFileStore(path).Turns()
+	if err != nil {
+		return err
+	}
+	for _, turn := range turns {
+		for _, name := range []string{GameFile, ClusterFile, TechFile, "reports"} {
+			rel, err := filepath.Rel(path, filepath.Join(TurnPath(path, turn), name))
+			if err != nil {
+				return err
+			}
+			names = append(names, rel)
+		}
+	}
+	aside := ""
+	for _, name := range names {
+		if _, err := os.Stat(filepath.Join(path, name)); errors.Is(err, os.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return err
+		}
+		if aside == "" {
+			if aside, err = unusedName(filepath.Join(path, "files.old")); err != nil {
+				return err
+			}
+		}
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(aside, name)), 0755); err != nil {
+			return err
+		} else if err := os.Rename(filepath.Join(path, name), filepath.Join(aside, name)); err != nil {
+			return err
+		}
+		log.Printf("storage: migrate: moved %s to %s\n", name, aside)
+	}
+	return nil
+}
+
+// unusedName returns the name, or the name with the first number that
+// makes it unused, like name.1.
+func unusedName(name string) (string, error) {
+	for n := 0; ; n++ {
+		try := name
+		if n != 0 {
+			try = fmt.Sprintf("%s.%d", name, n)
+		}
+		if _, err := os.Stat(try); errors.Is(err, os.ErrNotExist) {
+			return try, nil
+		} else if err != nil {
+			return "", err
+		}
+	}
+}
+
+// FleetAt returns the fleet as it was at the start of the turn.
+func FleetAt(s Store, id string, turn int) (*Fleet_t, error) {
+	g, err := s.TurnState(turn)
+	if err != nil {
+		return nil, err
+	} else if fleet := g.Fleet(id); fleet != nil {
+		return fleet, nil
+	}
+	return nil, fmt.Errorf("turn %d: %q: %w", turn, id, ErrUnknownFleet)
+}
+
+// ColonyAt returns the colony as it was at the start of the turn.
+func ColonyAt(s Store, id string, turn int) (*Colony_t, error) {
+	g, err := s.TurnState(turn)
+	if err != nil {
+		return nil, err
+	} else if colony := g.Colony(id); colony != nil {
+		return colony, nil
+	}
+	return nil, fmt.Errorf("turn %d: %q: %w", turn, id, ErrUnknownColony)
+}
+
+// History calls fn with the state at the start of every saved turn, in
+// order, until fn returns an error.
+func History(s Store, fn func(g *Game_t) error) error {
+	turns, err := s.Turns()
+	if err != nil {
+		return err
+	}
+	for _, turn := range turns {
+		g, err := s.TurnState(turn)
+		if err != nil {
+			return err
+		} else if err := fn(g); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// documents returns the game as the documents it is saved in, by file name.
+// The cluster is saved with the turn, so that a reader can tell that it
+// goes with the game.
+func (g *Game_t) documents() (map[string][]byte, error) {
+	game, err := json.MarshalIndent(g, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	cluster, err := json.MarshalIndent(struct {
+		Turn int `json:"turn"`
+		*aow.Catalog_t
+	}{g.Turn, g.Cluster}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	tree, err := json.MarshalIndent(g.TechTree, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return map[string][]byte{GameFile: game, ClusterFile: cluster, TechFile: tree}, nil
+}
+
+// decodeGame reads a game from the documents it was saved in. The cluster
+// is only read if it is given. Games saved before the tech tree was added
+// use the default tree.
+func decodeGame(docs map[string][]byte) (*Game_t, error) {
+	var g Game_t
+	if err := json.Unmarshal(docs[GameFile], &g); err != nil {
+		return nil, err
+	}
+	if g.NextId == nil {
+		g.NextId = make(map[string]int)
+	}
+	if data, ok := docs[ClusterFile]; ok {
+		cluster, err := aow.ParseCatalog(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", ClusterFile, err)
+		}
+		g.Cluster = cluster
+	}
+	if data, ok := docs[TechFile]; ok {
+		tree, err := parseTechTree(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", TechFile, err)
+		}
+		g.TechTree = tree
+	} else {
+		g.TechTree = DefaultTechTree()
+	}
+	return &g, nil
+}
+
+// decodeEvents reads the events for the turn, or every event if the turn
+// is 0, from a list of JSON documents.
+func decodeEvents(data []byte, turn int) ([]*Event_t, error) {
+	var events []*Event_t
+	d := json.NewDecoder(bytes.NewReader(data))
+	for d.More() {
+		var e Event_t
+		if err := d.Decode(&e); err != nil {
+			return nil, err
+		} else if turn == 0 || e.Turn == turn {
+			events = append(events, &e)
+		}
+	}
+	return events, nil
+}
+
+// documentTurn returns the turn a document was saved for, or 0 if the
+// document doesn't say.
+func documentTurn(data []byte) (int, error) {
+	var doc struct {
+		Turn int `json:"turn"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return 0, err
+	}
+	return doc.Turn, nil
+}
+
+// writeFile replaces the file, so that a reader never sees half of it.
+// The data is synced before the rename, so that a crash can't leave an
+// empty file in place of the old one.
+func writeFile(name string, data []byte, perm os.FileMode) error {
+	fd, err := os.OpenFile(name+".tmp", os.O_CREATE|os.O_TRUNC|os.O_WRONLY, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := fd.Write(data); err != nil {
+		_ = fd.Close()
+		return err
+	} else if err := fd.Sync(); err != nil {
+		_ = fd.Close()
+		return err
+	} else if err := fd.Close(); err != nil {
+		return err
+	}
+	return os.Rename(name+".tmp", name)
+}
@@ -0,0 +1,334 @@
+// Copyright (c) 2024 Michael D Henderson. All rights reserved.
+
+package fargo
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"go.etcd.io/bbolt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// BoltStore_t keeps a game in a bbolt database in the game directory.
+//
+// the database has a bucket for the current state and a bucket for each
+// turn, keyed by the turn number, that holds the state at the start of
+// the turn and buckets for its orders and reports. the state is kept as
+// the same JSON documents the file store writes.
+//
+//	current/game.json
+//	current/cluster.json
+//	current/techtree.json
+//	turns/0001/game.json
+//	turns/0001/orders/R001
+//	turns/0001/reports/R001
+//	events/0000000000000001
+//
+// the audit log is a bucket of events keyed by a sequence number.
+//
+// the tech tree is kept in the database too, so a GM who wants to change
+// it must move the game back to the file store, change it and move the
+// game to the database again.
+type BoltStore_t struct {
+	db *bbolt.DB
+}
+
+const BoltFile = "game.db"
+
+var (
+	boltCurrent = []byte("current")
+	boltTurns   = []byte("turns")
+	boltOrders  = []byte("orders")
+	boltReports = []byte("reports")
+	boltEvents  = []byte("events")
+)
+
+// OpenBoltStore opens the database in the game directory, creating it if
+// it doesn't exist and the store isn't read-only. It waits a few seconds
+// for another process that has the database open for writing.
+func OpenBoltStore(path string, readOnly bool) (*BoltStore_t, error) {
+	name := filepath.Join(path, BoltFile)
+	if readOnly {
+		// bbolt would create the file
+		if _, err := os.Stat(name); err != nil {
+			return nil, err
+		}
+	}
+	db, err := bbolt.Open(name, 0644, &bbolt.Options{Timeout: 5 * time.Second, ReadOnly: readOnly})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return &BoltStore_t{db: db}, nil
+}
+
+func (s *BoltStore_t) Game() (*Game_t, error) {
+	return s.read(func(tx *bbolt.Tx) *bbolt.Bucket {
+		return tx.Bucket(boltCurrent)
+	}, GameFile, ClusterFile, TechFile)
+}
+
+func (s *BoltStore_t) Turn(turn int) (*Game_t, error) {
+	g, err := s.read(func(tx *bbolt.Tx) *bbolt.Bucket {
+		return turnBucket(tx, turn)
+	}, GameFile, ClusterFile, TechFile)
+	if err != nil {
+		return nil, fmt.Errorf("turn %d: %w", turn, err)
+	}
+	return g, nil
+}
+
+func (s *BoltStore_t) TurnState(turn int) (*Game_t, error) {
+	g, err := s.read(func(tx *bbolt.Tx) *bbolt.Bucket {
+		return turnBucket(tx, turn)
+	}, GameFile)
+	if err != nil {
+		return nil, fmt.Errorf("turn %d: %w", turn, err)
+	}
+	return g, nil
+}
+
+// read loads the documents from the bucket. The tech tree is optional.
+func (s *BoltStore_t) read(bucket func(tx *bbolt.Tx) *bbolt.Bucket, names ...string) (*Game_t, error) {
+	docs := make(map[string][]byte)
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		b := bucket(tx)
+		if b == nil {
+			return os.ErrNotExist
+		}
+		for _, name := range names {
+			data := b.Get([]byte(name))
+			if data == nil && name == TechFile {
+				continue
+			} else if data == nil {
+				return fmt.Errorf("%s: %w", name, os.ErrNotExist)
+			}
+			// the data is only good for the life of the transaction
+			docs[name] = append([]byte(nil), data...)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return decodeGame(docs)
+}
+
+func (s *BoltStore_t) Turns() ([]int, error) {
+	var turns []int
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(boltTurns)
+		if b == nil {
+			return nil
+		}
+		// keys are zero padded, so they are in order
+		return b.ForEach(func(k, v []byte) error {
+			if turn, err := strconv.Atoi(string(k)); err == nil && v == nil && b.Bucket(k).Get([]byte(GameFile)) != nil {
+				turns = append(turns, turn)
+			}
+			return nil
+		})
+	})
+	return turns, err
+}
+
+func (s *BoltStore_t) Report(turn int, race string) ([]byte, error) {
+	return s.text(turn, boltReports, race)
+}
+
+func (s *BoltStore_t) Orders(turn int, race string) ([]byte, error) {
+	return s.text(turn, boltOrders, race)
+}
+
+func (s *BoltStore_t) text(turn int, kind []byte, race string) ([]byte, error) {
+	var text []byte
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		if b := turnBucket(tx, turn); b != nil {
+			if b = b.Bucket(kind); b != nil {
+				text = append([]byte(nil), b.Get([]byte(race))...)
+			}
+		}
+		if text == nil {
+			return fmt.Errorf("turn %d: %s: %s: %w", turn, kind, race, os.ErrNotExist)
+		}
+		return nil
+	})
+	return text, err
+}
+
+func (s *BoltStore_t) Events(turn int) ([]*Event_t, error) {
+	var events []*Event_t
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(boltEvents)
+		if b == nil {
+			return nil
+		}
+		return b.ForEach(func(k, v []byte) error {
+			var e Event_t
+			if err := json.Unmarshal(v, &e); err != nil {
+				return err
+			} else if turn == 0 || e.Turn == turn {
+				events = append(events, &e)
+			}
+			return nil
+		})
+	})
+	return events, err
+}
+
+// appendEvents adds the events to the end of the audit log.
+func appendEvents(tx *bbolt.Tx, events []*Event_t) error {
+	if len(events) == 0 {
+		return nil
+	}
+	b, err := tx.CreateBucketIfNotExists(boltEvents)
+	if err != nil {
+		return err
+	}
+	for _, e := range events {
+		data, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		seq, err := b.NextSequence()
+		if err != nil {
+			return err
+		}
+		key := make([]byte, 8)
+		binary.BigEndian.PutUint64(key, seq)
+		if err := b.Put(key, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Commit saves the turn and makes it the current state in one transaction.
+// A turn that was saved before is replaced.
+func (s *BoltStore_t) Commit(c *Commit_t) error {
+	docs, err := c.Game.documents()
+	if err != nil {
+		return err
+	}
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		turns, err := tx.CreateBucketIfNotExists(boltTurns)
+		if err != nil {
+			return err
+		}
+		key := turnKey(c.Game.Turn)
+		if turns.Bucket(key) != nil {
+			if err := turns.DeleteBucket(key); err != nil {
+				return err
+			}
+		}
+		turn, err := turns.CreateBucket(key)
+		if err != nil {
+			return err
+		}
+		if err := putAll(turn, docs); err != nil {
+			return err
+		}
+		reports, err := turn.CreateBucket(boltReports)
+		if err != nil {
+			return err
+		}
+		if err := putAll(reports, c.Reports); err != nil {
+			return err
+		}
+		if len(c.Orders) != 0 {
+			previous, err := turns.CreateBucketIfNotExists(turnKey(c.Game.Turn - 1))
+			if err != nil {
+				return err
+			}
+			orders, err := previous.CreateBucketIfNotExists(boltOrders)
+			if err != nil {
+				return err
+			}
+			if err := putAll(orders, c.Orders); err != nil {
+				return err
+			}
+		}
+		if err := appendEvents(tx, c.Events); err != nil {
+			return err
+		}
+		current, err := tx.CreateBucketIfNotExists(boltCurrent)
+		if err != nil {
+			return err
+		}
+		return putAll(current, docs)
+	})
+}
+
+// Rollback copies the turn's state to the current state and drops the
+// later turns and the orders for the turn in one transaction. The orders
+// are saved again when the turn is processed.
+func (s *BoltStore_t) Rollback(turn int, events []*Event_t) error {
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		b := turnBucket(tx, turn)
+		if b == nil || b.Get([]byte(GameFile)) == nil {
+			return fmt.Errorf("turn %d: %w", turn, os.ErrNotExist)
+		}
+		docs := make(map[string][]byte)
+		for _, name := range []string{GameFile, ClusterFile, TechFile} {
+			if data := b.Get([]byte(name)); data != nil {
+				docs[name] = append([]byte(nil), data...)
+			}
+		}
+		if b.Bucket(boltOrders) != nil {
+			if err := b.DeleteBucket(boltOrders); err != nil {
+				return err
+			}
+		}
+		turns := tx.Bucket(boltTurns)
+		var later [][]byte
+		key := turnKey(turn)
+		c := turns.Cursor()
+		for k, _ := c.Seek(key); k != nil; k, _ = c.Next() {
+			if !bytes.Equal(k, key) {
+				later = append(later, append([]byte(nil), k...))
+			}
+		}
+		for _, k := range later {
+			if err := turns.DeleteBucket(k); err != nil {
+				return err
+			}
+		}
+		if err := appendEvents(tx, events); err != nil {
+			return err
+		}
+		current, err := tx.CreateBucketIfNotExists(boltCurrent)
+		if err != nil {
+			return err
+		}
+		return putAll(current, docs)
+	})
+}
+
+func (s *BoltStore_t) Close() error {
+	return s.db.Close()
+}
+
+func turnKey(turn int) []byte {
+	return []byte(fmt.Sprintf("%04d", turn))
+}
+
+// turnBucket returns the bucket for the turn, or nil if it hasn't been saved.
+func turnBucket(tx *bbolt.Tx, turn int) *bbolt.Bucket {
+	if b := tx.Bucket(boltTurns); b != nil {
+		return b.Bucket(turnKey(turn))
+	}
+	return nil
+}
+
+func putAll(b *bbolt.Bucket, values map[string][]byte) error {
+	for k, v := range values {
+		if err := b.Put([]byte(k), v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,248 @@
+// Copyright (c) 2024 Michael D Henderson. All rights reserved.
+
+package fargo
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+)
+
+// FileStore_t keeps a game in its directory, as JSON files for the
+// current state and a directory for each turn. The audit log is kept
+// in events.jsonl, one event to a line.
+type FileStore_t struct {
+	path string
+}
+
+const EventsFile = "events.jsonl"
+
+// NewFileStore returns the store for the game in the directory.
+func NewFileStore(path string) *FileStore_t {
+	return &FileStore_t{path: path}
+}
+
+// Game reads the current state. The current state is three files that
+// can't be replaced at once, so a crash while they are written can leave
+// a cluster for the next turn next to the game for this one. The turn's
+// directory is written before the current state, so in that case the
+// cluster and tech tree are read from there.
+func (s *FileStore_t) Game() (*Game_t, error) {
+	docs, err := s.readDocuments(s.path, GameFile, ClusterFile, TechFile)
+	if err != nil {
+		return nil, err
+	}
+	turn, err := documentTurn(docs[GameFile])
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", GameFile, err)
+	}
+	if clusterTurn, err := documentTurn(docs[ClusterFile]); err != nil {
+		return nil, fmt.Errorf("%s: %w", ClusterFile, err)
+	} else if clusterTurn != 0 && clusterTurn != turn {
+		log.Printf("storage: %s is for turn %d, not %d: reading the turn's copy\n", ClusterFile, clusterTurn, turn)
+		saved, err := s.readDocuments(TurnPath(s.path, turn), ClusterFile, TechFile)
+		if err != nil {
+			return nil, err
+		}
+		docs[ClusterFile], docs[TechFile] = saved[ClusterFile], saved[TechFile]
+		if docs[TechFile] == nil {
+			delete(docs, TechFile)
+		}
+	}
+	return decodeGame(docs)
+}
+
+func (s *FileStore_t) Turn(turn int) (*Game_t, error) {
+	return s.read(TurnPath(s.path, turn), GameFile, ClusterFile, TechFile)
+}
+
+func (s *FileStore_t) TurnState(turn int) (*Game_t, error) {
+	return s.read(TurnPath(s.path, turn), GameFile)
+}
+
+// read loads the documents in the directory. The tech tree is optional.
+func (s *FileStore_t) read(dir string, names ...string) (*Game_t, error) {
+	docs, err := s.readDocuments(dir, names...)
+	if err != nil {
+		return nil, err
+	}
+	return decodeGame(docs)
+}
+
+// readDocuments returns the documents in the directory, by name.
+// The tech tree is optional.
+func (s *FileStore_t) readDocuments(dir string, names ...string) (map[string][]byte, error) {
+	docs := make(map[string][]byte)
+	for _, name := range names {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if name == TechFile && errors.Is(err, os.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		docs[name] = data
+	}
+	return docs, nil
+}
+
+func (s *FileStore_t) Turns() ([]int, error) {
+	entries, err := os.ReadDir(filepath.Dir(TurnPath(s.path, 1)))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var turns []int
+	for _, entry := range entries {
+		turn, err := strconv.Atoi(entry.Name())
+		if err != nil || !entry.IsDir() {
+			continue
+		} else if _, err := os.Stat(filepath.Join(TurnPath(s.path, turn), GameFile)); err == nil {
+			turns = append(turns, turn)
+		}
+	}
+	sort.Ints(turns)
+	return turns, nil
+}
+
+func (s *FileStore_t) Report(turn int, race string) ([]byte, error) {
+	return os.ReadFile(ReportPath(s.path, turn, race))
+}
+
+func (s *FileStore_t) Orders(turn int, race string) ([]byte, error) {
+	return os.ReadFile(OrdersPath(s.path, turn, race))
+}
+
+func (s *FileStore_t) Events(turn int) ([]*Event_t, error) {
+	data, err := os.ReadFile(filepath.Join(s.path, EventsFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return decodeEvents(data, turn)
+}
+
+// appendEvents adds the events to the end of the audit log.
+func (s *FileStore_t) appendEvents(events []*Event_t) error {
+	if len(events) == 0 {
+		return nil
+	}
+	var buf bytes.Buffer
+	for _, e := range events {
+		data, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		buf.Write(data)
+		buf.WriteByte('\n')
+	}
+	fd, err := os.OpenFile(filepath.Join(s.path, EventsFile), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := fd.Write(buf.Bytes()); err != nil {
+		_ = fd.Close()
+		return err
+	} else if err := fd.Sync(); err != nil {
+		_ = fd.Close()
+		return err
+	}
+	return fd.Close()
+}
+
+// Commit writes the turn's directory and the audit log, then the current
+// state. Orders that are already in the turn's orders directory are left alone.
+func (s *FileStore_t) Commit(c *Commit_t) error {
+	docs, err := c.Game.documents()
+	if err != nil {
+		return err
+	}
+	turnPath := TurnPath(s.path, c.Game.Turn)
+	if err := os.MkdirAll(filepath.Join(turnPath, "reports"), 0755); err != nil {
+		return err
+	}
+	for name, data := range docs {
+		if err := writeFile(filepath.Join(turnPath, name), data, 0644); err != nil {
+			return err
+		}
+	}
+	for race, data := range c.Reports {
+		if err := writeFile(ReportPath(s.path, c.Game.Turn, race), data, 0644); err != nil {
+			return err
+		}
+	}
+	for race, data := range c.Orders {
+		name := OrdersPath(s.path, c.Game.Turn-1, race)
+		if old, err := os.ReadFile(name); err == nil && bytes.Equal(old, data) {
+			continue
+		} else if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+			return err
+		} else if err := writeFile(name, data, 0644); err != nil {
+			return err
+		}
+	}
+	if err := s.appendEvents(c.Events); err != nil {
+		return err
+	}
+	return s.writeCurrent(docs)
+}
+
+// writeCurrent writes the current state. The game file is written last,
+// so the game doesn't move to another turn until the rest is saved; Game
+// reads past a cluster and tech tree that were saved without it.
+func (s *FileStore_t) writeCurrent(docs map[string][]byte) error {
+	for _, name := range []string{ClusterFile, TechFile, GameFile} {
+		if err := writeFile(filepath.Join(s.path, name), docs[name], 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Rollback writes the turn's state as the current state, then removes the
+// state and reports of the later turns. Their orders are left alone.
+func (s *FileStore_t) Rollback(turn int, events []*Event_t) error {
+	g, err := s.Turn(turn)
+	if err != nil {
+		return err
+	}
+	docs, err := g.documents()
+	if err != nil {
+		return err
+	}
+	turns, err := s.Turns()
+	if err != nil {
+		return err
+	}
+	if err := s.appendEvents(events); err != nil {
+		return err
+	} else if err := s.writeCurrent(docs); err != nil {
+		return err
+	}
+	for _, later := range turns {
+		if later <= turn {
+			continue
+		}
+		// the game file goes first, so that a turn that is half removed isn't listed
+		for _, name := range []string{GameFile, ClusterFile, TechFile} {
+			if err := os.Remove(filepath.Join(TurnPath(s.path, later), name)); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return err
+			}
+		}
+		if err := os.RemoveAll(filepath.Join(TurnPath(s.path, later), "reports")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *FileStore_t) Close() error {
+	return nil
+}
@@ -0,0 +1,183 @@
+// Copyright (c) 2024 Michael D Henderson. All rights reserved.
+
+package fargo
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// newTestGame creates a small game in a temporary directory, kept in a
+// store of the kind, and saves its first turn.
+func newTestGame(t *testing.T, kind string) (string, *Game_t) {
+	t.Helper()
+	path := t.TempDir()
+	if err := (&Settings_t{Storage: kind}).Save(path); err != nil {
+		t.Fatal(err)
+	}
+	g, err := CreateGame(GameOptions_t{Name: "Test", Seed: "test", NumberOfRaces: 2, SystemsPerRace: 4, Culture: "classical", NameStyle: "syllable"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := g.SaveTurn(path); err != nil {
+		t.Fatal(err)
+	}
+	return path, g
+}
+
+// writeTestOrders submits the race's orders for the game's current turn.
+func writeTestOrders(t *testing.T, path string, g *Game_t, race, text string) {
+	t.Helper()
+	name := OrdersPath(path, g.Turn, race)
+	if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+		t.Fatal(err)
+	} else if err := os.WriteFile(name, []byte(text), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// sameGame fails the test if the games would not be saved as the same documents.
+func sameGame(t *testing.T, what string, want, got *Game_t) {
+	t.Helper()
+	wantDocs, err := want.documents()
+	if err != nil {
+		t.Fatal(err)
+	}
+	gotDocs, err := got.documents()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, data := range wantDocs {
+		if !bytes.Equal(data, gotDocs[name]) {
+			t.Errorf("%s: %s: does not match", what, name)
+		}
+	}
+}
+
+func TestStoreRoundTrip(t *testing.T) {
+	for _, kind := range []string{StorageFiles, StorageBolt} {
+		t.Run(kind, func(t *testing.T) {
+			path, first := newTestGame(t, kind)
+			orders := "race R001\nresearch drive 25\n"
+			writeTestOrders(t, path, first, "R001", orders)
+			second, err := RunTurn(path)
+			if err != nil {
+				t.Fatal(err)
+			} else if second.Turn != 2 {
+				t.Fatalf("turn: want 2, got %d", second.Turn)
+			}
+
+			s, err := OpenStore(path, true)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer s.Close()
+			if turns, err := s.Turns(); err != nil {
+				t.Fatal(err)
+			} else if !slices.Equal(turns, []int{1, 2}) {
+				t.Errorf("turns: want [1 2], got %v", turns)
+			}
+			current, err := s.Game()
+			if err != nil {
+				t.Fatal(err)
+			}
+			sameGame(t, "current", second, current)
+			for _, g := range []*Game_t{first, second} {
+				saved, err := s.Turn(g.Turn)
+				if err != nil {
+					t.Fatal(err)
+				}
+				sameGame(t, "turn", g, saved)
+			}
+			if data, err := s.Orders(1, "R001"); err != nil {
+				t.Fatal(err)
+			} else if string(data) != orders {
+				t.Errorf("orders: want %q, got %q", orders, data)
+			}
+			if _, err := s.Orders(1, "R002"); !errors.Is(err, os.ErrNotExist) {
+				t.Errorf("orders: R002: want ErrNotExist, got %v", err)
+			}
+			var report bytes.Buffer
+			if err := second.WriteReport(&report, second.Race("R001")); err != nil {
+				t.Fatal(err)
+			} else if data, err := s.Report(2, "R001"); err != nil {
+				t.Fatal(err)
+			} else if !bytes.Equal(data, report.Bytes()) {
+				t.Errorf("report: does not match")
+			}
+			if events, err := s.Events(1); err != nil {
+				t.Fatal(err)
+			} else if len(events) == 0 {
+				t.Errorf("events: want changes for turn 1, got none")
+			}
+		})
+	}
+}
+
+func TestMigrateStore(t *testing.T) {
+	for _, kinds := range [][]string{{StorageFiles, StorageBolt, StorageFiles, StorageBolt}, {StorageBolt, StorageFiles, StorageBolt}} {
+		t.Run(kinds[0], func(t *testing.T) {
+			path, first := newTestGame(t, kinds[0])
+			writeTestOrders(t, path, first, "R001", "race R001\nresearch drive 25\n")
+			second, err := RunTurn(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			events, err := loadEvents(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, kind := range kinds[1:] {
+				if err := MigrateStore(path, kind); err != nil {
+					t.Fatalf("%s: %v", kind, err)
+				} else if settings, err := LoadSettings(path); err != nil {
+					t.Fatal(err)
+				} else if settings.Storage != kind {
+					t.Fatalf("%s: storage: got %q", kind, settings.Storage)
+				}
+				current, err := LoadGame(path)
+				if err != nil {
+					t.Fatal(err)
+				}
+				sameGame(t, kind, second, current)
+				if got, err := loadEvents(path); err != nil {
+					t.Fatal(err)
+				} else if len(got) != len(events) {
+					t.Errorf("%s: events: want %d, got %d", kind, len(events), len(got))
+				}
+			}
+
+			// the migrated game must still run
+			writeTestOrders(t, path, second, "R002", "race R002\nresearch weapons 30\n")
+			if third, err := RunTurn(path); err != nil {
+				t.Fatal(err)
+			} else if third.Turn != 3 {
+				t.Errorf("turn: want 3, got %d", third.Turn)
+			}
+		})
+	}
+}
+
+func TestMigrateStoreSameKind(t *testing.T) {
+	path, _ := newTestGame(t, StorageFiles)
+	if err := MigrateStore(path, StorageFiles); !errors.Is(err, ErrInvalidArguments) {
+		t.Errorf("want ErrInvalidArguments, got %v", err)
+	}
+	if err := MigrateStore(path, "sqlite"); !errors.Is(err, ErrInvalidArguments) {
+		t.Errorf("want ErrInvalidArguments, got %v", err)
+	}
+}
+
+func loadEvents(path string) ([]*Event_t, error) {
+	s, err := OpenStore(path, true)
+	if err != nil {
+		return nil, err
+	}
+	defer s.Close()
+	return s.Events(0)
+}
@@ -0,0 +1,143 @@
+// Copyright (c) 2024 Michael D Henderson. All rights reserved.
+
+package fargo
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// functions to accept orders and track who has submitted them.
+//
+// submitted orders are saved in the turn's orders directory, where the
+// turn processor reads them. a race may submit orders as often as it
+// likes before the turn is processed; the last orders submitted are the
+// ones that are used. a secret in the race order is removed before the
+// orders are saved.
+//
+// orders are saved while holding the turn lock, so that they can't land
+// in a turn that is being processed or has just been processed.
+
+// Submission_t is the state of a race's orders for the current turn.
+type Submission_t struct {
+	Race      string     `json:"race"`
+	Name      string     `json:"name"`
+	Submitted bool       `json:"submitted"`
+	Updated   *time.Time `json:"updated,omitempty"` // when the orders were last saved
+}
+
+// Submission returns the state of the race's orders for the current turn.
+func (g *Game_t) Submission(path string, race *Race_t) (*Submission_t, error) {
+	s := &Submission_t{Race: race.Id, Name: race.Name}
+	sb, err := os.Stat(OrdersPath(path, g.Turn, race.Id))
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	} else if err != nil {
+		return nil, err
+	}
+	updated := sb.ModTime().UTC()
+	s.Submitted, s.Updated = true, &updated
+	return s, nil
+}
+
+// Submissions returns the state of every race's orders for the current turn.
+func (g *Game_t) Submissions(path string) ([]*Submission_t, error) {
+	var list []*Submission_t
+	for _, race := range g.Races {
+		s, err := g.Submission(path, race)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, s)
+	}
+	return list, nil
+}
+
+// CheckSubmission parses the race's orders and checks them like SubmitOrders,
+// without saving them.
+func (g *Game_t) CheckSubmission(race *Race_t, text []byte) (*Orders_t, []*OrderError_t, error) {
+	o, err := ParseOrders(bytes.NewReader(text))
+	if err != nil {
+		return nil, nil, err
+	} else if o.Race == "" {
+		return nil, nil, ErrMissingRace
+	} else if g.Race(o.Race) != race {
+		return nil, nil, fmt.Errorf("orders are for %q, not %s: %w", o.Race, race.Id, ErrUnknownRace)
+	}
+	errs, err := g.CheckOrders(o)
+	if err != nil {
+		return nil, nil, err
+	}
+	return o, errs, nil
+}
+
+// SubmitOrders checks the race's orders and saves them for the current turn,
+// replacing any orders the race submitted before. Orders that fail the check
+// are still saved; the failures are returned so that the player can fix them.
+// If the turn was processed after the game was loaded, the orders are not
+// saved and ErrTurnChanged is returned.
+func (g *Game_t) SubmitOrders(path string, race *Race_t, text []byte) ([]*OrderError_t, error) {
+	unlock, err := lockSubmission(path)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	if current, err := LoadGame(path); err != nil {
+		return nil, err
+	} else if current.Turn != g.Turn {
+		return nil, fmt.Errorf("orders are for turn %d, game is on turn %d: %w", g.Turn, current.Turn, ErrTurnChanged)
+	} else if settings, err := LoadSettings(path); err != nil {
+		return nil, err
+	} else if settings.State == StateArchived {
+		return nil, ErrGameArchived
+	}
+	o, errs, err := g.CheckSubmission(race, text)
+	if err != nil {
+		return nil, err
+	}
+
+	if o.Secret != "" {
+		text = redactSecret(text, o.Race)
+	}
+
+	// write to a temporary file first so that the turn processor never sees half of the orders
+	name := OrdersPath(path, g.Turn, race.Id)
+	if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+		return nil, err
+	} else if err := os.WriteFile(name+".tmp", text, 0644); err != nil {
+		return nil, err
+	} else if err := os.Rename(name+".tmp", name); err != nil {
+		return nil, err
+	}
+	return errs, nil
+}
+
+// lockSubmission takes the turn lock. Another submission only holds the
+// lock for a moment, so it waits a little before giving up.
+func lockSubmission(path string) (unlock func(), err error) {
+	for i := 0; ; i++ {
+		unlock, err = LockTurn(path)
+		if !errors.Is(err, ErrTurnLocked) || i == 20 {
+			return unlock, err
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+// redactSecret replaces the race order with one that doesn't have the secret.
+func redactSecret(text []byte, race string) []byte {
+	lines := strings.SplitAfter(string(text), "\n")
+	for i, line := range lines {
+		fields := strings.Fields(line)
+		if len(fields) != 0 && strings.EqualFold(fields[0], "race") {
+			lines[i] = "race " + race + "\n"
+			break
+		}
+	}
+	return []byte(strings.Join(lines, ""))
+}
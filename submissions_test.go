@@ -0,0 +1,70 @@
+// Copyright (c) 2024 Michael D Henderson. All rights reserved.
+
+package fargo
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestSubmitOrdersLocked(t *testing.T) {
+	path, g := newTestGame(t, StorageFiles)
+	race := g.Race("R001")
+	orders := []byte("race R001\nresearch drive 25\n")
+
+	// a turn that is being processed holds the lock for longer than a submission waits
+	unlock, err := LockTurn(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := g.SubmitOrders(path, race, orders); !errors.Is(err, ErrTurnLocked) {
+		t.Errorf("locked: want ErrTurnLocked, got %v", err)
+	} else if _, err := os.Stat(OrdersPath(path, g.Turn, race.Id)); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("locked: orders were saved")
+	}
+
+	// a lock that is released while the submission waits is taken
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		unlock()
+	}()
+	if _, err := g.SubmitOrders(path, race, orders); err != nil {
+		t.Fatalf("unlocked: %v", err)
+	} else if data, err := os.ReadFile(OrdersPath(path, g.Turn, race.Id)); err != nil {
+		t.Fatal(err)
+	} else if string(data) != string(orders) {
+		t.Errorf("orders: want %q, got %q", orders, data)
+	}
+	if TurnLocked(path) {
+		t.Errorf("submission did not release the lock")
+	}
+}
+
+func TestSubmitOrdersTurnChanged(t *testing.T) {
+	path, g := newTestGame(t, StorageFiles)
+	race := g.Race("R002")
+	if _, err := RunTurn(path); err != nil {
+		t.Fatal(err)
+	}
+
+	// the game was loaded before the turn was processed
+	if _, err := g.SubmitOrders(path, race, []byte("race R002\nresearch weapons 30\n")); !errors.Is(err, ErrTurnChanged) {
+		t.Errorf("want ErrTurnChanged, got %v", err)
+	} else if _, err := os.Stat(OrdersPath(path, g.Turn, race.Id)); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("orders were saved for turn %d", g.Turn)
+	}
+}
+
+func TestSubmitOrdersRedactsSecret(t *testing.T) {
+	path, g := newTestGame(t, StorageFiles)
+	race := g.Race("R001")
+	if _, err := g.SubmitOrders(path, race, []byte("race r001 hunter2\nresearch drive 25\n")); err != nil {
+		t.Fatal(err)
+	} else if data, err := os.ReadFile(OrdersPath(path, g.Turn, race.Id)); err != nil {
+		t.Fatal(err)
+	} else if want := "race R001\nresearch drive 25\n"; string(data) != want {
+		t.Errorf("orders: want %q, got %q", want, data)
+	}
+}
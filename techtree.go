@@ -0,0 +1,229 @@
+// Copyright (c) 2024 Michael D Henderson. All rights reserved.
+
+package fargo
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+)
+
+// functions to define the technology tree.
+//
+// every race has a level in each field of technology, starting at 1.
+// credits spent on research in a field accumulate until they pay for
+// the next level. the cost of each level and the effects of the levels
+// are read from a data file. a new game gets a copy of the default tree
+// in techtree.json; the GM may edit it to tune the game.
+
+const (
+	TechFile = "techtree.json"
+
+	// the fields of technology
+	TechDrive         = "drive"
+	TechWeapons       = "weapons"
+	TechShields       = "shields"
+	TechLifeSupport   = "life-support"
+	TechSensors       = "sensors"
+	TechManufacturing = "manufacturing"
+	TechTerraforming  = "terraforming"
+
+	// the effects of technology
+	EffectSpeed        = "speed"        // drive, light years per turn
+	EffectAttack       = "attack"       // weapons, multiplier for damage done
+	EffectDefense      = "defense"      // shields, multiplier for damage absorbed
+	EffectHabitability = "habitability" // life support and terraforming, points added to habitability above 0
+	EffectScanRange    = "scan-range"   // sensors, light years
+	EffectProduction   = "production"   // manufacturing, multiplier for colony output
+	EffectMaxHull      = "max-hull"     // manufacturing, largest hull that can be built
+	EffectMounts       = "mounts"       // weapons, shields and sensors, most components of the kind on one ship
+)
+
+// techFields is the list of fields that every tech tree must define, along with the effects the engine reads from them.
+var techFields = []struct{ field, effect string }{
+	{TechDrive, EffectSpeed},
+	{TechWeapons, EffectAttack},
+	{TechWeapons, EffectMounts},
+	{TechShields, EffectDefense},
+	{TechShields, EffectMounts},
+	{TechLifeSupport, EffectHabitability},
+	{TechSensors, EffectScanRange},
+	{TechSensors, EffectMounts},
+	{TechManufacturing, EffectProduction},
+	{TechManufacturing, EffectMaxHull},
+	{TechTerraforming, EffectHabitability},
+}
+
+//go:embed techtree.json
+var defaultTechTree []byte
+
+type TechTree_t struct {
+	Fields []*TechField_t `json:"fields"`
+}
+
+type TechField_t struct {
+	Id          string                  `json:"id"`
+	Name        string                  `json:"name"`
+	Description string                  `json:"description,omitempty"`
+	MaxLevel    int                     `json:"max-level"`
+	Cost        TechCost_t              `json:"cost"`
+	Effects     map[string]TechEffect_t `json:"effects"`
+}
+
+// TechCost_t is the cost curve for a field.
+// Reaching level n costs Base * (n-1)^Exponent research points.
+type TechCost_t struct {
+	Base     float64 `json:"base"`
+	Exponent float64 `json:"exponent"`
+}
+
+// TechEffect_t is the value of an effect at each level.
+// At level n the value is Base + PerLevel * (n-1).
+type TechEffect_t struct {
+	Base     float64 `json:"base"`
+	PerLevel float64 `json:"per-level"`
+}
+
+// DefaultTechTree returns the tree that is built into the engine.
+func DefaultTechTree() *TechTree_t {
+	tree, err := parseTechTree(defaultTechTree)
+	if err != nil {
+		panic(fmt.Sprintf("assert(default tech tree is valid): %v", err))
+	}
+	return tree
+}
+
+// LoadTechTree reads a tree from a file and checks that it defines every field.
+func LoadTechTree(filename string) (*TechTree_t, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	tree, err := parseTechTree(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	return tree, nil
+}
+
+func parseTechTree(data []byte) (*TechTree_t, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	var tree TechTree_t
+	if err := dec.Decode(&tree); err != nil {
+		return nil, err
+	}
+	for _, f := range tree.Fields {
+		if f.MaxLevel < 1 {
+			return nil, fmt.Errorf("%s: max-level: %w", f.Id, ErrInvalidTechTree)
+		} else if !(f.Cost.Base > 0) || f.Cost.Exponent < 0 {
+			return nil, fmt.Errorf("%s: cost: %w", f.Id, ErrInvalidTechTree)
+		}
+	}
+	for _, tf := range techFields {
+		f := tree.Field(tf.field)
+		if f == nil {
+			return nil, fmt.Errorf("%s: missing field: %w", tf.field, ErrInvalidTechTree)
+		} else if _, ok := f.Effects[tf.effect]; !ok {
+			return nil, fmt.Errorf("%s: missing effect %q: %w", tf.field, tf.effect, ErrInvalidTechTree)
+		}
+	}
+	return &tree, nil
+}
+
+// Save writes the tree to a file.
+func (t *TechTree_t) Save(filename string) error {
+	data, err := json.MarshalIndent(t, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filename, data, 0644)
+}
+
+// Field returns the field with the id, or nil if there is none.
+func (t *TechTree_t) Field(id string) *TechField_t {
+	for _, f := range t.Fields {
+		if f.Id == id {
+			return f
+		}
+	}
+	return nil
+}
+
+// LevelCost returns the research points needed to reach the level from the level below it.
+func (f *TechField_t) LevelCost(level int) float64 {
+	if level < 2 {
+		return 0
+	}
+	return math.Round(f.Cost.Base * math.Pow(float64(level-1), f.Cost.Exponent))
+}
+
+// Effect returns the value of the effect at the level.
+// Levels above the maximum are treated as the maximum.
+func (f *TechField_t) Effect(effect string, level int) float64 {
+	e, ok := f.Effects[effect]
+	if !ok {
+		return 0
+	}
+	level = max(1, min(level, f.MaxLevel))
+	return e.Base + e.PerLevel*float64(level-1)
+}
+
+// TechLevel returns the race's level in a field. Every race starts at level 1.
+func (r *Race_t) TechLevel(field string) int {
+	return max(1, r.Tech[field])
+}
+
+// TechEffect returns the value of an effect of the race's technology.
+func (g *Game_t) TechEffect(race *Race_t, field, effect string) float64 {
+	f := g.TechTree.Field(field)
+	if f == nil {
+		return 0
+	}
+	return f.Effect(effect, race.TechLevel(field))
+}
+
+// Speed returns the distance the race's ships travel in one turn, in light years.
+func (g *Game_t) Speed(race *Race_t) float64 {
+	return g.TechEffect(race, TechDrive, EffectSpeed)
+}
+
+// ScanRange returns the range of the race's sensors, in light years.
+func (g *Game_t) ScanRange(race *Race_t) float64 {
+	return g.TechEffect(race, TechSensors, EffectScanRange)
+}
+
+// Attack returns the multiplier for damage done by the race's weapons.
+func (g *Game_t) Attack(race *Race_t) float64 {
+	return g.TechEffect(race, TechWeapons, EffectAttack)
+}
+
+// Defense returns the multiplier for damage absorbed by the race's shields.
+func (g *Game_t) Defense(race *Race_t) float64 {
+	return g.TechEffect(race, TechShields, EffectDefense)
+}
+
+// research adds points to a field and raises the race's level for as long
+// as the points pay for the next level. It returns the new levels reached.
+func (g *Game_t) research(race *Race_t, f *TechField_t, points float64) ([]int, error) {
+	if race.TechLevel(f.Id) >= f.MaxLevel {
+		return nil, fmt.Errorf("%s: %w", f.Id, ErrMaximumTechLevel)
+	}
+	if race.Tech == nil {
+		race.Tech = make(map[string]int)
+	}
+	if race.Research == nil {
+		race.Research = make(map[string]float64)
+	}
+	var levels []int
+	race.Research[f.Id] += points
+	for level := race.TechLevel(f.Id); level < f.MaxLevel && race.Research[f.Id] >= f.LevelCost(level+1); level++ {
+		race.Research[f.Id] -= f.LevelCost(level + 1)
+		race.Tech[f.Id] = level + 1
+		levels = append(levels, level+1)
+	}
+	return levels, nil
+}
@@ -0,0 +1,86 @@
+// Copyright (c) 2024 Michael D Henderson. All rights reserved.
+
+package fargo
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTechTree(t *testing.T) {
+	tree := DefaultTechTree()
+	f := tree.Field(TechDrive)
+	if f == nil {
+		t.Fatalf("field: %s is missing", TechDrive)
+	} else if tree.Field("warp") != nil {
+		t.Errorf("field: want nil for warp")
+	}
+	if got := f.LevelCost(1); got != 0 {
+		t.Errorf("cost: level 1: want 0, got %g", got)
+	} else if !(f.LevelCost(3) > f.LevelCost(2)) {
+		t.Errorf("cost: want level 3 to cost more than level 2, got %g and %g", f.LevelCost(3), f.LevelCost(2))
+	}
+	if f.Effect(EffectSpeed, f.MaxLevel+5) != f.Effect(EffectSpeed, f.MaxLevel) {
+		t.Errorf("effect: want levels above the maximum to count as the maximum")
+	} else if f.Effect(EffectSpeed, 0) != f.Effect(EffectSpeed, 1) {
+		t.Errorf("effect: want levels below 1 to count as 1")
+	} else if f.Effect("no-such-effect", 3) != 0 {
+		t.Errorf("effect: want 0 for an effect the field doesn't have")
+	}
+
+	// a saved tree loads again, and a broken one is rejected
+	path := t.TempDir()
+	name := filepath.Join(path, TechFile)
+	if err := tree.Save(name); err != nil {
+		t.Fatal(err)
+	} else if loaded, err := LoadTechTree(name); err != nil {
+		t.Fatal(err)
+	} else if len(loaded.Fields) != len(tree.Fields) {
+		t.Errorf("load: want %d fields, got %d", len(tree.Fields), len(loaded.Fields))
+	}
+	data, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for what, text := range map[string]string{
+		"missing field": strings.Replace(string(data), `"id": "sensors"`, `"id": "radar"`, 1),
+		"negative cost": strings.Replace(string(data), `"base": `, `"base": -`, 1),
+	} {
+		if err := os.WriteFile(name, []byte(text), 0644); err != nil {
+			t.Fatal(err)
+		} else if _, err := LoadTechTree(name); !errors.Is(err, ErrInvalidTechTree) {
+			t.Errorf("%s: want %v, got %v", what, ErrInvalidTechTree, err)
+		}
+	}
+}
+
+func TestResearch(t *testing.T) {
+	g := &Game_t{TechTree: DefaultTechTree()}
+	f := g.TechTree.Field(TechDrive)
+	race := &Race_t{Id: "R001"}
+	if race.TechLevel(TechDrive) != 1 {
+		t.Fatalf("level: want 1 to start, got %d", race.TechLevel(TechDrive))
+	}
+
+	// enough for two levels, with a little left over
+	points := f.LevelCost(2) + f.LevelCost(3) + 1
+	levels, err := g.research(race, f, points)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(levels) != 2 || race.TechLevel(TechDrive) != 3 {
+		t.Fatalf("research: want levels [2 3], got %v at level %d", levels, race.TechLevel(TechDrive))
+	} else if race.Research[TechDrive] != 1 {
+		t.Errorf("research: want 1 point left over, got %g", race.Research[TechDrive])
+	}
+	if !(g.Speed(race) > g.Speed(&Race_t{})) {
+		t.Errorf("speed: want level 3 faster than level 1")
+	}
+
+	race.Tech[TechDrive] = f.MaxLevel
+	if _, err := g.research(race, f, 1); !errors.Is(err, ErrMaximumTechLevel) {
+		t.Errorf("maximum: want %v, got %v", ErrMaximumTechLevel, err)
+	}
+}
@@ -0,0 +1,350 @@
+// Copyright (c) 2024 Michael D Henderson. All rights reserved.
+
+package fargo
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// functions to trade and transfer credits, fleets, tech and colonies
+// between races.
+//
+// a trade is an exchange that both races agree to. each race writes a
+// trade order naming the other race, what it gives and what it gets, and
+// the trade happens only if the two orders match in the same turn:
+//
+//	trade R002 give credits 100 get tech drive
+//	trade R001 give tech drive get credits 100
+//
+// the whole exchange is checked before anything changes hands. if either
+// side can't deliver, nothing is traded and both orders fail.
+//
+// a transfer is a gift that needs no agreement:
+//
+//	transfer fleet F003 to R002
+//
+// players who don't trust each other should trade. a deal made with
+// messages and carried out with transfers can be broken by either side,
+// which is the only way to cheat a trading partner.
+//
+// credits are goods and must be delivered by ship. the giver needs
+// fleets with cargo holds in systems where the other race has a colony
+// or a fleet; each hold carries CargoPerHold credits and makes one
+// delivery a turn. tech is copied, so it needs no ship, but a race can
+// only give tech it is ahead in. a fleet is handed over where it is, so
+// the other race must have a colony or a fleet in the same system. a
+// colony keeps its people and infrastructure. races at war don't trade.
+//
+// trades are settled before transfers, in the order of the races and
+// then the order the orders were written, so the results never depend
+// on anything but the orders.
+
+// TradeItem_t is something one race gives to another.
+type TradeItem_t struct {
+	Kind   string  // "credits", "colony", "fleet" or "tech"
+	Id     string  // id of the colony or fleet, or the tech field
+	Amount float64 // credits
+}
+
+func (i TradeItem_t) String() string {
+	if i.Kind == "credits" {
+		return fmt.Sprintf("credits %g", i.Amount)
+	}
+	return i.Kind + " " + i.Id
+}
+
+// sameItems returns true if the lists hold the same items, in any order.
+func sameItems(a, b []TradeItem_t) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	keys := func(items []TradeItem_t) []string {
+		var list []string
+		for _, item := range items {
+			list = append(list, item.String())
+		}
+		sort.Strings(list)
+		return list
+	}
+	ka, kb := keys(a), keys(b)
+	for i := range ka {
+		if ka[i] != kb[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// itemList returns the items as text for the report.
+func itemList(items []TradeItem_t) string {
+	if len(items) == 0 {
+		return "nothing"
+	}
+	var list []string
+	for _, item := range items {
+		list = append(list, item.String())
+	}
+	return strings.Join(list, ", ")
+}
+
+// tradePhase settles the trades that both races agreed to, then the transfers.
+func (t *turn_t) tradePhase() {
+	matched := make(map[*TradeOrder_t]bool)
+	for _, race := range t.g.Races {
+		for _, order := range ordersFor[*TradeOrder_t](t, race) {
+			t.cause(race, order)
+			if matched[order] {
+				continue
+			}
+			other := t.g.Race(order.Race)
+			if other == nil || other == race {
+				t.reject(race, order.OrderSource_t, fmt.Errorf("%q: %w", order.Race, ErrUnknownRace))
+				continue
+			}
+			var reply *TradeOrder_t
+			for _, o := range ordersFor[*TradeOrder_t](t, other) {
+				if !matched[o] && t.g.Race(o.Race) == race && sameItems(order.Give, o.Get) && sameItems(order.Get, o.Give) {
+					reply = o
+					break
+				}
+			}
+			if reply == nil {
+				t.reject(race, order.OrderSource_t, fmt.Errorf("%s: %w", other.Id, ErrUnconfirmed))
+				continue
+			}
+			matched[order], matched[reply] = true, true
+			if err := t.trade(race, other, order.Give, order.Get); err != nil {
+				t.reject(race, order.OrderSource_t, err)
+				t.reject(other, reply.OrderSource_t, err)
+			}
+		}
+	}
+
+	for _, race := range t.g.Races {
+		for _, order := range ordersFor[*TransferOrder_t](t, race) {
+			t.cause(race, order)
+			if err := t.transfer(race, order); err != nil {
+				t.reject(race, order.OrderSource_t, err)
+			}
+		}
+	}
+}
+
+// trade exchanges the items. Nothing changes hands unless both races can deliver everything.
+func (t *turn_t) trade(a, b *Race_t, give, get []TradeItem_t) error {
+	if t.g.Status(a.Id, b.Id) == StatusWar {
+		return fmt.Errorf("%s: %w", b.Id, ErrAtWar)
+	}
+	sides := []struct {
+		from, to *Race_t
+		items    []TradeItem_t
+		credits  float64
+	}{{from: a, to: b, items: give}, {from: b, to: a, items: get}}
+	for i, side := range sides {
+		for _, item := range side.items {
+			if err := t.g.checkItem(side.from, side.to, item); err != nil {
+				return fmt.Errorf("%s: %w", side.from.Id, err)
+			}
+			if item.Kind == "credits" {
+				sides[i].credits += item.Amount
+			}
+		}
+		if err := t.checkDelivery(side.from, side.to, sides[i].credits); err != nil {
+			return fmt.Errorf("%s: %w", side.from.Id, err)
+		}
+	}
+	// the cargo is loaded before any fleet changes hands
+	for _, side := range sides {
+		t.haul(side.from, side.to, side.credits)
+	}
+	for _, item := range give {
+		t.g.giveItem(a, b, item)
+	}
+	for _, item := range get {
+		t.g.giveItem(b, a, item)
+	}
+	t.g.logf(a, "traded with %s %s: gave %s, got %s", b.Id, b.Name, itemList(give), itemList(get))
+	t.g.logf(b, "traded with %s %s: gave %s, got %s", a.Id, a.Name, itemList(get), itemList(give))
+	return nil
+}
+
+// transfer gives an item to another race.
+func (t *turn_t) transfer(race *Race_t, order *TransferOrder_t) error {
+	other := t.g.Race(order.Race)
+	if other == nil || other == race {
+		return fmt.Errorf("%q: %w", order.Race, ErrUnknownRace)
+	} else if t.g.Status(race.Id, other.Id) == StatusWar {
+		return fmt.Errorf("%s: %w", other.Id, ErrAtWar)
+	} else if err := t.g.checkItem(race, other, order.Item); err != nil {
+		return err
+	}
+	if order.Item.Kind == "credits" {
+		if err := t.checkDelivery(race, other, order.Item.Amount); err != nil {
+			return err
+		}
+		t.haul(race, other, order.Item.Amount)
+	}
+	t.g.giveItem(race, other, order.Item)
+	t.g.logf(race, "transferred %s to %s %s", order.Item, other.Id, other.Name)
+	t.g.logf(other, "received %s from %s %s", order.Item, race.Id, race.Name)
+	return nil
+}
+
+// checkDelivery returns an error if the race can't pay the credits or
+// doesn't have the cargo space to deliver them to the other race.
+func (t *turn_t) checkDelivery(from, to *Race_t, credits float64) error {
+	if credits == 0 {
+		return nil
+	} else if credits > from.Credits+1e-9 {
+		return fmt.Errorf("%w: need %.1f, have %.1f", ErrInsufficientCredits, credits, from.Credits)
+	} else if space := t.cargoSpace(from, to); credits > space+1e-9 {
+		return fmt.Errorf("%s: %w: need %.1f, have %.1f", to.Id, ErrInsufficientCargo, credits, space)
+	}
+	return nil
+}
+
+// carriers returns the race's fleets that can deliver cargo to the other
+// race, in the order of the fleets.
+func (t *turn_t) carriers(from, to *Race_t) []*Fleet_t {
+	var list []*Fleet_t
+	for _, fleet := range t.g.FleetsOf(from) {
+		if !fleet.InTransit() && t.g.present(to, fleet.System) && t.g.fleetCargo(fleet) > 0 {
+			list = append(list, fleet)
+		}
+	}
+	return list
+}
+
+// cargoSpace returns the cargo space the race has left this turn to
+// deliver goods to the other race.
+func (t *turn_t) cargoSpace(from, to *Race_t) float64 {
+	space := 0.0
+	for _, fleet := range t.carriers(from, to) {
+		space += t.g.fleetCargo(fleet) - t.hauled[fleet.Id]
+	}
+	return space
+}
+
+// haul uses up the cargo space for the delivery, filling the fleets in order.
+// The space must have been checked.
+func (t *turn_t) haul(from, to *Race_t, amount float64) {
+	if t.hauled == nil {
+		t.hauled = make(map[string]float64)
+	}
+	for _, fleet := range t.carriers(from, to) {
+		if amount <= 0 {
+			return
+		}
+		load := min(amount, t.g.fleetCargo(fleet)-t.hauled[fleet.Id])
+		t.hauled[fleet.Id] += load
+		amount -= load
+	}
+}
+
+// fleetCargo returns the cargo the fleet's ships can carry.
+func (g *Game_t) fleetCargo(fleet *Fleet_t) float64 {
+	cargo := 0.0
+	for _, id := range fleet.Ships {
+		if ship := g.Ship(id); ship != nil {
+			if d := g.DesignOf(ship); d != nil {
+				cargo += float64(d.Cargo) * CargoPerHold
+			}
+		}
+	}
+	return cargo
+}
+
+// present returns true if the race has a colony or a fleet in the system.
+func (g *Game_t) present(race *Race_t, system string) bool {
+	for _, colony := range g.ColoniesOf(race) {
+		if colony.System == system {
+			return true
+		}
+	}
+	for _, fleet := range g.FleetsOf(race) {
+		if fleet.System == system {
+			return true
+		}
+	}
+	return false
+}
+
+// checkItem returns an error if the race can't give the item to the other race.
+// Credits are checked by the caller, since several items may draw on the same treasury.
+func (g *Game_t) checkItem(from, to *Race_t, item TradeItem_t) error {
+	switch item.Kind {
+	case "credits":
+		return nil
+	case "tech":
+		field := g.TechTree.Field(item.Id)
+		if field == nil {
+			return fmt.Errorf("%q: %w", item.Id, ErrUnknownTechField)
+		} else if level, other := from.TechLevel(field.Id), to.TechLevel(field.Id); level <= other {
+			return fmt.Errorf("%s: %w: %d, %s has %d", field.Id, ErrNoTechLead, level, to.Id, other)
+		}
+		return nil
+	case "colony":
+		if colony := g.Colony(item.Id); colony == nil || colony.Race != from.Id {
+			return fmt.Errorf("%q: %w", item.Id, ErrUnknownColony)
+		}
+		return nil
+	case "fleet":
+		fleet := g.Fleet(item.Id)
+		if fleet == nil || fleet.Race != from.Id {
+			return fmt.Errorf("%q: %w", item.Id, ErrUnknownFleet)
+		} else if fleet.InTransit() {
+			return fmt.Errorf("%s: %w", fleet.Id, ErrInTransit)
+		}
+		if !g.present(to, fleet.System) {
+			return fmt.Errorf("%s and %s: %w", fleet.Id, to.Id, ErrNotTogether)
+		}
+		// the ships keep their design, so it mustn't clash with one the other race has
+		for _, id := range fleet.Ships {
+			if ship := g.Ship(id); ship != nil {
+				if d, od := g.DesignOf(ship), to.Design(ship.Design); d != nil && od != nil && *d != *od {
+					return fmt.Errorf("%q: %w", d.Name, ErrDuplicateDesign)
+				}
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("%q: %w", item.Kind, ErrUnknownItem)
+}
+
+// giveItem moves an item from one race to another. The item must have been checked.
+func (g *Game_t) giveItem(from, to *Race_t, item TradeItem_t) {
+	switch item.Kind {
+	case "credits":
+		from.Credits -= item.Amount
+		to.Credits += item.Amount
+	case "tech":
+		field := g.TechTree.Field(item.Id)
+		if to.Tech == nil {
+			to.Tech = make(map[string]int)
+		}
+		to.Tech[field.Id] = from.TechLevel(field.Id)
+	case "colony":
+		colony := g.Colony(item.Id)
+		colony.Race = to.Id
+		if ss, planet := g.Planet(colony); planet != nil {
+			planet.Owner = to.Id
+			g.updateSystemOwner(ss)
+		}
+	case "fleet":
+		fleet := g.Fleet(item.Id)
+		for _, id := range fleet.Ships {
+			ship := g.Ship(id)
+			if ship == nil {
+				continue
+			}
+			if d := g.DesignOf(ship); d != nil && to.Design(d.Name) == nil {
+				copied := *d
+				to.Designs = append(to.Designs, &copied)
+			}
+			ship.Race = to.Id
+		}
+		fleet.Race, fleet.Route, fleet.Destination = to.Id, nil, ""
+	}
+}
@@ -0,0 +1,125 @@
+// Copyright (c) 2024 Michael D Henderson. All rights reserved.
+
+package fargo
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// tradeGame returns a new game and a function that runs a turn with the orders.
+func tradeGame(t *testing.T) (*Game_t, func(texts ...string) map[string][]*OrderError_t) {
+	t.Helper()
+	g, err := CreateGame(GameOptions_t{Name: "Test", Seed: "test", NumberOfRaces: 2, SystemsPerRace: 4, Culture: "classical", NameStyle: "syllable"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return g, func(texts ...string) map[string][]*OrderError_t {
+		var list []*Orders_t
+		for _, text := range texts {
+			o, err := ParseOrders(strings.NewReader(text))
+			if err != nil {
+				t.Fatal(err)
+			}
+			list = append(list, o)
+		}
+		results, err := g.ProcessTurn(list)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return results
+	}
+}
+
+func TestTrade(t *testing.T) {
+	g, turn := tradeGame(t)
+	red, blue := g.Race("R001"), g.Race("R002")
+	red.Tech[TechDrive], blue.Tech[TechWeapons] = 3, 3
+
+	// orders that don't match are not carried out
+	results := turn("race R001\ntrade R002 give tech drive get tech weapons\n", "race R002\ntrade R001 give tech weapons get tech sensors\n")
+	for _, id := range []string{"R001", "R002"} {
+		if failed := results[id]; len(failed) != 1 || !errors.Is(failed[0].Err, ErrUnconfirmed) {
+			t.Errorf("%s: want %v, got %v", id, ErrUnconfirmed, failed)
+		}
+	}
+	if blue.TechLevel(TechDrive) != 1 || red.TechLevel(TechWeapons) != 1 {
+		t.Fatalf("unmatched: want no tech traded")
+	}
+
+	// nothing changes hands unless both sides can deliver
+	results = turn("race R001\ntrade R002 give tech drive get tech weapons tech sensors\n", "race R002\ntrade R001 give tech sensors tech weapons get tech drive\n")
+	if failed := results["R001"]; len(failed) != 1 || !errors.Is(failed[0].Err, ErrNoTechLead) {
+		t.Errorf("no lead: want %v, got %v", ErrNoTechLead, failed)
+	} else if len(results["R002"]) != 1 {
+		t.Errorf("no lead: want both orders to fail, got %v", results["R002"])
+	}
+	if blue.TechLevel(TechDrive) != 1 || red.TechLevel(TechWeapons) != 1 {
+		t.Fatalf("no lead: want no tech traded")
+	}
+
+	// the items may be listed in any order
+	results = turn("race R001\ntrade R002 get tech weapons give tech drive\n", "race R002\ntrade r001 give tech weapons get tech drive\n")
+	if len(results["R001"]) != 0 || len(results["R002"]) != 0 {
+		t.Fatalf("trade: %v %v", results["R001"], results["R002"])
+	} else if blue.TechLevel(TechDrive) != 3 || red.TechLevel(TechWeapons) != 3 {
+		t.Errorf("trade: want the tech exchanged")
+	}
+
+	red.Stances = map[string]Status_e{blue.Id: StatusWar}
+	results = turn("race R001\ntrade R002 give colony C001 get colony C002\n", "race R002\ntrade R001 give colony C002 get colony C001\n")
+	if failed := results["R001"]; len(failed) != 1 || !errors.Is(failed[0].Err, ErrAtWar) {
+		t.Errorf("war: want %v, got %v", ErrAtWar, failed)
+	}
+}
+
+func TestTransfer(t *testing.T) {
+	g, turn := tradeGame(t)
+	red, blue := g.Race("R001"), g.Race("R002")
+	if failed := turn("race R001\ndesign Hauler hull 10 drive 1 cargo 2\nbuild 1 hauler at C001\n")["R001"]; len(failed) != 0 {
+		t.Fatalf("build: %v", failed)
+	}
+	hauler := g.Fleet(g.ShipsOf(red)[0].Fleet)
+
+	// credits are delivered by ship, and blue has nothing where the hauler is
+	if failed := turn("race R001\ntransfer credits 10 to R002\n")["R001"]; len(failed) != 1 || !errors.Is(failed[0].Err, ErrInsufficientCargo) {
+		t.Errorf("credits: want %v, got %v", ErrInsufficientCargo, failed)
+	}
+	if failed := turn("race R001\ntransfer fleet " + hauler.Id + " to R002\n")["R001"]; len(failed) != 1 || !errors.Is(failed[0].Err, ErrNotTogether) {
+		t.Errorf("fleet: want %v, got %v", ErrNotTogether, failed)
+	}
+
+	// once blue has a fleet in the system the hauler can make one delivery a turn
+	g.Fleets = append(g.Fleets, &Fleet_t{Id: "F901", Race: blue.Id, System: hauler.System, Position: hauler.Position})
+	idle, err := g.Clone()
+	if err != nil {
+		t.Fatal(err)
+	} else if _, err := idle.ProcessTurn(nil); err != nil {
+		t.Fatal(err)
+	}
+	failed := turn("race R001\ntransfer credits 15 to R002\ntransfer credits 10 to R002\n")["R001"]
+	if len(failed) != 1 || failed[0].Line != 3 || !errors.Is(failed[0].Err, ErrInsufficientCargo) {
+		t.Errorf("credits: want the second delivery to overflow the holds, got %v", failed)
+	}
+	if got := idle.Race(red.Id).Credits - red.Credits; got != 15 {
+		t.Errorf("credits: want 15 paid, got %g", got)
+	} else if got := blue.Credits - idle.Race(blue.Id).Credits; got != 15 {
+		t.Errorf("credits: want 15 received, got %g", got)
+	}
+
+	// the colony is handed over first, so blue is there to take the fleet
+	if failed := turn("race R001\ntransfer colony C001 to R002\ntransfer fleet " + hauler.Id + " to R002\n")["R001"]; len(failed) != 0 {
+		t.Fatalf("transfer: %v", failed)
+	}
+	if hauler.Race != blue.Id || g.ShipsOf(blue)[0].Race != blue.Id || blue.Design("hauler") == nil {
+		t.Errorf("fleet: want the hauler and its design given to %s", blue.Id)
+	}
+	colony := g.Colony("C001")
+	if _, planet := g.Planet(colony); colony.Race != blue.Id || planet.Owner != blue.Id {
+		t.Errorf("colony: want C001 given to %s", blue.Id)
+	}
+	if failed := turn("race R001\ntransfer colony C001 to R002\n")["R001"]; len(failed) != 1 || !errors.Is(failed[0].Err, ErrUnknownColony) {
+		t.Errorf("given away: want %v, got %v", ErrUnknownColony, failed)
+	}
+}
@@ -0,0 +1,657 @@
+// Copyright (c) 2024 Michael D Henderson. All rights reserved.
+
+package fargo
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// functions to process a turn.
+//
+// orders are carried out in phases. within a phase, races are handled
+// in the order they were created and each race's orders are handled in
+// the order they were written.
+
+// ProcessTurn carries out the orders for the current turn and advances the game to the next turn.
+// It returns the orders that failed, by race id.
+func (g *Game_t) ProcessTurn(orders []*Orders_t) (map[string][]*OrderError_t, error) {
+	return g.processTurn(orders, nil)
+}
+
+// processTurn carries out the orders, recording the changes in the audit if there is one.
+func (g *Game_t) processTurn(orders []*Orders_t, audit *audit_t) (map[string][]*OrderError_t, error) {
+	t := &turn_t{g: g, orders: make(map[string]*Orders_t), errors: make(map[string][]*OrderError_t), audit: audit}
+	for _, o := range orders {
+		race := g.Race(o.Race)
+		if race == nil {
+			return nil, fmt.Errorf("%q: %w", o.Race, ErrUnknownRace)
+		} else if _, ok := t.orders[race.Id]; ok {
+			return nil, fmt.Errorf("%s: %w", race.Id, ErrDuplicateOrders)
+		}
+		t.orders[race.Id] = o
+		for _, err := range o.Errors {
+			t.reject(race, err.OrderSource_t, err.Err)
+		}
+	}
+
+	for _, phase := range []struct {
+		name string
+		run  func()
+	}{
+		{"naming", t.namingPhase},
+		{"message", t.messagePhase},
+		{"diplomacy", t.diplomacyPhase},
+		{"trade", t.tradePhase},
+		{"research", t.researchPhase},
+		{"design", t.designPhase},
+		{"abandon", t.abandonPhase},
+		{"build", t.buildPhase},
+		{"fleet", t.fleetPhase},
+		{"movement", t.movementPhase},
+		{"combat", t.combatPhase},
+		{"colonization", t.colonizationPhase},
+		{"survey", t.surveyPhase},
+		{"production", t.productionPhase},
+		{"growth", t.growthPhase},
+		{"scan", func() {
+			g.Turn++
+			g.Scan()
+		}},
+	} {
+		t.phase = phase.name
+		t.cause(nil, nil)
+		phase.run()
+	}
+	t.cause(nil, nil)
+	return t.errors, nil
+}
+
+// CheckOrders reports the orders that would fail if the turn were processed now.
+// The orders are carried out on a copy of the game, so the game is not changed.
+func (g *Game_t) CheckOrders(o *Orders_t) ([]*OrderError_t, error) {
+	if g.Race(o.Race) == nil {
+		return nil, fmt.Errorf("%q: %w", o.Race, ErrUnknownRace)
+	}
+	clone, err := g.Clone()
+	if err != nil {
+		return nil, err
+	}
+	results, err := clone.ProcessTurn([]*Orders_t{o})
+	if err != nil {
+		return nil, err
+	}
+	errs := results[clone.Race(o.Race).Id]
+	sort.SliceStable(errs, func(i, j int) bool {
+		return errs[i].Line < errs[j].Line
+	})
+	return errs, nil
+}
+
+// Clone returns a deep copy of the game.
+func (g *Game_t) Clone() (*Game_t, error) {
+	data, err := json.Marshal(g)
+	if err != nil {
+		return nil, err
+	}
+	var clone Game_t
+	if err := json.Unmarshal(data, &clone); err != nil {
+		return nil, err
+	}
+	if data, err = json.Marshal(g.Cluster); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &clone.Cluster); err != nil {
+		return nil, err
+	}
+	// the tree is never changed while a turn is processed, so it can be shared
+	clone.TechTree = g.TechTree
+	return &clone, nil
+}
+
+// turn_t holds the working state while a turn is processed.
+type turn_t struct {
+	g          *Game_t
+	orders     map[string]*Orders_t
+	errors     map[string][]*OrderError_t
+	routes     *RouteGraph_t      // built when the first route is planned
+	encounters []*encounter_t     // fleets that met during movement
+	hauled     map[string]float64 // cargo carried for trades this turn, by fleet id
+	phase      string             // the phase being carried out
+	audit      *audit_t           // records the changes, if the turn is audited
+}
+
+// ordersFor returns the orders of the given kind for the race.
+func ordersFor[T Order](t *turn_t, race *Race_t) []T {
+	var list []T
+	if o, ok := t.orders[race.Id]; ok {
+		for _, order := range o.Orders {
+			if order, ok := order.(T); ok {
+				list = append(list, order)
+			}
+		}
+	}
+	return list
+}
+
+// cause notes that the changes that follow are made by the race's order,
+// or by the phase itself if the race is nil, for the audit log.
+func (t *turn_t) cause(race *Race_t, order Order) {
+	if t.audit != nil {
+		t.audit.begin(t.g, t.phase, race, order)
+	}
+}
+
+// prng returns the PRNG for a phase of the turn. The values drawn from it
+// are counted for the audit log.
+func (t *turn_t) prng(phase string) *rand.Rand {
+	if t.audit == nil {
+		return t.g.prngFor(phase, t.g.Turn)
+	}
+	return t.audit.stream(phase, t.g.sourceFor(phase, t.g.Turn))
+}
+
+// reject records an order that failed and notes it in the race's report.
+func (t *turn_t) reject(race *Race_t, src OrderSource_t, err error) {
+	t.errors[race.Id] = append(t.errors[race.Id], &OrderError_t{OrderSource_t: src, Err: err})
+	t.g.logf(race, "line %d: %q: %v", src.Line, src.Text, err)
+}
+
+// spend takes credits from the race's treasury, if it has enough.
+func (t *turn_t) spend(race *Race_t, amount float64) error {
+	if amount > race.Credits+1e-9 {
+		return fmt.Errorf("%w: need %.1f, have %.1f", ErrInsufficientCredits, amount, race.Credits)
+	}
+	race.Credits -= amount
+	return nil
+}
+
+func (t *turn_t) namingPhase() {
+	for _, race := range t.g.Races {
+		for _, order := range ordersFor[*NameOrder_t](t, race) {
+			t.cause(race, order)
+			ss, err := t.g.Cluster.Lookup(order.System)
+			if err == nil {
+				old := ss.Name
+				if err = t.g.Cluster.Rename(ss, race.Id, order.Name, t.g.Turn); err == nil {
+					t.g.logf(race, "renamed %s from %q to %q", ss.Id, old, ss.Name)
+				}
+			}
+			if err != nil {
+				t.reject(race, order.OrderSource_t, err)
+			}
+		}
+	}
+}
+
+func (t *turn_t) researchPhase() {
+	for _, race := range t.g.Races {
+		for _, order := range ordersFor[*ResearchOrder_t](t, race) {
+			t.cause(race, order)
+			if err := t.research(race, order); err != nil {
+				t.reject(race, order.OrderSource_t, err)
+			}
+		}
+	}
+}
+
+func (t *turn_t) research(race *Race_t, order *ResearchOrder_t) error {
+	f := t.g.TechTree.Field(order.Field)
+	if f == nil {
+		return fmt.Errorf("%q: %w", order.Field, ErrUnknownTechField)
+	} else if race.TechLevel(f.Id) >= f.MaxLevel {
+		return fmt.Errorf("%s: %w", f.Id, ErrMaximumTechLevel)
+	} else if err := t.spend(race, order.Amount); err != nil {
+		return err
+	}
+	levels, err := t.g.research(race, f, order.Amount)
+	if err != nil {
+		return err
+	}
+	t.g.logf(race, "spent %g credits on %s research", order.Amount, f.Name)
+	for _, level := range levels {
+		t.g.logf(race, "reached %s level %d", f.Name, level)
+	}
+	return nil
+}
+
+func (t *turn_t) designPhase() {
+	for _, race := range t.g.Races {
+		for _, order := range ordersFor[*DesignOrder_t](t, race) {
+			t.cause(race, order)
+			d := order.Design
+			if race.Design(d.Name) != nil {
+				t.reject(race, order.OrderSource_t, fmt.Errorf("%q: %w", d.Name, ErrDuplicateDesign))
+				continue
+			} else if err := t.g.ValidateDesign(race, &d); err != nil {
+				t.reject(race, order.OrderSource_t, err)
+				continue
+			}
+			race.Designs = append(race.Designs, &d)
+			t.g.logf(race, "saved design %s: %s, cost %.1f", d.Name, d.String(), d.Cost())
+		}
+	}
+}
+
+func (t *turn_t) buildPhase() {
+	for _, race := range t.g.Races {
+		for _, order := range ordersFor[*BuildOrder_t](t, race) {
+			t.cause(race, order)
+			if err := t.build(race, order); err != nil {
+				t.reject(race, order.OrderSource_t, err)
+			}
+		}
+	}
+}
+
+func (t *turn_t) build(race *Race_t, order *BuildOrder_t) error {
+	colony := t.g.Colony(order.Colony)
+	if colony == nil || colony.Race != race.Id {
+		return fmt.Errorf("%q: %w", order.Colony, ErrUnknownColony)
+	}
+	switch order.Item {
+	case "infrastructure":
+		if err := t.spend(race, order.Quantity*InfrastructureCost); err != nil {
+			return err
+		}
+		colony.Infrastructure += order.Quantity
+		t.g.logf(race, "built %g infrastructure at %s", order.Quantity, colony.Id)
+		return nil
+	case "defenses":
+		if err := t.spend(race, order.Quantity*DefenseCost); err != nil {
+			return err
+		}
+		colony.Defenses += order.Quantity
+		t.g.logf(race, "built %g defenses at %s", order.Quantity, colony.Id)
+		return nil
+	case "shipyard":
+		if order.Quantity != 1 || colony.Shipyard {
+			return fmt.Errorf("%s can have one shipyard: %w", colony.Id, ErrInvalidAmount)
+		} else if colony.Population < ShipyardPopulation {
+			return fmt.Errorf("%w: a shipyard needs %g million people", ErrInsufficientPeople, ShipyardPopulation)
+		} else if err := t.spend(race, ShipyardCost); err != nil {
+			return err
+		}
+		colony.Shipyard = true
+		t.g.logf(race, "built a shipyard at %s", colony.Id)
+		return nil
+	}
+	d := race.Design(order.Item)
+	if d == nil {
+		return fmt.Errorf("%q: %w", order.Item, ErrUnknownItem)
+	} else if !colony.Shipyard {
+		return fmt.Errorf("%s: %w", colony.Id, ErrNoShipyard)
+	} else if order.Quantity != math.Trunc(order.Quantity) || order.Quantity > MaximumShipsPerBuild {
+		return fmt.Errorf("%g ships: %w: build 1 to %d at a time", order.Quantity, ErrInvalidAmount, MaximumShipsPerBuild)
+	}
+	// find the system first, so that nothing is spent on ships that can't be placed
+	ss, err := t.g.Cluster.Lookup(colony.System)
+	if err != nil {
+		return err
+	}
+	colonists := order.Quantity * float64(d.Colonists) * ColonistsPerPod
+	if colonists > 0 && colony.Population-colonists < MinimumPopulation {
+		return fmt.Errorf("%w: need %.1f million colonists, have %.1f", ErrInsufficientPeople, colonists, colony.Population)
+	} else if err := t.spend(race, order.Quantity*d.Cost()); err != nil {
+		return err
+	}
+	colony.Population -= colonists
+	fleet := t.g.newFleet(race, ss)
+	for n := 0; n < int(order.Quantity); n++ {
+		ship := &Ship_t{Id: t.g.nextId("SH"), Race: race.Id, Design: d.Name, Colonists: float64(d.Colonists) * ColonistsPerPod}
+		t.g.Ships = append(t.g.Ships, ship)
+		t.g.addShip(fleet, ship)
+	}
+	t.g.logf(race, "built %d %s at %s in fleet %s: %s", len(fleet.Ships), d.Name, colony.Id, fleet.Id, strings.Join(fleet.Ships, ", "))
+	return nil
+}
+
+// productionPhase adds each race's income to its treasury.
+func (t *turn_t) productionPhase() {
+	for _, race := range t.g.Races {
+		income, _ := t.g.Income(race)
+		race.Credits += income
+		t.g.logf(race, "earned %.1f credits", income)
+	}
+}
+
+// LoadOrders reads every orders file for the game's current turn.
+// A missing orders directory means that no orders were submitted.
+func (g *Game_t) LoadOrders(path string) ([]*Orders_t, error) {
+	files, err := readOrderFiles(path, g.Turn)
+	if err != nil {
+		return nil, err
+	}
+	orders, _, err := g.parseOrderFiles(files)
+	return orders, err
+}
+
+// readOrderFiles returns the text of every orders file for the turn, by the
+// name of the file without the extension.
+func readOrderFiles(path string, turn int) (map[string][]byte, error) {
+	dir := filepath.Dir(OrdersPath(path, turn, "x"))
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	files := make(map[string][]byte)
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".txt") {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		files[strings.TrimSuffix(entry.Name(), ".txt")] = data
+	}
+	return files, nil
+}
+
+// parseOrderFiles parses the orders files, sorted by race. It also returns
+// the text of the files it kept, by race id. A file that is for another
+// race, for a race that isn't in the game, or for a race that already has
+// a file is logged and ignored, so that it doesn't stop the turn. Files
+// are read in order of their names, so the first of two files for a race
+// is the one kept.
+func (g *Game_t) parseOrderFiles(files map[string][]byte) ([]*Orders_t, map[string][]byte, error) {
+	var names []string
+	for id := range files {
+		names = append(names, id)
+	}
+	sort.Strings(names)
+	var list []*Orders_t
+	kept := make(map[string][]byte)
+	for _, id := range names {
+		o, err := ParseOrders(bytes.NewReader(files[id]))
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s.txt: %w", id, err)
+		}
+		// the file is named for the race; the header must agree with it
+		race := g.Race(o.Race)
+		if !strings.EqualFold(o.Race, id) {
+			log.Printf("turn: %d: %s.txt: orders are for race %q, ignoring them\n", g.Turn, id, o.Race)
+			continue
+		} else if race == nil {
+			log.Printf("turn: %d: %s.txt: %q: %v, ignoring them\n", g.Turn, id, o.Race, ErrUnknownRace)
+			continue
+		} else if _, ok := kept[race.Id]; ok {
+			log.Printf("turn: %d: %s.txt: %s: %v, ignoring them\n", g.Turn, id, race.Id, ErrDuplicateOrders)
+			continue
+		}
+		list = append(list, o)
+		kept[race.Id] = files[id]
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Race < list[j].Race
+	})
+	return list, kept, nil
+}
+
+// SaveTurn saves the game at the start of a turn in the game's store,
+// with a report for every race.
+func (g *Game_t) SaveTurn(path string) error {
+	return g.CommitTurn(path, nil, nil)
+}
+
+// CommitTurn saves the game at the start of a turn in the game's store,
+// with a report for every race, the orders for the turn before, by race,
+// and the events from processing it. The events are numbered as the next
+// run of that turn. The directory for the new turn's orders is created.
+func (g *Game_t) CommitTurn(path string, orders map[string][]byte, events []*Event_t) error {
+	c := &Commit_t{Game: g, Reports: make(map[string][]byte), Orders: orders, Events: events}
+	for _, race := range g.Races {
+		buf := &bytes.Buffer{}
+		if err := g.WriteReport(buf, race); err != nil {
+			return err
+		}
+		c.Reports[race.Id] = buf.Bytes()
+	}
+	if err := os.MkdirAll(filepath.Dir(OrdersPath(path, g.Turn, "x")), 0755); err != nil {
+		return err
+	}
+	s, err := OpenStore(path, false)
+	if err != nil {
+		return err
+	}
+	if len(events) != 0 {
+		prior, err := s.Events(g.Turn - 1)
+		if err != nil {
+			_ = s.Close()
+			return err
+		}
+		run := 1
+		for _, e := range prior {
+			if e.Run >= run {
+				run = e.Run + 1
+			}
+		}
+		for _, e := range events {
+			e.Run = run
+		}
+	}
+	if err := s.Commit(c); err != nil {
+		_ = s.Close()
+		return err
+	}
+	return s.Close()
+}
+
+// RunTurn processes the current turn of the game in the directory and
+// saves the next turn. It holds the turn lock while it works, so that two
+// turns are never processed at once. It returns the game at the new turn.
+// An archived game is not processed.
+func RunTurn(path string) (*Game_t, error) {
+	unlock, err := LockTurn(path)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	if settings, err := LoadSettings(path); err != nil {
+		return nil, err
+	} else if settings.State == StateArchived {
+		return nil, ErrGameArchived
+	}
+	g, err := LoadGame(path)
+	if err != nil {
+		return nil, err
+	}
+	files, err := readOrderFiles(path, g.Turn)
+	if err != nil {
+		return nil, err
+	}
+	orders, history, err := g.parseOrderFiles(files)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("turn: process: turn %d: %d of %d races submitted orders\n", g.Turn, len(orders), len(g.Races))
+	results, events, err := g.AuditTurn(orders)
+	if err != nil {
+		return nil, err
+	}
+	for race, errs := range results {
+		log.Printf("turn: process: %s: %d orders failed\n", race, len(errs))
+	}
+	// keep the orders that were carried out
+	if err := g.CommitTurn(path, history, events); err != nil {
+		return nil, err
+	}
+	log.Printf("turn: process: turn %d: %d changes in the audit log\n", g.Turn-1, len(events))
+	return g, nil
+}
+
+// RollbackTurn restores the game in the directory to the start of an
+// earlier turn, so that the turn can be processed again. The later turns'
+// state and reports are dropped; the orders submitted for the turn are
+// kept so that the GM can correct them. The orders submitted for the later
+// turns were written against a state that is gone, so they are moved to
+// an "orders.rolled-back" directory next to the turn's orders directory.
+// The rollback is recorded in the audit log. It returns the game at the
+// restored turn.
+func RollbackTurn(path string, turn int) (*Game_t, error) {
+	unlock, err := LockTurn(path)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	if settings, err := LoadSettings(path); err != nil {
+		return nil, err
+	} else if settings.State == StateArchived {
+		return nil, ErrGameArchived
+	}
+	s, err := OpenStore(path, false)
+	if err != nil {
+		return nil, err
+	}
+	defer s.Close()
+	current, err := s.Game()
+	if err != nil {
+		return nil, err
+	} else if turn < 1 || turn >= current.Turn {
+		return nil, fmt.Errorf("turn %d: must be before turn %d: %w", turn, current.Turn, ErrInvalidArguments)
+	}
+	g, err := s.Turn(turn)
+	if err != nil {
+		return nil, err
+	}
+	before, _ := json.Marshal(current.Turn)
+	after, _ := json.Marshal(turn)
+	event := &Event_t{Turn: turn, Seq: 1, Phase: "rollback", Change_t: Change_t{Path: "turn", Before: before, After: after}}
+	if err := s.Rollback(turn, []*Event_t{event}); err != nil {
+		return nil, err
+	}
+	log.Printf("turn: rollback: game is back at turn %d from turn %d\n", turn, current.Turn)
+	kept, err := filepath.Glob(OrdersPath(path, turn, "*"))
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range kept {
+		log.Printf("turn: rollback: kept %s\n", name)
+	}
+	for later := turn + 1; later <= current.Turn; later++ {
+		if err := setOrdersAside(path, later); err != nil {
+			return nil, err
+		}
+	}
+	return g, nil
+}
+
+// setOrdersAside moves the turn's orders directory to orders.rolled-back,
+// or orders.rolled-back.N if that was used by an earlier rollback.
+func setOrdersAside(path string, turn int) error {
+	dir := filepath.Dir(OrdersPath(path, turn, ""))
+	files, err := filepath.Glob(filepath.Join(dir, "*"))
+	if err != nil {
+		return err
+	} else if len(files) == 0 {
+		return nil
+	}
+	aside, err := unusedName(dir + ".rolled-back")
+	if err != nil {
+		return err
+	} else if err := os.Rename(dir, aside); err != nil {
+		return err
+	}
+	for _, name := range files {
+		log.Printf("turn: rollback: moved %s to %s\n", name, aside)
+	}
+	return nil
+}
+
+// LockTurn takes the lock that is held while a turn is processed.
+// It returns ErrTurnLocked if another process holds it. The lock is a
+// file holding the id of the process, so a lock left behind by a crash
+// can be found by ReadTurnLock and removed by BreakTurnLock.
+func LockTurn(path string) (unlock func(), err error) {
+	name := filepath.Join(path, LockFile)
+	fp, err := os.OpenFile(name, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if errors.Is(err, os.ErrExist) {
+		return nil, fmt.Errorf("%s: %w", name, ErrTurnLocked)
+	} else if err != nil {
+		return nil, err
+	}
+	_, err = fmt.Fprintf(fp, "%d\n", os.Getpid())
+	if cerr := fp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		_ = os.Remove(name)
+		return nil, err
+	}
+	return func() { _ = os.Remove(name) }, nil
+}
+
+// TurnLocked returns true if a turn is being processed.
+func TurnLocked(path string) bool {
+	_, err := os.Stat(filepath.Join(path, LockFile))
+	return err == nil
+}
+
+// TurnLock_t describes the lock held while a turn is processed.
+type TurnLock_t struct {
+	Pid int           // process that took the lock, 0 if the file doesn't say
+	Age time.Duration // time since the lock was taken
+}
+
+// ReadTurnLock returns the turn lock, or nil if the turn isn't locked.
+func ReadTurnLock(path string, now time.Time) (*TurnLock_t, error) {
+	name := filepath.Join(path, LockFile)
+	sb, err := os.Stat(name)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(name)
+	if errors.Is(err, os.ErrNotExist) {
+		// released while we were looking
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	lock := &TurnLock_t{Age: now.Sub(sb.ModTime())}
+	if pid, err := strconv.Atoi(strings.TrimSpace(string(data))); err == nil && pid > 0 {
+		lock.Pid = pid
+	}
+	return lock, nil
+}
+
+// Stale returns true if the process that took the lock has exited, which
+// means it crashed without releasing it. A lock that doesn't name a process
+// is never stale. The check only works for processes on this host.
+func (l *TurnLock_t) Stale() bool {
+	if l.Pid == 0 || l.Pid == os.Getpid() {
+		return false
+	}
+	p, err := os.FindProcess(l.Pid)
+	if err != nil {
+		return true
+	}
+	err = p.Signal(syscall.Signal(0))
+	return errors.Is(err, os.ErrProcessDone) || errors.Is(err, syscall.ESRCH)
+}
+
+// BreakTurnLock removes a stale turn lock.
+func BreakTurnLock(path string) error {
+	err := os.Remove(filepath.Join(path, LockFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,203 @@
+// Copyright (c) 2024 Michael D Henderson. All rights reserved.
+
+package fargo
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRollbackTurn(t *testing.T) {
+	for _, kind := range []string{StorageFiles, StorageBolt} {
+		t.Run(kind, func(t *testing.T) {
+			path, first := newTestGame(t, kind)
+			writeTestOrders(t, path, first, "R001", "race R001\nresearch drive 25\nbuild 10 infrastructure at C001\n")
+			second, err := RunTurn(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			writeTestOrders(t, path, second, "R002", "race R002\nresearch weapons 30\n")
+			third, err := RunTurn(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			writeTestOrders(t, path, third, "R001", "race R001\nresearch sensors 10\n")
+
+			if _, err := RollbackTurn(path, 3); !errors.Is(err, ErrInvalidArguments) {
+				t.Errorf("rollback: current turn: want ErrInvalidArguments, got %v", err)
+			}
+			g, err := RollbackTurn(path, 2)
+			if err != nil {
+				t.Fatal(err)
+			}
+			sameGame(t, "rollback", second, g)
+			if current, err := LoadGame(path); err != nil {
+				t.Fatal(err)
+			} else {
+				sameGame(t, "current", second, current)
+			}
+			// the orders for the restored turn stay, the later ones are moved aside
+			if _, err := os.Stat(OrdersPath(path, 2, "R002")); err != nil {
+				t.Errorf("orders: turn 2: %v", err)
+			}
+			if _, err := os.Stat(OrdersPath(path, 3, "R001")); !errors.Is(err, os.ErrNotExist) {
+				t.Errorf("orders: turn 3: want ErrNotExist, got %v", err)
+			} else if _, err := os.Stat(filepath.Join(TurnPath(path, 3), "orders.rolled-back", "R001.txt")); err != nil {
+				t.Errorf("orders: turn 3: %v", err)
+			}
+
+			// processing the turn again gives the same result
+			again, err := RunTurn(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			sameGame(t, "again", third, again)
+			s, err := OpenStore(path, true)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer s.Close()
+			if turns, err := s.Turns(); err != nil {
+				t.Fatal(err)
+			} else if !slices.Equal(turns, []int{1, 2, 3}) {
+				t.Errorf("turns: want [1 2 3], got %v", turns)
+			}
+			events, err := s.Events(2)
+			if err != nil {
+				t.Fatal(err)
+			}
+			runs, rollbacks := map[int]bool{}, 0
+			for _, e := range events {
+				if e.Phase == "rollback" {
+					rollbacks++
+				} else {
+					runs[e.Run] = true
+				}
+			}
+			if rollbacks != 1 {
+				t.Errorf("events: want 1 rollback, got %d", rollbacks)
+			} else if !runs[1] || !runs[2] {
+				t.Errorf("events: want runs 1 and 2, got %v", runs)
+			}
+		})
+	}
+}
+
+func TestBuildSpendsNothingOnFailure(t *testing.T) {
+	g, err := CreateGame(GameOptions_t{Name: "Test", Seed: "test", NumberOfRaces: 2, SystemsPerRace: 4, Culture: "classical", NameStyle: "syllable"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// a colony whose system is missing from the cluster can't place its ships
+	g.Colony("C001").System = "S999"
+	run := func(text string) (*Game_t, map[string][]*OrderError_t) {
+		clone, err := g.Clone()
+		if err != nil {
+			t.Fatal(err)
+		}
+		o, err := ParseOrders(strings.NewReader(text))
+		if err != nil {
+			t.Fatal(err)
+		}
+		results, err := clone.ProcessTurn([]*Orders_t{o})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return clone, results
+	}
+	want, _ := run("race R001\ndesign Pod hull 10 drive 1 colonists 2\n")
+	got, results := run("race R001\ndesign Pod hull 10 drive 1 colonists 2\nbuild 1 pod at C001\n")
+	if len(results["R001"]) != 1 {
+		t.Fatalf("build: want 1 failed order, got %d", len(results["R001"]))
+	}
+	if got.Race("R001").Credits != want.Race("R001").Credits {
+		t.Errorf("credits: want %g, got %g", want.Race("R001").Credits, got.Race("R001").Credits)
+	}
+	if got.Colony("C001").Population != want.Colony("C001").Population {
+		t.Errorf("population: want %g, got %g", want.Colony("C001").Population, got.Colony("C001").Population)
+	}
+}
+
+func TestStaleTurnLock(t *testing.T) {
+	path := t.TempDir()
+	if lock, err := ReadTurnLock(path, time.Now()); err != nil || lock != nil {
+		t.Fatalf("unlocked: got %v, %v: want nil, nil", lock, err)
+	}
+
+	unlock, err := LockTurn(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lock, err := ReadTurnLock(path, time.Now().Add(time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	} else if lock == nil || lock.Pid != os.Getpid() || lock.Age < time.Minute-time.Second {
+		t.Fatalf("locked: got %+v: want pid %d, age about 1m", lock, os.Getpid())
+	} else if lock.Stale() {
+		t.Errorf("locked: lock held by this process is stale")
+	}
+	unlock()
+
+	// a lock left behind by a process that has exited
+	cmd := exec.Command(os.Args[0], "-test.run=^$")
+	if err := cmd.Run(); err != nil {
+		t.Fatal(err)
+	}
+	name := filepath.Join(path, LockFile)
+	if err := os.WriteFile(name, []byte(fmt.Sprintf("%d\n", cmd.Process.Pid)), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if lock, err = ReadTurnLock(path, time.Now()); err != nil {
+		t.Fatal(err)
+	} else if lock == nil || !lock.Stale() {
+		t.Fatalf("crashed: got %+v: want stale lock", lock)
+	} else if err := BreakTurnLock(path); err != nil {
+		t.Fatal(err)
+	} else if TurnLocked(path) {
+		t.Errorf("crashed: lock not broken")
+	}
+
+	// a lock that doesn't name a process is never stale
+	if err := os.WriteFile(name, []byte("garbage\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if lock, err = ReadTurnLock(path, time.Now()); err != nil {
+		t.Fatal(err)
+	} else if lock == nil || lock.Pid != 0 || lock.Stale() {
+		t.Errorf("garbage: got %+v: want lock with no pid that isn't stale", lock)
+	}
+}
+
+func TestRunTurnSkipsBadOrderFiles(t *testing.T) {
+	path, g := newTestGame(t, StorageFiles)
+	kept := "race R001\nresearch drive 25\n"
+	writeTestOrders(t, path, g, "R001", kept)
+	// the same race in another case, a race that isn't in the game,
+	// and a file whose header names another race
+	writeTestOrders(t, path, g, "r001", "race R001\nresearch drive 50\n")
+	writeTestOrders(t, path, g, "R999", "race R999\nresearch drive 25\n")
+	writeTestOrders(t, path, g, "R002", "race R001\nresearch drive 25\n")
+	next, err := RunTurn(path)
+	if err != nil {
+		t.Fatal(err)
+	} else if next.Turn != g.Turn+1 {
+		t.Fatalf("turn: want %d, got %d", g.Turn+1, next.Turn)
+	}
+	s, err := OpenStore(path, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	if data, err := s.Orders(g.Turn, "R001"); err != nil {
+		t.Fatal(err)
+	} else if string(data) != kept {
+		t.Errorf("orders: R001: want %q, got %q", kept, data)
+	}
+}